@@ -0,0 +1,140 @@
+package calendarController
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"permit-app/helper/apiresponse"
+	"permit-app/service/calendarService"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CalendarController struct {
+	service calendarService.CalendarService
+}
+
+func NewCalendarController(service calendarService.CalendarService) *CalendarController {
+	return &CalendarController{service: service}
+}
+
+// GetToken godoc
+// @Summary Get calendar feed token status
+// @Description Show whether the authenticated user has an active calendar feed token
+// @Tags calendar
+// @Produce json
+// @Success 200 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /calendar/token [get]
+func (c *CalendarController) GetToken(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return
+	}
+
+	token, err := c.service.GetToken(userID.(int64))
+	if err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to retrieve calendar token", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, token, "Calendar token retrieved successfully", nil)
+}
+
+// CreateToken godoc
+// @Summary Create calendar feed token
+// @Description Issue a new calendar feed token for the current domain, revoking the previous one. The token and feed URL are only shown once.
+// @Tags calendar
+// @Produce json
+// @Success 201 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /calendar/token [post]
+func (c *CalendarController) CreateToken(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return
+	}
+
+	domainID, exists := ctx.Get("domain_id")
+	if !exists || domainID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	token, err := c.service.CreateToken(userID.(int64), domainID.(int64))
+	if err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to create calendar token", err, nil)
+		return
+	}
+	token.FeedURL = feedURL(ctx, token.Token)
+
+	apiresponse.Created(ctx, token, "Calendar token created successfully", nil)
+}
+
+// RevokeToken godoc
+// @Summary Revoke calendar feed token
+// @Description Revoke the authenticated user's calendar feed token
+// @Tags calendar
+// @Produce json
+// @Success 200 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /calendar/token [delete]
+func (c *CalendarController) RevokeToken(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return
+	}
+
+	if err := c.service.RevokeToken(userID.(int64)); err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to revoke calendar token", err, nil)
+		return
+	}
+
+	type EmptyData struct{}
+	apiresponse.OK(ctx, EmptyData{}, "Calendar token revoked successfully", nil)
+}
+
+// Feed godoc
+// @Summary Calendar feed
+// @Description iCalendar feed of permit expiry dates and task due dates. Authenticated by the token in the URL so calendar clients can subscribe to it.
+// @Tags calendar
+// @Produce text/calendar
+// @Param token path string true "Calendar token, optionally followed by .ics"
+// @Success 200 {string} string
+// @Failure 404 {object} apiresponse.Response
+// @Router /calendar/feed/{token} [get]
+func (c *CalendarController) Feed(ctx *gin.Context) {
+	token := strings.TrimSuffix(ctx.Param("token"), ".ics")
+
+	var buf bytes.Buffer
+	if err := c.service.WriteFeed(token, &buf); err != nil {
+		if errors.Is(err, calendarService.ErrCalendarFeedNotFound) {
+			apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Calendar feed not found", nil, nil)
+			return
+		}
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to generate calendar feed", err, nil)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Data(http.StatusOK, "text/calendar; charset=utf-8", buf.Bytes())
+}
+
+// feedURL builds the subscription URL from the host the request was sent to
+func feedURL(ctx *gin.Context, token string) string {
+	scheme := "http"
+	if ctx.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := ctx.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + ctx.Request.Host + "/calendar/feed/" + token + ".ics"
+}
@@ -0,0 +1,55 @@
+package dashboardController
+
+import (
+	"permit-app/helper/apiresponse"
+	"permit-app/middleware"
+	"permit-app/model"
+	"permit-app/service/dashboardService"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DashboardController struct {
+	service dashboardService.DashboardService
+}
+
+func NewDashboardController(service dashboardService.DashboardService) *DashboardController {
+	return &DashboardController{service: service}
+}
+
+// GetPermitDashboard godoc
+// @Summary Permit compliance dashboard
+// @Description Permit counts by status, upcoming expiries, expired-but-active permits, permits missing a license document and a weighted risk score, per domain and division
+// @Tags dashboard
+// @Produce json
+// @Param domain_id query int false "Domain ID (super admin only)"
+// @Param division_id query int false "Division ID"
+// @Success 200 {object} apiresponse.Response
+// @Failure 400 {object} apiresponse.Response
+// @Failure 500 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /dashboard/permits [get]
+func (c *DashboardController) GetPermitDashboard(ctx *gin.Context) {
+	var req model.PermitDashboardRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid query parameters", err, nil)
+		return
+	}
+
+	// Limited to the domain from the JWT token, super admins may pass domain_id
+	requested := int64(0)
+	if req.DomainID != nil {
+		requested = *req.DomainID
+	}
+	if domainID, exists := middleware.ResolveDomainID(ctx, requested); exists {
+		req.DomainID = &domainID
+	}
+
+	dashboard, err := c.service.GetPermitDashboard(&req)
+	if err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to retrieve permit dashboard", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, dashboard, "Permit dashboard retrieved successfully", nil)
+}
@@ -3,6 +3,7 @@ package divisionController
 import (
 	"net/http"
 	"permit-app/helper/apiresponse"
+	"permit-app/middleware"
 	"permit-app/model"
 	"permit-app/service/divisionService"
 	"strconv"
@@ -26,20 +27,16 @@ func (c *DivisionController) Create(ctx *gin.Context) {
 		return
 	}
 	
-	if err := validator.New().Struct(&req); err != nil {
-		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
-		return
-	}
-
-	// Validate domain_id from JWT token
-	domainID, exists := ctx.Get("domain_id")
-	if !exists || domainID == nil {
+	// The division belongs to the domain from the JWT token, super admins may pick another domain
+	domainID, exists := middleware.ResolveDomainID(ctx, req.DomainID)
+	if !exists {
 		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
 		return
 	}
-	
-	if req.DomainID != domainID.(int64) {
-		apiresponse.Error(ctx, http.StatusForbidden, "FORBIDDEN", "Cannot create division in different domain", nil, nil)
+	req.DomainID = domainID
+
+	if err := validator.New().Struct(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
 		return
 	}
 
@@ -59,7 +56,13 @@ func (c *DivisionController) GetByID(ctx *gin.Context) {
 		return
 	}
 
-	division, err := c.service.GetDivisionByID(id)
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	division, err := c.service.GetDivisionByID(id, domainID)
 	if err != nil {
 		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Division not found", err, nil)
 		return
@@ -80,10 +83,13 @@ func (c *DivisionController) GetAll(ctx *gin.Context) {
 		return
 	}
 
-	// Extract domain_id from JWT token context
-	if domainID, exists := ctx.Get("domain_id"); exists && domainID != nil {
-		did := domainID.(int64)
-		filter.DomainID = &did
+	// Lists are limited to the domain from the JWT token, super admins may pass domain_id
+	requested := int64(0)
+	if filter.DomainID != nil {
+		requested = *filter.DomainID
+	}
+	if domainID, exists := middleware.ResolveDomainID(ctx, requested); exists {
+		filter.DomainID = &domainID
 	}
 
 	divisions, total, err := c.service.GetAllDivisions(&filter)
@@ -118,18 +124,21 @@ func (c *DivisionController) Update(ctx *gin.Context) {
 		return
 	}
 
-	// Validate domain_id from JWT token (only if domain_id is provided in request)
-	if req.DomainID != 0 {
-		domainID, exists := ctx.Get("domain_id")
-		if !exists || domainID == nil {
-			apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
-			return
-		}
-		
-		if req.DomainID != domainID.(int64) {
-			apiresponse.Error(ctx, http.StatusForbidden, "FORBIDDEN", "Cannot update division in different domain", nil, nil)
-			return
-		}
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	if _, err := c.service.GetDivisionByID(id, domainID); err != nil {
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Division not found", err, nil)
+		return
+	}
+
+	// Only super admins may move a division to another domain
+	if req.DomainID != 0 && domainID != nil && req.DomainID != *domainID {
+		apiresponse.Error(ctx, http.StatusForbidden, "FORBIDDEN", "Cannot move division to different domain", nil, nil)
+		return
 	}
 
 	division, err := c.service.UpdateDivision(id, &req)
@@ -148,6 +157,17 @@ func (c *DivisionController) Delete(ctx *gin.Context) {
 		return
 	}
 
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	if _, err := c.service.GetDivisionByID(id, domainID); err != nil {
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Division not found", err, nil)
+		return
+	}
+
 	err = c.service.DeleteDivision(id)
 	if err != nil {
 		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to delete division", err, nil)
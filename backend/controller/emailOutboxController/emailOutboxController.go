@@ -0,0 +1,125 @@
+package emailOutboxController
+
+import (
+	"errors"
+	"net/http"
+	"permit-app/helper/apiresponse"
+	"permit-app/model"
+	"permit-app/service/emailOutboxService"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type EmailOutboxController struct {
+	service emailOutboxService.EmailOutboxService
+}
+
+func NewEmailOutboxController(service emailOutboxService.EmailOutboxService) *EmailOutboxController {
+	return &EmailOutboxController{service: service}
+}
+
+// GetAll godoc
+// @Summary List outbox emails
+// @Description List queued, sent and failed emails, newest first. Admin only.
+// @Tags email-outbox
+// @Produce json
+// @Param status query string false "pending, sending, sent or failed"
+// @Param recipient query string false "Part of a recipient address"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} apiresponse.Response
+// @Failure 400 {object} apiresponse.Response
+// @Failure 403 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /email-outbox [get]
+func (c *EmailOutboxController) GetAll(ctx *gin.Context) {
+	var filter model.EmailOutboxListRequest
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid query parameters", err, nil)
+		return
+	}
+
+	if err := validator.New().Struct(&filter); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	emails, total, err := c.service.GetAll(&filter)
+	if err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to retrieve emails", err, nil)
+		return
+	}
+
+	meta := apiresponse.PageMeta{
+		Page:  filter.Page,
+		Limit: filter.Limit,
+		Total: total,
+	}
+	apiresponse.OK(ctx, emails, "Emails retrieved successfully", meta)
+}
+
+// GetByID godoc
+// @Summary Get outbox email
+// @Description Get an email with the outcome of each delivery attempt. Admin only.
+// @Tags email-outbox
+// @Produce json
+// @Param id path int true "Email ID"
+// @Success 200 {object} apiresponse.Response
+// @Failure 404 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /email-outbox/{id} [get]
+func (c *EmailOutboxController) GetByID(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	email, err := c.service.GetByID(id)
+	if err != nil {
+		if errors.Is(err, emailOutboxService.ErrEmailNotFound) {
+			apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Email not found", err, nil)
+			return
+		}
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to retrieve email", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, email, "Email retrieved successfully", nil)
+}
+
+// Resend godoc
+// @Summary Resend failed email
+// @Description Queue a failed email for delivery again with a fresh set of attempts. Admin only.
+// @Tags email-outbox
+// @Produce json
+// @Param id path int true "Email ID"
+// @Success 200 {object} apiresponse.Response
+// @Failure 400 {object} apiresponse.Response
+// @Failure 404 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /email-outbox/{id}/resend [post]
+func (c *EmailOutboxController) Resend(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	email, err := c.service.Resend(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, emailOutboxService.ErrEmailNotFound):
+			apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Email not found", err, nil)
+		case errors.Is(err, emailOutboxService.ErrEmailNotFailed):
+			apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, err.Error(), err, nil)
+		default:
+			apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to resend email", err, nil)
+		}
+		return
+	}
+
+	apiresponse.OK(ctx, email, "Email queued for delivery", nil)
+}
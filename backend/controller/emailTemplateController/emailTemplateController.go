@@ -0,0 +1,180 @@
+package emailTemplateController
+
+import (
+	"errors"
+	"net/http"
+	"permit-app/helper/apiresponse"
+	"permit-app/middleware"
+	"permit-app/model"
+	"permit-app/service/emailTemplateService"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type EmailTemplateController struct {
+	service emailTemplateService.EmailTemplateService
+}
+
+func NewEmailTemplateController(service emailTemplateService.EmailTemplateService) *EmailTemplateController {
+	return &EmailTemplateController{service: service}
+}
+
+// GetAll godoc
+// @Summary List email templates
+// @Description List the domain's email templates in every locale, overridden or built-in. Admin only, super admins may pass domain_id.
+// @Tags email-templates
+// @Produce json
+// @Param domain_id query int false "Domain ID (super admin only)"
+// @Success 200 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Failure 403 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /email-templates [get]
+func (c *EmailTemplateController) GetAll(ctx *gin.Context) {
+	requested, _ := strconv.ParseInt(ctx.Query("domain_id"), 10, 64)
+	domainID, exists := middleware.ResolveDomainID(ctx, requested)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	templates, err := c.service.GetTemplates(domainID)
+	if err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to retrieve email templates", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, templates, "Email templates retrieved successfully", nil)
+}
+
+// Update godoc
+// @Summary Override email template
+// @Description Replace the subject, HTML and text content of a built-in email template for the domain. The template must render with sample data. Admin only.
+// @Tags email-templates
+// @Accept json
+// @Produce json
+// @Param name path string true "Template name (permit_expiry, permit_escalation or obligation_reminder)"
+// @Param locale path string true "Locale (id or en)"
+// @Param request body model.UpdateEmailTemplateRequest true "Template source"
+// @Success 200 {object} apiresponse.Response
+// @Failure 400 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Failure 403 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /email-templates/{name}/{locale} [put]
+func (c *EmailTemplateController) Update(ctx *gin.Context) {
+	var request model.UpdateEmailTemplateRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid request body", err, nil)
+		return
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.ResolveDomainID(ctx, request.DomainID)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+	userID, _ := middleware.GetUserIDFromContext(ctx)
+
+	template, err := c.service.UpdateTemplate(domainID, ctx.Param("name"), ctx.Param("locale"), &request, userID)
+	if err != nil {
+		if errors.Is(err, emailTemplateService.ErrUnknownTemplate) || errors.Is(err, emailTemplateService.ErrInvalidTemplate) {
+			apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, err.Error(), err, nil)
+			return
+		}
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to update email template", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, template, "Email template updated successfully", nil)
+}
+
+// Reset godoc
+// @Summary Reset email template
+// @Description Remove the domain's override so the built-in email template applies again. Admin only.
+// @Tags email-templates
+// @Produce json
+// @Param name path string true "Template name (permit_expiry, permit_escalation or obligation_reminder)"
+// @Param locale path string true "Locale (id or en)"
+// @Param domain_id query int false "Domain ID (super admin only)"
+// @Success 200 {object} apiresponse.Response
+// @Failure 400 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Failure 403 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /email-templates/{name}/{locale} [delete]
+func (c *EmailTemplateController) Reset(ctx *gin.Context) {
+	requested, _ := strconv.ParseInt(ctx.Query("domain_id"), 10, 64)
+	domainID, exists := middleware.ResolveDomainID(ctx, requested)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	if err := c.service.ResetTemplate(domainID, ctx.Param("name"), ctx.Param("locale")); err != nil {
+		if errors.Is(err, emailTemplateService.ErrUnknownTemplate) {
+			apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, err.Error(), err, nil)
+			return
+		}
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to reset email template", err, nil)
+		return
+	}
+
+	data := gin.H{"success": true}
+	apiresponse.OK(ctx, data, "Email template reset successfully", nil)
+}
+
+// Preview godoc
+// @Summary Preview email template
+// @Description Render the posted template source, or the domain's current template when the body is empty, with sample data. Admin only.
+// @Tags email-templates
+// @Accept json
+// @Produce json
+// @Param name path string true "Template name (permit_expiry, permit_escalation or obligation_reminder)"
+// @Param locale path string true "Locale (id or en)"
+// @Param request body model.PreviewEmailTemplateRequest false "Template source"
+// @Success 200 {object} apiresponse.Response
+// @Failure 400 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Failure 403 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /email-templates/{name}/{locale}/preview [post]
+func (c *EmailTemplateController) Preview(ctx *gin.Context) {
+	var request model.PreviewEmailTemplateRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid request body", err, nil)
+			return
+		}
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.ResolveDomainID(ctx, request.DomainID)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	preview, err := c.service.Preview(domainID, ctx.Param("name"), ctx.Param("locale"), &request)
+	if err != nil {
+		if errors.Is(err, emailTemplateService.ErrUnknownTemplate) || errors.Is(err, emailTemplateService.ErrInvalidTemplate) {
+			apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, err.Error(), err, nil)
+			return
+		}
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to preview email template", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, preview, "Email template rendered successfully", nil)
+}
@@ -0,0 +1,177 @@
+package jobController
+
+import (
+	"errors"
+	"net/http"
+	"permit-app/helper/apiresponse"
+	"permit-app/middleware"
+	"permit-app/model"
+	"permit-app/service/jobService"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type JobController struct {
+	service jobService.JobService
+}
+
+func NewJobController(service jobService.JobService) *JobController {
+	return &JobController{service: service}
+}
+
+// GetAll godoc
+// @Summary List scheduled jobs
+// @Description List the registered jobs with their schedule, pause state and last run. Admin only.
+// @Tags jobs
+// @Produce json
+// @Success 200 {object} apiresponse.Response
+// @Failure 403 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /jobs [get]
+func (c *JobController) GetAll(ctx *gin.Context) {
+	jobs, err := c.service.GetJobs()
+	if err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to retrieve jobs", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, jobs, "Jobs retrieved successfully", nil)
+}
+
+// GetByName godoc
+// @Summary Get scheduled job
+// @Description Get a job with its schedule, pause state and last run. Admin only.
+// @Tags jobs
+// @Produce json
+// @Param name path string true "Job name"
+// @Success 200 {object} apiresponse.Response
+// @Failure 404 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /jobs/{name} [get]
+func (c *JobController) GetByName(ctx *gin.Context) {
+	job, err := c.service.GetJob(ctx.Param("name"))
+	if err != nil {
+		respondError(ctx, "Failed to retrieve job", err)
+		return
+	}
+
+	apiresponse.OK(ctx, job, "Job retrieved successfully", nil)
+}
+
+// GetRuns godoc
+// @Summary List job runs
+// @Description List the runs of a job, newest first. Admin only.
+// @Tags jobs
+// @Produce json
+// @Param name path string true "Job name"
+// @Param status query string false "running, succeeded or failed"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} apiresponse.Response
+// @Failure 400 {object} apiresponse.Response
+// @Failure 404 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /jobs/{name}/runs [get]
+func (c *JobController) GetRuns(ctx *gin.Context) {
+	var filter model.JobRunListRequest
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid query parameters", err, nil)
+		return
+	}
+
+	if err := validator.New().Struct(&filter); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	runs, total, err := c.service.GetRuns(ctx.Param("name"), &filter)
+	if err != nil {
+		respondError(ctx, "Failed to retrieve job runs", err)
+		return
+	}
+
+	meta := apiresponse.PageMeta{
+		Page:  filter.Page,
+		Limit: filter.Limit,
+		Total: total,
+	}
+	apiresponse.OK(ctx, runs, "Job runs retrieved successfully", meta)
+}
+
+// Run godoc
+// @Summary Run job now
+// @Description Start a run of the job right away, even while it is paused. The run is returned while it is still running. Admin only.
+// @Tags jobs
+// @Produce json
+// @Param name path string true "Job name"
+// @Success 200 {object} apiresponse.Response
+// @Failure 404 {object} apiresponse.Response
+// @Failure 409 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /jobs/{name}/run [post]
+func (c *JobController) Run(ctx *gin.Context) {
+	userID, _ := middleware.GetUserIDFromContext(ctx)
+
+	run, err := c.service.Trigger(ctx.Param("name"), userID)
+	if err != nil {
+		respondError(ctx, "Failed to run job", err)
+		return
+	}
+
+	apiresponse.OK(ctx, run, "Job started", nil)
+}
+
+// Pause godoc
+// @Summary Pause job
+// @Description Stop the scheduler from running the job until it is resumed. A run in progress is left to finish. Admin only.
+// @Tags jobs
+// @Produce json
+// @Param name path string true "Job name"
+// @Success 200 {object} apiresponse.Response
+// @Failure 404 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /jobs/{name}/pause [post]
+func (c *JobController) Pause(ctx *gin.Context) {
+	userID, _ := middleware.GetUserIDFromContext(ctx)
+
+	job, err := c.service.Pause(ctx.Param("name"), userID)
+	if err != nil {
+		respondError(ctx, "Failed to pause job", err)
+		return
+	}
+
+	apiresponse.OK(ctx, job, "Job paused", nil)
+}
+
+// Resume godoc
+// @Summary Resume job
+// @Description Let the scheduler run a paused job again. Admin only.
+// @Tags jobs
+// @Produce json
+// @Param name path string true "Job name"
+// @Success 200 {object} apiresponse.Response
+// @Failure 404 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /jobs/{name}/resume [post]
+func (c *JobController) Resume(ctx *gin.Context) {
+	job, err := c.service.Resume(ctx.Param("name"))
+	if err != nil {
+		respondError(ctx, "Failed to resume job", err)
+		return
+	}
+
+	apiresponse.OK(ctx, job, "Job resumed", nil)
+}
+
+// respondError maps the job errors to not found and conflict responses
+func respondError(ctx *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, jobService.ErrJobNotFound):
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Job not found", err, nil)
+	case errors.Is(err, jobService.ErrJobRunning):
+		apiresponse.Error(ctx, http.StatusConflict, "CONFLICT", err.Error(), err, nil)
+	default:
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, message, err, nil)
+	}
+}
@@ -1,24 +1,38 @@
 package notificationController
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"permit-app/helper"
 	"permit-app/helper/apiresponse"
+	"permit-app/middleware"
 	"permit-app/model"
 	"permit-app/service/notificationService"
+	"permit-app/service/notificationStreamService"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 )
 
+// streamHeartbeatInterval is how often an idle notification stream sends a
+// comment, keeping proxies from closing it
+const streamHeartbeatInterval = 25 * time.Second
+
 type NotificationController struct {
 	notificationService notificationService.NotificationService
+	streamService       notificationStreamService.NotificationStreamService
 	validate            *validator.Validate
 }
 
-func NewNotificationController(notificationService notificationService.NotificationService, validate *validator.Validate) *NotificationController {
+func NewNotificationController(notificationService notificationService.NotificationService, streamService notificationStreamService.NotificationStreamService, validate *validator.Validate) *NotificationController {
 	return &NotificationController{
 		notificationService: notificationService,
+		streamService:       streamService,
 		validate:            validate,
 	}
 }
@@ -110,6 +124,146 @@ func (c *NotificationController) GetUnreadCount(ctx *gin.Context) {
 	apiresponse.OK(ctx, gin.H{"count": count}, "Unread count retrieved successfully", nil)
 }
 
+// StreamToken godoc
+// @Summary Create notification stream token
+// @Description Create a token that opens the user's notification stream for 60 seconds, for clients that cannot set the Authorization header such as EventSource. It is passed as stream_token and is not accepted anywhere else.
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /notifications/stream-token [post]
+func (c *NotificationController) StreamToken(ctx *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized", nil, nil)
+		return
+	}
+	domainID, _ := middleware.GetDomainIDFromContext(ctx)
+
+	token, err := helper.GenerateStreamToken(userID, domainID)
+	if err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to create stream token", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, gin.H{
+		"token":      token,
+		"expires_in": int(helper.StreamTokenTTL.Seconds()),
+	}, "Stream token created successfully", nil)
+}
+
+// Stream godoc
+// @Summary Stream notifications
+// @Description Server-Sent Events stream of the authenticated user's new notifications. Each "notification" event carries the notification with its ID as the event ID and is followed by an "unread_count" event. The stream starts with the current unread count, and a client reconnecting with Last-Event-ID first receives every notification it missed. Clients that cannot set headers, such as EventSource, open the stream with a token from POST /notifications/stream-token. Once it expired they create a new one and pass the last event ID as last_event_id.
+// @Tags notifications
+// @Produce text/event-stream
+// @Param Last-Event-ID header int false "ID of the last notification received"
+// @Param last_event_id query int false "ID of the last notification received, for a new EventSource"
+// @Param stream_token query string false "Stream token, for clients that cannot set the Authorization header"
+// @Success 200 {string} string "text/event-stream"
+// @Failure 401 {object} apiresponse.Response
+// @Failure 500 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /notifications/stream [get]
+func (c *NotificationController) Stream(ctx *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized", nil, nil)
+		return
+	}
+
+	lastEventID, _ := strconv.ParseInt(ctx.GetHeader("Last-Event-ID"), 10, 64)
+	if lastEventID == 0 {
+		lastEventID, _ = strconv.ParseInt(ctx.Query("last_event_id"), 10, 64)
+	}
+
+	// Subscribe before reading what was missed so nothing falls in between,
+	// notifications in both are sent once
+	subscription := c.streamService.Subscribe(userID)
+	defer c.streamService.Unsubscribe(subscription)
+
+	var missed []model.NotificationResponse
+	more := false
+	if lastEventID > 0 {
+		var err error
+		missed, more, err = c.streamService.Missed(userID, lastEventID)
+		if err != nil {
+			apiresponse.Error(ctx, http.StatusInternalServerError, apiresponse.ErrCodeInternal, "Failed to retrieve missed notifications", err, nil)
+			return
+		}
+	}
+	unreadCount, err := c.streamService.UnreadCount(userID)
+	if err != nil {
+		apiresponse.Error(ctx, http.StatusInternalServerError, apiresponse.ErrCodeInternal, "Failed to retrieve unread count", err, nil)
+		return
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no") // disable nginx response buffering
+	ctx.Status(http.StatusOK)
+
+	// Reconnect after 5 seconds when the stream drops
+	fmt.Fprint(ctx.Writer, "retry: 5000\n\n")
+	for {
+		for _, notification := range missed {
+			writeStreamEvent(ctx.Writer, notification.ID, "notification", notification)
+			lastEventID = notification.ID
+		}
+		if !more {
+			break
+		}
+		ctx.Writer.Flush()
+
+		missed, more, err = c.streamService.Missed(userID, lastEventID)
+		if err != nil {
+			// The client reconnects and resumes after the last event it received
+			return
+		}
+	}
+	writeStreamEvent(ctx.Writer, 0, "unread_count", gin.H{"count": unreadCount})
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// Dropped by the broker, the client reconnects and resumes
+				return
+			}
+			if event.Notification.ID <= lastEventID {
+				continue
+			}
+			writeStreamEvent(ctx.Writer, event.Notification.ID, "notification", event.Notification)
+			writeStreamEvent(ctx.Writer, 0, "unread_count", gin.H{"count": event.UnreadCount})
+			lastEventID = event.Notification.ID
+		case <-heartbeat.C:
+			fmt.Fprint(ctx.Writer, ": heartbeat\n\n")
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+// writeStreamEvent writes a Server-Sent Event with JSON data, with an ID when
+// id is set so the client can resume from it
+func writeStreamEvent(w io.Writer, id int64, event string, data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	if id > 0 {
+		fmt.Fprintf(w, "id: %d\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+}
+
 // MarkAsRead godoc
 // @Summary Mark notifications as read
 // @Description Mark specific notifications as read
@@ -211,3 +365,369 @@ func (c *NotificationController) DeleteNotification(ctx *gin.Context) {
 	data := gin.H{"success": true}
 	apiresponse.OK(ctx, data, "Notification deleted successfully", nil)
 }
+
+// GetPreferences godoc
+// @Summary Get notification preferences
+// @Description Get the authenticated user's notification channels (in-app, email, digest) for every notification event
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Success 200 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Failure 500 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /notifications/preferences [get]
+func (c *NotificationController) GetPreferences(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, apiresponse.ErrCodeBadRequest, "Unauthorized", nil, nil)
+		return
+	}
+
+	preferences, err := c.notificationService.GetPreferences(userID.(int64))
+	if err != nil {
+		apiresponse.Error(ctx, http.StatusInternalServerError, apiresponse.ErrCodeInternal, "Failed to retrieve notification preferences", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, preferences, "Notification preferences retrieved successfully", nil)
+}
+
+// UpdatePreferences godoc
+// @Summary Update notification preferences
+// @Description Set the authenticated user's notification channels for the given events. Events left out keep their current channels.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param request body model.UpdateNotificationPreferencesRequest true "Channels per event"
+// @Success 200 {object} apiresponse.Response
+// @Failure 400 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Failure 500 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /notifications/preferences [put]
+func (c *NotificationController) UpdatePreferences(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, apiresponse.ErrCodeBadRequest, "Unauthorized", nil, nil)
+		return
+	}
+
+	var request model.UpdateNotificationPreferencesRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		apiresponse.Error(ctx, http.StatusBadRequest, apiresponse.ErrCodeBadRequest, "Invalid request body", err, nil)
+		return
+	}
+
+	if err := c.validate.Struct(request); err != nil {
+		apiresponse.Error(ctx, http.StatusBadRequest, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	preferences, err := c.notificationService.UpdatePreferences(userID.(int64), &request)
+	if err != nil {
+		apiresponse.Error(ctx, http.StatusInternalServerError, apiresponse.ErrCodeInternal, "Failed to update notification preferences", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, preferences, "Notification preferences updated successfully", nil)
+}
+
+// GetDigestSetting godoc
+// @Summary Get digest setting
+// @Description Get how often the authenticated user's digest email is sent and how many notifications are waiting for it
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Success 200 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Failure 500 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /notifications/digest [get]
+func (c *NotificationController) GetDigestSetting(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, apiresponse.ErrCodeBadRequest, "Unauthorized", nil, nil)
+		return
+	}
+
+	setting, err := c.notificationService.GetDigestSetting(userID.(int64))
+	if err != nil {
+		apiresponse.Error(ctx, http.StatusInternalServerError, apiresponse.ErrCodeInternal, "Failed to retrieve digest setting", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, setting, "Digest setting retrieved successfully", nil)
+}
+
+// UpdateDigestSetting godoc
+// @Summary Update digest setting
+// @Description Set whether the authenticated user's digest email is sent daily or weekly. Events go into the digest when their digest preference is on.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param request body model.UpdateNotificationDigestSettingRequest true "Digest frequency"
+// @Success 200 {object} apiresponse.Response
+// @Failure 400 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Failure 500 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /notifications/digest [put]
+func (c *NotificationController) UpdateDigestSetting(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, apiresponse.ErrCodeBadRequest, "Unauthorized", nil, nil)
+		return
+	}
+
+	var request model.UpdateNotificationDigestSettingRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		apiresponse.Error(ctx, http.StatusBadRequest, apiresponse.ErrCodeBadRequest, "Invalid request body", err, nil)
+		return
+	}
+
+	if err := c.validate.Struct(request); err != nil {
+		apiresponse.Error(ctx, http.StatusBadRequest, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	setting, err := c.notificationService.UpdateDigestSetting(userID.(int64), &request)
+	if err != nil {
+		apiresponse.Error(ctx, http.StatusInternalServerError, apiresponse.ErrCodeInternal, "Failed to update digest setting", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, setting, "Digest setting updated successfully", nil)
+}
+
+// GetRecipientRules godoc
+// @Summary Get notification recipient rules
+// @Description Get who receives permit expiry and obligation notifications in the domain. Super admins may pass domain_id.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param domain_id query int false "Domain ID (super admin only)"
+// @Success 200 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Failure 500 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /notifications/recipient-rules [get]
+func (c *NotificationController) GetRecipientRules(ctx *gin.Context) {
+	requested, _ := strconv.ParseInt(ctx.Query("domain_id"), 10, 64)
+	domainID, exists := middleware.ResolveDomainID(ctx, requested)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	rules, err := c.notificationService.GetRecipientRules(domainID)
+	if err != nil {
+		apiresponse.Error(ctx, http.StatusInternalServerError, apiresponse.ErrCodeInternal, "Failed to retrieve notification recipient rules", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, rules, "Notification recipient rules retrieved successfully", nil)
+}
+
+// UpdateRecipientRules godoc
+// @Summary Update notification recipient rules
+// @Description Replace the built-in recipients of permit expiry or obligation notifications in the domain. Admin only.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param request body model.UpdateNotificationRecipientRulesRequest true "Recipient rules"
+// @Success 200 {object} apiresponse.Response
+// @Failure 400 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Failure 403 {object} apiresponse.Response
+// @Failure 500 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /notifications/recipient-rules [put]
+func (c *NotificationController) UpdateRecipientRules(ctx *gin.Context) {
+	var request model.UpdateNotificationRecipientRulesRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		apiresponse.Error(ctx, http.StatusBadRequest, apiresponse.ErrCodeBadRequest, "Invalid request body", err, nil)
+		return
+	}
+
+	if err := c.validate.Struct(request); err != nil {
+		apiresponse.Error(ctx, http.StatusBadRequest, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.ResolveDomainID(ctx, request.DomainID)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	rules, err := c.notificationService.UpdateRecipientRules(domainID, &request)
+	if err != nil {
+		if errors.Is(err, notificationService.ErrInvalidRecipientRule) {
+			apiresponse.Error(ctx, http.StatusBadRequest, apiresponse.ErrCodeBadRequest, err.Error(), err, nil)
+			return
+		}
+		apiresponse.Error(ctx, http.StatusInternalServerError, apiresponse.ErrCodeInternal, "Failed to update notification recipient rules", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, rules, "Notification recipient rules updated successfully", nil)
+}
+
+// ResetRecipientRule godoc
+// @Summary Reset a notification recipient rule
+// @Description Remove the domain's recipient rule for an event so the built-in recipients apply again. Admin only.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param event_type path string true "Event type (permit_expiry or permit_obligation)"
+// @Param domain_id query int false "Domain ID (super admin only)"
+// @Success 200 {object} apiresponse.Response
+// @Failure 400 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Failure 403 {object} apiresponse.Response
+// @Failure 500 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /notifications/recipient-rules/{event_type} [delete]
+func (c *NotificationController) ResetRecipientRule(ctx *gin.Context) {
+	requested, _ := strconv.ParseInt(ctx.Query("domain_id"), 10, 64)
+	domainID, exists := middleware.ResolveDomainID(ctx, requested)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	if err := c.notificationService.ResetRecipientRule(domainID, ctx.Param("event_type")); err != nil {
+		if errors.Is(err, notificationService.ErrInvalidRecipientRule) {
+			apiresponse.Error(ctx, http.StatusBadRequest, apiresponse.ErrCodeBadRequest, err.Error(), err, nil)
+			return
+		}
+		apiresponse.Error(ctx, http.StatusInternalServerError, apiresponse.ErrCodeInternal, "Failed to reset notification recipient rule", err, nil)
+		return
+	}
+
+	data := gin.H{"success": true}
+	apiresponse.OK(ctx, data, "Notification recipient rule reset successfully", nil)
+}
+
+// GetEscalationRules godoc
+// @Summary Get expiry escalation rules
+// @Description Get who is notified when a permit's expiry reminders are ignored in the domain. Super admins may pass domain_id.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param domain_id query int false "Domain ID (super admin only)"
+// @Success 200 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Failure 500 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /notifications/escalation-rules [get]
+func (c *NotificationController) GetEscalationRules(ctx *gin.Context) {
+	requested, _ := strconv.ParseInt(ctx.Query("domain_id"), 10, 64)
+	domainID, exists := middleware.ResolveDomainID(ctx, requested)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	rules, err := c.notificationService.GetEscalationRules(domainID)
+	if err != nil {
+		apiresponse.Error(ctx, http.StatusInternalServerError, apiresponse.ErrCodeInternal, "Failed to retrieve escalation rules", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, rules, "Escalation rules retrieved successfully", nil)
+}
+
+// UpdateEscalationRules godoc
+// @Summary Update expiry escalation rules
+// @Description Replace the domain's escalation rules, an empty list turns escalation off. days_before counts like a reminder offset, negative values are days after expiry. Admin only.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param request body model.UpdateEscalationRulesRequest true "Escalation rules"
+// @Success 200 {object} apiresponse.Response
+// @Failure 400 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Failure 403 {object} apiresponse.Response
+// @Failure 500 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /notifications/escalation-rules [put]
+func (c *NotificationController) UpdateEscalationRules(ctx *gin.Context) {
+	var request model.UpdateEscalationRulesRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		apiresponse.Error(ctx, http.StatusBadRequest, apiresponse.ErrCodeBadRequest, "Invalid request body", err, nil)
+		return
+	}
+
+	if err := c.validate.Struct(request); err != nil {
+		apiresponse.Error(ctx, http.StatusBadRequest, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.ResolveDomainID(ctx, request.DomainID)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	rules, err := c.notificationService.UpdateEscalationRules(domainID, &request)
+	if err != nil {
+		if errors.Is(err, notificationService.ErrInvalidEscalationRule) {
+			apiresponse.Error(ctx, http.StatusBadRequest, apiresponse.ErrCodeBadRequest, err.Error(), err, nil)
+			return
+		}
+		apiresponse.Error(ctx, http.StatusInternalServerError, apiresponse.ErrCodeInternal, "Failed to update escalation rules", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, rules, "Escalation rules updated successfully", nil)
+}
+
+// GetEscalations godoc
+// @Summary Get permit escalations
+// @Description List the escalation levels permits of the domain have reached, newest first. Super admins may pass domain_id. Admin only.
+// @Tags notifications
+// @Produce json
+// @Param domain_id query int false "Domain ID (super admin only)"
+// @Param permit_id query int false "Permit ID"
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
+// @Success 200 {object} apiresponse.Response
+// @Failure 400 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Failure 403 {object} apiresponse.Response
+// @Failure 500 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /notifications/escalations [get]
+func (c *NotificationController) GetEscalations(ctx *gin.Context) {
+	var filter model.PermitEscalationListRequest
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		apiresponse.Error(ctx, http.StatusBadRequest, apiresponse.ErrCodeBadRequest, "Invalid query parameters", err, nil)
+		return
+	}
+
+	if err := c.validate.Struct(filter); err != nil {
+		apiresponse.Error(ctx, http.StatusBadRequest, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.ResolveDomainID(ctx, filter.DomainID)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	escalations, total, err := c.notificationService.GetEscalations(domainID, &filter)
+	if err != nil {
+		apiresponse.Error(ctx, http.StatusInternalServerError, apiresponse.ErrCodeInternal, "Failed to retrieve permit escalations", err, nil)
+		return
+	}
+
+	meta := apiresponse.PageMeta{
+		Page:  filter.Page,
+		Limit: filter.Limit,
+		Total: total,
+	}
+	apiresponse.OK(ctx, escalations, "Permit escalations retrieved successfully", meta)
+}
@@ -0,0 +1,191 @@
+package permitApprovalController
+
+import (
+	"errors"
+	"net/http"
+	"permit-app/helper/apiresponse"
+	"permit-app/middleware"
+	"permit-app/model"
+	"permit-app/service/permitService"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PermitApprovalController struct {
+	permitService permitService.PermitService
+}
+
+func NewPermitApprovalController(permitService permitService.PermitService) *PermitApprovalController {
+	return &PermitApprovalController{
+		permitService: permitService,
+	}
+}
+
+// findPermitID parses the permit ID and checks the permit is within the caller's
+// domain scope. Permits of other domains are reported as not found.
+func (c *PermitApprovalController) findPermitID(ctx *gin.Context) (int64, *int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return 0, nil, false
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return 0, nil, false
+	}
+
+	if _, err := c.permitService.GetPermitByID(id, domainID); err != nil {
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Permit not found", err, nil)
+		return 0, nil, false
+	}
+
+	return id, domainID, true
+}
+
+// respondError maps the approval errors to not found, forbidden, bad request and conflict responses
+func respondError(ctx *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, permitService.ErrApprovalNotFound):
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Permit approval not found", err, nil)
+	case errors.Is(err, permitService.ErrNotApprover):
+		apiresponse.Error(ctx, http.StatusForbidden, "FORBIDDEN", err.Error(), nil, nil)
+	case errors.Is(err, permitService.ErrInvalidApproval):
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, err.Error(), err, nil)
+	case errors.Is(err, permitService.ErrApprovalAlreadyDecided):
+		apiresponse.Error(ctx, http.StatusConflict, "CONFLICT", err.Error(), err, nil)
+	default:
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, message, err, nil)
+	}
+}
+
+// GetPending retrieves the pending permits the current user can verify or approve
+func (c *PermitApprovalController) GetPending(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists || userID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	permits, err := c.permitService.GetPendingApprovals(userID.(int64), domainID)
+	if err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to retrieve pending permit approvals", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, permits, "Pending permit approvals retrieved successfully", nil)
+}
+
+// Submit sends a draft permit to its division head for verification
+func (c *PermitApprovalController) Submit(ctx *gin.Context) {
+	id, _, ok := c.findPermitID(ctx)
+	if !ok {
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists || userID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return
+	}
+
+	permit, err := c.permitService.SubmitPermit(id, userID.(int64))
+	if err != nil {
+		respondError(ctx, "Failed to submit permit", err)
+		return
+	}
+
+	apiresponse.OK(ctx, permit, "Permit submitted for approval successfully", nil)
+}
+
+// GetApprovals retrieves the approval steps of every submission of a permit
+func (c *PermitApprovalController) GetApprovals(ctx *gin.Context) {
+	id, _, ok := c.findPermitID(ctx)
+	if !ok {
+		return
+	}
+
+	approvals, err := c.permitService.GetPermitApprovals(id)
+	if err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to retrieve permit approvals", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, approvals, "Permit approvals retrieved successfully", nil)
+}
+
+// Approve approves a permit in the approval workflow
+func (c *PermitApprovalController) Approve(ctx *gin.Context) {
+	id, domainID, ok := c.findPermitID(ctx)
+	if !ok {
+		return
+	}
+
+	approvalID, err := strconv.ParseInt(ctx.Param("approval_id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid approval ID", err, nil)
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists || userID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return
+	}
+
+	var req model.ApprovalRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		// Note is optional, so we ignore binding errors
+		req.Note = nil
+	}
+
+	permit, err := c.permitService.ApprovePermit(id, approvalID, &req, userID.(int64), domainID)
+	if err != nil {
+		respondError(ctx, "Failed to approve permit", err)
+		return
+	}
+
+	apiresponse.OK(ctx, permit, "Permit approved successfully", nil)
+}
+
+// Reject rejects a permit in the approval workflow and returns it to the requester
+func (c *PermitApprovalController) Reject(ctx *gin.Context) {
+	id, domainID, ok := c.findPermitID(ctx)
+	if !ok {
+		return
+	}
+
+	approvalID, err := strconv.ParseInt(ctx.Param("approval_id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid approval ID", err, nil)
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists || userID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return
+	}
+
+	var req model.ApprovalRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		// Note is optional, so we ignore binding errors
+		req.Note = nil
+	}
+
+	permit, err := c.permitService.RejectPermit(id, approvalID, &req, userID.(int64), domainID)
+	if err != nil {
+		respondError(ctx, "Failed to reject permit", err)
+		return
+	}
+
+	apiresponse.OK(ctx, permit, "Permit rejected successfully", nil)
+}
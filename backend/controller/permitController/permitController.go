@@ -1,9 +1,12 @@
 package permitController
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"permit-app/helper"
 	"permit-app/helper/apiresponse"
+	"permit-app/middleware"
 	"permit-app/model"
 	"permit-app/service/permitService"
 	"strconv"
@@ -23,6 +26,24 @@ func NewPermitController(service permitService.PermitService) *PermitController
 	return &PermitController{service: service}
 }
 
+// findPermit loads a permit of the caller's domain, or of any domain for super
+// admins. Permits of other domains are reported as not found.
+func (c *PermitController) findPermit(ctx *gin.Context, id int64) (*model.PermitResponse, bool) {
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return nil, false
+	}
+
+	permit, err := c.service.GetPermitByID(id, domainID)
+	if err != nil {
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Permit not found", err, nil)
+		return nil, false
+	}
+
+	return permit, true
+}
+
 func (c *PermitController) Create(ctx *gin.Context) {
 	contentType := ctx.GetHeader("Content-Type")
 	
@@ -43,10 +64,14 @@ func (c *PermitController) Create(ctx *gin.Context) {
 			return
 		}
 		
-		expiryDate, err := time.Parse("2006-01-02", formReq.ExpiryDate)
-		if err != nil {
-			apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid expiry_date format", err, nil)
-			return
+		// An omitted expiry date is derived from the permit type's validity period
+		var expiryDate time.Time
+		if formReq.ExpiryDate != "" {
+			expiryDate, err = time.Parse("2006-01-02", formReq.ExpiryDate)
+			if err != nil {
+				apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid expiry_date format", err, nil)
+				return
+			}
 		}
 		
 		// Convert to PermitRequest
@@ -78,25 +103,35 @@ func (c *PermitController) Create(ctx *gin.Context) {
 		}
 	}
 
-	if err := validator.New().Struct(&req); err != nil {
-		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+	// The permit belongs to the domain from the JWT token, super admins may pick another domain
+	domainID, exists := middleware.ResolveDomainID(ctx, req.DomainID)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
 		return
 	}
+	req.DomainID = domainID
 
-	// Validate domain_id from JWT token
-	domainID, exists := ctx.Get("domain_id")
-	if !exists || domainID == nil {
-		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+	if err := validator.New().Struct(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
 		return
 	}
-	
-	if req.DomainID != domainID.(int64) {
-		apiresponse.Error(ctx, http.StatusForbidden, "FORBIDDEN", "Cannot create permit in different domain", nil, nil)
+
+	userID, exists := ctx.Get("user_id")
+	if !exists || userID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
 		return
 	}
 
-	permit, err := c.service.CreatePermit(&req)
+	permit, err := c.service.CreatePermit(&req, userID.(int64))
 	if err != nil {
+		if errors.Is(err, helper.ErrOutsideDomain) {
+			apiresponse.Error(ctx, http.StatusForbidden, "FORBIDDEN", err.Error(), nil, nil)
+			return
+		}
+		if errors.Is(err, permitService.ErrInvalidPermit) {
+			apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, err.Error(), err, nil)
+			return
+		}
 		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to create permit", err, nil)
 		return
 	}
@@ -104,11 +139,13 @@ func (c *PermitController) Create(ctx *gin.Context) {
 	// Handle file upload if present
 	file, err := ctx.FormFile("file")
 	if err == nil && file != nil {
-		permit, err = c.service.HandleFileUpload(permit.ID, file)
+		warnings := permit.Warnings
+		permit, err = c.service.HandleFileUpload(permit.ID, file, userID.(int64))
 		if err != nil {
 			apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Permit created but file upload failed", err, nil)
 			return
 		}
+		permit.Warnings = warnings
 	}
 
 	apiresponse.Created(ctx, permit, "Permit created successfully", nil)
@@ -121,9 +158,8 @@ func (c *PermitController) GetByID(ctx *gin.Context) {
 		return
 	}
 
-	permit, err := c.service.GetPermitByID(id)
-	if err != nil {
-		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Permit not found", err, nil)
+	permit, ok := c.findPermit(ctx, id)
+	if !ok {
 		return
 	}
 
@@ -142,10 +178,13 @@ func (c *PermitController) GetAll(ctx *gin.Context) {
 		return
 	}
 
-	// Extract domain_id from JWT token context
-	if domainID, exists := ctx.Get("domain_id"); exists && domainID != nil {
-		did := domainID.(int64)
-		filter.DomainID = &did
+	// Lists are limited to the domain from the JWT token, super admins may pass domain_id
+	requested := int64(0)
+	if filter.DomainID != nil {
+		requested = *filter.DomainID
+	}
+	if domainID, exists := middleware.ResolveDomainID(ctx, requested); exists {
+		filter.DomainID = &domainID
 	}
 
 	permits, total, err := c.service.GetAllPermits(&filter)
@@ -181,10 +220,13 @@ func (c *PermitController) Search(ctx *gin.Context) {
 		filter.PermitNo = query
 	}
 
-	// Extract domain_id from JWT token context
-	if domainID, exists := ctx.Get("domain_id"); exists && domainID != nil {
-		did := domainID.(int64)
-		filter.DomainID = &did
+	// Lists are limited to the domain from the JWT token, super admins may pass domain_id
+	requested := int64(0)
+	if filter.DomainID != nil {
+		requested = *filter.DomainID
+	}
+	if domainID, exists := middleware.ResolveDomainID(ctx, requested); exists {
+		filter.DomainID = &domainID
 	}
 
 	permits, total, err := c.service.SearchPermits(query, &filter)
@@ -261,25 +303,39 @@ func (c *PermitController) Update(ctx *gin.Context) {
 		}
 	}
 
-	if err := validator.New().Struct(&req); err != nil {
-		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+	if _, ok := c.findPermit(ctx, id); !ok {
 		return
 	}
 
-	// Validate domain_id from JWT token
-	domainID, exists := ctx.Get("domain_id")
-	if !exists || domainID == nil {
+	// Only super admins may move a permit to another domain
+	domainID, exists := middleware.ResolveDomainID(ctx, req.DomainID)
+	if !exists {
 		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
 		return
 	}
-	
-	if req.DomainID != domainID.(int64) {
-		apiresponse.Error(ctx, http.StatusForbidden, "FORBIDDEN", "Cannot update permit in different domain", nil, nil)
+	req.DomainID = domainID
+
+	if err := validator.New().Struct(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists || userID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
 		return
 	}
 
-	permit, err := c.service.UpdatePermit(id, &req)
+	permit, err := c.service.UpdatePermit(id, &req, userID.(int64))
 	if err != nil {
+		if errors.Is(err, helper.ErrOutsideDomain) {
+			apiresponse.Error(ctx, http.StatusForbidden, "FORBIDDEN", err.Error(), nil, nil)
+			return
+		}
+		if errors.Is(err, permitService.ErrInvalidStatusTransition) || errors.Is(err, permitService.ErrPermitPendingApproval) {
+			apiresponse.Error(ctx, http.StatusConflict, "CONFLICT", err.Error(), err, nil)
+			return
+		}
 		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to update permit", err, nil)
 		return
 	}
@@ -287,7 +343,7 @@ func (c *PermitController) Update(ctx *gin.Context) {
 	// Handle file upload if present
 	file, err := ctx.FormFile("file")
 	if err == nil && file != nil {
-		permit, err = c.service.HandleFileUpload(permit.ID, file)
+		permit, err = c.service.HandleFileUpload(permit.ID, file, userID.(int64))
 		if err != nil {
 			apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Permit updated but file upload failed", err, nil)
 			return
@@ -304,7 +360,17 @@ func (c *PermitController) Delete(ctx *gin.Context) {
 		return
 	}
 
-	err = c.service.DeletePermit(id)
+	userID, exists := ctx.Get("user_id")
+	if !exists || userID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return
+	}
+
+	if _, ok := c.findPermit(ctx, id); !ok {
+		return
+	}
+
+	err = c.service.DeletePermit(id, userID.(int64))
 	if err != nil {
 		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to delete permit", err, nil)
 		return
@@ -314,6 +380,111 @@ func (c *PermitController) Delete(ctx *gin.Context) {
 	apiresponse.OK(ctx, EmptyData{}, "Permit deleted successfully", nil)
 }
 
+func (c *PermitController) GetTrash(ctx *gin.Context) {
+	var filter model.PermitListRequest
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid query parameters", err, nil)
+		return
+	}
+
+	if err := validator.New().Struct(&filter); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	// The trash is limited to the domain from the JWT token, super admins may pass domain_id
+	requested := int64(0)
+	if filter.DomainID != nil {
+		requested = *filter.DomainID
+	}
+	if domainID, exists := middleware.ResolveDomainID(ctx, requested); exists {
+		filter.DomainID = &domainID
+	}
+
+	permits, total, err := c.service.GetDeletedPermits(&filter)
+	if err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to retrieve deleted permits", err, nil)
+		return
+	}
+
+	meta := apiresponse.PageMeta{
+		Page:  filter.Page,
+		Limit: filter.Limit,
+		Total: total,
+	}
+	apiresponse.OK(ctx, permits, "Deleted permits retrieved successfully", meta)
+}
+
+func (c *PermitController) Restore(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists || userID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	permit, err := c.service.RestorePermit(id, domainID, userID.(int64))
+	if err != nil {
+		if errors.Is(err, permitService.ErrPermitNotInTrash) {
+			apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Deleted permit not found", err, nil)
+			return
+		}
+		if errors.Is(err, permitService.ErrPermitAlreadyRenewed) {
+			apiresponse.Error(ctx, http.StatusConflict, "CONFLICT", err.Error(), err, nil)
+			return
+		}
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to restore permit", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, permit, "Permit restored successfully", nil)
+}
+
+// Purge permanently removes a permit from the trash, along with its stored files
+func (c *PermitController) Purge(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists || userID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	err = c.service.PurgePermit(id, domainID, userID.(int64))
+	if err != nil {
+		if errors.Is(err, permitService.ErrPermitNotInTrash) {
+			apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Deleted permit not found", err, nil)
+			return
+		}
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to purge permit", err, nil)
+		return
+	}
+
+	type EmptyData struct{}
+	apiresponse.OK(ctx, EmptyData{}, "Permit purged successfully", nil)
+}
+
 func (c *PermitController) UploadDocument(ctx *gin.Context) {
 	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
 	if err != nil {
@@ -327,7 +498,17 @@ func (c *PermitController) UploadDocument(ctx *gin.Context) {
 		return
 	}
 
-	permit, err := c.service.HandleFileUpload(id, file)
+	userID, exists := ctx.Get("user_id")
+	if !exists || userID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return
+	}
+
+	if _, ok := c.findPermit(ctx, id); !ok {
+		return
+	}
+
+	permit, err := c.service.HandleFileUpload(id, file, userID.(int64))
 	if err != nil {
 		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to upload file", err, nil)
 		return
@@ -343,9 +524,8 @@ func (c *PermitController) DownloadDocument(ctx *gin.Context) {
 		return
 	}
 
-	permit, err := c.service.GetPermitByID(id)
-	if err != nil {
-		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Permit not found", err, nil)
+	permit, ok := c.findPermit(ctx, id)
+	if !ok {
 		return
 	}
 
@@ -372,9 +552,8 @@ func (c *PermitController) PreviewDocument(ctx *gin.Context) {
 		return
 	}
 
-	permit, err := c.service.GetPermitByID(id)
-	if err != nil {
-		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Permit not found", err, nil)
+	permit, ok := c.findPermit(ctx, id)
+	if !ok {
 		return
 	}
 
@@ -392,3 +571,434 @@ func (c *PermitController) PreviewDocument(ctx *gin.Context) {
 
 	ctx.File(*permit.DocFilePath)
 }
+
+func (c *PermitController) Renew(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	var req model.PermitRenewRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid request body", err, nil)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	if _, ok := c.findPermit(ctx, id); !ok {
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists || userID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return
+	}
+
+	permit, err := c.service.RenewPermit(id, &req, userID.(int64))
+	if err != nil {
+		switch {
+		case errors.Is(err, permitService.ErrPermitAlreadyRenewed),
+			errors.Is(err, permitService.ErrPermitNotRenewable),
+			errors.Is(err, permitService.ErrDuplicatePermitNo):
+			apiresponse.Error(ctx, http.StatusConflict, "CONFLICT", err.Error(), err, nil)
+		case errors.Is(err, permitService.ErrInvalidPermit):
+			apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, err.Error(), err, nil)
+		default:
+			apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to renew permit", err, nil)
+		}
+		return
+	}
+
+	// Replace the carried over document if a new one is uploaded
+	file, err := ctx.FormFile("file")
+	if err == nil && file != nil {
+		permit, err = c.service.HandleFileUpload(permit.ID, file, userID.(int64))
+		if err != nil {
+			apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Permit renewed but file upload failed", err, nil)
+			return
+		}
+	}
+
+	apiresponse.Created(ctx, permit, "Permit renewed successfully", nil)
+}
+
+func (c *PermitController) ChangeStatus(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	var req model.PermitStatusRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid request body", err, nil)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	if _, ok := c.findPermit(ctx, id); !ok {
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists || userID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return
+	}
+
+	permit, err := c.service.ChangePermitStatus(id, req.Status, userID.(int64))
+	if err != nil {
+		if errors.Is(err, permitService.ErrInvalidStatusTransition) {
+			apiresponse.Error(ctx, http.StatusConflict, "CONFLICT", err.Error(), err, nil)
+			return
+		}
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to change permit status", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, permit, "Permit status changed successfully", nil)
+}
+
+func (c *PermitController) Import(ctx *gin.Context) {
+	var req model.PermitImportRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid request body", err, nil)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "File is required", err, nil)
+		return
+	}
+
+	// Permits are imported into the domain from the JWT token, super admins may pick another domain
+	domainID, exists := middleware.ResolveDomainID(ctx, req.DomainID)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists || userID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return
+	}
+
+	result, err := c.service.ImportPermits(domainID, file, req.Mode, userID.(int64))
+	if err != nil {
+		if errors.Is(err, permitService.ErrInvalidPermit) {
+			apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, err.Error(), err, nil)
+			return
+		}
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to import permits", err, nil)
+		return
+	}
+
+	if result.Mode == model.PermitImportModeCommit {
+		apiresponse.OK(ctx, result, fmt.Sprintf("%d permit(s) imported successfully", result.Imported), nil)
+		return
+	}
+
+	apiresponse.OK(ctx, result, "Permit import validated successfully", nil)
+}
+
+func (c *PermitController) GetRenewals(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	if _, ok := c.findPermit(ctx, id); !ok {
+		return
+	}
+
+	chain, err := c.service.GetRenewalChain(id)
+	if err != nil {
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Permit not found", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, chain, "Renewal chain retrieved successfully", nil)
+}
+
+func (c *PermitController) GetHistory(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	history, err := c.service.GetPermitHistory(id)
+	if err != nil {
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Permit not found", err, nil)
+		return
+	}
+
+	// History outlives the permit, so the domain is taken from the latest snapshot
+	if domainID != nil && len(history) > 0 && history[0].Snapshot.DomainID != *domainID {
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Permit not found", nil, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, history, "Permit history retrieved successfully", nil)
+}
+
+func (c *PermitController) GetRevision(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	rev, err := strconv.Atoi(ctx.Param("rev"))
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid revision", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	revision, err := c.service.GetPermitRevision(id, rev)
+	if err != nil {
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Permit revision not found", err, nil)
+		return
+	}
+
+	if domainID != nil && revision.Snapshot.DomainID != *domainID {
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Permit revision not found", nil, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, revision, "Permit revision retrieved successfully", nil)
+}
+
+func (c *PermitController) RestoreRevision(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	rev, err := strconv.Atoi(ctx.Param("rev"))
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid revision", err, nil)
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists || userID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return
+	}
+
+	if _, ok := c.findPermit(ctx, id); !ok {
+		return
+	}
+
+	permit, err := c.service.RestorePermitRevision(id, rev, userID.(int64))
+	if err != nil {
+		if errors.Is(err, permitService.ErrPermitPendingApproval) {
+			apiresponse.Error(ctx, http.StatusConflict, "CONFLICT", err.Error(), err, nil)
+			return
+		}
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to restore permit revision", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, permit, "Permit revision restored successfully", nil)
+}
+
+func (c *PermitController) GetDocuments(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	permit, ok := c.findPermit(ctx, id)
+	if !ok {
+		return
+	}
+
+	documents, err := c.service.GetPermitDocuments(permit.ID)
+	if err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to retrieve permit documents", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, documents, "Permit documents retrieved successfully", nil)
+}
+
+func (c *PermitController) UploadPermitDocument(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	var req model.PermitDocumentUploadRequest
+	if err := ctx.ShouldBindWith(&req, binding.FormMultipart); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid request body", err, nil)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "No file uploaded", err, nil)
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists || userID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return
+	}
+
+	permit, ok := c.findPermit(ctx, id)
+	if !ok {
+		return
+	}
+
+	document, err := c.service.UploadPermitDocument(permit.ID, req.DocumentTypeID, file, userID.(int64))
+	if err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to upload permit document", err, nil)
+		return
+	}
+
+	apiresponse.Created(ctx, document, "Permit document uploaded successfully", nil)
+}
+
+func (c *PermitController) DeletePermitDocument(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	documentID, err := strconv.ParseInt(ctx.Param("document_id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid document ID", err, nil)
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists || userID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return
+	}
+
+	permit, ok := c.findPermit(ctx, id)
+	if !ok {
+		return
+	}
+
+	err = c.service.DeletePermitDocument(permit.ID, documentID, userID.(int64))
+	if err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to delete permit document", err, nil)
+		return
+	}
+
+	type EmptyData struct{}
+	apiresponse.OK(ctx, EmptyData{}, "Permit document deleted successfully", nil)
+}
+
+func (c *PermitController) DownloadPermitDocument(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	documentID, err := strconv.ParseInt(ctx.Param("document_id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid document ID", err, nil)
+		return
+	}
+
+	permit, ok := c.findPermit(ctx, id)
+	if !ok {
+		return
+	}
+
+	document, err := c.service.GetPermitDocument(permit.ID, documentID)
+	if err != nil {
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Permit document not found", err, nil)
+		return
+	}
+
+	ctx.Header("Content-Description", "File Transfer")
+	ctx.Header("Content-Transfer-Encoding", "binary")
+	ctx.Header("Content-Disposition", "attachment; filename="+document.FileName)
+
+	if document.FileType != nil {
+		ctx.Header("Content-Type", *document.FileType)
+	}
+
+	ctx.File(document.FilePath)
+}
+
+func (c *PermitController) PreviewPermitDocument(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	documentID, err := strconv.ParseInt(ctx.Param("document_id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid document ID", err, nil)
+		return
+	}
+
+	permit, ok := c.findPermit(ctx, id)
+	if !ok {
+		return
+	}
+
+	document, err := c.service.GetPermitDocument(permit.ID, documentID)
+	if err != nil {
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Permit document not found", err, nil)
+		return
+	}
+
+	// Set Content-Disposition to inline for preview
+	ctx.Header("Content-Disposition", "inline; filename="+document.FileName)
+
+	if document.FileType != nil {
+		ctx.Header("Content-Type", *document.FileType)
+	}
+
+	ctx.File(document.FilePath)
+}
@@ -0,0 +1,59 @@
+package permitExportController
+
+import (
+	"fmt"
+	"log"
+	"permit-app/helper"
+	"permit-app/helper/apiresponse"
+	"permit-app/middleware"
+	"permit-app/model"
+	"permit-app/service/permitExportService"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type PermitExportController struct {
+	service permitExportService.PermitExportService
+}
+
+func NewPermitExportController(service permitExportService.PermitExportService) *PermitExportController {
+	return &PermitExportController{service: service}
+}
+
+func (c *PermitExportController) Export(ctx *gin.Context) {
+	var req model.PermitExportRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid query parameters", err, nil)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	// Limited to the domain from the JWT token, super admins may pass domain_id
+	requested := int64(0)
+	if req.DomainID != nil {
+		requested = *req.DomainID
+	}
+	if domainID, exists := middleware.ResolveDomainID(ctx, requested); exists {
+		req.DomainID = &domainID
+	}
+
+	filename := fmt.Sprintf("permit-register-%s.%s", time.Now().Format("20060102"), req.Format)
+	ctx.Header("Content-Type", helper.ExportContentTypes[req.Format])
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	if err := c.service.ExportPermits(&req, ctx.Writer); err != nil {
+		// Once the body has started the response can no longer be turned into an error
+		if ctx.Writer.Written() {
+			log.Printf("Permit export interrupted: %v", err)
+			return
+		}
+		ctx.Writer.Header().Del("Content-Disposition")
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to export permits", err, nil)
+	}
+}
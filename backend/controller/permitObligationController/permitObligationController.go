@@ -0,0 +1,269 @@
+package permitObligationController
+
+import (
+	"errors"
+	"net/http"
+	"permit-app/helper/apiresponse"
+	"permit-app/middleware"
+	"permit-app/model"
+	"permit-app/service/permitObligationService"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+type PermitObligationController struct {
+	service permitObligationService.PermitObligationService
+}
+
+func NewPermitObligationController(service permitObligationService.PermitObligationService) *PermitObligationController {
+	return &PermitObligationController{service: service}
+}
+
+// parseIDs reads the permit ID and, when present, the obligation ID from the path
+func parseIDs(ctx *gin.Context) (int64, int64, bool) {
+	permitID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return 0, 0, false
+	}
+
+	if ctx.Param("obligation_id") == "" {
+		return permitID, 0, true
+	}
+
+	obligationID, err := strconv.ParseInt(ctx.Param("obligation_id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid obligation ID", err, nil)
+		return 0, 0, false
+	}
+
+	return permitID, obligationID, true
+}
+
+// respondError maps the service errors to not found and bad request responses
+func respondError(ctx *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, permitObligationService.ErrPermitNotFound):
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Permit not found", err, nil)
+	case errors.Is(err, permitObligationService.ErrObligationNotFound):
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Obligation not found", err, nil)
+	case errors.Is(err, permitObligationService.ErrEvidenceNotFound):
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Evidence not found", err, nil)
+	case errors.Is(err, permitObligationService.ErrInvalidObligation):
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, err.Error(), err, nil)
+	default:
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, message, err, nil)
+	}
+}
+
+func (c *PermitObligationController) GetAll(ctx *gin.Context) {
+	permitID, _, ok := parseIDs(ctx)
+	if !ok {
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	obligations, err := c.service.GetObligations(permitID, domainID)
+	if err != nil {
+		respondError(ctx, "Failed to retrieve permit obligations", err)
+		return
+	}
+
+	apiresponse.OK(ctx, obligations, "Permit obligations retrieved successfully", nil)
+}
+
+func (c *PermitObligationController) GetByID(ctx *gin.Context) {
+	permitID, obligationID, ok := parseIDs(ctx)
+	if !ok {
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	obligation, err := c.service.GetObligation(permitID, obligationID, domainID)
+	if err != nil {
+		respondError(ctx, "Failed to retrieve permit obligation", err)
+		return
+	}
+
+	apiresponse.OK(ctx, obligation, "Permit obligation retrieved successfully", nil)
+}
+
+func (c *PermitObligationController) Create(ctx *gin.Context) {
+	permitID, _, ok := parseIDs(ctx)
+	if !ok {
+		return
+	}
+
+	var req model.PermitObligationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid request body", err, nil)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists || userID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	obligation, err := c.service.CreateObligation(permitID, &req, userID.(int64), domainID)
+	if err != nil {
+		respondError(ctx, "Failed to create permit obligation", err)
+		return
+	}
+
+	apiresponse.Created(ctx, obligation, "Permit obligation created successfully", nil)
+}
+
+func (c *PermitObligationController) Update(ctx *gin.Context) {
+	permitID, obligationID, ok := parseIDs(ctx)
+	if !ok {
+		return
+	}
+
+	var req model.PermitObligationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid request body", err, nil)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	obligation, err := c.service.UpdateObligation(permitID, obligationID, &req, domainID)
+	if err != nil {
+		respondError(ctx, "Failed to update permit obligation", err)
+		return
+	}
+
+	apiresponse.OK(ctx, obligation, "Permit obligation updated successfully", nil)
+}
+
+func (c *PermitObligationController) Delete(ctx *gin.Context) {
+	permitID, obligationID, ok := parseIDs(ctx)
+	if !ok {
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	if err := c.service.DeleteObligation(permitID, obligationID, domainID); err != nil {
+		respondError(ctx, "Failed to delete permit obligation", err)
+		return
+	}
+
+	type EmptyData struct{}
+	apiresponse.OK(ctx, EmptyData{}, "Permit obligation deleted successfully", nil)
+}
+
+// Complete records the current due date as fulfilled. Evidence files are sent
+// as multipart/form-data in the "files" field.
+func (c *PermitObligationController) Complete(ctx *gin.Context) {
+	permitID, obligationID, ok := parseIDs(ctx)
+	if !ok {
+		return
+	}
+
+	var req model.PermitObligationCompleteRequest
+	if err := ctx.ShouldBindWith(&req, binding.FormMultipart); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid request body", err, nil)
+		return
+	}
+
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid multipart form", err, nil)
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists || userID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	obligation, err := c.service.CompleteObligation(permitID, obligationID, &req, form.File["files"], userID.(int64), domainID)
+	if err != nil {
+		respondError(ctx, "Failed to complete permit obligation", err)
+		return
+	}
+
+	apiresponse.OK(ctx, obligation, "Permit obligation completed successfully", nil)
+}
+
+func (c *PermitObligationController) DownloadEvidence(ctx *gin.Context) {
+	permitID, obligationID, ok := parseIDs(ctx)
+	if !ok {
+		return
+	}
+
+	evidenceID, err := strconv.ParseInt(ctx.Param("evidence_id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid evidence ID", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	evidence, err := c.service.GetEvidence(permitID, obligationID, evidenceID, domainID)
+	if err != nil {
+		respondError(ctx, "Failed to retrieve evidence", err)
+		return
+	}
+
+	ctx.Header("Content-Description", "File Transfer")
+	ctx.Header("Content-Transfer-Encoding", "binary")
+	ctx.Header("Content-Disposition", "attachment; filename="+evidence.FileName)
+
+	if evidence.FileType != nil {
+		ctx.Header("Content-Type", *evidence.FileType)
+	}
+
+	ctx.File(evidence.FilePath)
+}
@@ -1,8 +1,11 @@
 package permitTypeController
 
 import (
+	"errors"
 	"net/http"
+	"permit-app/helper"
 	"permit-app/helper/apiresponse"
+	"permit-app/middleware"
 	"permit-app/model"
 	"permit-app/service/permitTypeService"
 	"strconv"
@@ -31,8 +34,22 @@ func (c *PermitTypeController) Create(ctx *gin.Context) {
 		return
 	}
 
-	permitType, err := c.service.CreatePermitType(&req)
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	permitType, err := c.service.CreatePermitType(&req, domainID)
 	if err != nil {
+		if errors.Is(err, helper.ErrOutsideDomain) {
+			apiresponse.Error(ctx, http.StatusForbidden, "FORBIDDEN", err.Error(), nil, nil)
+			return
+		}
+		if errors.Is(err, helper.ErrInvalidValidityPeriod) {
+			apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, err.Error(), err, nil)
+			return
+		}
 		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to create permit type", err, nil)
 		return
 	}
@@ -47,7 +64,13 @@ func (c *PermitTypeController) GetByID(ctx *gin.Context) {
 		return
 	}
 
-	permitType, err := c.service.GetPermitTypeByID(id)
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	permitType, err := c.service.GetPermitTypeByID(id, domainID)
 	if err != nil {
 		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Permit type not found", err, nil)
 		return
@@ -68,6 +91,15 @@ func (c *PermitTypeController) GetAll(ctx *gin.Context) {
 		return
 	}
 
+	// Lists are limited to the domain from the JWT token, super admins may pass domain_id
+	requested := int64(0)
+	if filter.DomainID != nil {
+		requested = *filter.DomainID
+	}
+	if domainID, exists := middleware.ResolveDomainID(ctx, requested); exists {
+		filter.DomainID = &domainID
+	}
+
 	permitTypes, total, err := c.service.GetAllPermitTypes(&filter)
 	if err != nil {
 		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to retrieve permit types", err, nil)
@@ -100,8 +132,27 @@ func (c *PermitTypeController) Update(ctx *gin.Context) {
 		return
 	}
 
-	permitType, err := c.service.UpdatePermitType(id, &req)
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	if _, err := c.service.GetPermitTypeByID(id, domainID); err != nil {
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Permit type not found", err, nil)
+		return
+	}
+
+	permitType, err := c.service.UpdatePermitType(id, &req, domainID)
 	if err != nil {
+		if errors.Is(err, helper.ErrOutsideDomain) {
+			apiresponse.Error(ctx, http.StatusForbidden, "FORBIDDEN", err.Error(), nil, nil)
+			return
+		}
+		if errors.Is(err, helper.ErrInvalidValidityPeriod) {
+			apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, err.Error(), err, nil)
+			return
+		}
 		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to update permit type", err, nil)
 		return
 	}
@@ -116,8 +167,23 @@ func (c *PermitTypeController) Delete(ctx *gin.Context) {
 		return
 	}
 
-	err = c.service.DeletePermitType(id)
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	if _, err := c.service.GetPermitTypeByID(id, domainID); err != nil {
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Permit type not found", err, nil)
+		return
+	}
+
+	err = c.service.DeletePermitType(id, domainID)
 	if err != nil {
+		if errors.Is(err, helper.ErrOutsideDomain) {
+			apiresponse.Error(ctx, http.StatusForbidden, "FORBIDDEN", err.Error(), nil, nil)
+			return
+		}
 		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to delete permit type", err, nil)
 		return
 	}
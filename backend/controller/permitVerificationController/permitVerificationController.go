@@ -0,0 +1,225 @@
+package permitVerificationController
+
+import (
+	"errors"
+	"net/http"
+	"permit-app/helper"
+	"permit-app/helper/apiresponse"
+	"permit-app/middleware"
+	"permit-app/model"
+	"permit-app/service/permitVerificationService"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type PermitVerificationController struct {
+	service permitVerificationService.PermitVerificationService
+}
+
+func NewPermitVerificationController(service permitVerificationService.PermitVerificationService) *PermitVerificationController {
+	return &PermitVerificationController{service: service}
+}
+
+// parseRequest reads the permit ID, the caller's domain scope and user ID
+func parseRequest(ctx *gin.Context) (int64, *int64, int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return 0, nil, 0, false
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return 0, nil, 0, false
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists || userID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "User context not found", nil, nil)
+		return 0, nil, 0, false
+	}
+
+	return id, domainID, userID.(int64), true
+}
+
+// respondError maps the service errors to not found responses
+func respondError(ctx *gin.Context, message string, err error) {
+	if errors.Is(err, permitVerificationService.ErrPermitNotFound) {
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Permit not found", err, nil)
+		return
+	}
+	apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, message, err, nil)
+}
+
+// GetToken godoc
+// @Summary Get permit verification token
+// @Description Show the permit's verification token and the URL its QR code points to, issuing a token the first time
+// @Tags permits
+// @Produce json
+// @Param id path int true "Permit ID"
+// @Success 200 {object} apiresponse.Response
+// @Failure 404 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /permits/{id}/verification [get]
+func (c *PermitVerificationController) GetToken(ctx *gin.Context) {
+	id, domainID, userID, ok := parseRequest(ctx)
+	if !ok {
+		return
+	}
+
+	token, err := c.service.GetToken(id, domainID, userID)
+	if err != nil {
+		respondError(ctx, "Failed to retrieve permit verification token", err)
+		return
+	}
+	token.VerifyURL = verifyURL(ctx, token.Token)
+
+	apiresponse.OK(ctx, token, "Permit verification token retrieved successfully", nil)
+}
+
+// RotateToken godoc
+// @Summary Rotate permit verification token
+// @Description Revoke the permit's verification token and issue a new one. QR codes printed with the old token stop verifying.
+// @Tags permits
+// @Produce json
+// @Param id path int true "Permit ID"
+// @Success 201 {object} apiresponse.Response
+// @Failure 404 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /permits/{id}/verification [post]
+func (c *PermitVerificationController) RotateToken(ctx *gin.Context) {
+	id, domainID, userID, ok := parseRequest(ctx)
+	if !ok {
+		return
+	}
+
+	token, err := c.service.RotateToken(id, domainID, userID)
+	if err != nil {
+		respondError(ctx, "Failed to rotate permit verification token", err)
+		return
+	}
+	token.VerifyURL = verifyURL(ctx, token.Token)
+
+	apiresponse.Created(ctx, token, "Permit verification token rotated successfully", nil)
+}
+
+// RevokeToken godoc
+// @Summary Revoke permit verification token
+// @Description Revoke the permit's verification token so its posted QR codes stop verifying
+// @Tags permits
+// @Produce json
+// @Param id path int true "Permit ID"
+// @Success 200 {object} apiresponse.Response
+// @Failure 404 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /permits/{id}/verification [delete]
+func (c *PermitVerificationController) RevokeToken(ctx *gin.Context) {
+	id, domainID, _, ok := parseRequest(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.service.RevokeToken(id, domainID); err != nil {
+		respondError(ctx, "Failed to revoke permit verification token", err)
+		return
+	}
+
+	type EmptyData struct{}
+	apiresponse.OK(ctx, EmptyData{}, "Permit verification token revoked successfully", nil)
+}
+
+// QRCode godoc
+// @Summary Permit verification QR code
+// @Description QR code image of the permit's verification URL, to be printed on the posted permit
+// @Tags permits
+// @Produce image/png
+// @Produce image/svg+xml
+// @Param id path int true "Permit ID"
+// @Param format query string false "png (default) or svg"
+// @Param size query int false "Image size in pixels, 64 to 1024 (default 256)"
+// @Success 200 {file} file
+// @Failure 404 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /permits/{id}/verification/qr [get]
+func (c *PermitVerificationController) QRCode(ctx *gin.Context) {
+	id, domainID, userID, ok := parseRequest(ctx)
+	if !ok {
+		return
+	}
+
+	var req model.PermitQRCodeRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid query parameters", err, nil)
+		return
+	}
+	if err := validator.New().Struct(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+	if req.Size == 0 {
+		req.Size = helper.QRCodeDefaultSize
+	}
+
+	token, err := c.service.GetToken(id, domainID, userID)
+	if err != nil {
+		respondError(ctx, "Failed to retrieve permit verification token", err)
+		return
+	}
+	content := verifyURL(ctx, token.Token)
+
+	if req.Format == "svg" {
+		image, err := helper.EncodeQRCodeSVG(content, req.Size)
+		if err != nil {
+			apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to generate QR code", err, nil)
+			return
+		}
+		ctx.Data(http.StatusOK, "image/svg+xml", image)
+		return
+	}
+
+	image, err := helper.EncodeQRCodePNG(content, req.Size)
+	if err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to generate QR code", err, nil)
+		return
+	}
+	ctx.Data(http.StatusOK, "image/png", image)
+}
+
+// Verify godoc
+// @Summary Verify a permit
+// @Description Public check of a scanned permit QR code. Returns the permit's name, number, validity dates and current status.
+// @Tags verify
+// @Produce json
+// @Param token path string true "Verification token"
+// @Success 200 {object} apiresponse.Response
+// @Failure 404 {object} apiresponse.Response
+// @Router /verify/{token} [get]
+func (c *PermitVerificationController) Verify(ctx *gin.Context) {
+	verification, err := c.service.Verify(ctx.Param("token"))
+	if err != nil {
+		if errors.Is(err, permitVerificationService.ErrVerificationNotFound) {
+			apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Permit verification not found", nil, nil)
+			return
+		}
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to verify permit", err, nil)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	apiresponse.OK(ctx, verification, "Permit verified successfully", nil)
+}
+
+// verifyURL builds the public verification URL from the host the request was sent to
+func verifyURL(ctx *gin.Context, token string) string {
+	scheme := "http"
+	if ctx.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := ctx.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + ctx.Request.Host + "/verify/" + token
+}
@@ -0,0 +1,147 @@
+package reminderScheduleController
+
+import (
+	"errors"
+	"net/http"
+	"permit-app/helper"
+	"permit-app/helper/apiresponse"
+	"permit-app/middleware"
+	"permit-app/model"
+	"permit-app/service/reminderScheduleService"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type ReminderScheduleController struct {
+	service reminderScheduleService.ReminderScheduleService
+}
+
+func NewReminderScheduleController(service reminderScheduleService.ReminderScheduleService) *ReminderScheduleController {
+	return &ReminderScheduleController{service: service}
+}
+
+func (c *ReminderScheduleController) GetByPermitType(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	schedules, err := c.service.GetPermitTypeSchedules(id, domainID)
+	if err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to retrieve reminder schedules", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, schedules, "Reminder schedules retrieved successfully", nil)
+}
+
+func (c *ReminderScheduleController) UpdateByPermitType(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	var req model.ReminderScheduleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid request body", err, nil)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	schedules, err := c.service.UpdatePermitTypeSchedules(id, &req, domainID)
+	if err != nil {
+		if errors.Is(err, helper.ErrOutsideDomain) {
+			apiresponse.Error(ctx, http.StatusForbidden, "FORBIDDEN", err.Error(), nil, nil)
+			return
+		}
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to update reminder schedules", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, schedules, "Reminder schedules updated successfully", nil)
+}
+
+func (c *ReminderScheduleController) GetByDomain(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	if domainID != nil && *domainID != id {
+		apiresponse.Error(ctx, http.StatusForbidden, "FORBIDDEN", "Cannot view reminder schedules of different domain", nil, nil)
+		return
+	}
+
+	schedules, err := c.service.GetDomainSchedules(id)
+	if err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to retrieve reminder schedules", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, schedules, "Reminder schedules retrieved successfully", nil)
+}
+
+func (c *ReminderScheduleController) UpdateByDomain(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	var req model.ReminderScheduleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid request body", err, nil)
+		return
+	}
+
+	if err := validator.New().Struct(&req); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	if domainID != nil && *domainID != id {
+		apiresponse.Error(ctx, http.StatusForbidden, "FORBIDDEN", "Cannot update reminder schedules of different domain", nil, nil)
+		return
+	}
+
+	schedules, err := c.service.UpdateDomainSchedules(id, &req)
+	if err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to update reminder schedules", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, schedules, "Reminder schedules updated successfully", nil)
+}
@@ -211,6 +211,43 @@ func (c *TaskController) GetByCode(ctx *gin.Context) {
 	apiresponse.OK(ctx, task, "Task retrieved successfully", nil)
 }
 
+// DownloadFile sends a file attached to a task of the user's domain
+func (c *TaskController) DownloadFile(ctx *gin.Context) {
+	domainID, exists := ctx.Get("domain_id")
+	if !exists || domainID == nil {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid task ID", err, nil)
+		return
+	}
+
+	fileID, err := strconv.ParseInt(ctx.Param("file_id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid file ID", err, nil)
+		return
+	}
+
+	taskFile, err := c.taskService.GetFile(id, fileID, domainID.(int64))
+	if err != nil {
+		apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Task file not found", err, nil)
+		return
+	}
+
+	ctx.Header("Content-Description", "File Transfer")
+	ctx.Header("Content-Transfer-Encoding", "binary")
+	ctx.Header("Content-Disposition", "attachment; filename="+taskFile.FileName)
+
+	if taskFile.FileType != nil {
+		ctx.Header("Content-Type", *taskFile.FileType)
+	}
+
+	ctx.File(taskFile.FilePath)
+}
+
 // Update updates a task
 func (c *TaskController) Update(ctx *gin.Context) {
 	domainID, exists := ctx.Get("domain_id")
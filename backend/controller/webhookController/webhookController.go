@@ -0,0 +1,403 @@
+package webhookController
+
+import (
+	"errors"
+	"net/http"
+	"permit-app/helper/apiresponse"
+	"permit-app/middleware"
+	"permit-app/model"
+	"permit-app/service/webhookService"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type WebhookController struct {
+	service webhookService.WebhookService
+}
+
+func NewWebhookController(service webhookService.WebhookService) *WebhookController {
+	return &WebhookController{service: service}
+}
+
+// GetAll godoc
+// @Summary List webhook subscriptions
+// @Description List the domain's webhook subscriptions. Secrets are never returned. Admin only, super admins may pass domain_id.
+// @Tags webhooks
+// @Produce json
+// @Param domain_id query int false "Domain ID (super admin only)"
+// @Success 200 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Failure 403 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /webhooks [get]
+func (c *WebhookController) GetAll(ctx *gin.Context) {
+	requested, _ := strconv.ParseInt(ctx.Query("domain_id"), 10, 64)
+	domainID, exists := middleware.ResolveDomainID(ctx, requested)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	subscriptions, err := c.service.GetSubscriptions(domainID)
+	if err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to retrieve webhooks", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, subscriptions, "Webhooks retrieved successfully", nil)
+}
+
+// GetByID godoc
+// @Summary Get webhook subscription
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} apiresponse.Response
+// @Failure 404 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /webhooks/{id} [get]
+func (c *WebhookController) GetByID(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	subscription, err := c.service.GetSubscription(id, domainID)
+	if err != nil {
+		if errors.Is(err, webhookService.ErrWebhookNotFound) {
+			apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Webhook not found", err, nil)
+			return
+		}
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to retrieve webhook", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, subscription, "Webhook retrieved successfully", nil)
+}
+
+// Create godoc
+// @Summary Create webhook subscription
+// @Description Register a receiver URL for the selected events (permit.created, permit.expiring, permit.expired, task.assigned, task.approved, task.rejected). Every delivery is signed with the secret. Admin only.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param request body model.CreateWebhookSubscriptionRequest true "Webhook subscription"
+// @Success 201 {object} apiresponse.Response
+// @Failure 400 {object} apiresponse.Response
+// @Failure 401 {object} apiresponse.Response
+// @Failure 403 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /webhooks [post]
+func (c *WebhookController) Create(ctx *gin.Context) {
+	var request model.CreateWebhookSubscriptionRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid request body", err, nil)
+		return
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.ResolveDomainID(ctx, request.DomainID)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+	userID, _ := middleware.GetUserIDFromContext(ctx)
+
+	subscription, err := c.service.CreateSubscription(domainID, &request, userID)
+	if err != nil {
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to create webhook", err, nil)
+		return
+	}
+
+	apiresponse.Created(ctx, subscription, "Webhook created successfully", nil)
+}
+
+// Update godoc
+// @Summary Update webhook subscription
+// @Description Change the URL, secret, events, description or active state of a webhook. Deliveries of an inactive webhook wait until it is activated again. Admin only.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param request body model.UpdateWebhookSubscriptionRequest true "Fields to change"
+// @Success 200 {object} apiresponse.Response
+// @Failure 400 {object} apiresponse.Response
+// @Failure 404 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /webhooks/{id} [put]
+func (c *WebhookController) Update(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	var request model.UpdateWebhookSubscriptionRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid request body", err, nil)
+		return
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+	userID, _ := middleware.GetUserIDFromContext(ctx)
+
+	subscription, err := c.service.UpdateSubscription(id, domainID, &request, userID)
+	if err != nil {
+		if errors.Is(err, webhookService.ErrWebhookNotFound) {
+			apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Webhook not found", err, nil)
+			return
+		}
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to update webhook", err, nil)
+		return
+	}
+
+	apiresponse.OK(ctx, subscription, "Webhook updated successfully", nil)
+}
+
+// Delete godoc
+// @Summary Delete webhook subscription
+// @Description Delete a webhook together with its deliveries. Admin only.
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} apiresponse.Response
+// @Failure 404 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /webhooks/{id} [delete]
+func (c *WebhookController) Delete(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	if err := c.service.DeleteSubscription(id, domainID); err != nil {
+		if errors.Is(err, webhookService.ErrWebhookNotFound) {
+			apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Webhook not found", err, nil)
+			return
+		}
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to delete webhook", err, nil)
+		return
+	}
+
+	data := gin.H{"success": true}
+	apiresponse.OK(ctx, data, "Webhook deleted successfully", nil)
+}
+
+// Ping godoc
+// @Summary Ping webhook receiver
+// @Description Post a signed ping event to the webhook's URL right away and return the delivery with the receiver's answer. Works for inactive webhooks too, pings are not retried. Admin only.
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} apiresponse.Response
+// @Failure 404 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /webhooks/{id}/ping [post]
+func (c *WebhookController) Ping(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	delivery, err := c.service.Ping(id, domainID)
+	if err != nil {
+		if errors.Is(err, webhookService.ErrWebhookNotFound) {
+			apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Webhook not found", err, nil)
+			return
+		}
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to ping webhook", err, nil)
+		return
+	}
+
+	message := "Ping delivered successfully"
+	if delivery.Status != model.WebhookStatusDelivered {
+		message = "Ping failed"
+	}
+	apiresponse.OK(ctx, delivery, message, nil)
+}
+
+// GetDeliveries godoc
+// @Summary List webhook deliveries
+// @Description List the events queued, delivered and failed for a webhook, newest first. Admin only.
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param status query string false "pending, sending, delivered or failed"
+// @Param event_type query string false "Event type"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} apiresponse.Response
+// @Failure 400 {object} apiresponse.Response
+// @Failure 404 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /webhooks/{id}/deliveries [get]
+func (c *WebhookController) GetDeliveries(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+
+	var filter model.WebhookDeliveryListRequest
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid query parameters", err, nil)
+		return
+	}
+
+	if err := validator.New().Struct(&filter); err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Validation failed", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	deliveries, total, err := c.service.GetDeliveries(id, domainID, &filter)
+	if err != nil {
+		if errors.Is(err, webhookService.ErrWebhookNotFound) {
+			apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Webhook not found", err, nil)
+			return
+		}
+		apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to retrieve webhook deliveries", err, nil)
+		return
+	}
+
+	meta := apiresponse.PageMeta{
+		Page:  filter.Page,
+		Limit: filter.Limit,
+		Total: total,
+	}
+	apiresponse.OK(ctx, deliveries, "Webhook deliveries retrieved successfully", meta)
+}
+
+// GetDelivery godoc
+// @Summary Get webhook delivery
+// @Description Get a delivery with the receiver's answer to each attempt. Admin only.
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param deliveryId path int true "Delivery ID"
+// @Success 200 {object} apiresponse.Response
+// @Failure 404 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /webhooks/{id}/deliveries/{deliveryId} [get]
+func (c *WebhookController) GetDelivery(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+	deliveryID, err := strconv.ParseInt(ctx.Param("deliveryId"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid delivery ID", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	delivery, err := c.service.GetDelivery(id, deliveryID, domainID)
+	if err != nil {
+		switch {
+		case errors.Is(err, webhookService.ErrWebhookNotFound):
+			apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Webhook not found", err, nil)
+		case errors.Is(err, webhookService.ErrDeliveryNotFound):
+			apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Webhook delivery not found", err, nil)
+		default:
+			apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to retrieve webhook delivery", err, nil)
+		}
+		return
+	}
+
+	apiresponse.OK(ctx, delivery, "Webhook delivery retrieved successfully", nil)
+}
+
+// Redeliver godoc
+// @Summary Redeliver failed webhook delivery
+// @Description Queue a failed delivery again with a fresh set of attempts. Admin only.
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param deliveryId path int true "Delivery ID"
+// @Success 200 {object} apiresponse.Response
+// @Failure 400 {object} apiresponse.Response
+// @Failure 404 {object} apiresponse.Response
+// @Security BearerAuth
+// @Router /webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func (c *WebhookController) Redeliver(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid ID", err, nil)
+		return
+	}
+	deliveryID, err := strconv.ParseInt(ctx.Param("deliveryId"), 10, 64)
+	if err != nil {
+		apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, "Invalid delivery ID", err, nil)
+		return
+	}
+
+	domainID, exists := middleware.GetDomainScope(ctx)
+	if !exists {
+		apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Domain context not found", nil, nil)
+		return
+	}
+
+	delivery, err := c.service.Redeliver(id, deliveryID, domainID)
+	if err != nil {
+		switch {
+		case errors.Is(err, webhookService.ErrWebhookNotFound):
+			apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Webhook not found", err, nil)
+		case errors.Is(err, webhookService.ErrDeliveryNotFound):
+			apiresponse.Error(ctx, http.StatusNotFound, "NOT_FOUND", "Webhook delivery not found", err, nil)
+		case errors.Is(err, webhookService.ErrDeliveryNotFailed):
+			apiresponse.BadRequest(ctx, apiresponse.ErrCodeBadRequest, err.Error(), err, nil)
+		default:
+			apiresponse.InternalServerError(ctx, apiresponse.ErrCodeInternal, "Failed to redeliver webhook", err, nil)
+		}
+		return
+	}
+
+	apiresponse.OK(ctx, delivery, "Webhook delivery queued again", nil)
+}
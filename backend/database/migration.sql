@@ -4,12 +4,56 @@
 -- Updated: 2025-12-06 - Added roles and users table
 -- Updated: 2025-12-08 - Added menus and menu_roles tables
 -- Updated: 2025-12-15 - Restructured user-role-domain for multi-app support
+-- Updated: 2026-10-16 - Added permit renewal chain (previous_permit_id, superseded_at)
+-- Updated: 2026-10-16 - Added permit_revisions table for permit change history
+-- Updated: 2026-10-16 - Moved permit documents into permit_documents table
+-- Updated: 2026-10-16 - Added reminder_schedules table and notifications.reminder_days
+-- Updated: 2026-10-16 - Restricted permits.status to the permit status lifecycle
+-- Updated: 2026-10-16 - Added calendar_tokens table for per-user iCalendar feeds
+-- Updated: 2026-10-16 - Added SUPER_ADMIN role for cross-domain access
+-- Updated: 2026-10-16 - Added permit obligations with completion records and evidence files
+-- Updated: 2026-10-16 - Added permit approval workflow (permit_approvals, pending status, division heads)
+-- Updated: 2026-10-16 - Added permit_verification_tokens table for public QR code verification
+-- Updated: 2026-10-16 - Added soft delete for permits (permits.deleted_at)
+-- Updated: 2026-10-16 - Generalized notifications to any subject (entity_type, entity_id, link)
+-- Updated: 2026-10-16 - Added notification_preferences, notification_recipient_rules and reminder_deliveries tables
+-- Updated: 2026-10-16 - Added notification_digest_items and notification_digest_settings tables for digest emails
+-- Updated: 2026-10-16 - Added email_outbox and email_delivery_attempts tables for queued email delivery
+-- Updated: 2026-10-16 - Added email_templates table, users.locale, domains.locale and email_outbox.text_body for localized email templates
+-- Updated: 2026-10-16 - Added notify_notification_created trigger announcing new notifications to the notification streams
+-- Updated: 2026-10-16 - Added webhook_subscriptions, webhook_deliveries and webhook_delivery_attempts tables for outbound webhooks
+-- Updated: 2026-10-16 - Added escalation_rules and permit_escalations tables for escalating ignored expiry reminders
+-- Updated: 2026-10-16 - Added job_states and job_runs tables for scheduled jobs and their run history
 
 -- Drop tables if exists (for clean migration)
 DROP TABLE IF EXISTS approval_tasks CASCADE;
 DROP TABLE IF EXISTS task_files CASCADE;
 DROP TABLE IF EXISTS tasks CASCADE;
+DROP TABLE IF EXISTS calendar_tokens CASCADE;
+DROP TABLE IF EXISTS permit_verification_tokens CASCADE;
+DROP TABLE IF EXISTS job_runs CASCADE;
+DROP TABLE IF EXISTS job_states CASCADE;
+DROP TABLE IF EXISTS permit_escalations CASCADE;
+DROP TABLE IF EXISTS escalation_rules CASCADE;
+DROP TABLE IF EXISTS webhook_delivery_attempts CASCADE;
+DROP TABLE IF EXISTS webhook_deliveries CASCADE;
+DROP TABLE IF EXISTS webhook_subscriptions CASCADE;
+DROP TABLE IF EXISTS email_templates CASCADE;
+DROP TABLE IF EXISTS email_delivery_attempts CASCADE;
+DROP TABLE IF EXISTS email_outbox CASCADE;
+DROP TABLE IF EXISTS notification_digest_settings CASCADE;
+DROP TABLE IF EXISTS notification_digest_items CASCADE;
+DROP TABLE IF EXISTS reminder_deliveries CASCADE;
+DROP TABLE IF EXISTS notification_recipient_rules CASCADE;
+DROP TABLE IF EXISTS notification_preferences CASCADE;
 DROP TABLE IF EXISTS notifications CASCADE;
+DROP TABLE IF EXISTS permit_obligation_evidence CASCADE;
+DROP TABLE IF EXISTS permit_obligation_completions CASCADE;
+DROP TABLE IF EXISTS permit_obligations CASCADE;
+DROP TABLE IF EXISTS reminder_schedules CASCADE;
+DROP TABLE IF EXISTS permit_approvals CASCADE;
+DROP TABLE IF EXISTS permit_revisions CASCADE;
+DROP TABLE IF EXISTS permit_documents CASCADE;
 DROP TABLE IF EXISTS permits CASCADE;
 DROP TABLE IF EXISTS permit_types CASCADE;
 DROP TABLE IF EXISTS divisions CASCADE;
@@ -32,6 +76,7 @@ CREATE TABLE domains (
     code VARCHAR(50) UNIQUE NOT NULL,
     name VARCHAR(255) NOT NULL,
     description TEXT,
+    locale VARCHAR(5) NOT NULL DEFAULT 'id' CHECK (locale IN ('id', 'en')),
     is_active BOOLEAN NOT NULL DEFAULT true,
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
@@ -90,6 +135,7 @@ CREATE TABLE users (
     is_active BOOLEAN NOT NULL DEFAULT true,
     phone_number VARCHAR(20),
     nip VARCHAR(50),
+    locale VARCHAR(5) CHECK (locale IN ('id', 'en')),
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
@@ -142,9 +188,11 @@ CREATE TABLE divisions (
     domain_id BIGINT NOT NULL,
     code VARCHAR(50) NOT NULL,
     name VARCHAR(255) NOT NULL,
+    head_user_id BIGINT,
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE RESTRICT,
+    FOREIGN KEY (head_user_id) REFERENCES users(id) ON DELETE SET NULL,
     UNIQUE(domain_id, code)
 );
 
@@ -163,6 +211,21 @@ CREATE TABLE permit_types (
     FOREIGN KEY (division_id) REFERENCES divisions(id) ON DELETE SET NULL
 );
 
+-- Create Reminder Schedules table for expiry reminder offsets
+-- A row belongs to a permit type, or to a domain as the default for permit types without a schedule
+CREATE TABLE reminder_schedules (
+    id BIGSERIAL PRIMARY KEY,
+    permit_type_id BIGINT,
+    domain_id BIGINT,
+    days_before INTEGER NOT NULL CHECK (days_before >= 0),
+    severity VARCHAR(20) NOT NULL CHECK (severity IN ('info', 'warning', 'critical')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (permit_type_id) REFERENCES permit_types(id) ON DELETE CASCADE,
+    FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE,
+    CHECK ((permit_type_id IS NULL) <> (domain_id IS NULL))
+);
+
 -- Create Permits table
 CREATE TABLE permits (
     id BIGSERIAL PRIMARY KEY,
@@ -179,33 +242,387 @@ CREATE TABLE permits (
     responsible_doc_person_id BIGINT,
     doc_name VARCHAR(255),
     doc_number VARCHAR(100),
-    doc_file_name VARCHAR(255),
-    doc_file_path VARCHAR(500),
-    doc_file_size BIGINT,
-    doc_file_type VARCHAR(100),
     status VARCHAR(50) NOT NULL DEFAULT 'active',
+    previous_permit_id BIGINT,
+    superseded_at TIMESTAMP,
+    requested_by BIGINT,
+    approval_status_id BIGINT,
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP,
     FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE RESTRICT,
     FOREIGN KEY (division_id) REFERENCES divisions(id) ON DELETE SET NULL,
     FOREIGN KEY (permit_type_id) REFERENCES permit_types(id) ON DELETE RESTRICT,
     FOREIGN KEY (responsible_person_id) REFERENCES users(id) ON DELETE SET NULL,
     FOREIGN KEY (responsible_doc_person_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (previous_permit_id) REFERENCES permits(id) ON DELETE SET NULL,
+    FOREIGN KEY (requested_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (approval_status_id) REFERENCES "references"(id) ON DELETE SET NULL,
+    CONSTRAINT chk_permits_status CHECK (status IN ('draft', 'pending', 'active', 'expiring', 'expired', 'in_renewal', 'revoked', 'archived', 'superseded')),
     UNIQUE(domain_id, permit_no)
 );
 
+-- Create Permit Documents table for permit attachments
+CREATE TABLE permit_documents (
+    id BIGSERIAL PRIMARY KEY,
+    permit_id BIGINT NOT NULL,
+    document_type_id BIGINT NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    file_path VARCHAR(500) NOT NULL,
+    file_size BIGINT,
+    file_type VARCHAR(100),
+    uploaded_by BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (permit_id) REFERENCES permits(id) ON DELETE CASCADE,
+    FOREIGN KEY (document_type_id) REFERENCES "references"(id) ON DELETE RESTRICT,
+    FOREIGN KEY (uploaded_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Create Permit Approvals table for the permit approval workflow
+-- Sequence 1 is the division head verification, sequence 2 the permit manager approval.
+-- Each submission adds a new round, rows of earlier rounds have status = false.
+CREATE TABLE permit_approvals (
+    id BIGSERIAL PRIMARY KEY,
+    permit_id BIGINT NOT NULL,
+    sequence SMALLINT NOT NULL CHECK (sequence IN (1, 2)),
+    approved_by BIGINT,
+    approval_status_id BIGINT,
+    approval_date TIMESTAMP WITH TIME ZONE,
+    note VARCHAR(500),
+    status BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (permit_id) REFERENCES permits(id) ON DELETE CASCADE,
+    FOREIGN KEY (approved_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (approval_status_id) REFERENCES "references"(id) ON DELETE SET NULL
+);
+
+-- Create Permit Revisions table for permit change history
+-- No foreign key to permits so the history is kept after a permit is deleted
+CREATE TABLE permit_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    permit_id BIGINT NOT NULL,
+    revision INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    snapshot JSONB NOT NULL,
+    changes JSONB,
+    changed_by BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (changed_by) REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE(permit_id, revision)
+);
+
+-- Create Permit Obligations table for conditions attached to a permit
+CREATE TABLE permit_obligations (
+    id BIGSERIAL PRIMARY KEY,
+    permit_id BIGINT NOT NULL,
+    description TEXT NOT NULL,
+    recurrence_rule VARCHAR(20) NOT NULL DEFAULT 'once' CHECK (recurrence_rule IN ('once', 'monthly', 'quarterly', 'semi_annual', 'annual')),
+    next_due_date DATE NOT NULL,
+    responsible_user_id BIGINT,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'completed')),
+    created_by BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (permit_id) REFERENCES permits(id) ON DELETE CASCADE,
+    FOREIGN KEY (responsible_user_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Create Permit Obligation Completions table (one row per fulfilled due date)
+CREATE TABLE permit_obligation_completions (
+    id BIGSERIAL PRIMARY KEY,
+    obligation_id BIGINT NOT NULL,
+    due_date DATE NOT NULL,
+    completed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_by BIGINT,
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (obligation_id) REFERENCES permit_obligations(id) ON DELETE CASCADE,
+    FOREIGN KEY (completed_by) REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE(obligation_id, due_date)
+);
+
+-- Create Permit Obligation Evidence table for files uploaded with a completion
+CREATE TABLE permit_obligation_evidence (
+    id BIGSERIAL PRIMARY KEY,
+    completion_id BIGINT NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    file_path VARCHAR(500) NOT NULL,
+    file_size BIGINT,
+    file_type VARCHAR(100),
+    uploaded_by BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (completion_id) REFERENCES permit_obligation_completions(id) ON DELETE CASCADE,
+    FOREIGN KEY (uploaded_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
 CREATE TABLE IF NOT EXISTS notifications (
     id BIGSERIAL PRIMARY KEY,
     user_id BIGINT NOT NULL,
-    permit_id BIGINT NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    link VARCHAR(255),
+    permit_id BIGINT,
     type VARCHAR(50) NOT NULL,
     title VARCHAR(255) NOT NULL,
     message TEXT NOT NULL,
     is_read BOOLEAN DEFAULT FALSE,
     read_at TIMESTAMP,
+    reminder_days INTEGER,
+    obligation_id BIGINT,
+    due_date DATE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     CONSTRAINT fk_notifications_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-    CONSTRAINT fk_notifications_permit FOREIGN KEY (permit_id) REFERENCES permits(id) ON DELETE CASCADE
+    CONSTRAINT fk_notifications_permit FOREIGN KEY (permit_id) REFERENCES permits(id) ON DELETE CASCADE,
+    CONSTRAINT fk_notifications_obligation FOREIGN KEY (obligation_id) REFERENCES permit_obligations(id) ON DELETE CASCADE
+);
+
+-- Create Notification Preferences table (channels per user and notification event)
+CREATE TABLE notification_preferences (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    in_app BOOLEAN NOT NULL DEFAULT TRUE,
+    email BOOLEAN NOT NULL DEFAULT TRUE,
+    digest BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, event_type),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Create Notification Recipient Rules table (who receives an event's notifications in a domain)
+CREATE TABLE notification_recipient_rules (
+    id BIGSERIAL PRIMARY KEY,
+    domain_id BIGINT NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    notify_responsible BOOLEAN NOT NULL DEFAULT TRUE,
+    role_codes JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(domain_id, event_type),
+    FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE
+);
+
+-- Create Reminder Deliveries table (each reminder offset is sent once per permit expiry date or obligation due date)
+CREATE TABLE reminder_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    permit_id BIGINT NOT NULL,
+    obligation_id BIGINT,
+    expiry_date DATE,
+    due_date DATE,
+    reminder_days INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (permit_id) REFERENCES permits(id) ON DELETE CASCADE,
+    FOREIGN KEY (obligation_id) REFERENCES permit_obligations(id) ON DELETE CASCADE
+);
+
+-- Create Notification Digest Items table (notifications waiting for the user's next digest email)
+CREATE TABLE notification_digest_items (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    domain_id BIGINT NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    link VARCHAR(255),
+    type VARCHAR(50) NOT NULL,
+    severity VARCHAR(20) NOT NULL CHECK (severity IN ('info', 'warning', 'critical')),
+    title VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE
+);
+
+-- Create Notification Digest Settings table (digest frequency per user)
+CREATE TABLE notification_digest_settings (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL UNIQUE,
+    frequency VARCHAR(20) NOT NULL DEFAULT 'daily' CHECK (frequency IN ('daily', 'weekly')),
+    last_sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Create Email Outbox table (emails queued for delivery by the email worker)
+CREATE TABLE email_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    recipients JSONB NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    text_body TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sending', 'sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP,
+    last_error TEXT,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create Email Delivery Attempts table (outcome of each try to send an outbox email)
+CREATE TABLE email_delivery_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    outbox_id BIGINT NOT NULL,
+    attempt INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('sent', 'failed')),
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (outbox_id) REFERENCES email_outbox(id) ON DELETE CASCADE
+);
+
+-- Create Email Templates table (per-domain overrides of the built-in email templates)
+CREATE TABLE email_templates (
+    id BIGSERIAL PRIMARY KEY,
+    domain_id BIGINT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    locale VARCHAR(5) NOT NULL CHECK (locale IN ('id', 'en')),
+    subject VARCHAR(255) NOT NULL,
+    html_body TEXT NOT NULL,
+    text_body TEXT NOT NULL,
+    updated_by BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (domain_id, name, locale),
+    FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE,
+    FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Create Webhook Subscriptions table (receiver URLs of a domain and the events posted to them)
+CREATE TABLE webhook_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    domain_id BIGINT NOT NULL,
+    url VARCHAR(500) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types JSONB NOT NULL DEFAULT '[]',
+    description VARCHAR(255),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by BIGINT,
+    updated_by BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Create Webhook Deliveries table (events queued for one subscription, posted by the webhook worker)
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sending', 'delivered', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 8,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP,
+    last_error TEXT,
+    response_status INTEGER,
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id) ON DELETE CASCADE
+);
+
+-- Create Webhook Delivery Attempts table (outcome of each post of a webhook delivery)
+CREATE TABLE webhook_delivery_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    delivery_id BIGINT NOT NULL,
+    attempt INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('delivered', 'failed')),
+    response_status INTEGER,
+    response_body TEXT,
+    error TEXT,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries(id) ON DELETE CASCADE
+);
+
+-- Create Escalation Rules table (who is notified when a permit's expiry reminders are ignored, per offset)
+CREATE TABLE escalation_rules (
+    id BIGSERIAL PRIMARY KEY,
+    domain_id BIGINT NOT NULL,
+    level INTEGER NOT NULL,
+    days_before INTEGER NOT NULL,
+    notify_responsible BOOLEAN NOT NULL DEFAULT FALSE,
+    notify_division_head BOOLEAN NOT NULL DEFAULT FALSE,
+    role_codes JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(domain_id, days_before),
+    FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE
+);
+
+-- Create Permit Escalations table (escalation levels each permit has reached, each rule offset escalates once per permit)
+CREATE TABLE permit_escalations (
+    id BIGSERIAL PRIMARY KEY,
+    permit_id BIGINT NOT NULL,
+    domain_id BIGINT NOT NULL,
+    level INTEGER NOT NULL,
+    days_before INTEGER NOT NULL,
+    expiry_date DATE NOT NULL,
+    recipients INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(permit_id, days_before),
+    FOREIGN KEY (permit_id) REFERENCES permits(id) ON DELETE CASCADE,
+    FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE
+);
+
+-- Create Job States table (pause state of the scheduled jobs, jobs without a row have never been paused)
+CREATE TABLE job_states (
+    name VARCHAR(100) PRIMARY KEY,
+    is_paused BOOLEAN NOT NULL DEFAULT FALSE,
+    paused_by BIGINT,
+    paused_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (paused_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Create Job Runs table (one row per run of a scheduled job)
+CREATE TABLE job_runs (
+    id BIGSERIAL PRIMARY KEY,
+    job_name VARCHAR(100) NOT NULL,
+    trigger VARCHAR(20) NOT NULL CHECK (trigger IN ('schedule', 'manual')),
+    triggered_by BIGINT,
+    status VARCHAR(20) NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed')),
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP,
+    duration_ms BIGINT,
+    items_processed INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    FOREIGN KEY (triggered_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Create Calendar Tokens table (one iCalendar feed token per user)
+CREATE TABLE calendar_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL UNIQUE,
+    domain_id BIGINT NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE
+);
+
+-- Create Permit Verification Tokens table (token behind the QR code printed on a posted permit)
+CREATE TABLE permit_verification_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    permit_id BIGINT NOT NULL,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    created_by BIGINT,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (permit_id) REFERENCES permits(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
 );
 -- Create Projects table
 CREATE TABLE projects (
@@ -368,6 +785,7 @@ CREATE INDEX idx_menu_roles_menu_id ON menu_roles(menu_id);
 CREATE INDEX idx_menu_roles_role_id ON menu_roles(role_id);
 CREATE INDEX idx_divisions_domain_id ON divisions(domain_id);
 CREATE INDEX idx_divisions_code ON divisions(code);
+CREATE INDEX idx_divisions_head_user_id ON divisions(head_user_id);
 CREATE INDEX idx_permit_types_division_id ON permit_types(division_id);
 CREATE INDEX idx_permit_types_name ON permit_types(name);
 CREATE INDEX idx_permit_types_code ON permit_types(code);
@@ -381,11 +799,63 @@ CREATE INDEX idx_permits_responsible_doc_person_id ON permits(responsible_doc_pe
 CREATE INDEX idx_permits_status ON permits(status);
 CREATE INDEX idx_permits_effective_date ON permits(effective_date);
 CREATE INDEX idx_permits_expiry_date ON permits(expiry_date);
+CREATE INDEX idx_permits_requested_by ON permits(requested_by);
+CREATE INDEX idx_permits_approval_status_id ON permits(approval_status_id);
+CREATE INDEX idx_permits_deleted_at ON permits(deleted_at);
+CREATE UNIQUE INDEX idx_permits_previous_permit_id ON permits(previous_permit_id) WHERE previous_permit_id IS NOT NULL AND deleted_at IS NULL;
+CREATE INDEX idx_permit_documents_permit_id ON permit_documents(permit_id);
+CREATE INDEX idx_permit_documents_document_type_id ON permit_documents(document_type_id);
+CREATE INDEX idx_permit_approvals_permit_id ON permit_approvals(permit_id);
+CREATE INDEX idx_permit_approvals_approved_by ON permit_approvals(approved_by);
+CREATE INDEX idx_permit_approvals_approval_status_id ON permit_approvals(approval_status_id);
+CREATE INDEX idx_permit_approvals_permit_status ON permit_approvals(permit_id, status);
+
+-- Indexes for reminder_deliveries (one delivery per reminder offset)
+CREATE UNIQUE INDEX idx_reminder_deliveries_permit ON reminder_deliveries(permit_id, expiry_date, reminder_days) WHERE obligation_id IS NULL;
+CREATE UNIQUE INDEX idx_reminder_deliveries_obligation ON reminder_deliveries(obligation_id, due_date, reminder_days) WHERE obligation_id IS NOT NULL;
+
+-- Indexes for notification_digest_items (pending items per user)
+CREATE INDEX idx_notification_digest_items_pending ON notification_digest_items(user_id) WHERE sent_at IS NULL;
+
+-- Indexes for email_outbox and email_delivery_attempts
+CREATE INDEX idx_email_outbox_due ON email_outbox(next_attempt_at) WHERE status IN ('pending', 'sending');
+CREATE INDEX idx_email_outbox_status ON email_outbox(status);
+CREATE INDEX idx_email_outbox_created_at ON email_outbox(created_at DESC);
+CREATE INDEX idx_email_delivery_attempts_outbox_id ON email_delivery_attempts(outbox_id);
+
+-- Indexes for webhook_subscriptions, webhook_deliveries and webhook_delivery_attempts
+CREATE INDEX idx_webhook_subscriptions_domain_id ON webhook_subscriptions(domain_id);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status IN ('pending', 'sending');
+CREATE INDEX idx_webhook_deliveries_subscription_id ON webhook_deliveries(subscription_id, created_at DESC);
+CREATE INDEX idx_webhook_delivery_attempts_delivery_id ON webhook_delivery_attempts(delivery_id);
+
+-- Indexes for permit_escalations
+CREATE INDEX idx_permit_escalations_domain_id ON permit_escalations(domain_id, created_at DESC);
+
+-- Indexes for job_runs
+CREATE INDEX idx_job_runs_job_name ON job_runs(job_name, started_at DESC);
+
+-- Indexes for permit_verification_tokens (one unrevoked token per permit)
+CREATE INDEX idx_permit_verification_tokens_permit_id ON permit_verification_tokens(permit_id);
+CREATE UNIQUE INDEX idx_permit_verification_tokens_active ON permit_verification_tokens(permit_id) WHERE revoked_at IS NULL;
+CREATE INDEX idx_permit_revisions_permit_id ON permit_revisions(permit_id);
+CREATE INDEX idx_permit_revisions_changed_by ON permit_revisions(changed_by);
+CREATE INDEX idx_permit_revisions_created_at ON permit_revisions(created_at);
+CREATE INDEX idx_permit_obligations_permit_id ON permit_obligations(permit_id);
+CREATE INDEX idx_permit_obligations_responsible_user_id ON permit_obligations(responsible_user_id);
+CREATE INDEX idx_permit_obligations_status_due ON permit_obligations(status, next_due_date);
+CREATE INDEX idx_permit_obligation_completions_obligation_id ON permit_obligation_completions(obligation_id);
+CREATE INDEX idx_permit_obligation_evidence_completion_id ON permit_obligation_evidence(completion_id);
+CREATE UNIQUE INDEX idx_reminder_schedules_permit_type_days ON reminder_schedules(permit_type_id, days_before) WHERE permit_type_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_reminder_schedules_domain_days ON reminder_schedules(domain_id, days_before) WHERE domain_id IS NOT NULL;
 CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id);
 CREATE INDEX IF NOT EXISTS idx_notifications_permit_id ON notifications(permit_id);
+CREATE INDEX IF NOT EXISTS idx_notifications_entity ON notifications(entity_type, entity_id);
 CREATE INDEX IF NOT EXISTS idx_notifications_is_read ON notifications(is_read);
 CREATE INDEX IF NOT EXISTS idx_notifications_created_at ON notifications(created_at DESC);
 CREATE INDEX IF NOT EXISTS idx_notifications_type ON notifications(type);
+CREATE INDEX IF NOT EXISTS idx_notifications_permit_reminder ON notifications(permit_id, reminder_days);
+CREATE INDEX IF NOT EXISTS idx_notifications_obligation_reminder ON notifications(obligation_id, due_date, reminder_days);
 
 -- Insert sample data (optional)
 
@@ -408,7 +878,8 @@ INSERT INTO reference_categories (id, module_id, name, is_active) VALUES
 (4, 1, 'Task Stack', true),
 (5, 1, 'Task Approval Status', true),
 (6, 2, 'Project Status', true),
-(7, 1, 'Task File Type', true);
+(7, 1, 'Task File Type', true),
+(8, 3, 'Permit Document Type', true);
 
 -- Sample References for Task Management
 INSERT INTO "references" (id, reference_category_id, name, is_active) VALUES
@@ -446,7 +917,12 @@ INSERT INTO "references" (id, reference_category_id, name, is_active) VALUES
 (30, 7, 'Create', true),
 (31, 7, 'Before', true),
 (32, 7, 'After', true),
-(38, 7, 'File Revision', true);
+(38, 7, 'File Revision', true),
+-- Permit Document Type (category_id = 8)
+(40, 8, 'License', true),
+(41, 8, 'Attachment', true),
+(42, 8, 'Payment Receipt', true),
+(43, 8, 'Inspection Report', true);
 
 -- Sample Roles with category (Permit and Ticketing)
 INSERT INTO roles (code, name, category, description) VALUES
@@ -460,6 +936,11 @@ INSERT INTO roles (code, name, category, description) VALUES
 ('TICKETING_MANAGER', 'Ticketing Manager', 'Ticketing', 'Client Manager for ticketing operations'),
 ('TICKETING_HEAD_OF_UNIT', 'Ticketing Head of Unit', 'Ticketing', 'Client Head of Unit for ticketing system');
 
+-- Super admin is not limited to the domain of the token (role_id = 8).
+-- Grant it through user_domain_roles to users that manage every domain.
+INSERT INTO roles (code, name, category, description) VALUES
+('SUPER_ADMIN', 'Super Admin', 'Permit', 'Administrator with access to permits, divisions and permit types of every domain');
+
 -- Sample Users (password: "password123" hashed with bcrypt cost 10)
 INSERT INTO users (username, email, password, full_name, is_active) VALUES
 ('admin', 'admin@example.com', '$2a$10$JhNQe7yZL1btTXQ9q27g4Ooz09HnlGwYATSfDFVRJJYahHGiOUDxy', 'System Administrator', true),
@@ -563,6 +1044,10 @@ INSERT INTO menu_roles (menu_id, role_id) VALUES
 (4, 7), -- Task Requests -> Ticketing Head of Unit
 (5, 7); -- Projects -> Ticketing Head of Unit
 
+-- role_id = 8 (Super Admin) gets the same menus as Admin
+INSERT INTO menu_roles (menu_id, role_id)
+SELECT menu_id, 8 FROM menu_roles WHERE role_id = 1;
+
 -- Sample Divisions
 INSERT INTO divisions (domain_id, code, name) VALUES
 (1, 'IT', 'Information Technology'),
@@ -578,6 +1063,20 @@ INSERT INTO permit_types (division_id, code, name, risk_point, default_applicati
 (2, 'KOMP', 'Kompetensi', 'Medium', 'New Application', '1 year', 'Competency certificates for staff'),
 (3, 'OPER', 'Operasional', 'High', 'New Application', '3 years', 'Operational permits for business activities'),
 (4, 'FIN', 'Financial', 'Low', 'New Application', '2 years', 'Financial and regulatory permits');
+
+-- Sample Reminder Schedules
+-- Facility permits need a long lead time for renewal; domain 1 gets a default schedule
+INSERT INTO reminder_schedules (permit_type_id, domain_id, days_before, severity) VALUES
+(1, NULL, 90, 'info'),
+(1, NULL, 60, 'info'),
+(1, NULL, 30, 'warning'),
+(1, NULL, 7, 'critical'),
+(1, NULL, 0, 'critical'),
+(NULL, 1, 30, 'info'),
+(NULL, 1, 14, 'warning'),
+(NULL, 1, 7, 'critical'),
+(NULL, 1, 0, 'critical');
+
 -- Sample Projects (using project_status_id = 26 for 'Pending' from references)
 INSERT INTO projects (domain_id, name, code, description, status, project_status_id) VALUES
 (1, 'RS TRIA DIPA', 'rs-tria-dipa', 'Hospital Management System for RS TRIA DIPA', true, 26),
@@ -601,9 +1100,9 @@ INSERT INTO user_projects (user_id, project_id) VALUES
 
 -- Sample Permits
 INSERT INTO permits (domain_id, division_id, permit_type_id, name, application_type, permit_no, effective_date, expiry_date, effective_term, responsible_person_id, responsible_doc_person_id, doc_name, doc_number, status) VALUES
-(1, 1, 1, 'Building Construction', 'New Application', 'PRM-2025-001', '2025-01-01', '2026-01-01', '1 year', 1, 2, 'Building Construction Permit', 'DOC-2025-001', 'active'),
+(1, 1, 1, 'Building Construction', 'New Application', 'PRM-2025-001', '2025-01-01', '2026-01-01', '1 year', 1, 2, 'Building Construction Permit', 'DOC-2025-001', 'expired'),
 (1, 1, 2, 'Medical Laboratory', 'New Application', 'PRM-2025-002', '2025-01-15', '2027-01-15', '2 years', 2, 1, 'Clinical Lab Permit', 'DOC-2025-002', 'active'),
-(1, 2, 3, 'Nurse Certification', 'Renewal', 'PRM-2025-003', '2025-02-01', '2026-02-01', '1 year', 3, 2, 'Competency Certificate', 'DOC-2025-003', 'active'),
+(1, 2, 3, 'Nurse Certification', 'Renewal', 'PRM-2025-003', '2025-02-01', '2026-02-01', '1 year', 3, 2, 'Competency Certificate', 'DOC-2025-003', 'expired'),
 (2, 4, 4, 'Surgery Room Operations', 'New Application', 'PRM-2025-004', '2025-03-01', '2028-03-01', '3 years', 4, 4, 'Operational Permit', 'DOC-2025-004', 'active'),
 (2, 5, 5, 'Financial System', 'New Application', 'PRM-2025-005', '2025-04-01', '2027-04-01', '2 years', 4, 1, 'Financial License', 'DOC-2025-005', 'active');
 
@@ -656,9 +1155,78 @@ CREATE TRIGGER update_permits_updated_at BEFORE UPDATE ON permits
 CREATE TRIGGER update_projects_updated_at BEFORE UPDATE ON projects
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+CREATE TRIGGER update_reminder_schedules_updated_at BEFORE UPDATE ON reminder_schedules
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_permit_documents_updated_at BEFORE UPDATE ON permit_documents
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_permit_obligations_updated_at BEFORE UPDATE ON permit_obligations
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_permit_approvals_updated_at BEFORE UPDATE ON permit_approvals
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_notification_preferences_updated_at BEFORE UPDATE ON notification_preferences
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_notification_recipient_rules_updated_at BEFORE UPDATE ON notification_recipient_rules
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_notification_digest_settings_updated_at BEFORE UPDATE ON notification_digest_settings
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_email_outbox_updated_at BEFORE UPDATE ON email_outbox
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_email_templates_updated_at BEFORE UPDATE ON email_templates
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_webhook_subscriptions_updated_at BEFORE UPDATE ON webhook_subscriptions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_webhook_deliveries_updated_at BEFORE UPDATE ON webhook_deliveries
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_escalation_rules_updated_at BEFORE UPDATE ON escalation_rules
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_job_states_updated_at BEFORE UPDATE ON job_states
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Function to keep permit revisions immutable
+CREATE OR REPLACE FUNCTION prevent_permit_revision_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'permit_revisions is append-only';
+END;
+$$ language 'plpgsql';
+
+-- Trigger blocking updates and deletes of permit revisions
+-- (changed_by is nulled by its foreign key when a user is deleted, so only that column may change)
+CREATE TRIGGER prevent_permit_revisions_update BEFORE UPDATE OF permit_id, revision, action, snapshot, changes, created_at ON permit_revisions
+    FOR EACH ROW EXECUTE FUNCTION prevent_permit_revision_change();
+
+CREATE TRIGGER prevent_permit_revisions_delete BEFORE DELETE ON permit_revisions
+    FOR EACH ROW EXECUTE FUNCTION prevent_permit_revision_change();
+
 CREATE TRIGGER update_user_projects_updated_at BEFORE UPDATE ON user_projects
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+-- Function announcing new notifications on the notification_created channel,
+-- every instance listens and pushes them to its open notification streams
+CREATE OR REPLACE FUNCTION notify_notification_created()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('notification_created', json_build_object('id', NEW.id, 'user_id', NEW.user_id)::text);
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+-- Notifications are delivered when the inserting transaction commits
+CREATE TRIGGER notify_notifications_created AFTER INSERT ON notifications
+    FOR EACH ROW EXECUTE FUNCTION notify_notification_created();
+
 -- Add comments to tables
 COMMENT ON TABLE domains IS 'Stores company/organization domains for multi-tenancy';
 COMMENT ON TABLE modules IS 'Stores modules for categorizing reference data (Task, Project, Permit)';
@@ -672,13 +1240,35 @@ COMMENT ON TABLE menu_roles IS 'Junction table for role-based menu access contro
 COMMENT ON TABLE divisions IS 'Stores company divisions/departments';
 COMMENT ON TABLE permit_types IS 'Stores types of permits available';
 COMMENT ON TABLE permits IS 'Stores individual permit records';
+COMMENT ON TABLE reminder_schedules IS 'Stores expiry reminder offsets per permit type, with optional per-domain defaults';
+COMMENT ON TABLE permit_documents IS 'Stores files attached to permits (license, attachments, payment receipts, inspection reports)';
+COMMENT ON TABLE permit_approvals IS 'Stores the approval steps of permit submissions (division head verification, permit manager approval)';
+COMMENT ON TABLE permit_revisions IS 'Append-only change history of permits (snapshot and field-level diff per change)';
+COMMENT ON TABLE notification_preferences IS 'Stores the channels (in-app, email, digest) each user receives a notification event on, events without a row use the defaults';
+COMMENT ON TABLE notification_recipient_rules IS 'Stores per-domain replacements of the built-in recipients of permit expiry and obligation notifications';
+COMMENT ON TABLE reminder_deliveries IS 'Records the expiry and obligation reminders already sent, whichever channels their recipients receive them on';
+COMMENT ON TABLE notification_digest_items IS 'Stores notifications queued for digest emails, for users with the digest preference on for the event';
+COMMENT ON TABLE notification_digest_settings IS 'Stores how often each user''s digest email is sent, users without a row get a daily digest';
+COMMENT ON TABLE email_outbox IS 'Stores emails queued together with their notifications, delivered and retried by the email worker';
+COMMENT ON TABLE email_delivery_attempts IS 'Stores the outcome of every attempt to send an outbox email';
+COMMENT ON TABLE email_templates IS 'Stores per-domain overrides of the built-in email templates, rendered inside the shared layout';
+COMMENT ON TABLE webhook_subscriptions IS 'Stores the receiver URLs a domain registered for outbound webhooks, with the signing secret and the selected event types';
+COMMENT ON TABLE webhook_deliveries IS 'Stores the events queued for each webhook subscription, posted and retried by the webhook worker';
+COMMENT ON TABLE webhook_delivery_attempts IS 'Stores the receiver''s answer to every post of a webhook delivery';
+COMMENT ON TABLE escalation_rules IS 'Stores per-domain rules widening the audience of a permit''s expiry once its reminders were ignored';
+COMMENT ON TABLE permit_escalations IS 'Stores the escalation levels each permit has reached';
+COMMENT ON TABLE job_states IS 'Stores the pause state of the scheduled jobs';
+COMMENT ON TABLE job_runs IS 'Stores the run history of the scheduled jobs';
+COMMENT ON TABLE calendar_tokens IS 'Stores per-user iCalendar feed tokens (hashed), used by calendar clients to subscribe without a login';
+COMMENT ON TABLE permit_verification_tokens IS 'Stores the tokens encoded in permit QR codes, checked by the public verification endpoint';
 
 COMMENT ON COLUMN domains.code IS 'Unique code for the domain/company';
 COMMENT ON COLUMN domains.name IS 'Full name of the domain/company';
 COMMENT ON COLUMN domains.description IS 'Description of the domain/company';
 COMMENT ON COLUMN domains.is_active IS 'Whether the domain is active or not';
+COMMENT ON COLUMN domains.locale IS 'Locale of emails to users of the domain who have not chosen one (id or en)';
 
-COMMENT ON COLUMN roles.code IS 'Unique code for the role (e.g., ADMIN, PERMIT_MANAGER)';
+COMMENT ON COLUMN roles.code IS 'Unique code for the role (e.g., ADMIN, PERMIT_MANAGER). SUPER_ADMIN may access every domain';
 COMMENT ON COLUMN roles.name IS 'Display name of the role';
 COMMENT ON COLUMN roles.category IS 'Category of the role: Permit or Ticketing';
 COMMENT ON COLUMN roles.description IS 'Description of the role and its permissions';
@@ -690,6 +1280,7 @@ COMMENT ON COLUMN users.full_name IS 'Full name of the user';
 COMMENT ON COLUMN users.is_active IS 'Whether the user account is active';
 COMMENT ON COLUMN users.phone_number IS 'User phone number';
 COMMENT ON COLUMN users.nip IS 'Nomor Induk Pegawai (Employee ID Number)';
+COMMENT ON COLUMN users.locale IS 'Locale of the user''s emails (id or en), NULL follows the domain';
 
 COMMENT ON COLUMN user_domain_roles.user_id IS 'Reference to the user';
 COMMENT ON COLUMN user_domain_roles.domain_id IS 'Reference to the domain';
@@ -709,6 +1300,7 @@ COMMENT ON COLUMN menu_roles.role_id IS 'Reference to the role that can access t
 COMMENT ON COLUMN divisions.domain_id IS 'Reference to the domain/company';
 COMMENT ON COLUMN divisions.code IS 'Code for the division (unique within domain)';
 COMMENT ON COLUMN divisions.name IS 'Full name of the division';
+COMMENT ON COLUMN divisions.head_user_id IS 'Reference to the division head who verifies submitted permits';
 
 COMMENT ON COLUMN permit_types.division_id IS 'Reference to the division that manages this permit type';
 COMMENT ON COLUMN permit_types.name IS 'Category of the permit (e.g., Facility, Clinical, Kompetensi, Operasional)';
@@ -727,4 +1319,119 @@ COMMENT ON COLUMN permits.expiry_date IS 'Date when the permit expires';
 COMMENT ON COLUMN permits.effective_term IS 'Duration of the permit validity';
 COMMENT ON COLUMN permits.responsible_person_id IS 'Reference to user responsible for this permit';
 COMMENT ON COLUMN permits.responsible_doc_person_id IS 'Reference to user responsible for permit documentation';
-COMMENT ON COLUMN permits.status IS 'Current status of the permit (active, expired, revoked)';
+COMMENT ON COLUMN permits.status IS 'Current status of the permit (draft, pending, active, expiring, expired, in_renewal, revoked, archived, superseded)';
+COMMENT ON COLUMN permits.previous_permit_id IS 'Reference to the permit period this permit renews';
+COMMENT ON COLUMN permits.superseded_at IS 'When this permit period was superseded by a renewal';
+COMMENT ON COLUMN permits.requested_by IS 'Reference to the user who submitted the permit for approval';
+COMMENT ON COLUMN permits.approval_status_id IS 'Approval status of the latest submission (Waiting, Pending Manager, Approve, Reject)';
+COMMENT ON COLUMN permits.deleted_at IS 'When the permit was moved to the trash, NULL for live permits';
+COMMENT ON COLUMN permit_approvals.permit_id IS 'Reference to the submitted permit';
+COMMENT ON COLUMN permit_approvals.sequence IS 'Approval step (1 = division head verification, 2 = permit manager approval)';
+COMMENT ON COLUMN permit_approvals.approved_by IS 'Reference to the user who approved or rejected the step';
+COMMENT ON COLUMN permit_approvals.approval_status_id IS 'Step status (Waiting, Approve, Reject)';
+COMMENT ON COLUMN permit_approvals.note IS 'Note left by the approver, shown to the requester on rejection';
+COMMENT ON COLUMN permit_approvals.status IS 'true for steps of the current submission, false for earlier rounds';
+
+COMMENT ON COLUMN reminder_schedules.permit_type_id IS 'Permit type the offset belongs to';
+COMMENT ON COLUMN reminder_schedules.domain_id IS 'Domain whose default schedule the offset belongs to';
+COMMENT ON COLUMN reminder_schedules.days_before IS 'Days before expiry the reminder is sent (0 = expiry day)';
+COMMENT ON COLUMN reminder_schedules.severity IS 'Reminder severity (info, warning, critical)';
+
+COMMENT ON COLUMN notifications.entity_type IS 'Kind of record the notification is about (permit, task, project)';
+COMMENT ON COLUMN notifications.entity_id IS 'ID of the record the notification is about';
+COMMENT ON COLUMN notifications.link IS 'Deep link to the record in the client, e.g. /tasks/12';
+COMMENT ON COLUMN notifications.permit_id IS 'Permit the notification is about, NULL for notifications about other records';
+COMMENT ON COLUMN notifications.reminder_days IS 'Reminder offset that produced the notification (-1 = overdue), sent reminders are recorded in reminder_deliveries';
+COMMENT ON COLUMN notifications.obligation_id IS 'Obligation the reminder is about, NULL for permit expiry notifications';
+COMMENT ON COLUMN notifications.due_date IS 'Obligation due date the reminder is about';
+
+COMMENT ON COLUMN permit_obligations.permit_id IS 'Reference to the permit the condition is attached to';
+COMMENT ON COLUMN permit_obligations.description IS 'What has to be done (e.g., quarterly report, annual inspection, fee payment)';
+COMMENT ON COLUMN permit_obligations.recurrence_rule IS 'How often the obligation repeats (once, monthly, quarterly, semi_annual, annual)';
+COMMENT ON COLUMN permit_obligations.next_due_date IS 'Next date the obligation has to be fulfilled by';
+COMMENT ON COLUMN permit_obligations.responsible_user_id IS 'Reference to the user who fulfils the obligation, falls back to the permit responsible person';
+COMMENT ON COLUMN permit_obligations.status IS 'open while due dates remain, completed once a one-off obligation is fulfilled';
+COMMENT ON COLUMN permit_obligations.created_by IS 'Reference to the user who added the obligation';
+
+COMMENT ON COLUMN permit_obligation_completions.obligation_id IS 'Reference to the fulfilled obligation';
+COMMENT ON COLUMN permit_obligation_completions.due_date IS 'Due date the completion fulfils';
+COMMENT ON COLUMN permit_obligation_completions.completed_at IS 'When the obligation was fulfilled';
+COMMENT ON COLUMN permit_obligation_completions.completed_by IS 'Reference to the user who recorded the completion';
+COMMENT ON COLUMN permit_obligation_completions.notes IS 'Free text notes about the completion';
+
+COMMENT ON COLUMN permit_obligation_evidence.completion_id IS 'Reference to the completion the file proves';
+COMMENT ON COLUMN permit_obligation_evidence.file_name IS 'Original filename';
+COMMENT ON COLUMN permit_obligation_evidence.file_path IS 'Server storage path';
+COMMENT ON COLUMN permit_obligation_evidence.file_size IS 'File size in bytes';
+COMMENT ON COLUMN permit_obligation_evidence.file_type IS 'MIME type';
+COMMENT ON COLUMN permit_obligation_evidence.uploaded_by IS 'Reference to the user who uploaded the file';
+
+COMMENT ON COLUMN calendar_tokens.user_id IS 'Owner of the feed, a user has at most one active token';
+COMMENT ON COLUMN calendar_tokens.domain_id IS 'Domain whose permits are included in the feed';
+COMMENT ON COLUMN calendar_tokens.token_hash IS 'SHA-256 hex digest of the feed token, the token itself is not stored';
+COMMENT ON COLUMN calendar_tokens.last_used_at IS 'Last time a calendar client fetched the feed';
+COMMENT ON COLUMN notification_preferences.event_type IS 'Notification event: permit_expiry, permit_obligation, permit_approval, task_update or task_approval';
+COMMENT ON COLUMN notification_preferences.digest IS 'Whether the event is included in the user''s digest';
+COMMENT ON COLUMN notification_recipient_rules.event_type IS 'Notification event the rule applies to: permit_expiry or permit_obligation';
+COMMENT ON COLUMN notification_recipient_rules.notify_responsible IS 'Whether the permit''s or obligation''s responsible people are notified';
+COMMENT ON COLUMN notification_recipient_rules.role_codes IS 'JSON array of role codes whose users in the domain are notified';
+
+COMMENT ON COLUMN reminder_deliveries.obligation_id IS 'Obligation the reminder was about, NULL for permit expiry reminders';
+COMMENT ON COLUMN reminder_deliveries.expiry_date IS 'Permit expiry date the reminder counted down to, a changed expiry date is reminded again';
+COMMENT ON COLUMN reminder_deliveries.reminder_days IS 'Reminder offset that was sent (-1 = overdue)';
+
+COMMENT ON COLUMN notification_digest_items.severity IS 'Severity the item is grouped under in the digest (info, warning, critical)';
+COMMENT ON COLUMN notification_digest_items.sent_at IS 'When the digest including the item was sent, NULL while pending';
+COMMENT ON COLUMN notification_digest_settings.frequency IS 'How often the digest is sent: daily or weekly';
+COMMENT ON COLUMN notification_digest_settings.last_sent_at IS 'When the user''s last digest was sent';
+
+COMMENT ON COLUMN email_outbox.recipients IS 'JSON array of recipient addresses';
+COMMENT ON COLUMN email_outbox.status IS 'pending (waiting for its next attempt), sending (claimed by the worker), sent or failed (max_attempts reached)';
+COMMENT ON COLUMN email_outbox.next_attempt_at IS 'When the worker may try the email next, moved back exponentially after each failure';
+COMMENT ON COLUMN email_outbox.locked_until IS 'Lease of the worker sending the email, expired leases are claimed again';
+COMMENT ON COLUMN email_outbox.last_error IS 'Error of the latest failed attempt';
+
+COMMENT ON COLUMN email_outbox.text_body IS 'Plain text alternative of the HTML body';
+COMMENT ON COLUMN email_templates.name IS 'Built-in template the override replaces (permit_expiry, permit_escalation or obligation_reminder)';
+COMMENT ON COLUMN email_templates.html_body IS 'html/template content rendered inside the shared HTML layout';
+COMMENT ON COLUMN email_templates.text_body IS 'text/template content of the plain text part';
+COMMENT ON COLUMN webhook_subscriptions.secret IS 'Key of the HMAC-SHA256 signature sent in the X-Webhook-Signature header';
+COMMENT ON COLUMN webhook_subscriptions.event_types IS 'JSON array of the event types posted to the URL (permit.created, permit.expiring, permit.expired, task.assigned, task.approved, task.rejected)';
+COMMENT ON COLUMN webhook_subscriptions.is_active IS 'Deliveries of inactive subscriptions wait until the subscription is activated again';
+COMMENT ON COLUMN webhook_deliveries.event_id IS 'ID of the event, the same for every subscription it was queued for, sent in the X-Webhook-ID header';
+COMMENT ON COLUMN webhook_deliveries.payload IS 'JSON body posted to the receiver';
+COMMENT ON COLUMN webhook_deliveries.status IS 'pending (waiting for its next attempt), sending (claimed by the worker), delivered (2xx answer) or failed (max_attempts reached)';
+COMMENT ON COLUMN webhook_deliveries.next_attempt_at IS 'When the worker may post the delivery next, moved back exponentially after each failure';
+COMMENT ON COLUMN webhook_deliveries.locked_until IS 'Lease of the worker posting the delivery, expired leases are claimed again';
+COMMENT ON COLUMN webhook_deliveries.response_status IS 'HTTP status the receiver answered the latest attempt with';
+COMMENT ON COLUMN webhook_delivery_attempts.response_body IS 'First kilobyte of the receiver''s answer';
+COMMENT ON COLUMN escalation_rules.level IS 'Position of the rule in the domain, numbered from the earliest offset on';
+COMMENT ON COLUMN escalation_rules.days_before IS 'Offset the rule applies from, counted like a reminder offset (0 = expiry day, negative = days after expiry)';
+COMMENT ON COLUMN escalation_rules.notify_division_head IS 'Whether the head of the permit''s division is notified';
+COMMENT ON COLUMN escalation_rules.role_codes IS 'JSON array of role codes whose users in the domain are notified, SUPER_ADMIN users are notified in any domain';
+COMMENT ON COLUMN permit_escalations.expiry_date IS 'Expiry date of the permit when it was escalated';
+COMMENT ON COLUMN permit_escalations.recipients IS 'Number of users the escalation was sent to';
+COMMENT ON COLUMN job_states.name IS 'Name the job is registered under';
+COMMENT ON COLUMN job_runs.trigger IS 'What started the run: schedule or manual';
+COMMENT ON COLUMN job_runs.triggered_by IS 'User who started a manual run';
+COMMENT ON COLUMN job_runs.items_processed IS 'Number of items the job reported it handled, such as reminders or emails sent';
+COMMENT ON COLUMN job_runs.error IS 'Error the run failed with';
+COMMENT ON COLUMN permit_verification_tokens.permit_id IS 'Permit the QR code was printed for';
+COMMENT ON COLUMN permit_verification_tokens.token IS 'Random token in the verification URL, kept so the QR code can be rendered again';
+COMMENT ON COLUMN permit_verification_tokens.created_by IS 'User who issued the token';
+COMMENT ON COLUMN permit_verification_tokens.revoked_at IS 'When the token was revoked or rotated, NULL for the permit''s current token';
+
+COMMENT ON COLUMN permit_documents.permit_id IS 'Reference to the permit the document belongs to';
+COMMENT ON COLUMN permit_documents.document_type_id IS 'Reference to the document type (Permit Document Type reference category)';
+COMMENT ON COLUMN permit_documents.file_name IS 'Original filename';
+COMMENT ON COLUMN permit_documents.file_path IS 'Server storage path';
+COMMENT ON COLUMN permit_documents.file_size IS 'File size in bytes';
+COMMENT ON COLUMN permit_documents.file_type IS 'MIME type';
+COMMENT ON COLUMN permit_documents.uploaded_by IS 'Reference to the user who uploaded the document';
+
+COMMENT ON COLUMN permit_revisions.permit_id IS 'Permit the revision belongs to (kept after the permit is deleted)';
+COMMENT ON COLUMN permit_revisions.revision IS 'Revision number, sequential per permit starting at 1';
+COMMENT ON COLUMN permit_revisions.action IS 'Change that produced the revision (create, update, upload, remove_document, delete, restore, status_change, undelete, purge)';
+COMMENT ON COLUMN permit_revisions.snapshot IS 'Permit columns after the change';
+COMMENT ON COLUMN permit_revisions.changes IS 'Field-level diff against the previous state (field, old_value, new_value)';
+COMMENT ON COLUMN permit_revisions.changed_by IS 'Reference to the user who made the change';
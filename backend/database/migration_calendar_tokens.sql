@@ -0,0 +1,21 @@
+-- Migration for per-user iCalendar feeds of permit expiries and task due dates
+-- Created: 2026-10-16
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_calendar_tokens.sql
+
+-- One token per user, only its SHA-256 hash is stored
+CREATE TABLE IF NOT EXISTS calendar_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL UNIQUE,
+    domain_id BIGINT NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE
+);
+
+COMMENT ON TABLE calendar_tokens IS 'Stores per-user iCalendar feed tokens (hashed), used by calendar clients to subscribe without a login';
+COMMENT ON COLUMN calendar_tokens.user_id IS 'Owner of the feed, a user has at most one active token';
+COMMENT ON COLUMN calendar_tokens.domain_id IS 'Domain whose permits are included in the feed';
+COMMENT ON COLUMN calendar_tokens.token_hash IS 'SHA-256 hex digest of the feed token, the token itself is not stored';
+COMMENT ON COLUMN calendar_tokens.last_used_at IS 'Last time a calendar client fetched the feed';
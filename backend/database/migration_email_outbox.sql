@@ -0,0 +1,57 @@
+-- Migration for the transactional email outbox
+-- Created: 2026-10-16
+-- Notification emails are written to email_outbox in the same transaction as
+-- their notifications and delivered by a background worker, which retries
+-- failures with exponential backoff and logs every attempt in
+-- email_delivery_attempts.
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_email_outbox.sql
+
+BEGIN;
+
+-- Create Email Outbox table (emails queued for delivery by the email worker)
+CREATE TABLE IF NOT EXISTS email_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    recipients JSONB NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sending', 'sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP,
+    last_error TEXT,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create Email Delivery Attempts table (outcome of each try to send an outbox email)
+CREATE TABLE IF NOT EXISTS email_delivery_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    outbox_id BIGINT NOT NULL,
+    attempt INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('sent', 'failed')),
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (outbox_id) REFERENCES email_outbox(id) ON DELETE CASCADE
+);
+
+-- Indexes for email_outbox and email_delivery_attempts
+CREATE INDEX IF NOT EXISTS idx_email_outbox_due ON email_outbox(next_attempt_at) WHERE status IN ('pending', 'sending');
+CREATE INDEX IF NOT EXISTS idx_email_outbox_status ON email_outbox(status);
+CREATE INDEX IF NOT EXISTS idx_email_outbox_created_at ON email_outbox(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_email_delivery_attempts_outbox_id ON email_delivery_attempts(outbox_id);
+
+DROP TRIGGER IF EXISTS update_email_outbox_updated_at ON email_outbox;
+CREATE TRIGGER update_email_outbox_updated_at BEFORE UPDATE ON email_outbox
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE email_outbox IS 'Stores emails queued together with their notifications, delivered and retried by the email worker';
+COMMENT ON TABLE email_delivery_attempts IS 'Stores the outcome of every attempt to send an outbox email';
+COMMENT ON COLUMN email_outbox.recipients IS 'JSON array of recipient addresses';
+COMMENT ON COLUMN email_outbox.status IS 'pending (waiting for its next attempt), sending (claimed by the worker), sent or failed (max_attempts reached)';
+COMMENT ON COLUMN email_outbox.next_attempt_at IS 'When the worker may try the email next, moved back exponentially after each failure';
+COMMENT ON COLUMN email_outbox.locked_until IS 'Lease of the worker sending the email, expired leases are claimed again';
+COMMENT ON COLUMN email_outbox.last_error IS 'Error of the latest failed attempt';
+
+COMMIT;
@@ -0,0 +1,44 @@
+-- Migration for template-based, localized emails
+-- Created: 2026-10-16
+-- Emails are rendered from html/template and text/template sources with a
+-- plain text alternative part. Users and domains choose the email locale
+-- (id or en), and domains may override the built-in templates in
+-- email_templates.
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_email_templates.sql
+
+BEGIN;
+
+ALTER TABLE domains ADD COLUMN IF NOT EXISTS locale VARCHAR(5) NOT NULL DEFAULT 'id' CHECK (locale IN ('id', 'en'));
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale VARCHAR(5) CHECK (locale IN ('id', 'en'));
+ALTER TABLE email_outbox ADD COLUMN IF NOT EXISTS text_body TEXT NOT NULL DEFAULT '';
+
+-- Create Email Templates table (per-domain overrides of the built-in email templates)
+CREATE TABLE IF NOT EXISTS email_templates (
+    id BIGSERIAL PRIMARY KEY,
+    domain_id BIGINT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    locale VARCHAR(5) NOT NULL CHECK (locale IN ('id', 'en')),
+    subject VARCHAR(255) NOT NULL,
+    html_body TEXT NOT NULL,
+    text_body TEXT NOT NULL,
+    updated_by BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (domain_id, name, locale),
+    FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE,
+    FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+DROP TRIGGER IF EXISTS update_email_templates_updated_at ON email_templates;
+CREATE TRIGGER update_email_templates_updated_at BEFORE UPDATE ON email_templates
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE email_templates IS 'Stores per-domain overrides of the built-in email templates, rendered inside the shared layout';
+COMMENT ON COLUMN domains.locale IS 'Locale of emails to users of the domain who have not chosen one (id or en)';
+COMMENT ON COLUMN users.locale IS 'Locale of the user''s emails (id or en), NULL follows the domain';
+COMMENT ON COLUMN email_outbox.text_body IS 'Plain text alternative of the HTML body';
+COMMENT ON COLUMN email_templates.name IS 'Built-in template the override replaces (permit_expiry or obligation_reminder)';
+COMMENT ON COLUMN email_templates.html_body IS 'html/template content rendered inside the shared HTML layout';
+COMMENT ON COLUMN email_templates.text_body IS 'text/template content of the plain text part';
+
+COMMIT;
@@ -0,0 +1,59 @@
+-- Migration for escalating ignored expiry reminders
+-- Created: 2026-10-16
+-- Domains configure escalation rules per reminder offset. When nobody read a
+-- permit's expiry reminders, changed the permit or started a renewal, the
+-- expiry check notifies the rule's recipients (responsible people, division
+-- head, users with a role) and records the level the permit reached in
+-- permit_escalations.
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_escalations.sql
+
+BEGIN;
+
+-- Create Escalation Rules table (who is notified when a permit's expiry reminders are ignored, per offset)
+CREATE TABLE IF NOT EXISTS escalation_rules (
+    id BIGSERIAL PRIMARY KEY,
+    domain_id BIGINT NOT NULL,
+    level INTEGER NOT NULL,
+    days_before INTEGER NOT NULL,
+    notify_responsible BOOLEAN NOT NULL DEFAULT FALSE,
+    notify_division_head BOOLEAN NOT NULL DEFAULT FALSE,
+    role_codes JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(domain_id, days_before),
+    FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE
+);
+
+-- Create Permit Escalations table (escalation levels each permit has reached, each rule offset escalates once per permit)
+CREATE TABLE IF NOT EXISTS permit_escalations (
+    id BIGSERIAL PRIMARY KEY,
+    permit_id BIGINT NOT NULL,
+    domain_id BIGINT NOT NULL,
+    level INTEGER NOT NULL,
+    days_before INTEGER NOT NULL,
+    expiry_date DATE NOT NULL,
+    recipients INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(permit_id, days_before),
+    FOREIGN KEY (permit_id) REFERENCES permits(id) ON DELETE CASCADE,
+    FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE
+);
+
+-- Indexes for permit_escalations
+CREATE INDEX IF NOT EXISTS idx_permit_escalations_domain_id ON permit_escalations(domain_id, created_at DESC);
+
+DROP TRIGGER IF EXISTS update_escalation_rules_updated_at ON escalation_rules;
+CREATE TRIGGER update_escalation_rules_updated_at BEFORE UPDATE ON escalation_rules
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE escalation_rules IS 'Stores per-domain rules widening the audience of a permit''s expiry once its reminders were ignored';
+COMMENT ON TABLE permit_escalations IS 'Stores the escalation levels each permit has reached';
+COMMENT ON COLUMN email_templates.name IS 'Built-in template the override replaces (permit_expiry, permit_escalation or obligation_reminder)';
+COMMENT ON COLUMN escalation_rules.level IS 'Position of the rule in the domain, numbered from the earliest offset on';
+COMMENT ON COLUMN escalation_rules.days_before IS 'Offset the rule applies from, counted like a reminder offset (0 = expiry day, negative = days after expiry)';
+COMMENT ON COLUMN escalation_rules.notify_division_head IS 'Whether the head of the permit''s division is notified';
+COMMENT ON COLUMN escalation_rules.role_codes IS 'JSON array of role codes whose users in the domain are notified, SUPER_ADMIN users are notified in any domain';
+COMMENT ON COLUMN permit_escalations.expiry_date IS 'Expiry date of the permit when it was escalated';
+COMMENT ON COLUMN permit_escalations.recipients IS 'Number of users the escalation was sent to';
+
+COMMIT;
@@ -0,0 +1,31 @@
+-- Migration for notifications about tasks, approvals and projects
+-- Created: 2026-10-16
+-- Notifications now name their subject with entity_type and entity_id plus a deep
+-- link, and permit_id is only set for permit notifications. Existing rows are
+-- backfilled as permit notifications.
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_generic_notifications.sql
+
+BEGIN;
+
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS entity_type VARCHAR(50);
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS entity_id BIGINT;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS link VARCHAR(255);
+
+UPDATE notifications
+SET entity_type = 'permit',
+    entity_id = permit_id,
+    link = '/permits/' || permit_id
+WHERE entity_type IS NULL;
+
+ALTER TABLE notifications ALTER COLUMN entity_type SET NOT NULL;
+ALTER TABLE notifications ALTER COLUMN entity_id SET NOT NULL;
+ALTER TABLE notifications ALTER COLUMN permit_id DROP NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_entity ON notifications(entity_type, entity_id);
+
+COMMENT ON COLUMN notifications.entity_type IS 'Kind of record the notification is about (permit, task, project)';
+COMMENT ON COLUMN notifications.entity_id IS 'ID of the record the notification is about';
+COMMENT ON COLUMN notifications.link IS 'Deep link to the record in the client, e.g. /tasks/12';
+COMMENT ON COLUMN notifications.permit_id IS 'Permit the notification is about, NULL for notifications about other records';
+
+COMMIT;
@@ -0,0 +1,51 @@
+-- Migration for scheduled jobs
+-- Created: 2026-10-16
+-- The scheduler runs named jobs on cron expressions: expiry statuses,
+-- reminders, digests, the email outbox, webhook deliveries and cleanup.
+-- Admins can pause and resume a job, job_states keeps the pause state, and
+-- every run is recorded in job_runs with its outcome and item count.
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_jobs.sql
+
+BEGIN;
+
+-- Create Job States table (pause state of the scheduled jobs, jobs without a row have never been paused)
+CREATE TABLE IF NOT EXISTS job_states (
+    name VARCHAR(100) PRIMARY KEY,
+    is_paused BOOLEAN NOT NULL DEFAULT FALSE,
+    paused_by BIGINT,
+    paused_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (paused_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Create Job Runs table (one row per run of a scheduled job)
+CREATE TABLE IF NOT EXISTS job_runs (
+    id BIGSERIAL PRIMARY KEY,
+    job_name VARCHAR(100) NOT NULL,
+    trigger VARCHAR(20) NOT NULL CHECK (trigger IN ('schedule', 'manual')),
+    triggered_by BIGINT,
+    status VARCHAR(20) NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed')),
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP,
+    duration_ms BIGINT,
+    items_processed INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    FOREIGN KEY (triggered_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Indexes for job_runs
+CREATE INDEX IF NOT EXISTS idx_job_runs_job_name ON job_runs(job_name, started_at DESC);
+
+DROP TRIGGER IF EXISTS update_job_states_updated_at ON job_states;
+CREATE TRIGGER update_job_states_updated_at BEFORE UPDATE ON job_states
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE job_states IS 'Stores the pause state of the scheduled jobs';
+COMMENT ON TABLE job_runs IS 'Stores the run history of the scheduled jobs';
+COMMENT ON COLUMN job_states.name IS 'Name the job is registered under';
+COMMENT ON COLUMN job_runs.trigger IS 'What started the run: schedule or manual';
+COMMENT ON COLUMN job_runs.triggered_by IS 'User who started a manual run';
+COMMENT ON COLUMN job_runs.items_processed IS 'Number of items the job reported it handled, such as reminders or emails sent';
+COMMENT ON COLUMN job_runs.error IS 'Error the run failed with';
+
+COMMIT;
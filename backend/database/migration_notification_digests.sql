@@ -0,0 +1,54 @@
+-- Migration for daily and weekly digest emails
+-- Created: 2026-10-16
+-- Notifications of events a user has the digest preference on for are queued in
+-- notification_digest_items instead of being emailed one by one. The scheduler
+-- sends each user one summary email daily or weekly, as set in
+-- notification_digest_settings.
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_notification_digests.sql
+
+BEGIN;
+
+-- Create Notification Digest Items table (notifications waiting for the user's next digest email)
+CREATE TABLE IF NOT EXISTS notification_digest_items (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    domain_id BIGINT NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    link VARCHAR(255),
+    type VARCHAR(50) NOT NULL,
+    severity VARCHAR(20) NOT NULL CHECK (severity IN ('info', 'warning', 'critical')),
+    title VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE
+);
+
+-- Create Notification Digest Settings table (digest frequency per user)
+CREATE TABLE IF NOT EXISTS notification_digest_settings (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL UNIQUE,
+    frequency VARCHAR(20) NOT NULL DEFAULT 'daily' CHECK (frequency IN ('daily', 'weekly')),
+    last_sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Indexes for notification_digest_items (pending items per user)
+CREATE INDEX IF NOT EXISTS idx_notification_digest_items_pending ON notification_digest_items(user_id) WHERE sent_at IS NULL;
+
+DROP TRIGGER IF EXISTS update_notification_digest_settings_updated_at ON notification_digest_settings;
+CREATE TRIGGER update_notification_digest_settings_updated_at BEFORE UPDATE ON notification_digest_settings
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE notification_digest_items IS 'Stores notifications queued for digest emails, for users with the digest preference on for the event';
+COMMENT ON TABLE notification_digest_settings IS 'Stores how often each user''s digest email is sent, users without a row get a daily digest';
+COMMENT ON COLUMN notification_digest_items.severity IS 'Severity the item is grouped under in the digest (info, warning, critical)';
+COMMENT ON COLUMN notification_digest_items.sent_at IS 'When the digest including the item was sent, NULL while pending';
+COMMENT ON COLUMN notification_digest_settings.frequency IS 'How often the digest is sent: daily or weekly';
+COMMENT ON COLUMN notification_digest_settings.last_sent_at IS 'When the user''s last digest was sent';
+
+COMMIT;
@@ -0,0 +1,97 @@
+-- Migration for notification preferences and recipient rules
+-- Created: 2026-10-16
+-- Users choose the channels (in-app, email, digest) per notification event, and
+-- admins may replace the built-in recipients of permit expiry and obligation
+-- notifications per domain. Without rows the previous behaviour is kept.
+-- Sent reminders are recorded in reminder_deliveries, so a reminder is not sent
+-- again to recipients who turned in-app notifications off. Reminders already
+-- sent are backfilled from notifications. Expiry reminders are recorded per
+-- expiry date, so a permit whose expiry date changes is reminded again.
+-- The migration can be applied again to databases that already ran it.
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_notification_preferences.sql
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    in_app BOOLEAN NOT NULL DEFAULT TRUE,
+    email BOOLEAN NOT NULL DEFAULT TRUE,
+    digest BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, event_type),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS notification_recipient_rules (
+    id BIGSERIAL PRIMARY KEY,
+    domain_id BIGINT NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    notify_responsible BOOLEAN NOT NULL DEFAULT TRUE,
+    role_codes JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(domain_id, event_type),
+    FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE
+);
+
+-- Create Reminder Deliveries table (each reminder offset is sent once per permit expiry date or obligation due date)
+CREATE TABLE IF NOT EXISTS reminder_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    permit_id BIGINT NOT NULL,
+    obligation_id BIGINT,
+    expiry_date DATE,
+    due_date DATE,
+    reminder_days INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (permit_id) REFERENCES permits(id) ON DELETE CASCADE,
+    FOREIGN KEY (obligation_id) REFERENCES permit_obligations(id) ON DELETE CASCADE
+);
+
+ALTER TABLE reminder_deliveries ADD COLUMN IF NOT EXISTS expiry_date DATE;
+
+-- Expiry reminders sent before expiry dates were recorded count for the permit's current expiry date
+UPDATE reminder_deliveries
+SET expiry_date = permits.expiry_date
+FROM permits
+WHERE reminder_deliveries.permit_id = permits.id
+  AND reminder_deliveries.obligation_id IS NULL
+  AND reminder_deliveries.expiry_date IS NULL;
+
+-- Indexes for reminder_deliveries (one delivery per reminder offset)
+DROP INDEX IF EXISTS idx_reminder_deliveries_permit;
+CREATE UNIQUE INDEX idx_reminder_deliveries_permit ON reminder_deliveries(permit_id, expiry_date, reminder_days) WHERE obligation_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reminder_deliveries_obligation ON reminder_deliveries(obligation_id, due_date, reminder_days) WHERE obligation_id IS NOT NULL;
+
+INSERT INTO reminder_deliveries (permit_id, obligation_id, expiry_date, due_date, reminder_days)
+SELECT DISTINCT notifications.permit_id, notifications.obligation_id,
+    CASE WHEN notifications.obligation_id IS NULL THEN permits.expiry_date END,
+    notifications.due_date, notifications.reminder_days
+FROM notifications
+JOIN permits ON permits.id = notifications.permit_id
+WHERE notifications.reminder_days IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+DROP TRIGGER IF EXISTS update_notification_preferences_updated_at ON notification_preferences;
+CREATE TRIGGER update_notification_preferences_updated_at BEFORE UPDATE ON notification_preferences
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+DROP TRIGGER IF EXISTS update_notification_recipient_rules_updated_at ON notification_recipient_rules;
+CREATE TRIGGER update_notification_recipient_rules_updated_at BEFORE UPDATE ON notification_recipient_rules
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE notification_preferences IS 'Stores the channels (in-app, email, digest) each user receives a notification event on, events without a row use the defaults';
+COMMENT ON TABLE notification_recipient_rules IS 'Stores per-domain replacements of the built-in recipients of permit expiry and obligation notifications';
+COMMENT ON COLUMN notification_preferences.event_type IS 'Notification event: permit_expiry, permit_obligation, permit_approval, task_update or task_approval';
+COMMENT ON COLUMN notification_preferences.digest IS 'Whether the event is included in the user''s digest';
+COMMENT ON COLUMN notification_recipient_rules.event_type IS 'Notification event the rule applies to: permit_expiry or permit_obligation';
+COMMENT ON COLUMN notification_recipient_rules.notify_responsible IS 'Whether the permit''s or obligation''s responsible people are notified';
+COMMENT ON COLUMN notification_recipient_rules.role_codes IS 'JSON array of role codes whose users in the domain are notified';
+COMMENT ON TABLE reminder_deliveries IS 'Records the expiry and obligation reminders already sent, whichever channels their recipients receive them on';
+COMMENT ON COLUMN reminder_deliveries.obligation_id IS 'Obligation the reminder was about, NULL for permit expiry reminders';
+COMMENT ON COLUMN reminder_deliveries.expiry_date IS 'Permit expiry date the reminder counted down to, a changed expiry date is reminded again';
+COMMENT ON COLUMN reminder_deliveries.reminder_days IS 'Reminder offset that was sent (-1 = overdue)';
+
+COMMIT;
@@ -0,0 +1,25 @@
+-- Migration for real-time notification streams
+-- Created: 2026-10-16
+-- Every new notification is announced on the notification_created channel with
+-- pg_notify. Each backend instance LISTENs on it and pushes the notification
+-- to its recipient's open Server-Sent Events streams.
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_notification_stream.sql
+
+BEGIN;
+
+-- Function announcing new notifications on the notification_created channel,
+-- every instance listens and pushes them to its open notification streams
+CREATE OR REPLACE FUNCTION notify_notification_created()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('notification_created', json_build_object('id', NEW.id, 'user_id', NEW.user_id)::text);
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+-- Notifications are delivered when the inserting transaction commits
+DROP TRIGGER IF EXISTS notify_notifications_created ON notifications;
+CREATE TRIGGER notify_notifications_created AFTER INSERT ON notifications
+    FOR EACH ROW EXECUTE FUNCTION notify_notification_created();
+
+COMMIT;
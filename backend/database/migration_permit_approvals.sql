@@ -0,0 +1,67 @@
+-- Migration for the permit approval workflow
+-- Created: 2026-10-16
+-- Adds the pending permit status, division heads who verify submitted permits,
+-- and the permit_approvals table holding the verification and approval steps
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_permit_approvals.sql
+
+BEGIN;
+
+ALTER TABLE divisions ADD COLUMN IF NOT EXISTS head_user_id BIGINT;
+ALTER TABLE divisions DROP CONSTRAINT IF EXISTS fk_divisions_head_user;
+ALTER TABLE divisions ADD CONSTRAINT fk_divisions_head_user
+    FOREIGN KEY (head_user_id) REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_divisions_head_user_id ON divisions(head_user_id);
+
+ALTER TABLE permits ADD COLUMN IF NOT EXISTS requested_by BIGINT;
+ALTER TABLE permits ADD COLUMN IF NOT EXISTS approval_status_id BIGINT;
+ALTER TABLE permits DROP CONSTRAINT IF EXISTS fk_permits_requested_by;
+ALTER TABLE permits ADD CONSTRAINT fk_permits_requested_by
+    FOREIGN KEY (requested_by) REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE permits DROP CONSTRAINT IF EXISTS fk_permits_approval_status;
+ALTER TABLE permits ADD CONSTRAINT fk_permits_approval_status
+    FOREIGN KEY (approval_status_id) REFERENCES "references"(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_permits_requested_by ON permits(requested_by);
+CREATE INDEX IF NOT EXISTS idx_permits_approval_status_id ON permits(approval_status_id);
+
+ALTER TABLE permits DROP CONSTRAINT IF EXISTS chk_permits_status;
+ALTER TABLE permits ADD CONSTRAINT chk_permits_status
+    CHECK (status IN ('draft', 'pending', 'active', 'expiring', 'expired', 'in_renewal', 'revoked', 'archived', 'superseded'));
+
+CREATE TABLE IF NOT EXISTS permit_approvals (
+    id BIGSERIAL PRIMARY KEY,
+    permit_id BIGINT NOT NULL,
+    sequence SMALLINT NOT NULL CHECK (sequence IN (1, 2)),
+    approved_by BIGINT,
+    approval_status_id BIGINT,
+    approval_date TIMESTAMP WITH TIME ZONE,
+    note VARCHAR(500),
+    status BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_permit_approvals_permit FOREIGN KEY (permit_id) REFERENCES permits(id) ON DELETE CASCADE,
+    CONSTRAINT fk_permit_approvals_approved_by FOREIGN KEY (approved_by) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT fk_permit_approvals_approval_status FOREIGN KEY (approval_status_id) REFERENCES "references"(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_permit_approvals_permit_id ON permit_approvals(permit_id);
+CREATE INDEX IF NOT EXISTS idx_permit_approvals_approved_by ON permit_approvals(approved_by);
+CREATE INDEX IF NOT EXISTS idx_permit_approvals_approval_status_id ON permit_approvals(approval_status_id);
+CREATE INDEX IF NOT EXISTS idx_permit_approvals_permit_status ON permit_approvals(permit_id, status);
+
+DROP TRIGGER IF EXISTS update_permit_approvals_updated_at ON permit_approvals;
+CREATE TRIGGER update_permit_approvals_updated_at BEFORE UPDATE ON permit_approvals
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE permit_approvals IS 'Stores the approval steps of permit submissions (division head verification, permit manager approval)';
+COMMENT ON COLUMN divisions.head_user_id IS 'Reference to the division head who verifies submitted permits';
+COMMENT ON COLUMN permits.status IS 'Current status of the permit (draft, pending, active, expiring, expired, in_renewal, revoked, archived, superseded)';
+COMMENT ON COLUMN permits.requested_by IS 'Reference to the user who submitted the permit for approval';
+COMMENT ON COLUMN permits.approval_status_id IS 'Approval status of the latest submission (Waiting, Pending Manager, Approve, Reject)';
+COMMENT ON COLUMN permit_approvals.permit_id IS 'Reference to the submitted permit';
+COMMENT ON COLUMN permit_approvals.sequence IS 'Approval step (1 = division head verification, 2 = permit manager approval)';
+COMMENT ON COLUMN permit_approvals.approved_by IS 'Reference to the user who approved or rejected the step';
+COMMENT ON COLUMN permit_approvals.approval_status_id IS 'Step status (Waiting, Approve, Reject)';
+COMMENT ON COLUMN permit_approvals.note IS 'Note left by the approver, shown to the requester on rejection';
+COMMENT ON COLUMN permit_approvals.status IS 'true for steps of the current submission, false for earlier rounds';
+
+COMMIT;
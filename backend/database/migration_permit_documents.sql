@@ -0,0 +1,64 @@
+-- Migration for multiple documents per permit
+-- Created: 2026-10-16
+-- Moves the single document stored on permits (doc_file_*) into permit_documents as a License document
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_permit_documents.sql
+
+BEGIN;
+
+-- Permit Document Type reference category (module_id = 3, permit)
+INSERT INTO reference_categories (id, module_id, name, is_active) VALUES
+(8, 3, 'Permit Document Type', true)
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO "references" (id, reference_category_id, name, is_active) VALUES
+(40, 8, 'License', true),
+(41, 8, 'Attachment', true),
+(42, 8, 'Payment Receipt', true),
+(43, 8, 'Inspection Report', true)
+ON CONFLICT (id) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS permit_documents (
+    id BIGSERIAL PRIMARY KEY,
+    permit_id BIGINT NOT NULL,
+    document_type_id BIGINT NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    file_path VARCHAR(500) NOT NULL,
+    file_size BIGINT,
+    file_type VARCHAR(100),
+    uploaded_by BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_permit_documents_permit FOREIGN KEY (permit_id) REFERENCES permits(id) ON DELETE CASCADE,
+    CONSTRAINT fk_permit_documents_document_type FOREIGN KEY (document_type_id) REFERENCES "references"(id) ON DELETE RESTRICT,
+    CONSTRAINT fk_permit_documents_uploaded_by FOREIGN KEY (uploaded_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_permit_documents_permit_id ON permit_documents(permit_id);
+CREATE INDEX IF NOT EXISTS idx_permit_documents_document_type_id ON permit_documents(document_type_id);
+
+DROP TRIGGER IF EXISTS update_permit_documents_updated_at ON permit_documents;
+CREATE TRIGGER update_permit_documents_updated_at BEFORE UPDATE ON permit_documents
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Move existing single documents into permit_documents as License documents
+INSERT INTO permit_documents (permit_id, document_type_id, file_name, file_path, file_size, file_type, created_at, updated_at)
+SELECT id, 40, COALESCE(doc_file_name, doc_file_path), doc_file_path, doc_file_size, doc_file_type, updated_at, updated_at
+FROM permits
+WHERE doc_file_path IS NOT NULL AND doc_file_path <> '';
+
+ALTER TABLE permits DROP COLUMN IF EXISTS doc_file_name;
+ALTER TABLE permits DROP COLUMN IF EXISTS doc_file_path;
+ALTER TABLE permits DROP COLUMN IF EXISTS doc_file_size;
+ALTER TABLE permits DROP COLUMN IF EXISTS doc_file_type;
+
+COMMENT ON TABLE permit_documents IS 'Stores files attached to permits (license, attachments, payment receipts, inspection reports)';
+COMMENT ON COLUMN permit_documents.permit_id IS 'Reference to the permit the document belongs to';
+COMMENT ON COLUMN permit_documents.document_type_id IS 'Reference to the document type (Permit Document Type reference category)';
+COMMENT ON COLUMN permit_documents.file_name IS 'Original filename';
+COMMENT ON COLUMN permit_documents.file_path IS 'Server storage path';
+COMMENT ON COLUMN permit_documents.file_size IS 'File size in bytes';
+COMMENT ON COLUMN permit_documents.file_type IS 'MIME type';
+COMMENT ON COLUMN permit_documents.uploaded_by IS 'Reference to the user who uploaded the document';
+COMMENT ON COLUMN permit_revisions.action IS 'Change that produced the revision (create, update, upload, remove_document, delete, restore)';
+
+COMMIT;
@@ -0,0 +1,97 @@
+-- Migration for permit obligations and conditions checklist
+-- Created: 2026-10-16
+-- Adds recurring obligations attached to permits, their completion records with evidence files,
+-- and links obligation reminders in notifications to the obligation due date
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_permit_obligations.sql
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS permit_obligations (
+    id BIGSERIAL PRIMARY KEY,
+    permit_id BIGINT NOT NULL,
+    description TEXT NOT NULL,
+    recurrence_rule VARCHAR(20) NOT NULL DEFAULT 'once' CHECK (recurrence_rule IN ('once', 'monthly', 'quarterly', 'semi_annual', 'annual')),
+    next_due_date DATE NOT NULL,
+    responsible_user_id BIGINT,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'completed')),
+    created_by BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_permit_obligations_permit FOREIGN KEY (permit_id) REFERENCES permits(id) ON DELETE CASCADE,
+    CONSTRAINT fk_permit_obligations_responsible_user FOREIGN KEY (responsible_user_id) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT fk_permit_obligations_created_by FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS permit_obligation_completions (
+    id BIGSERIAL PRIMARY KEY,
+    obligation_id BIGINT NOT NULL,
+    due_date DATE NOT NULL,
+    completed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_by BIGINT,
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_permit_obligation_completions_obligation FOREIGN KEY (obligation_id) REFERENCES permit_obligations(id) ON DELETE CASCADE,
+    CONSTRAINT fk_permit_obligation_completions_completed_by FOREIGN KEY (completed_by) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT uq_permit_obligation_completions_due_date UNIQUE (obligation_id, due_date)
+);
+
+CREATE TABLE IF NOT EXISTS permit_obligation_evidence (
+    id BIGSERIAL PRIMARY KEY,
+    completion_id BIGINT NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    file_path VARCHAR(500) NOT NULL,
+    file_size BIGINT,
+    file_type VARCHAR(100),
+    uploaded_by BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_permit_obligation_evidence_completion FOREIGN KEY (completion_id) REFERENCES permit_obligation_completions(id) ON DELETE CASCADE,
+    CONSTRAINT fk_permit_obligation_evidence_uploaded_by FOREIGN KEY (uploaded_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_permit_obligations_permit_id ON permit_obligations(permit_id);
+CREATE INDEX IF NOT EXISTS idx_permit_obligations_responsible_user_id ON permit_obligations(responsible_user_id);
+CREATE INDEX IF NOT EXISTS idx_permit_obligations_status_due ON permit_obligations(status, next_due_date);
+CREATE INDEX IF NOT EXISTS idx_permit_obligation_completions_obligation_id ON permit_obligation_completions(obligation_id);
+CREATE INDEX IF NOT EXISTS idx_permit_obligation_evidence_completion_id ON permit_obligation_evidence(completion_id);
+
+DROP TRIGGER IF EXISTS update_permit_obligations_updated_at ON permit_obligations;
+CREATE TRIGGER update_permit_obligations_updated_at BEFORE UPDATE ON permit_obligations
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Obligation reminders are sent once per offset and due date
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS obligation_id BIGINT;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS due_date DATE;
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS fk_notifications_obligation;
+ALTER TABLE notifications ADD CONSTRAINT fk_notifications_obligation
+    FOREIGN KEY (obligation_id) REFERENCES permit_obligations(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_obligation_reminder ON notifications(obligation_id, due_date, reminder_days);
+
+COMMENT ON COLUMN notifications.reminder_days IS 'Reminder offset that produced the notification, each offset is sent once per permit or obligation due date (-1 = overdue)';
+COMMENT ON COLUMN notifications.obligation_id IS 'Obligation the reminder is about, NULL for permit expiry notifications';
+COMMENT ON COLUMN notifications.due_date IS 'Obligation due date the reminder is about';
+
+COMMENT ON TABLE permit_obligations IS 'Conditions attached to permits (reports, inspections, fee payments)';
+COMMENT ON COLUMN permit_obligations.permit_id IS 'Reference to the permit the condition is attached to';
+COMMENT ON COLUMN permit_obligations.description IS 'What has to be done (e.g., quarterly report, annual inspection, fee payment)';
+COMMENT ON COLUMN permit_obligations.recurrence_rule IS 'How often the obligation repeats (once, monthly, quarterly, semi_annual, annual)';
+COMMENT ON COLUMN permit_obligations.next_due_date IS 'Next date the obligation has to be fulfilled by';
+COMMENT ON COLUMN permit_obligations.responsible_user_id IS 'Reference to the user who fulfils the obligation, falls back to the permit responsible person';
+COMMENT ON COLUMN permit_obligations.status IS 'open while due dates remain, completed once a one-off obligation is fulfilled';
+COMMENT ON COLUMN permit_obligations.created_by IS 'Reference to the user who added the obligation';
+
+COMMENT ON COLUMN permit_obligation_completions.obligation_id IS 'Reference to the fulfilled obligation';
+COMMENT ON COLUMN permit_obligation_completions.due_date IS 'Due date the completion fulfils';
+COMMENT ON COLUMN permit_obligation_completions.completed_at IS 'When the obligation was fulfilled';
+COMMENT ON COLUMN permit_obligation_completions.completed_by IS 'Reference to the user who recorded the completion';
+COMMENT ON COLUMN permit_obligation_completions.notes IS 'Free text notes about the completion';
+
+COMMENT ON COLUMN permit_obligation_evidence.completion_id IS 'Reference to the completion the file proves';
+COMMENT ON COLUMN permit_obligation_evidence.file_name IS 'Original filename';
+COMMENT ON COLUMN permit_obligation_evidence.file_path IS 'Server storage path';
+COMMENT ON COLUMN permit_obligation_evidence.file_size IS 'File size in bytes';
+COMMENT ON COLUMN permit_obligation_evidence.file_type IS 'MIME type';
+COMMENT ON COLUMN permit_obligation_evidence.uploaded_by IS 'Reference to the user who uploaded the file';
+
+COMMIT;
@@ -0,0 +1,18 @@
+-- Migration for permit renewal chain
+-- Created: 2026-10-16
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_permit_renewals.sql
+
+ALTER TABLE permits ADD COLUMN IF NOT EXISTS previous_permit_id BIGINT;
+ALTER TABLE permits ADD COLUMN IF NOT EXISTS superseded_at TIMESTAMP;
+
+ALTER TABLE permits DROP CONSTRAINT IF EXISTS fk_permits_previous_permit;
+ALTER TABLE permits ADD CONSTRAINT fk_permits_previous_permit
+    FOREIGN KEY (previous_permit_id) REFERENCES permits(id) ON DELETE SET NULL;
+
+-- A permit period can only be renewed once, a renewal in the trash does not count
+DROP INDEX IF EXISTS idx_permits_previous_permit_id;
+CREATE UNIQUE INDEX idx_permits_previous_permit_id ON permits(previous_permit_id) WHERE previous_permit_id IS NOT NULL AND deleted_at IS NULL;
+
+COMMENT ON COLUMN permits.status IS 'Current status of the permit (active, expired, revoked, superseded)';
+COMMENT ON COLUMN permits.previous_permit_id IS 'Reference to the permit period this permit renews';
+COMMENT ON COLUMN permits.superseded_at IS 'When this permit period was superseded by a renewal';
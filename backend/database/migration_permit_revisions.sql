@@ -0,0 +1,46 @@
+-- Migration for permit change history
+-- Created: 2026-10-16
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_permit_revisions.sql
+
+-- No foreign key to permits so the history is kept after a permit is deleted
+CREATE TABLE IF NOT EXISTS permit_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    permit_id BIGINT NOT NULL,
+    revision INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    snapshot JSONB NOT NULL,
+    changes JSONB,
+    changed_by BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_permit_revisions_changed_by FOREIGN KEY (changed_by) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT uq_permit_revisions_permit_revision UNIQUE (permit_id, revision)
+);
+
+CREATE INDEX IF NOT EXISTS idx_permit_revisions_permit_id ON permit_revisions(permit_id);
+CREATE INDEX IF NOT EXISTS idx_permit_revisions_changed_by ON permit_revisions(changed_by);
+CREATE INDEX IF NOT EXISTS idx_permit_revisions_created_at ON permit_revisions(created_at);
+
+-- Function to keep permit revisions immutable
+CREATE OR REPLACE FUNCTION prevent_permit_revision_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'permit_revisions is append-only';
+END;
+$$ language 'plpgsql';
+
+-- changed_by is nulled by its foreign key when a user is deleted, so only that column may change
+DROP TRIGGER IF EXISTS prevent_permit_revisions_update ON permit_revisions;
+CREATE TRIGGER prevent_permit_revisions_update BEFORE UPDATE OF permit_id, revision, action, snapshot, changes, created_at ON permit_revisions
+    FOR EACH ROW EXECUTE FUNCTION prevent_permit_revision_change();
+
+DROP TRIGGER IF EXISTS prevent_permit_revisions_delete ON permit_revisions;
+CREATE TRIGGER prevent_permit_revisions_delete BEFORE DELETE ON permit_revisions
+    FOR EACH ROW EXECUTE FUNCTION prevent_permit_revision_change();
+
+COMMENT ON TABLE permit_revisions IS 'Append-only change history of permits (snapshot and field-level diff per change)';
+COMMENT ON COLUMN permit_revisions.permit_id IS 'Permit the revision belongs to (kept after the permit is deleted)';
+COMMENT ON COLUMN permit_revisions.revision IS 'Revision number, sequential per permit starting at 1';
+COMMENT ON COLUMN permit_revisions.action IS 'Change that produced the revision (create, update, upload, delete, restore)';
+COMMENT ON COLUMN permit_revisions.snapshot IS 'Permit columns after the change';
+COMMENT ON COLUMN permit_revisions.changes IS 'Field-level diff against the previous state (field, old_value, new_value)';
+COMMENT ON COLUMN permit_revisions.changed_by IS 'Reference to the user who made the change';
@@ -0,0 +1,15 @@
+-- Migration for permit soft delete
+-- Created: 2026-10-16
+-- Deleting a permit now moves it to the trash by setting deleted_at. Trashed permits
+-- can be restored, or purged by an administrator together with their stored files.
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_permit_soft_delete.sql
+
+BEGIN;
+
+ALTER TABLE permits ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_permits_deleted_at ON permits(deleted_at);
+
+COMMENT ON COLUMN permits.deleted_at IS 'When the permit was moved to the trash, NULL for live permits';
+COMMENT ON COLUMN permit_revisions.action IS 'Change that produced the revision (create, update, upload, remove_document, delete, restore, status_change, undelete, purge)';
+
+COMMIT;
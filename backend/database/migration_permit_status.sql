@@ -0,0 +1,26 @@
+-- Migration for the permit status lifecycle
+-- Created: 2026-10-16
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_permit_status.sql
+
+BEGIN;
+
+-- Statuses were free text before, fold them into the known values
+UPDATE permits SET status = LOWER(TRIM(status));
+UPDATE permits SET status = 'in_renewal' WHERE status IN ('in-renewal', 'in renewal');
+UPDATE permits SET status = 'active'
+WHERE status NOT IN ('draft', 'active', 'expiring', 'expired', 'in_renewal', 'revoked', 'archived', 'superseded');
+
+-- Bring date driven statuses up to date, the scheduler keeps them current from here on
+UPDATE permits SET status = 'expired'
+WHERE status IN ('active', 'expiring') AND expiry_date < CURRENT_DATE;
+UPDATE permits SET status = 'expiring'
+WHERE status = 'active' AND expiry_date <= CURRENT_DATE + 30;
+
+ALTER TABLE permits DROP CONSTRAINT IF EXISTS chk_permits_status;
+ALTER TABLE permits ADD CONSTRAINT chk_permits_status
+    CHECK (status IN ('draft', 'active', 'expiring', 'expired', 'in_renewal', 'revoked', 'archived', 'superseded'));
+
+COMMENT ON COLUMN permits.status IS 'Current status of the permit (draft, active, expiring, expired, in_renewal, revoked, archived, superseded)';
+COMMENT ON COLUMN permit_revisions.action IS 'Change that produced the revision (create, update, upload, remove_document, delete, restore, status_change)';
+
+COMMIT;
@@ -0,0 +1,29 @@
+-- Migration for public permit verification by QR code
+-- Created: 2026-10-16
+-- Adds the permit_verification_tokens table. Each permit has at most one unrevoked
+-- token, encoded in the QR code that links to GET /verify/:token
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_permit_verification_tokens.sql
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS permit_verification_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    permit_id BIGINT NOT NULL,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    created_by BIGINT,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (permit_id) REFERENCES permits(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_permit_verification_tokens_permit_id ON permit_verification_tokens(permit_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_permit_verification_tokens_active ON permit_verification_tokens(permit_id) WHERE revoked_at IS NULL;
+
+COMMENT ON TABLE permit_verification_tokens IS 'Stores the tokens encoded in permit QR codes, checked by the public verification endpoint';
+COMMENT ON COLUMN permit_verification_tokens.permit_id IS 'Permit the QR code was printed for';
+COMMENT ON COLUMN permit_verification_tokens.token IS 'Random token in the verification URL, kept so the QR code can be rendered again';
+COMMENT ON COLUMN permit_verification_tokens.created_by IS 'User who issued the token';
+COMMENT ON COLUMN permit_verification_tokens.revoked_at IS 'When the token was revoked or rotated, NULL for the permit''s current token';
+
+COMMIT;
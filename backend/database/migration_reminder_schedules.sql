@@ -0,0 +1,35 @@
+-- Migration for configurable expiry reminder schedules
+-- Created: 2026-10-16
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_reminder_schedules.sql
+
+-- A row belongs to a permit type, or to a domain as the default for permit types without a schedule
+CREATE TABLE IF NOT EXISTS reminder_schedules (
+    id BIGSERIAL PRIMARY KEY,
+    permit_type_id BIGINT,
+    domain_id BIGINT,
+    days_before INTEGER NOT NULL CHECK (days_before >= 0),
+    severity VARCHAR(20) NOT NULL CHECK (severity IN ('info', 'warning', 'critical')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (permit_type_id) REFERENCES permit_types(id) ON DELETE CASCADE,
+    FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE,
+    CHECK ((permit_type_id IS NULL) <> (domain_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reminder_schedules_permit_type_days ON reminder_schedules(permit_type_id, days_before) WHERE permit_type_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reminder_schedules_domain_days ON reminder_schedules(domain_id, days_before) WHERE domain_id IS NOT NULL;
+
+DROP TRIGGER IF EXISTS update_reminder_schedules_updated_at ON reminder_schedules;
+CREATE TRIGGER update_reminder_schedules_updated_at BEFORE UPDATE ON reminder_schedules
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Each reminder offset is sent once per permit
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS reminder_days INTEGER;
+CREATE INDEX IF NOT EXISTS idx_notifications_permit_reminder ON notifications(permit_id, reminder_days);
+
+COMMENT ON TABLE reminder_schedules IS 'Stores expiry reminder offsets per permit type, with optional per-domain defaults';
+COMMENT ON COLUMN reminder_schedules.permit_type_id IS 'Permit type the offset belongs to';
+COMMENT ON COLUMN reminder_schedules.domain_id IS 'Domain whose default schedule the offset belongs to';
+COMMENT ON COLUMN reminder_schedules.days_before IS 'Days before expiry the reminder is sent (0 = expiry day)';
+COMMENT ON COLUMN reminder_schedules.severity IS 'Reminder severity (info, warning, critical)';
+COMMENT ON COLUMN notifications.reminder_days IS 'Reminder offset that produced the notification, each offset is sent once per permit';
@@ -0,0 +1,23 @@
+-- Migration for domain-scoped authorization with a super admin override
+-- Created: 2026-10-16
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_super_admin_role.sql
+-- Users have to log in again to get a token with the role_code claim.
+
+BEGIN;
+
+INSERT INTO roles (code, name, category, description)
+SELECT 'SUPER_ADMIN', 'Super Admin', 'Permit', 'Administrator with access to permits, divisions and permit types of every domain'
+WHERE NOT EXISTS (SELECT 1 FROM roles WHERE code = 'SUPER_ADMIN');
+
+-- Super admins see the same menus as admins
+INSERT INTO menu_roles (menu_id, role_id)
+SELECT mr.menu_id, r.id
+FROM menu_roles mr
+JOIN roles admin ON admin.id = mr.role_id AND admin.code = 'ADMIN'
+CROSS JOIN roles r
+WHERE r.code = 'SUPER_ADMIN'
+  AND NOT EXISTS (SELECT 1 FROM menu_roles x WHERE x.menu_id = mr.menu_id AND x.role_id = r.id);
+
+COMMENT ON COLUMN roles.code IS 'Unique code for the role (e.g., ADMIN, PERMIT_MANAGER). SUPER_ADMIN may access every domain';
+
+COMMIT;
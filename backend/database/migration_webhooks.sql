@@ -0,0 +1,91 @@
+-- Migration for outbound webhooks
+-- Created: 2026-10-16
+-- Domains register receiver URLs with a signing secret and the permit and task
+-- events they want. Events are queued in webhook_deliveries, posted with an
+-- HMAC-SHA256 signature by a background worker that retries failures with
+-- exponential backoff, and every post is logged in webhook_delivery_attempts.
+-- Apply on existing databases: psql -U postgres -d permit_db -f database/migration_webhooks.sql
+
+BEGIN;
+
+-- Create Webhook Subscriptions table (receiver URLs of a domain and the events posted to them)
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    domain_id BIGINT NOT NULL,
+    url VARCHAR(500) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types JSONB NOT NULL DEFAULT '[]',
+    description VARCHAR(255),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by BIGINT,
+    updated_by BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Create Webhook Deliveries table (events queued for one subscription, posted by the webhook worker)
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sending', 'delivered', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 8,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP,
+    last_error TEXT,
+    response_status INTEGER,
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id) ON DELETE CASCADE
+);
+
+-- Create Webhook Delivery Attempts table (outcome of each post of a webhook delivery)
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    delivery_id BIGINT NOT NULL,
+    attempt INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('delivered', 'failed')),
+    response_status INTEGER,
+    response_body TEXT,
+    error TEXT,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries(id) ON DELETE CASCADE
+);
+
+-- Indexes for webhook_subscriptions, webhook_deliveries and webhook_delivery_attempts
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_domain_id ON webhook_subscriptions(domain_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status IN ('pending', 'sending');
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries(subscription_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery_id ON webhook_delivery_attempts(delivery_id);
+
+DROP TRIGGER IF EXISTS update_webhook_subscriptions_updated_at ON webhook_subscriptions;
+CREATE TRIGGER update_webhook_subscriptions_updated_at BEFORE UPDATE ON webhook_subscriptions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+DROP TRIGGER IF EXISTS update_webhook_deliveries_updated_at ON webhook_deliveries;
+CREATE TRIGGER update_webhook_deliveries_updated_at BEFORE UPDATE ON webhook_deliveries
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE webhook_subscriptions IS 'Stores the receiver URLs a domain registered for outbound webhooks, with the signing secret and the selected event types';
+COMMENT ON TABLE webhook_deliveries IS 'Stores the events queued for each webhook subscription, posted and retried by the webhook worker';
+COMMENT ON TABLE webhook_delivery_attempts IS 'Stores the receiver''s answer to every post of a webhook delivery';
+COMMENT ON COLUMN webhook_subscriptions.secret IS 'Key of the HMAC-SHA256 signature sent in the X-Webhook-Signature header';
+COMMENT ON COLUMN webhook_subscriptions.event_types IS 'JSON array of the event types posted to the URL (permit.created, permit.expiring, permit.expired, task.assigned, task.approved, task.rejected)';
+COMMENT ON COLUMN webhook_subscriptions.is_active IS 'Deliveries of inactive subscriptions wait until the subscription is activated again';
+COMMENT ON COLUMN webhook_deliveries.event_id IS 'ID of the event, the same for every subscription it was queued for, sent in the X-Webhook-ID header';
+COMMENT ON COLUMN webhook_deliveries.payload IS 'JSON body posted to the receiver';
+COMMENT ON COLUMN webhook_deliveries.status IS 'pending (waiting for its next attempt), sending (claimed by the worker), delivered (2xx answer) or failed (max_attempts reached)';
+COMMENT ON COLUMN webhook_deliveries.next_attempt_at IS 'When the worker may post the delivery next, moved back exponentially after each failure';
+COMMENT ON COLUMN webhook_deliveries.locked_until IS 'Lease of the worker posting the delivery, expired leases are claimed again';
+COMMENT ON COLUMN webhook_deliveries.response_status IS 'HTTP status the receiver answered the latest attempt with';
+COMMENT ON COLUMN webhook_delivery_attempts.response_body IS 'First kilobyte of the receiver''s answer';
+
+COMMIT;
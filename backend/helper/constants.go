@@ -15,6 +15,31 @@ const (
 	TaskStatusInReview   = 37
 	TaskStatusRevision   = 39
 
+	// Permit Document Type IDs
+	PermitDocumentTypeLicense          = 40
+	PermitDocumentTypeAttachment       = 41
+	PermitDocumentTypePaymentReceipt   = 42
+	PermitDocumentTypeInspectionReport = 43
+
+	// RoleCodeSuperAdmin is the role that may read and write permits, divisions
+	// and permit types of every domain
+	RoleCodeSuperAdmin = "SUPER_ADMIN"
+
+	// RoleCodeAdmin is the administrator role of a domain
+	RoleCodeAdmin = "ADMIN"
+
+	// RoleCodePermitManager is the role that gives the final approval of
+	// submitted permits in its domain
+	RoleCodePermitManager = "PERMIT_MANAGER"
+
+	// RoleCodeTicketingPIC and RoleCodeTicketingManager decide the two approval
+	// steps of a task, verification by the client PIC then the manager's approval
+	RoleCodeTicketingPIC     = "TICKETING_PIC"
+	RoleCodeTicketingManager = "TICKETING_MANAGER"
+
+	// Reference Category IDs
+	ReferenceCategoryPermitDocumentType = 8
+
 	// File upload paths
 	TaskFileUploadPath   = "file/tasks/"
 	PermitFileUploadPath = "file/permits/"
@@ -1,11 +1,18 @@
 package helper
 
 import (
+	"bytes"
 	"crypto/tls"
 	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
 	"net/smtp"
+	"net/textproto"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type EmailConfig struct {
@@ -29,31 +36,62 @@ func GetEmailConfig() EmailConfig {
 	}
 }
 
-func SendEmail(to []string, subject string, body string) error {
+// SendEmail sends a multipart/alternative email with a plain text and an HTML
+// part. Clients show the HTML part when they can. An empty textBody sends the
+// HTML part alone.
+func SendEmail(to []string, subject string, htmlBody string, textBody string) error {
 	config := GetEmailConfig()
 
 	// Setup headers
 	from := config.From
 	if config.FromName != "" {
-		from = fmt.Sprintf("%s <%s>", config.FromName, config.From)
+		from = fmt.Sprintf("%s <%s>", mime.QEncoding.Encode("utf-8", config.FromName), config.From)
 	}
 
-	headers := make(map[string]string)
-	headers["From"] = from
-	headers["To"] = strings.Join(to, ",")
-	headers["Subject"] = subject
-	headers["MIME-Version"] = "1.0"
-	headers["Content-Type"] = "text/html; charset=\"utf-8\""
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", from)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(to, ","))
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
 
 	// Setup message
-	message := ""
-	for k, v := range headers {
-		message += fmt.Sprintf("%s: %s\r\n", k, v)
+	if textBody == "" {
+		message.WriteString("Content-Type: text/html; charset=\"utf-8\"\r\n")
+		message.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&message, htmlBody); err != nil {
+			return fmt.Errorf("failed to encode message: %v", err)
+		}
+	} else {
+		parts := multipart.NewWriter(&message)
+		fmt.Fprintf(&message, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+
+		// Parts go from the simplest to the preferred one
+		for _, part := range []struct{ contentType, body string }{
+			{"text/plain", textBody},
+			{"text/html", htmlBody},
+		} {
+			writer, err := parts.CreatePart(textproto.MIMEHeader{
+				"Content-Type":              {part.contentType + "; charset=\"utf-8\""},
+				"Content-Transfer-Encoding": {"quoted-printable"},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create part: %v", err)
+			}
+			if err = writeQuotedPrintable(writer, part.body); err != nil {
+				return fmt.Errorf("failed to encode message: %v", err)
+			}
+		}
+		if err := parts.Close(); err != nil {
+			return fmt.Errorf("failed to close message: %v", err)
+		}
 	}
-	message += "\r\n" + body
 
-	// Setup authentication
-	auth := smtp.PlainAuth("", config.Username, config.Password, config.Host)
+	// Setup authentication, local SMTP stand-ins such as Mailpit accept mail without it
+	var auth smtp.Auth
+	if config.Username != "" {
+		auth = smtp.PlainAuth("", config.Username, config.Password, config.Host)
+	}
 
 	// Connect to SMTP server
 	addr := fmt.Sprintf("%s:%d", config.Host, config.Port)
@@ -76,8 +114,10 @@ func SendEmail(to []string, subject string, body string) error {
 		}
 		defer client.Close()
 
-		if err = client.Auth(auth); err != nil {
-			return fmt.Errorf("failed to authenticate: %v", err)
+		if auth != nil {
+			if err = client.Auth(auth); err != nil {
+				return fmt.Errorf("failed to authenticate: %v", err)
+			}
 		}
 
 		if err = client.Mail(config.From); err != nil {
@@ -95,7 +135,7 @@ func SendEmail(to []string, subject string, body string) error {
 			return fmt.Errorf("failed to create data writer: %v", err)
 		}
 
-		_, err = writer.Write([]byte(message))
+		_, err = writer.Write(message.Bytes())
 		if err != nil {
 			return fmt.Errorf("failed to write message: %v", err)
 		}
@@ -109,54 +149,15 @@ func SendEmail(to []string, subject string, body string) error {
 	}
 
 	// For STARTTLS (port 587)
-	return smtp.SendMail(addr, auth, config.From, to, []byte(message))
+	return smtp.SendMail(addr, auth, config.From, to, message.Bytes())
 }
 
-func SendPermitExpiryNotification(to []string, permitName string, permitNo string, expiryDate string, daysLeft int) error {
-	subject := fmt.Sprintf("Reminder: Permit %s akan segera expired", permitName)
-
-	body := fmt.Sprintf(`
-<!DOCTYPE html>
-<html>
-<head>
-    <style>
-        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
-        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { background-color: #dc2626; color: white; padding: 20px; text-align: center; border-radius: 5px 5px 0 0; }
-        .content { background-color: #f9f9f9; padding: 20px; border: 1px solid #ddd; }
-        .info-box { background-color: #fff; padding: 15px; margin: 15px 0; border-left: 4px solid #dc2626; }
-        .footer { text-align: center; margin-top: 20px; font-size: 12px; color: #666; }
-        .warning { color: #dc2626; font-weight: bold; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>Permit Expiry Notification</h1>
-        </div>
-        <div class="content">
-            <p>Dear User,</p>
-            <p>Ini adalah pengingat bahwa permit berikut akan segera expired:</p>
-            
-            <div class="info-box">
-                <h3>Detail Permit:</h3>
-                <p><strong>Nama Permit:</strong> %s</p>
-                <p><strong>Nomor Permit:</strong> %s</p>
-                <p><strong>Tanggal Expired:</strong> %s</p>
-                <p class="warning">Sisa Waktu: %d hari</p>
-            </div>
-            
-            <p>Harap segera melakukan perpanjangan atau pembaruan permit sebelum tanggal expired.</p>
-            <p>Silakan login ke aplikasi Permit Management untuk informasi lebih lanjut.</p>
-        </div>
-        <div class="footer">
-            <p>Email ini dikirim secara otomatis oleh Permit Management System.</p>
-            <p>Mohon tidak membalas email ini.</p>
-        </div>
-    </div>
-</body>
-</html>
-`, permitName, permitNo, expiryDate, daysLeft)
-
-	return SendEmail(to, subject, body)
+// writeQuotedPrintable encodes body as quoted-printable, which also turns its
+// line breaks into CRLF
+func writeQuotedPrintable(w io.Writer, body string) error {
+	encoder := quotedprintable.NewWriter(w)
+	if _, err := encoder.Write([]byte(body)); err != nil {
+		return err
+	}
+	return encoder.Close()
 }
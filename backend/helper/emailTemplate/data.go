@@ -0,0 +1,124 @@
+package emailTemplate
+
+import "time"
+
+// PermitExpiryData is rendered by the permit_expiry template. DaysLeft is zero
+// or less once the permit has expired.
+type PermitExpiryData struct {
+	PermitName string
+	PermitNo   string
+	ExpiryDate time.Time
+	DaysLeft   int
+	Link       string
+}
+
+// PermitEscalationData is rendered by the permit_escalation template, sent when
+// a permit's expiry reminders were ignored. DaysLeft is negative once the
+// permit has been expired for a while, RemindedSince is when the first
+// reminder went out.
+type PermitEscalationData struct {
+	PermitName    string
+	PermitNo      string
+	ExpiryDate    time.Time
+	DaysLeft      int
+	Level         int
+	RemindedSince time.Time
+	Link          string
+}
+
+// ObligationReminderData is rendered by the obligation_reminder template.
+// DaysLeft is negative when the obligation is overdue.
+type ObligationReminderData struct {
+	Description string
+	PermitName  string
+	PermitNo    string
+	DueDate     time.Time
+	DaysLeft    int
+	Link        string
+}
+
+// DigestData is rendered by the notification_digest template
+type DigestData struct {
+	Frequency string // daily or weekly
+	Total     int
+	Domains   []DigestDomain
+}
+
+// DigestDomain is a domain's section of a digest email
+type DigestDomain struct {
+	Name   string
+	Count  int
+	Groups []DigestGroup
+}
+
+// DigestGroup lists a domain's digest entries of one severity
+type DigestGroup struct {
+	Severity string
+	Entries  []DigestEntry
+}
+
+// DigestEntry is one notification in a digest email. Link is the record's path
+// in the client, made absolute with APP_URL.
+type DigestEntry struct {
+	Title   string
+	Message string
+	Link    string
+}
+
+// NewDigestData counts the entries of the domains
+func NewDigestData(frequency string, domains []DigestDomain) DigestData {
+	data := DigestData{Frequency: frequency, Domains: domains}
+	for i := range data.Domains {
+		data.Domains[i].Count = 0
+		for _, group := range data.Domains[i].Groups {
+			data.Domains[i].Count += len(group.Entries)
+		}
+		data.Total += data.Domains[i].Count
+	}
+	return data
+}
+
+// SampleData returns example data of a template, used to validate and preview
+// overrides
+func SampleData(name string) any {
+	now := time.Now()
+	switch name {
+	case PermitExpiry:
+		return PermitExpiryData{
+			PermitName: "Izin Lingkungan",
+			PermitNo:   "PRM-2026-001",
+			ExpiryDate: now.AddDate(0, 0, 30),
+			DaysLeft:   30,
+			Link:       "/permits/1",
+		}
+	case PermitEscalation:
+		return PermitEscalationData{
+			PermitName:    "Izin Lingkungan",
+			PermitNo:      "PRM-2026-001",
+			ExpiryDate:    now.AddDate(0, 0, 3),
+			DaysLeft:      3,
+			Level:         1,
+			RemindedSince: now.AddDate(0, 0, -27),
+			Link:          "/permits/1",
+		}
+	case ObligationReminder:
+		return ObligationReminderData{
+			Description: "Laporan pemantauan lingkungan semester I",
+			PermitName:  "Izin Lingkungan",
+			PermitNo:    "PRM-2026-001",
+			DueDate:     now.AddDate(0, 0, 7),
+			DaysLeft:    7,
+			Link:        "/permits/1",
+		}
+	case NotificationDigest:
+		return NewDigestData("daily", []DigestDomain{{
+			Name: "Head Office",
+			Groups: []DigestGroup{
+				{Severity: "critical", Entries: []DigestEntry{{Title: "Permit expired", Message: "Izin Lingkungan (PRM-2026-001) has expired", Link: "/permits/1"}}},
+				{Severity: "info", Entries: []DigestEntry{{Title: "Task assigned", Message: "You were assigned to a task", Link: "/tasks/1"}}},
+			},
+		}})
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,224 @@
+// Package emailTemplate renders the application's emails from templates. Every
+// email has a subject, an HTML part and a plain text part per locale, rendered
+// inside the shared layout and partials under templates/. Domains may override
+// the subject and both parts of an email, the layout and partials stay shared.
+package emailTemplate
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"permit-app/helper"
+)
+
+//go:embed templates
+var files embed.FS
+
+// Locales emails are available in
+const (
+	LocaleID = "id"
+	LocaleEN = "en"
+)
+
+// DefaultLocale applies when neither the user nor the domain chose a locale
+const DefaultLocale = LocaleID
+
+// Locales lists every supported locale
+var Locales = []string{LocaleID, LocaleEN}
+
+// Email template names
+const (
+	PermitExpiry       = "permit_expiry"
+	PermitEscalation   = "permit_escalation"
+	ObligationReminder = "obligation_reminder"
+	NotificationDigest = "notification_digest"
+)
+
+// Names lists every email template
+var Names = []string{PermitExpiry, PermitEscalation, ObligationReminder, NotificationDigest}
+
+// DomainNames lists the templates a domain can override. Digests combine the
+// notifications of several domains and always use the built-in template.
+var DomainNames = []string{PermitExpiry, PermitEscalation, ObligationReminder}
+
+// Source is the editable part of an email template: the subject line and the
+// content of the HTML and plain text parts
+type Source struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Email is a rendered email
+type Email struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+func IsLocale(locale string) bool {
+	return slices.Contains(Locales, locale)
+}
+
+func IsName(name string) bool {
+	return slices.Contains(Names, name)
+}
+
+// DefaultSource returns the built-in source of a template in the locale
+func DefaultSource(name string, locale string) (Source, error) {
+	if !IsName(name) {
+		return Source{}, fmt.Errorf("unknown email template %q", name)
+	}
+	if !IsLocale(locale) {
+		return Source{}, fmt.Errorf("unknown email locale %q", locale)
+	}
+
+	var source Source
+	parts := []struct {
+		file string
+		dst  *string
+	}{
+		{name + ".subject.txt", &source.Subject},
+		{name + ".html", &source.HTML},
+		{name + ".txt", &source.Text},
+	}
+	for _, part := range parts {
+		content, err := fs.ReadFile(files, "templates/"+locale+"/"+part.file)
+		if err != nil {
+			return Source{}, err
+		}
+		*part.dst = string(content)
+	}
+
+	return source, nil
+}
+
+// Render renders the named template in the locale, falling back to the default
+// locale for unknown ones. A non-nil override replaces the built-in source.
+func Render(name string, locale string, data any, override *Source) (*Email, error) {
+	if !IsLocale(locale) {
+		locale = DefaultLocale
+	}
+
+	source := override
+	if source == nil {
+		builtIn, err := DefaultSource(name, locale)
+		if err != nil {
+			return nil, err
+		}
+		source = &builtIn
+	}
+
+	funcs := funcMap(locale)
+
+	subject, err := renderText(locale, funcs, source.Subject, data, false)
+	if err != nil {
+		return nil, fmt.Errorf("subject: %w", err)
+	}
+	// Headers are a single line
+	subject = strings.Join(strings.Fields(subject), " ")
+
+	htmlBody, err := renderHTML(locale, funcs, source.HTML, data)
+	if err != nil {
+		return nil, fmt.Errorf("html: %w", err)
+	}
+
+	textBody, err := renderText(locale, funcs, source.Text, data, true)
+	if err != nil {
+		return nil, fmt.Errorf("text: %w", err)
+	}
+
+	return &Email{Subject: subject, HTML: htmlBody, Text: textBody}, nil
+}
+
+// Validate checks that a source parses and renders with the template's sample
+// data, so a broken override is rejected before any email uses it
+func Validate(name string, locale string, source Source) error {
+	if strings.TrimSpace(source.Subject) == "" {
+		return fmt.Errorf("subject is empty")
+	}
+	_, err := Render(name, locale, SampleData(name), &source)
+	return err
+}
+
+func renderHTML(locale string, funcs map[string]any, content string, data any) (string, error) {
+	tmpl, err := htmltemplate.New("layout.html").Funcs(funcs).ParseFS(files, "templates/layout.html", "templates/"+locale+"/partials.html")
+	if err != nil {
+		return "", err
+	}
+	if _, err = tmpl.New("content").Parse(content); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// renderText renders a text/template, inside the text layout when withLayout
+// is set
+func renderText(locale string, funcs map[string]any, content string, data any, withLayout bool) (string, error) {
+	tmpl, err := texttemplate.New("layout.txt").Funcs(funcs).ParseFS(files, "templates/layout.txt", "templates/"+locale+"/partials.txt")
+	if err != nil {
+		return "", err
+	}
+	if _, err = tmpl.New("content").Parse(content); err != nil {
+		return "", err
+	}
+
+	entry := "content"
+	if withLayout {
+		entry = "layout"
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.ExecuteTemplate(&buf, entry, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+var monthNames = map[string][]string{
+	LocaleID: {"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"},
+	LocaleEN: {"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+}
+
+var severityColors = map[string]string{
+	"critical": "#dc2626",
+	"warning":  "#d97706",
+	"info":     "#2563eb",
+}
+
+func funcMap(locale string) map[string]any {
+	return map[string]any{
+		// date formats a date with the locale's month names, e.g. 02 Januari 2026
+		"date": func(t time.Time) string {
+			return fmt.Sprintf("%02d %s %d", t.Day(), monthNames[locale][t.Month()-1], t.Year())
+		},
+		// url makes a client path absolute with APP_URL
+		"url": func(path string) string {
+			return strings.TrimRight(helper.GetEnv("APP_URL"), "/") + path
+		},
+		"abs": func(n int) int {
+			if n < 0 {
+				return -n
+			}
+			return n
+		},
+		"severityColor": func(severity string) string {
+			if color, ok := severityColors[severity]; ok {
+				return color
+			}
+			return severityColors["info"]
+		},
+	}
+}
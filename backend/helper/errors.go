@@ -0,0 +1,7 @@
+package helper
+
+import "errors"
+
+// ErrOutsideDomain is returned when a request refers to a record of a domain
+// the caller has no access to
+var ErrOutsideDomain = errors.New("record belongs to a different domain")
@@ -62,6 +62,38 @@ func SaveFile(file *multipart.FileHeader, directory string) (string, error) {
 	return filePath, nil
 }
 
+// CopyFile duplicates a stored file into the specified directory and returns the new path
+func CopyFile(srcPath string, directory string) (string, error) {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open source file: %v", err)
+	}
+	defer src.Close()
+
+	// Create directory if it doesn't exist
+	uploadDir := filepath.Join("file", directory)
+	if err := os.MkdirAll(uploadDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create upload directory: %v", err)
+	}
+
+	// Generate unique filename
+	ext := filepath.Ext(srcPath)
+	uniqueFilename := fmt.Sprintf("%s_%s%s", time.Now().Format("20060102150405"), uuid.New().String(), ext)
+	filePath := filepath.Join(uploadDir, uniqueFilename)
+
+	dst, err := os.Create(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create destination file: %v", err)
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return "", fmt.Errorf("failed to copy file: %v", err)
+	}
+
+	return filePath, nil
+}
+
 // DeleteFile removes a file from storage
 func DeleteFile(filePath string) error {
 	if filePath == "" {
@@ -0,0 +1,140 @@
+package helper
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// CalendarAlarm is a VALARM reminder. Before is how long before the event start
+// the alarm fires.
+type CalendarAlarm struct {
+	Before      time.Duration
+	Description string
+}
+
+// CalendarEvent is an all-day VEVENT. UID must stay the same for the same
+// item across feed refreshes so clients update the event instead of adding one.
+type CalendarEvent struct {
+	UID          string
+	Date         time.Time
+	Summary      string
+	Description  string
+	Categories   []string
+	LastModified time.Time
+	Alarms       []CalendarAlarm
+}
+
+// WriteCalendar writes the events as an iCalendar (RFC 5545) document
+func WriteCalendar(w io.Writer, name string, events []CalendarEvent) error {
+	b := &icsBuilder{}
+	b.line("BEGIN:VCALENDAR")
+	b.line("VERSION:2.0")
+	b.line("PRODID:-//Permit App//Permit Calendar//EN")
+	b.line("CALSCALE:GREGORIAN")
+	b.line("METHOD:PUBLISH")
+	b.property("X-WR-CALNAME", name)
+	b.line("X-PUBLISHED-TTL:PT1H")
+	b.line("REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	for _, event := range events {
+		b.line("BEGIN:VEVENT")
+		b.property("UID", event.UID)
+		b.line("DTSTAMP:" + stamp)
+		if !event.LastModified.IsZero() {
+			b.line("LAST-MODIFIED:" + event.LastModified.UTC().Format("20060102T150405Z"))
+		}
+		b.line("DTSTART;VALUE=DATE:" + event.Date.Format("20060102"))
+		b.line("DTEND;VALUE=DATE:" + event.Date.AddDate(0, 0, 1).Format("20060102"))
+		b.property("SUMMARY", event.Summary)
+		if event.Description != "" {
+			b.property("DESCRIPTION", event.Description)
+		}
+		if len(event.Categories) > 0 {
+			escaped := make([]string, len(event.Categories))
+			for i, category := range event.Categories {
+				escaped[i] = escapeICSText(category)
+			}
+			b.line("CATEGORIES:" + strings.Join(escaped, ","))
+		}
+		b.line("TRANSP:TRANSPARENT")
+		for _, alarm := range event.Alarms {
+			b.line("BEGIN:VALARM")
+			b.line("ACTION:DISPLAY")
+			b.line("TRIGGER:" + icsDuration(alarm.Before))
+			b.property("DESCRIPTION", alarm.Description)
+			b.line("END:VALARM")
+		}
+		b.line("END:VEVENT")
+	}
+	b.line("END:VCALENDAR")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type icsBuilder struct {
+	strings.Builder
+}
+
+func (b *icsBuilder) property(name string, value string) {
+	b.line(name + ":" + escapeICSText(value))
+}
+
+// line folds content lines longer than 75 octets as required by RFC 5545,
+// without splitting multi-byte characters
+func (b *icsBuilder) line(content string) {
+	limit := 75
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(content[cut]) {
+			cut--
+		}
+		b.WriteString(content[:cut])
+		b.WriteString("\r\n ")
+		content = content[cut:]
+		limit = 74
+	}
+	b.WriteString(content)
+	b.WriteString("\r\n")
+}
+
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
+
+func escapeICSText(value string) string {
+	return strings.NewReplacer(
+		"\\", "\\\\",
+		";", "\\;",
+		",", "\\,",
+		"\r\n", "\\n",
+		"\n", "\\n",
+	).Replace(value)
+}
+
+// icsDuration formats a negative trigger offset such as -P7D or -PT9H
+func icsDuration(before time.Duration) string {
+	if before <= 0 {
+		return "PT0S"
+	}
+	days := int(before / (24 * time.Hour))
+	rest := before % (24 * time.Hour)
+	if rest == 0 {
+		return fmt.Sprintf("-P%dD", days)
+	}
+	result := "-P"
+	if days > 0 {
+		result += fmt.Sprintf("%dD", days)
+	}
+	result += "T"
+	if hours := int(rest / time.Hour); hours > 0 {
+		result += fmt.Sprintf("%dH", hours)
+	}
+	if minutes := int(rest % time.Hour / time.Minute); minutes > 0 {
+		result += fmt.Sprintf("%dM", minutes)
+	}
+	return result
+}
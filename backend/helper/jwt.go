@@ -11,6 +11,13 @@ import (
 
 var SECRETKEY string = getJWTSecret()
 
+const (
+	// StreamTokenPurpose marks the tokens that only open the notification stream
+	StreamTokenPurpose = "notification_stream"
+	// StreamTokenTTL is how long a stream token can be used to open the stream
+	StreamTokenTTL = 60 * time.Second
+)
+
 func getJWTSecret() string {
 	secret := GetEnv("JWT_SECRET")
 	if secret == "" {
@@ -36,13 +43,14 @@ func GenerateToken(idUser string, nmUser string) (string, error) {
 }
 
 // GenerateTokenWithDomain generates JWT token with user, domain, and role context
-func GenerateTokenWithDomain(userID int64, username string, email string, domainID int64, roleID int64) (string, error) {
+func GenerateTokenWithDomain(userID int64, username string, email string, domainID int64, roleID int64, roleCode string) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id":   userID,
 		"username":  username,
 		"email":     email,
 		"domain_id": domainID,
 		"role_id":   roleID,
+		"role_code": roleCode,
 		"exp":       time.Now().Add(time.Hour * 24).Unix(), // 24 hours
 		"iat":       time.Now().Unix(),
 	}
@@ -53,14 +61,44 @@ func GenerateTokenWithDomain(userID int64, username string, email string, domain
 	return res, err
 }
 
+// GenerateStreamToken generates a short-lived token that only opens the user's
+// notification stream. It is meant for the URL of clients that cannot set
+// headers, so the access token never ends up in URLs and logs.
+func GenerateStreamToken(userID int64, domainID int64) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id":   userID,
+		"domain_id": domainID,
+		"purpose":   StreamTokenPurpose,
+		"exp":       time.Now().Add(StreamTokenTTL).Unix(),
+		"iat":       time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(SECRETKEY))
+}
+
 func VerifyToken(ctx *gin.Context) (jwt.MapClaims, error) {
 	auth := ctx.Request.Header.Get("Authorization")
 	if !strings.HasPrefix(auth, "Bearer ") {
 		return nil, errors.New("please login to get the token")
 	}
 
-	tokenStr := strings.TrimPrefix(auth, "Bearer ")
+	return parseToken(strings.TrimPrefix(auth, "Bearer "))
+}
+
+// VerifyStreamToken validates a token made by GenerateStreamToken
+func VerifyStreamToken(tokenStr string) (jwt.MapClaims, error) {
+	claims, err := parseToken(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	if claims["purpose"] != StreamTokenPurpose {
+		return nil, errors.New("not a stream token")
+	}
+	return claims, nil
+}
 
+func parseToken(tokenStr string) (jwt.MapClaims, error) {
 	// Parse token with MapClaims
 	claims := jwt.MapClaims{}
 	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
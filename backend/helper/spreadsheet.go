@@ -0,0 +1,152 @@
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var (
+	AllowedSpreadsheetTypes = []string{".csv", ".xlsx"}
+	MaxSpreadsheetSize      = int64(5 * 1024 * 1024) // 5MB
+)
+
+// spreadsheetDateFormats are the text date layouts accepted in imported sheets
+var spreadsheetDateFormats = []string{
+	"2006-01-02",
+	time.RFC3339,
+	"02/01/2006",
+	"2/1/2006",
+	"02-01-2006",
+	"2-1-2006",
+}
+
+// ReadSpreadsheet reads all rows of an uploaded CSV file or of the first sheet of
+// an XLSX file. Cells are trimmed and trailing empty rows are dropped.
+func ReadSpreadsheet(file *multipart.FileHeader) ([][]string, error) {
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if ext != ".csv" && ext != ".xlsx" {
+		return nil, fmt.Errorf("file type not allowed. Allowed types: %s", strings.Join(AllowedSpreadsheetTypes, ", "))
+	}
+
+	if file.Size > MaxSpreadsheetSize {
+		return nil, fmt.Errorf("file size exceeds maximum allowed size of 5MB")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploaded file: %v", err)
+	}
+	defer src.Close()
+
+	var rows [][]string
+	if ext == ".csv" {
+		rows, err = readCSV(src)
+	} else {
+		rows, err = readXLSX(src)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		for i := range row {
+			row[i] = strings.TrimSpace(row[i])
+		}
+	}
+
+	for len(rows) > 0 && IsEmptyRow(rows[len(rows)-1]) {
+		rows = rows[:len(rows)-1]
+	}
+
+	return rows, nil
+}
+
+// readCSV accepts both comma and semicolon separated files, the latter is what
+// spreadsheet applications export with Indonesian regional settings
+func readCSV(src io.Reader) ([][]string, error) {
+	content, err := io.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV file: %v", err)
+	}
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+
+	firstLine, _ := bufio.NewReader(bytes.NewReader(content)).ReadString('\n')
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	if strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		reader.Comma = ';'
+	}
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV file: %v", err)
+	}
+	return rows, nil
+}
+
+// readXLSX returns raw cell values so date cells come back as serial numbers
+// instead of a locale dependent display format
+func readXLSX(src io.Reader) ([][]string, error) {
+	workbook, err := excelize.OpenReader(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX file: %v", err)
+	}
+	defer workbook.Close()
+
+	sheets := workbook.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("XLSX file has no sheets")
+	}
+
+	rows, err := workbook.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read XLSX sheet: %v", err)
+	}
+	return rows, nil
+}
+
+// IsEmptyRow reports whether every cell of a sheet row is blank
+func IsEmptyRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseSpreadsheetDate parses a date cell given as text (YYYY-MM-DD or DD/MM/YYYY)
+// or as an Excel serial number
+func ParseSpreadsheetDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if serial, err := strconv.ParseFloat(value, 64); err == nil {
+		if serial <= 0 {
+			return time.Time{}, fmt.Errorf("invalid date %q", value)
+		}
+		t, err := excelize.ExcelDateToTime(serial, false)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q", value)
+		}
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+
+	for _, layout := range spreadsheetDateFormats {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD or DD/MM/YYYY", value)
+}
@@ -0,0 +1,269 @@
+package helper
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/xuri/excelize/v2"
+)
+
+// Export formats supported by NewTableWriter
+const (
+	ExportFormatXLSX = "xlsx"
+	ExportFormatCSV  = "csv"
+	ExportFormatPDF  = "pdf"
+)
+
+var ExportContentTypes = map[string]string{
+	ExportFormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	ExportFormatCSV:  "text/csv; charset=utf-8",
+	ExportFormatPDF:  "application/pdf",
+}
+
+// TableColumn describes one exported column. Width is the column width in
+// characters for XLSX and in millimetres for PDF.
+type TableColumn struct {
+	Title string
+	Width float64
+}
+
+// TableWriter writes a report table row by row. Close must be called to flush
+// the formats that are only written out at the end.
+type TableWriter interface {
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// NewTableWriter starts a table in the given format and writes its header row
+func NewTableWriter(format string, w io.Writer, title string, columns []TableColumn) (TableWriter, error) {
+	switch format {
+	case ExportFormatCSV:
+		return newCSVTableWriter(w, columns)
+	case ExportFormatXLSX:
+		return newXLSXTableWriter(w, title, columns)
+	case ExportFormatPDF:
+		return newPDFTableWriter(w, title, columns), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+func formatTableValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return *v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format("2006-01-02")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+type csvTableWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVTableWriter(w io.Writer, columns []TableColumn) (*csvTableWriter, error) {
+	// The byte order mark lets spreadsheet applications detect UTF-8
+	if _, err := w.Write([]byte("\xef\xbb\xbf")); err != nil {
+		return nil, err
+	}
+
+	t := &csvTableWriter{writer: csv.NewWriter(w)}
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = column.Title
+	}
+	return t, t.WriteRow(header)
+}
+
+func (t *csvTableWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = formatTableValue(value)
+	}
+	return t.writer.Write(record)
+}
+
+func (t *csvTableWriter) Close() error {
+	t.writer.Flush()
+	return t.writer.Error()
+}
+
+type xlsxTableWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXTableWriter(w io.Writer, title string, columns []TableColumn) (*xlsxTableWriter, error) {
+	file := excelize.NewFile()
+	sheet := title
+	if len(sheet) > 31 {
+		sheet = sheet[:31]
+	}
+	if err := file.SetSheetName("Sheet1", sheet); err != nil {
+		return nil, err
+	}
+
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	headerStyle, err := file.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"D9E1F2"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, column := range columns {
+		if column.Width > 0 {
+			if err := stream.SetColWidth(i+1, i+1, column.Width); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Keep the header row visible while scrolling
+	if err := stream.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return nil, err
+	}
+
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = excelize.Cell{StyleID: headerStyle, Value: column.Title}
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		return nil, err
+	}
+
+	return &xlsxTableWriter{w: w, file: file, stream: stream, row: 1}, nil
+}
+
+func (t *xlsxTableWriter) WriteRow(values []interface{}) error {
+	t.row++
+	cells := make([]interface{}, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case int, int64, float64:
+			cells[i] = v
+		default:
+			cells[i] = formatTableValue(v)
+		}
+	}
+
+	cell, err := excelize.CoordinatesToCellName(1, t.row)
+	if err != nil {
+		return err
+	}
+	return t.stream.SetRow(cell, cells)
+}
+
+func (t *xlsxTableWriter) Close() error {
+	defer t.file.Close()
+	if err := t.stream.Flush(); err != nil {
+		return err
+	}
+	return t.file.Write(t.w)
+}
+
+const (
+	pdfRowHeight    = 6.0
+	pdfFontSize     = 8.0
+	pdfHeaderHeight = 7.0
+)
+
+type pdfTableWriter struct {
+	w         io.Writer
+	pdf       *fpdf.Fpdf
+	columns   []TableColumn
+	translate func(string) string
+	fill      bool
+}
+
+// newPDFTableWriter lays the table out on landscape A4 pages and repeats the
+// column header on every page
+func newPDFTableWriter(w io.Writer, title string, columns []TableColumn) *pdfTableWriter {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	t := &pdfTableWriter{
+		w:         w,
+		pdf:       pdf,
+		columns:   columns,
+		translate: pdf.UnicodeTranslatorFromDescriptor(""),
+	}
+
+	generatedAt := time.Now().Format("2006-01-02 15:04")
+	pdf.SetHeaderFunc(func() {
+		pdf.SetFont("Helvetica", "B", 14)
+		pdf.CellFormat(0, 8, t.translate(title), "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.CellFormat(0, 5, "Generated "+generatedAt, "", 1, "L", false, 0, "")
+		pdf.Ln(2)
+
+		pdf.SetFont("Helvetica", "B", pdfFontSize)
+		pdf.SetFillColor(217, 225, 242)
+		for _, column := range t.columns {
+			pdf.CellFormat(column.Width, pdfHeaderHeight, t.fit(column.Title, column.Width), "1", 0, "L", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", pdfFontSize)
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Page %d/{nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AliasNbPages("")
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	return t
+}
+
+// fit shortens text that is wider than the column
+func (t *pdfTableWriter) fit(text string, width float64) string {
+	text = t.translate(text)
+	maxWidth := width - 2
+	if t.pdf.GetStringWidth(text) <= maxWidth {
+		return text
+	}
+	for len(text) > 0 && t.pdf.GetStringWidth(text+"...") > maxWidth {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
+
+func (t *pdfTableWriter) WriteRow(values []interface{}) error {
+	t.pdf.SetFillColor(245, 247, 250)
+	for i, column := range t.columns {
+		var value interface{}
+		if i < len(values) {
+			value = values[i]
+		}
+		t.pdf.CellFormat(column.Width, pdfRowHeight, t.fit(formatTableValue(value), column.Width), "1", 0, "L", t.fill, 0, "")
+	}
+	t.pdf.Ln(-1)
+	t.fill = !t.fill
+	return t.pdf.Error()
+}
+
+func (t *pdfTableWriter) Close() error {
+	return t.pdf.Output(t.w)
+}
@@ -0,0 +1,122 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidValidityPeriod is returned when a validity period cannot be parsed
+var ErrInvalidValidityPeriod = errors.New("invalid validity period")
+
+// ValidityPeriod is a permit validity such as "5 years" or "P1Y6M", split into
+// calendar units so it can be added to an effective date
+type ValidityPeriod struct {
+	Years  int `json:"years"`
+	Months int `json:"months"`
+	Days   int `json:"days"`
+}
+
+// validityUnits maps the English and Indonesian unit words to y, m, w or d
+var validityUnits = map[string]string{
+	"y": "y", "yr": "y", "yrs": "y", "year": "y", "years": "y", "tahun": "y", "thn": "y",
+	"m": "m", "mo": "m", "mos": "m", "month": "m", "months": "m", "bulan": "m", "bln": "m",
+	"w": "w", "wk": "w", "wks": "w", "week": "w", "weeks": "w", "minggu": "w", "pekan": "w",
+	"d": "d", "day": "d", "days": "d", "hari": "d",
+}
+
+var (
+	isoPeriodPattern  = regexp.MustCompile(`^P(?:(\d{1,4})Y)?(?:(\d{1,4})M)?(?:(\d{1,4})W)?(?:(\d{1,4})D)?$`)
+	textPeriodPattern = regexp.MustCompile(`^(\d{1,4})\s*([a-z]+)\s*(?:(?:,|&|and|dan)\s*)?`)
+)
+
+// ParseValidityPeriod reads a validity period written as text ("5 years",
+// "1 year 6 months", "6 bulan") or as an ISO 8601 duration ("P1Y", "P18M", "P90D").
+// Weeks are counted as 7 days.
+func ParseValidityPeriod(value string) (ValidityPeriod, error) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return ValidityPeriod{}, fmt.Errorf("%w: value is empty", ErrInvalidValidityPeriod)
+	}
+
+	var period ValidityPeriod
+	if match := isoPeriodPattern.FindStringSubmatch(strings.ToUpper(trimmed)); match != nil {
+		period.Years = atoiOrZero(match[1])
+		period.Months = atoiOrZero(match[2])
+		period.Days = atoiOrZero(match[3])*7 + atoiOrZero(match[4])
+	} else {
+		rest := strings.ToLower(trimmed)
+		for rest != "" {
+			match := textPeriodPattern.FindStringSubmatch(rest)
+			if match == nil {
+				return ValidityPeriod{}, fmt.Errorf("%w: %q, use e.g. \"5 years\", \"6 months\" or \"P1Y\"", ErrInvalidValidityPeriod, value)
+			}
+
+			amount := atoiOrZero(match[1])
+			switch validityUnits[match[2]] {
+			case "y":
+				period.Years += amount
+			case "m":
+				period.Months += amount
+			case "w":
+				period.Days += amount * 7
+			case "d":
+				period.Days += amount
+			default:
+				return ValidityPeriod{}, fmt.Errorf("%w: unknown unit %q in %q", ErrInvalidValidityPeriod, match[2], value)
+			}
+			rest = rest[len(match[0]):]
+		}
+	}
+
+	if period.IsZero() {
+		return ValidityPeriod{}, fmt.Errorf("%w: %q is not longer than zero", ErrInvalidValidityPeriod, value)
+	}
+
+	return period, nil
+}
+
+func atoiOrZero(value string) int {
+	n, _ := strconv.Atoi(value)
+	return n
+}
+
+func (p ValidityPeriod) IsZero() bool {
+	return p.Years == 0 && p.Months == 0 && p.Days == 0
+}
+
+// AddTo returns the date the period ends when it starts on start. Years and
+// months that land past the end of a month are kept on its last day, so a
+// year from 29 February ends on 28 February.
+func (p ValidityPeriod) AddTo(start time.Time) time.Time {
+	months := p.Years*12 + p.Months
+	year, month, day := start.Date()
+	firstOfMonth := time.Date(year, month+time.Month(months), 1, 0, 0, 0, 0, start.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	end := time.Date(firstOfMonth.Year(), firstOfMonth.Month(), day, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	return end.AddDate(0, 0, p.Days)
+}
+
+// String formats the period in words, e.g. "1 year 6 months"
+func (p ValidityPeriod) String() string {
+	parts := []string{}
+	for _, unit := range []struct {
+		amount int
+		name   string
+	}{{p.Years, "year"}, {p.Months, "month"}, {p.Days, "day"}} {
+		switch {
+		case unit.amount == 1:
+			parts = append(parts, "1 "+unit.name)
+		case unit.amount > 1:
+			parts = append(parts, fmt.Sprintf("%d %ss", unit.amount, unit.name))
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -5,14 +5,31 @@ import (
 	"permit-app/database"
 	"permit-app/helper"
 	"permit-app/model"
+	"permit-app/repo/divisionRepository"
+	"permit-app/repo/domainRepository"
+	"permit-app/repo/emailOutboxRepository"
+	"permit-app/repo/emailTemplateRepository"
+	"permit-app/repo/escalationRepository"
+	"permit-app/repo/jobRepository"
+	"permit-app/repo/notificationPreferenceRepository"
 	"permit-app/repo/notificationRepository"
+	"permit-app/repo/permitObligationRepository"
 	"permit-app/repo/permitRepository"
+	"permit-app/repo/permitRevisionRepository"
+	"permit-app/repo/permitTypeRepository"
+	"permit-app/repo/referenceRepository"
+	"permit-app/repo/reminderScheduleRepository"
+	"permit-app/repo/roleRepository"
 	"permit-app/repo/userRepository"
+	"permit-app/repo/webhookRepository"
 	"permit-app/routes"
 	"permit-app/scheduler"
+	"permit-app/service/emailOutboxService"
+	"permit-app/service/jobService"
 	"permit-app/service/notificationService"
-	"strconv"
-	"time"
+	"permit-app/service/notificationStreamService"
+	"permit-app/service/permitService"
+	"permit-app/service/webhookService"
 )
 
 func main() {
@@ -41,36 +58,50 @@ func main() {
 		}
 	}
 
-	// Initialize notification scheduler
+	// Initialize the services run by the scheduler
 	notificationRepo := notificationRepository.NewNotificationRepository(db)
 	permitRepo := permitRepository.NewPermitRepository(db)
 	userRepo := userRepository.NewUserRepository(db)
-	notificationSvc := notificationService.NewNotificationService(notificationRepo, permitRepo, userRepo)
+	reminderScheduleRepo := reminderScheduleRepository.NewReminderScheduleRepository(db)
+	permitObligationRepo := permitObligationRepository.NewPermitObligationRepository(db)
+	notificationPreferenceRepo := notificationPreferenceRepository.NewNotificationPreferenceRepository(db)
+	roleRepo := roleRepository.NewRoleRepository(db)
+	domainRepo := domainRepository.NewDomainRepository(db)
+	emailTemplateRepo := emailTemplateRepository.NewEmailTemplateRepository(db)
+	webhookRepo := webhookRepository.NewWebhookRepository(db)
+	escalationRepo := escalationRepository.NewEscalationRepository(db)
+	notificationSvc := notificationService.NewNotificationService(notificationRepo, permitRepo, userRepo, reminderScheduleRepo, permitObligationRepo, notificationPreferenceRepo, roleRepo, domainRepo, emailTemplateRepo, escalationRepo)
+	permitSvc := permitService.NewPermitService(
+		permitRepo,
+		permitRevisionRepository.NewPermitRevisionRepository(db),
+		referenceRepository.NewReferenceRepository(db),
+		divisionRepository.NewDivisionRepository(db),
+		permitTypeRepository.NewPermitTypeRepository(db),
+		userRepo,
+		notificationRepo,
+		notificationPreferenceRepo,
+		webhookRepo,
+	)
 	
-	notificationScheduler := scheduler.NewScheduler(notificationSvc)
-	
-	// Start scheduler based on mode
-	schedulerMode := helper.GetEnv("SCHEDULER_MODE")
-	if schedulerMode == "testing" {
-		intervalMinutes := helper.GetEnv("SCHEDULER_INTERVAL_MINUTES")
-		if intervalMinutes == "" {
-			intervalMinutes = "5" // default 5 minutes
-		}
-		if minutes, err := strconv.Atoi(intervalMinutes); err == nil {
-			notificationScheduler.StartWithInterval(time.Duration(minutes) * time.Minute)
-		} else {
-			log.Printf("Invalid SCHEDULER_INTERVAL_MINUTES, using default 5 minutes")
-			notificationScheduler.StartWithInterval(5 * time.Minute)
-		}
-	} else {
-		// production mode - run daily at scheduled time
-		notificationScheduler.Start()
+	// Register the jobs and start the scheduler running them
+	jobSvc := jobService.NewJobService(jobRepository.NewJobRepository(db))
+	emailOutboxSvc := emailOutboxService.NewEmailOutboxService(emailOutboxRepository.NewEmailOutboxRepository(db))
+	webhookSvc := webhookService.NewWebhookService(webhookRepo)
+	if err := scheduler.RegisterJobs(jobSvc, notificationSvc, permitSvc, emailOutboxSvc, webhookSvc); err != nil {
+		log.Fatalf("Error registering jobs: %v", err)
 	}
-	defer notificationScheduler.Stop()
 
-	log.Println("Notification scheduler started successfully")
+	jobScheduler := scheduler.NewScheduler(jobSvc)
+	jobScheduler.Start()
+	defer jobScheduler.Stop()
+
+	// Initialize notification listener pushing new notifications to open streams
+	notificationStreamSvc := notificationStreamService.NewNotificationStreamService(notificationRepo)
+	notificationListener := scheduler.NewNotificationListener(notificationStreamSvc)
+	notificationListener.Start()
+	defer notificationListener.Stop()
 
-	app := routes.NewRoute(db)
+	app := routes.NewRoute(db, notificationStreamSvc, jobSvc)
 
 	apiPort := helper.GetEnv("PORT")
 	log.Fatal(app.Run(":" + apiPort))
@@ -39,6 +39,13 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Tokens made for a single purpose, such as stream tokens, are no access tokens
+		if _, ok := claims["purpose"]; ok {
+			apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid or expired token", nil, nil)
+			ctx.Abort()
+			return
+		}
+
 	// Set claims in context for downstream handlers
 	// Convert numeric claims from float64 to int64 (JWT standard numeric type)
 	if userID, ok := claims["user_id"]; ok {
@@ -56,6 +63,9 @@ func AuthMiddleware() gin.HandlerFunc {
 			ctx.Set("role_id", uint(rid))
 		}
 	}
+	if roleCode, ok := claims["role_code"].(string); ok {
+		ctx.Set("role_code", roleCode)
+	}
 	if username, ok := claims["username"]; ok {
 		ctx.Set("username", username)
 	}
@@ -111,6 +121,57 @@ func RequireRole(allowedRoles ...string) gin.HandlerFunc {
 	}
 }
 
+// StreamTokenAuth authenticates the notification stream with the stream_token
+// query parameter, for clients that cannot set headers such as the browser's
+// EventSource. Without it the request is authenticated like any other.
+func StreamTokenAuth() gin.HandlerFunc {
+	auth := AuthMiddleware()
+	return func(ctx *gin.Context) {
+		token := ctx.Query("stream_token")
+		if token == "" {
+			auth(ctx)
+			return
+		}
+
+		claims, err := helper.VerifyStreamToken(token)
+		if err != nil {
+			apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid or expired stream token", err, nil)
+			ctx.Abort()
+			return
+		}
+
+		if userID, ok := claims["user_id"].(float64); ok {
+			ctx.Set("user_id", int64(userID))
+		}
+		if domainID, ok := claims["domain_id"].(float64); ok {
+			ctx.Set("domain_id", int64(domainID))
+		}
+		ctx.Next()
+	}
+}
+
+// RequireRoleCode allows only users whose token carries one of the role codes
+func RequireRoleCode(roleCodes ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		roleCode, exists := ctx.Get("role_code")
+		if !exists {
+			apiresponse.Error(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required", nil, nil)
+			ctx.Abort()
+			return
+		}
+
+		for _, code := range roleCodes {
+			if roleCode == code {
+				ctx.Next()
+				return
+			}
+		}
+
+		apiresponse.Error(ctx, http.StatusForbidden, "FORBIDDEN", "Insufficient permissions", nil, nil)
+		ctx.Abort()
+	}
+}
+
 // GetUserIDFromContext retrieves user ID from context
 func GetUserIDFromContext(ctx *gin.Context) (int64, bool) {
 	userID, exists := ctx.Get("user_id")
@@ -137,3 +198,37 @@ func GetRoleIDFromContext(ctx *gin.Context) (int64, bool) {
 	}
 	return roleID.(int64), true
 }
+
+// IsSuperAdmin reports whether the authenticated user may work across domains
+func IsSuperAdmin(ctx *gin.Context) bool {
+	roleCode, exists := ctx.Get("role_code")
+	return exists && roleCode == helper.RoleCodeSuperAdmin
+}
+
+// GetDomainScope returns the domain records looked up by ID must belong to.
+// The scope is nil for super admins, who may access every domain. ok is false
+// when the token carries no domain.
+func GetDomainScope(ctx *gin.Context) (*int64, bool) {
+	domainID, exists := GetDomainIDFromContext(ctx)
+	if !exists {
+		return nil, false
+	}
+	if IsSuperAdmin(ctx) {
+		return nil, true
+	}
+	return &domainID, true
+}
+
+// ResolveDomainID returns the domain a create, update or list request applies
+// to. Regular users are always held to the domain of their token; super admins
+// may target another domain by passing its ID explicitly.
+func ResolveDomainID(ctx *gin.Context, requested int64) (int64, bool) {
+	domainID, exists := GetDomainIDFromContext(ctx)
+	if !exists {
+		return 0, false
+	}
+	if requested > 0 && IsSuperAdmin(ctx) {
+		return requested, true
+	}
+	return domainID, true
+}
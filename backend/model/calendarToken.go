@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// CalendarToken authenticates a user's iCalendar feed. Only the SHA-256 hash of
+// the token is stored, the token itself is shown once when it is created.
+type CalendarToken struct {
+	ID         int64      `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	UserID     int64      `json:"user_id" gorm:"column:user_id;not null"`
+	DomainID   int64      `json:"domain_id" gorm:"column:domain_id;not null"`
+	TokenHash  string     `json:"-" gorm:"column:token_hash;not null"`
+	LastUsedAt *time.Time `json:"last_used_at" gorm:"column:last_used_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (CalendarToken) TableName() string {
+	return "calendar_tokens"
+}
+
+// CalendarTokenResponse describes the user's feed. Token and FeedURL are only
+// filled right after the token is created.
+type CalendarTokenResponse struct {
+	Active     bool       `json:"active"`
+	DomainID   *int64     `json:"domain_id,omitempty"`
+	Token      string     `json:"token,omitempty"`
+	FeedURL    string     `json:"feed_url,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  *time.Time `json:"created_at"`
+}
@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+// PermitDashboardRequest filters the compliance dashboard. DomainID is taken
+// from the JWT token unless the caller is a super admin.
+type PermitDashboardRequest struct {
+	DomainID   *int64 `json:"domain_id" form:"domain_id"`
+	DivisionID *int64 `json:"division_id" form:"division_id"`
+}
+
+// PermitDashboardStats are the compliance figures of one group of permits.
+// Expiry windows, missing documents and the risk score only cover permits that
+// are in force or being renewed.
+type PermitDashboardStats struct {
+	Total                int            `json:"total"`
+	ByStatus             map[string]int `json:"by_status"`
+	ExpiringWithin30Days int            `json:"expiring_within_30_days"`
+	ExpiringWithin60Days int            `json:"expiring_within_60_days"`
+	ExpiringWithin90Days int            `json:"expiring_within_90_days"`
+	ExpiredButActive     int            `json:"expired_but_active"`
+	MissingDocuments     int            `json:"missing_documents"`
+	RiskScore            float64        `json:"risk_score"`
+}
+
+type PermitDashboardDivision struct {
+	DivisionID   *int64 `json:"division_id"`
+	DivisionName string `json:"division_name"`
+	PermitDashboardStats
+}
+
+type PermitDashboardDomain struct {
+	DomainID   int64  `json:"domain_id"`
+	DomainName string `json:"domain_name"`
+	PermitDashboardStats
+	Divisions []PermitDashboardDivision `json:"divisions"`
+}
+
+// PermitDashboardRiskItem is one of the permits contributing most to the risk score
+type PermitDashboardRiskItem struct {
+	PermitID       int64     `json:"permit_id"`
+	PermitNo       string    `json:"permit_no"`
+	Name           string    `json:"name"`
+	DomainName     string    `json:"domain_name"`
+	DivisionName   string    `json:"division_name"`
+	PermitTypeName string    `json:"permit_type_name"`
+	RiskPoint      *string   `json:"risk_point"`
+	Status         string    `json:"status"`
+	ExpiryDate     time.Time `json:"expiry_date"`
+	DaysToExpiry   int       `json:"days_to_expiry"`
+	RiskScore      float64   `json:"risk_score"`
+}
+
+type PermitDashboardResponse struct {
+	GeneratedAt time.Time                 `json:"generated_at"`
+	Summary     PermitDashboardStats      `json:"summary"`
+	Domains     []PermitDashboardDomain   `json:"domains"`
+	TopRisks    []PermitDashboardRiskItem `json:"top_risks"`
+}
@@ -3,36 +3,42 @@ package model
 import "time"
 
 type Division struct {
-	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	DomainID  int64     `gorm:"not null" json:"domain_id"`
-	Code      string    `gorm:"type:varchar(50);not null" json:"code"`
-	Name      string    `gorm:"type:varchar(255);not null" json:"name"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID         int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	DomainID   int64     `gorm:"not null" json:"domain_id"`
+	Code       string    `gorm:"type:varchar(50);not null" json:"code"`
+	Name       string    `gorm:"type:varchar(255);not null" json:"name"`
+	HeadUserID *int64    `gorm:"column:head_user_id" json:"head_user_id"` // verifies permits of the division
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 
-	Domain *Domain `json:"domain,omitempty" gorm:"foreignKey:DomainID;references:ID"`
+	Domain   *Domain `json:"domain,omitempty" gorm:"foreignKey:DomainID;references:ID"`
+	HeadUser *User   `json:"head_user,omitempty" gorm:"foreignKey:HeadUserID;references:ID"`
 }
 
 type DivisionRequest struct {
-	DomainID int64  `json:"domain_id" validate:"required"`
-	Code     string `json:"code" validate:"required,max=50"`
-	Name     string `json:"name" validate:"required,max=255"`
+	DomainID   int64  `json:"domain_id" validate:"required"`
+	Code       string `json:"code" validate:"required,max=50"`
+	Name       string `json:"name" validate:"required,max=255"`
+	HeadUserID *int64 `json:"head_user_id"`
 }
 
+// DivisionUpdateRequest leaves fields that are not sent unchanged. A head_user_id of 0 removes the division head.
 type DivisionUpdateRequest struct {
-	DomainID int64  `json:"domain_id" validate:"omitempty"`
-	Code     string `json:"code" validate:"omitempty,max=50"`
-	Name     string `json:"name" validate:"omitempty,max=255"`
+	DomainID   int64  `json:"domain_id" validate:"omitempty"`
+	Code       string `json:"code" validate:"omitempty,max=50"`
+	Name       string `json:"name" validate:"omitempty,max=255"`
+	HeadUserID *int64 `json:"head_user_id" validate:"omitempty,min=0"`
 }
 
 type DivisionResponse struct {
-	ID        int64           `json:"id"`
-	DomainID  int64           `json:"domain_id"`
-	Code      string          `json:"code"`
-	Name      string          `json:"name"`
-	CreatedAt time.Time       `json:"created_at"`
-	UpdatedAt time.Time       `json:"updated_at"`
-	Domain    *DomainResponse `json:"domain,omitempty"`
+	ID         int64           `json:"id"`
+	DomainID   int64           `json:"domain_id"`
+	Code       string          `json:"code"`
+	Name       string          `json:"name"`
+	HeadUserID *int64          `json:"head_user_id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	Domain     *DomainResponse `json:"domain,omitempty"`
 }
 
 type DivisionListRequest struct {
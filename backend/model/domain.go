@@ -7,6 +7,7 @@ type Domain struct {
 	Code        string    `gorm:"type:varchar(50);uniqueIndex;not null" json:"code"`
 	Name        string    `gorm:"type:varchar(255);not null" json:"name"`
 	Description *string   `gorm:"type:text" json:"description"`
+	Locale      string    `gorm:"type:varchar(5);not null;default:id" json:"locale"` // email locale of users without their own
 	IsActive    bool      `gorm:"type:boolean;not null;default:true" json:"is_active"`
 	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
@@ -16,6 +17,7 @@ type DomainRequest struct {
 	Code        string  `json:"code" validate:"required,max=50"`
 	Name        string  `json:"name" validate:"required,max=255"`
 	Description *string `json:"description"`
+	Locale      string  `json:"locale" validate:"omitempty,oneof=id en"`
 	IsActive    *bool   `json:"is_active"`
 }
 
@@ -23,6 +25,7 @@ type DomainUpdateRequest struct {
 	Code        string  `json:"code" validate:"omitempty,max=50"`
 	Name        string  `json:"name" validate:"omitempty,max=255"`
 	Description *string `json:"description"`
+	Locale      string  `json:"locale" validate:"omitempty,oneof=id en"`
 	IsActive    *bool   `json:"is_active"`
 }
 
@@ -31,6 +34,7 @@ type DomainResponse struct {
 	Code        string    `json:"code"`
 	Name        string    `json:"name"`
 	Description *string   `json:"description"`
+	Locale      string    `json:"locale"`
 	IsActive    bool      `json:"is_active"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
@@ -0,0 +1,75 @@
+package model
+
+import "time"
+
+// Email outbox statuses
+const (
+	EmailStatusPending = "pending" // waiting for its next attempt
+	EmailStatusSending = "sending" // claimed by the worker
+	EmailStatusSent    = "sent"
+	EmailStatusFailed  = "failed" // gave up after MaxAttempts
+)
+
+// EmailDefaultMaxAttempts is how often an email is tried before it is marked failed
+const EmailDefaultMaxAttempts = 5
+
+// EmailOutbox is an email queued for delivery by the email worker. Emails are
+// written together with the notifications they belong to and retried with
+// exponential backoff until sent or MaxAttempts is reached.
+type EmailOutbox struct {
+	ID            int64      `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	Recipients    []string   `json:"recipients" gorm:"column:recipients;type:jsonb;serializer:json;not null"`
+	Subject       string     `json:"subject" gorm:"column:subject;not null"`
+	Body          string     `json:"body" gorm:"column:body;not null"`           // HTML part
+	TextBody      string     `json:"text_body" gorm:"column:text_body;not null"` // plain text part
+	Status        string     `json:"status" gorm:"column:status;not null"`
+	Attempts      int        `json:"attempts" gorm:"column:attempts;not null"`
+	MaxAttempts   int        `json:"max_attempts" gorm:"column:max_attempts;not null"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"column:next_attempt_at;not null"`
+	LockedUntil   *time.Time `json:"locked_until" gorm:"column:locked_until"` // lease of the worker sending it, expired leases are claimed again
+	LastError     *string    `json:"last_error" gorm:"column:last_error"`
+	SentAt        *time.Time `json:"sent_at" gorm:"column:sent_at"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+
+	DeliveryAttempts []EmailDeliveryAttempt `json:"delivery_attempts,omitempty" gorm:"foreignKey:OutboxID;references:ID"`
+}
+
+func (EmailOutbox) TableName() string {
+	return "email_outbox"
+}
+
+// NewEmailOutbox queues an email with an HTML and a plain text part for
+// immediate delivery
+func NewEmailOutbox(to []string, subject string, htmlBody string, textBody string) *EmailOutbox {
+	return &EmailOutbox{
+		Recipients:    to,
+		Subject:       subject,
+		Body:          htmlBody,
+		TextBody:      textBody,
+		Status:        EmailStatusPending,
+		MaxAttempts:   EmailDefaultMaxAttempts,
+		NextAttemptAt: time.Now(),
+	}
+}
+
+// EmailDeliveryAttempt is the outcome of one try to send an outbox email
+type EmailDeliveryAttempt struct {
+	ID        int64     `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	OutboxID  int64     `json:"outbox_id" gorm:"column:outbox_id;not null"`
+	Attempt   int       `json:"attempt" gorm:"column:attempt;not null"`
+	Status    string    `json:"status" gorm:"column:status;not null"` // sent or failed
+	Error     *string   `json:"error" gorm:"column:error"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (EmailDeliveryAttempt) TableName() string {
+	return "email_delivery_attempts"
+}
+
+type EmailOutboxListRequest struct {
+	Status    string `form:"status" validate:"omitempty,oneof=pending sending sent failed"`
+	Recipient string `form:"recipient"`
+	Page      int    `form:"page" validate:"omitempty,min=1"`
+	Limit     int    `form:"limit" validate:"omitempty,min=1,max=10000"`
+}
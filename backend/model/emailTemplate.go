@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// EmailTemplate is a domain's override of a built-in email template in one
+// locale. The shared layout and partials still wrap its HTML and text content.
+type EmailTemplate struct {
+	ID        int64     `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	DomainID  int64     `json:"domain_id" gorm:"column:domain_id;not null"`
+	Name      string    `json:"name" gorm:"column:name;not null"`
+	Locale    string    `json:"locale" gorm:"column:locale;not null"`
+	Subject   string    `json:"subject" gorm:"column:subject;not null"`
+	HTMLBody  string    `json:"html_body" gorm:"column:html_body;not null"`
+	TextBody  string    `json:"text_body" gorm:"column:text_body;not null"`
+	UpdatedBy *int64    `json:"updated_by" gorm:"column:updated_by"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (EmailTemplate) TableName() string {
+	return "email_templates"
+}
+
+type EmailTemplateResponse struct {
+	DomainID  int64      `json:"domain_id"`
+	Name      string     `json:"name"`
+	Locale    string     `json:"locale"`
+	Subject   string     `json:"subject"`
+	HTMLBody  string     `json:"html_body"`
+	TextBody  string     `json:"text_body"`
+	IsDefault bool       `json:"is_default"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+type UpdateEmailTemplateRequest struct {
+	DomainID int64  `json:"domain_id"`
+	Subject  string `json:"subject" validate:"required,max=255"`
+	HTMLBody string `json:"html_body" validate:"required"`
+	TextBody string `json:"text_body" validate:"required"`
+}
+
+// PreviewEmailTemplateRequest renders the posted source, or the domain's
+// current template when all fields are empty, with sample data
+type PreviewEmailTemplateRequest struct {
+	DomainID int64  `json:"domain_id"`
+	Subject  string `json:"subject" validate:"max=255"`
+	HTMLBody string `json:"html_body"`
+	TextBody string `json:"text_body"`
+}
+
+type EmailTemplatePreviewResponse struct {
+	Subject  string `json:"subject"`
+	HTMLBody string `json:"html_body"`
+	TextBody string `json:"text_body"`
+}
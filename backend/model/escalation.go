@@ -0,0 +1,65 @@
+package model
+
+import "time"
+
+// EscalationRule widens the audience of a permit's expiry once its reminders
+// were ignored: nobody read them, updated the permit or started a renewal.
+// DaysBefore is counted like a reminder offset, 0 is the expiry day and
+// negative values are days after it. Level numbers a domain's rules from the
+// earliest offset on.
+type EscalationRule struct {
+	ID                 int64     `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	DomainID           int64     `json:"domain_id" gorm:"column:domain_id;not null"`
+	Level              int       `json:"level" gorm:"column:level;not null"`
+	DaysBefore         int       `json:"days_before" gorm:"column:days_before;not null"`
+	NotifyResponsible  bool      `json:"notify_responsible" gorm:"column:notify_responsible;not null"`
+	NotifyDivisionHead bool      `json:"notify_division_head" gorm:"column:notify_division_head;not null"`
+	RoleCodes          []string  `json:"role_codes" gorm:"column:role_codes;type:jsonb;serializer:json;not null"`
+	CreatedAt          time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt          time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (EscalationRule) TableName() string {
+	return "escalation_rules"
+}
+
+// PermitEscalation records that a permit's expiry was escalated at a rule's
+// offset. Each offset escalates once per permit, like a reminder.
+type PermitEscalation struct {
+	ID         int64     `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	PermitID   int64     `json:"permit_id" gorm:"column:permit_id;not null"`
+	DomainID   int64     `json:"domain_id" gorm:"column:domain_id;not null"`
+	Level      int       `json:"level" gorm:"column:level;not null"`
+	DaysBefore int       `json:"days_before" gorm:"column:days_before;not null"`
+	ExpiryDate time.Time `json:"expiry_date" gorm:"column:expiry_date;type:date;not null"`
+	Recipients int       `json:"recipients" gorm:"column:recipients;not null"`
+	CreatedAt  time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+
+	Permit *Permit `json:"permit,omitempty" gorm:"foreignKey:PermitID;references:ID"`
+}
+
+func (PermitEscalation) TableName() string {
+	return "permit_escalations"
+}
+
+// EscalationRuleItem is one level of an escalation rules request
+type EscalationRuleItem struct {
+	DaysBefore         int      `json:"days_before" validate:"min=-365,max=3650"`
+	NotifyResponsible  bool     `json:"notify_responsible"`
+	NotifyDivisionHead bool     `json:"notify_division_head"`
+	RoleCodes          []string `json:"role_codes" validate:"dive,required,max=50"`
+}
+
+// UpdateEscalationRulesRequest replaces the domain's escalation rules, an
+// empty list turns escalation off
+type UpdateEscalationRulesRequest struct {
+	DomainID int64                `json:"domain_id"`
+	Rules    []EscalationRuleItem `json:"rules" validate:"unique=DaysBefore,dive"`
+}
+
+type PermitEscalationListRequest struct {
+	DomainID int64  `form:"domain_id"`
+	PermitID *int64 `form:"permit_id"`
+	Page     int    `form:"page" validate:"omitempty,min=1"`
+	Limit    int    `form:"limit" validate:"omitempty,min=1,max=10000"`
+}
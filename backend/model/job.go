@@ -0,0 +1,69 @@
+package model
+
+import "time"
+
+// Job run triggers
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+)
+
+// Job run statuses
+const (
+	JobRunStatusRunning   = "running"
+	JobRunStatusSucceeded = "succeeded"
+	JobRunStatusFailed    = "failed"
+)
+
+// JobState is the stored state of a registered job. Jobs without a row have
+// never been paused.
+type JobState struct {
+	Name      string     `json:"name" gorm:"primaryKey;column:name"`
+	IsPaused  bool       `json:"is_paused" gorm:"column:is_paused;not null"`
+	PausedBy  *int64     `json:"paused_by" gorm:"column:paused_by"`
+	PausedAt  *time.Time `json:"paused_at" gorm:"column:paused_at"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (JobState) TableName() string {
+	return "job_states"
+}
+
+// JobRun records one run of a job. ItemsProcessed is what the job reports it
+// handled, such as reminders or emails sent.
+type JobRun struct {
+	ID             int64      `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	JobName        string     `json:"job_name" gorm:"column:job_name;not null"`
+	Trigger        string     `json:"trigger" gorm:"column:trigger;not null"`
+	TriggeredBy    *int64     `json:"triggered_by" gorm:"column:triggered_by"`
+	Status         string     `json:"status" gorm:"column:status;not null"`
+	StartedAt      time.Time  `json:"started_at" gorm:"column:started_at;not null"`
+	FinishedAt     *time.Time `json:"finished_at" gorm:"column:finished_at"`
+	DurationMs     *int64     `json:"duration_ms" gorm:"column:duration_ms"`
+	ItemsProcessed int        `json:"items_processed" gorm:"column:items_processed;not null"`
+	Error          *string    `json:"error" gorm:"column:error"`
+}
+
+func (JobRun) TableName() string {
+	return "job_runs"
+}
+
+// JobResponse describes a registered job. NextRunAt is empty while the job is
+// paused.
+type JobResponse struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Cron        string     `json:"cron"`
+	IsPaused    bool       `json:"is_paused"`
+	PausedBy    *int64     `json:"paused_by"`
+	PausedAt    *time.Time `json:"paused_at"`
+	IsRunning   bool       `json:"is_running"`
+	NextRunAt   *time.Time `json:"next_run_at"`
+	LastRun     *JobRun    `json:"last_run"`
+}
+
+type JobRunListRequest struct {
+	Status string `form:"status" validate:"omitempty,oneof=running succeeded failed"`
+	Page   int    `form:"page" validate:"omitempty,min=1"`
+	Limit  int    `form:"limit" validate:"omitempty,min=1,max=10000"`
+}
@@ -1,19 +1,50 @@
 package model
 
 import (
+	"fmt"
 	"time"
 )
 
+// Notification subjects, the kind of record a notification is about
+const (
+	NotificationEntityPermit  = "permit"
+	NotificationEntityTask    = "task"
+	NotificationEntityProject = "project"
+)
+
+// NotificationLink is the deep link of a notification subject, the path of the
+// record in the client
+func NotificationLink(entityType string, entityID int64) string {
+	switch entityType {
+	case NotificationEntityPermit:
+		return fmt.Sprintf("/permits/%d", entityID)
+	case NotificationEntityTask:
+		return fmt.Sprintf("/tasks/%d", entityID)
+	case NotificationEntityProject:
+		return fmt.Sprintf("/projects/%d", entityID)
+	default:
+		return ""
+	}
+}
+
+// Notification is an in-app notification about a subject identified by
+// EntityType and EntityID. PermitID is also set for permit notifications.
 type Notification struct {
-	ID        int64     `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
-	UserID    int64     `json:"user_id" gorm:"column:user_id;not null"`
-	PermitID  int64     `json:"permit_id" gorm:"column:permit_id;not null"`
-	Type      string    `json:"type" gorm:"column:type;not null"` // expiry_reminder, expiry_warning, expired
-	Title     string    `json:"title" gorm:"column:title;not null"`
-	Message   string    `json:"message" gorm:"column:message;not null"`
-	IsRead    bool      `json:"is_read" gorm:"column:is_read;default:false"`
-	ReadAt    *time.Time `json:"read_at" gorm:"column:read_at"`
-	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	ID           int64      `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	UserID       int64      `json:"user_id" gorm:"column:user_id;not null"`
+	EntityType   string     `json:"entity_type" gorm:"column:entity_type;not null"`
+	EntityID     int64      `json:"entity_id" gorm:"column:entity_id;not null"`
+	Link         string     `json:"link" gorm:"column:link"`
+	PermitID     *int64     `json:"permit_id" gorm:"column:permit_id"`
+	Type         string     `json:"type" gorm:"column:type;not null"` // expiry_reminder, expiry_warning, expiry_critical, expired, obligation_reminder, obligation_warning, obligation_critical, obligation_due, obligation_overdue, expiry_escalation, approval_requested, approval_approved, approval_rejected, task_assigned, task_status_changed, task_in_review, task_approval_requested, task_approved, task_rejected, task_revision
+	Title        string     `json:"title" gorm:"column:title;not null"`
+	Message      string     `json:"message" gorm:"column:message;not null"`
+	IsRead       bool       `json:"is_read" gorm:"column:is_read;default:false"`
+	ReadAt       *time.Time `json:"read_at" gorm:"column:read_at"`
+	ReminderDays *int       `json:"reminder_days" gorm:"column:reminder_days"` // reminder offset (days before expiry or due date) that produced it
+	ObligationID *int64     `json:"obligation_id" gorm:"column:obligation_id"`
+	DueDate      *time.Time `json:"due_date" gorm:"column:due_date;type:date"` // obligation due date the reminder is about
+	CreatedAt    time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
 
 	User   *User   `json:"user,omitempty" gorm:"foreignKey:UserID;references:ID"`
 	Permit *Permit `json:"permit,omitempty" gorm:"foreignKey:PermitID;references:ID"`
@@ -23,20 +54,63 @@ func (Notification) TableName() string {
 	return "notifications"
 }
 
+// NotificationDispatch is everything one notification event produces for its
+// recipients. It is saved in one transaction, so emails are queued exactly when
+// the notifications are.
+type NotificationDispatch struct {
+	Notifications []*Notification
+	DigestItems   []*NotificationDigestItem
+	Emails        []*EmailOutbox
+	Reminder      *ReminderDelivery
+	Escalation    *PermitEscalation
+}
+
+// Add sends the notification to its recipient in the app and in the digest, on
+// the channels the recipient chose
+func (d *NotificationDispatch) Add(notification *Notification, domainID int64, channels NotificationChannels) {
+	if channels.InApp {
+		d.Notifications = append(d.Notifications, notification)
+	}
+	if channels.Digest {
+		d.DigestItems = append(d.DigestItems, NewNotificationDigestItem(notification, domainID))
+	}
+}
+
+// ReminderDelivery records that a reminder offset was sent for a permit's
+// expiry date, or for an obligation's due date when ObligationID is set.
+// Reminders are sent once, whichever channels their recipients receive them on.
+type ReminderDelivery struct {
+	ID           int64      `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	PermitID     int64      `json:"permit_id" gorm:"column:permit_id;not null"`
+	ObligationID *int64     `json:"obligation_id" gorm:"column:obligation_id"`
+	ExpiryDate   *time.Time `json:"expiry_date" gorm:"column:expiry_date;type:date"`
+	DueDate      *time.Time `json:"due_date" gorm:"column:due_date;type:date"`
+	ReminderDays int        `json:"reminder_days" gorm:"column:reminder_days;not null"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (ReminderDelivery) TableName() string {
+	return "reminder_deliveries"
+}
+
 type NotificationResponse struct {
-	ID        int64      `json:"id"`
-	UserID    int64      `json:"user_id"`
-	PermitID  int64      `json:"permit_id"`
-	Type      string     `json:"type"`
-	Title     string     `json:"title"`
-	Message   string     `json:"message"`
-	IsRead    bool       `json:"is_read"`
-	ReadAt    *time.Time `json:"read_at"`
-	CreatedAt time.Time  `json:"created_at"`
-	Permit    *struct {
-		ID        int64  `json:"id"`
-		Name      string `json:"name"`
-		PermitNo  string `json:"permit_no"`
+	ID           int64      `json:"id"`
+	UserID       int64      `json:"user_id"`
+	EntityType   string     `json:"entity_type"`
+	EntityID     int64      `json:"entity_id"`
+	Link         string     `json:"link"`
+	PermitID     *int64     `json:"permit_id"`
+	ObligationID *int64     `json:"obligation_id"`
+	Type         string     `json:"type"`
+	Title        string     `json:"title"`
+	Message      string     `json:"message"`
+	IsRead       bool       `json:"is_read"`
+	ReadAt       *time.Time `json:"read_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	Permit       *struct {
+		ID         int64     `json:"id"`
+		Name       string    `json:"name"`
+		PermitNo   string    `json:"permit_no"`
 		ExpiryDate time.Time `json:"expiry_date"`
 	} `json:"permit,omitempty"`
 }
@@ -0,0 +1,89 @@
+package model
+
+import "time"
+
+// Digest frequencies, how often a user's digest email is sent
+const (
+	DigestFrequencyDaily  = "daily"
+	DigestFrequencyWeekly = "weekly"
+)
+
+// DefaultDigestFrequency applies to users who have not chosen a frequency
+const DefaultDigestFrequency = DigestFrequencyDaily
+
+// NotificationSeverityFor returns the severity a notification type is listed
+// under in digests, using the reminder severities
+func NotificationSeverityFor(notificationType string) string {
+	switch notificationType {
+	case "expired", "expiry_critical", "expiry_escalation", "obligation_critical", "obligation_due", "obligation_overdue":
+		return ReminderSeverityCritical
+	case "expiry_warning", "obligation_warning", "approval_rejected", "task_rejected", "task_revision":
+		return ReminderSeverityWarning
+	default:
+		return ReminderSeverityInfo
+	}
+}
+
+// NotificationDigestItem is a notification waiting to be included in the
+// user's next digest email. SentAt is set once the digest went out.
+type NotificationDigestItem struct {
+	ID         int64      `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	UserID     int64      `json:"user_id" gorm:"column:user_id;not null"`
+	DomainID   int64      `json:"domain_id" gorm:"column:domain_id;not null"`
+	EntityType string     `json:"entity_type" gorm:"column:entity_type;not null"`
+	EntityID   int64      `json:"entity_id" gorm:"column:entity_id;not null"`
+	Link       string     `json:"link" gorm:"column:link"`
+	Type       string     `json:"type" gorm:"column:type;not null"`
+	Severity   string     `json:"severity" gorm:"column:severity;not null"`
+	Title      string     `json:"title" gorm:"column:title;not null"`
+	Message    string     `json:"message" gorm:"column:message;not null"`
+	SentAt     *time.Time `json:"sent_at" gorm:"column:sent_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+
+	Domain *Domain `json:"domain,omitempty" gorm:"foreignKey:DomainID;references:ID"`
+}
+
+func (NotificationDigestItem) TableName() string {
+	return "notification_digest_items"
+}
+
+// NewNotificationDigestItem queues a notification for its recipient's digest
+func NewNotificationDigestItem(notification *Notification, domainID int64) *NotificationDigestItem {
+	return &NotificationDigestItem{
+		UserID:     notification.UserID,
+		DomainID:   domainID,
+		EntityType: notification.EntityType,
+		EntityID:   notification.EntityID,
+		Link:       notification.Link,
+		Type:       notification.Type,
+		Severity:   NotificationSeverityFor(notification.Type),
+		Title:      notification.Title,
+		Message:    notification.Message,
+	}
+}
+
+// NotificationDigestSetting is a user's digest frequency and when the last
+// digest was sent
+type NotificationDigestSetting struct {
+	ID         int64      `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	UserID     int64      `json:"user_id" gorm:"column:user_id;not null;uniqueIndex"`
+	Frequency  string     `json:"frequency" gorm:"column:frequency;not null"`
+	LastSentAt *time.Time `json:"last_sent_at" gorm:"column:last_sent_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (NotificationDigestSetting) TableName() string {
+	return "notification_digest_settings"
+}
+
+type NotificationDigestSettingResponse struct {
+	Frequency    string     `json:"frequency"`
+	LastSentAt   *time.Time `json:"last_sent_at"`
+	PendingCount int64      `json:"pending_count"`
+	IsDefault    bool       `json:"is_default"`
+}
+
+type UpdateNotificationDigestSettingRequest struct {
+	Frequency string `json:"frequency" validate:"required,oneof=daily weekly"`
+}
@@ -0,0 +1,128 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Notification events, the groups of notification types users set their
+// preferences for
+const (
+	NotificationEventPermitExpiry     = "permit_expiry"
+	NotificationEventPermitObligation = "permit_obligation"
+	NotificationEventPermitApproval   = "permit_approval"
+	NotificationEventTaskUpdate       = "task_update"
+	NotificationEventTaskApproval     = "task_approval"
+)
+
+// NotificationEvents lists every notification event in display order
+var NotificationEvents = []string{
+	NotificationEventPermitExpiry,
+	NotificationEventPermitObligation,
+	NotificationEventPermitApproval,
+	NotificationEventTaskUpdate,
+	NotificationEventTaskApproval,
+}
+
+// NotificationEventFor returns the event a notification type belongs to
+func NotificationEventFor(notificationType string) string {
+	switch {
+	case notificationType == "expired" || strings.HasPrefix(notificationType, "expiry_"):
+		return NotificationEventPermitExpiry
+	case strings.HasPrefix(notificationType, "obligation_"):
+		return NotificationEventPermitObligation
+	case strings.HasPrefix(notificationType, "approval_"):
+		return NotificationEventPermitApproval
+	case notificationType == "task_in_review" || notificationType == "task_approval_requested" ||
+		notificationType == "task_approved" || notificationType == "task_rejected":
+		return NotificationEventTaskApproval
+	case strings.HasPrefix(notificationType, "task_"):
+		return NotificationEventTaskUpdate
+	default:
+		return ""
+	}
+}
+
+// NotificationChannels are the ways a user receives the notifications of an event
+type NotificationChannels struct {
+	InApp  bool `json:"in_app"`
+	Email  bool `json:"email"`
+	Digest bool `json:"digest"`
+}
+
+// DefaultNotificationChannels apply to events the user has no preference for
+var DefaultNotificationChannels = NotificationChannels{InApp: true, Email: true, Digest: false}
+
+// NotificationPreference is a user's choice of channels for one event
+type NotificationPreference struct {
+	ID        int64     `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	UserID    int64     `json:"user_id" gorm:"column:user_id;not null"`
+	EventType string    `json:"event_type" gorm:"column:event_type;not null"`
+	InApp     bool      `json:"in_app" gorm:"column:in_app;not null"`
+	Email     bool      `json:"email" gorm:"column:email;not null"`
+	Digest    bool      `json:"digest" gorm:"column:digest;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
+
+// Channels returns the preference's channels
+func (p *NotificationPreference) Channels() NotificationChannels {
+	return NotificationChannels{InApp: p.InApp, Email: p.Email, Digest: p.Digest}
+}
+
+type NotificationPreferenceResponse struct {
+	EventType string `json:"event_type"`
+	NotificationChannels
+	IsDefault bool `json:"is_default"`
+}
+
+type NotificationPreferenceItem struct {
+	EventType string `json:"event_type" validate:"required,oneof=permit_expiry permit_obligation permit_approval task_update task_approval"`
+	InApp     bool   `json:"in_app"`
+	Email     bool   `json:"email"`
+	Digest    bool   `json:"digest"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreferenceItem `json:"preferences" validate:"required,min=1,dive"`
+}
+
+// NotificationRecipientRule replaces the built-in recipients of an event's
+// notifications in a domain: the permit's responsible people when
+// NotifyResponsible is set, and the domain's users with one of RoleCodes
+type NotificationRecipientRule struct {
+	ID                int64     `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	DomainID          int64     `json:"domain_id" gorm:"column:domain_id;not null"`
+	EventType         string    `json:"event_type" gorm:"column:event_type;not null"`
+	NotifyResponsible bool      `json:"notify_responsible" gorm:"column:notify_responsible;not null"`
+	RoleCodes         []string  `json:"role_codes" gorm:"column:role_codes;type:jsonb;serializer:json;not null"`
+	CreatedAt         time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt         time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (NotificationRecipientRule) TableName() string {
+	return "notification_recipient_rules"
+}
+
+type NotificationRecipientRuleResponse struct {
+	DomainID          int64    `json:"domain_id"`
+	EventType         string   `json:"event_type"`
+	NotifyResponsible bool     `json:"notify_responsible"`
+	RoleCodes         []string `json:"role_codes"`
+	IsDefault         bool     `json:"is_default"`
+}
+
+type NotificationRecipientRuleItem struct {
+	EventType         string   `json:"event_type" validate:"required,oneof=permit_expiry permit_obligation"`
+	NotifyResponsible bool     `json:"notify_responsible"`
+	RoleCodes         []string `json:"role_codes" validate:"dive,required,max=50"`
+}
+
+type UpdateNotificationRecipientRulesRequest struct {
+	DomainID int64                           `json:"domain_id"`
+	Rules    []NotificationRecipientRuleItem `json:"rules" validate:"required,min=1,dive"`
+}
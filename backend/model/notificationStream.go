@@ -0,0 +1,19 @@
+package model
+
+// NotificationCreatedChannel is the Postgres NOTIFY channel the
+// notify_notification_created trigger announces new notifications on
+const NotificationCreatedChannel = "notification_created"
+
+// NotificationCreatedEvent is the payload of a NotificationCreatedChannel
+// notification
+type NotificationCreatedEvent struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+// NotificationStreamEvent is pushed to a user's open notification streams when
+// a notification for them is created
+type NotificationStreamEvent struct {
+	Notification NotificationResponse
+	UnreadCount  int64
+}
@@ -5,77 +5,146 @@ import (
 	"time"
 )
 
+// Permit status values. Expiring, expired and superseded are set by the system,
+// pending and the move out of it by the approval flow, the others can be chosen
+// by users within the allowed transitions.
+const (
+	PermitStatusDraft      = "draft"
+	PermitStatusPending    = "pending"
+	PermitStatusActive     = "active"
+	PermitStatusExpiring   = "expiring"
+	PermitStatusExpired    = "expired"
+	PermitStatusInRenewal  = "in_renewal"
+	PermitStatusRevoked    = "revoked"
+	PermitStatusArchived   = "archived"
+	PermitStatusSuperseded = "superseded"
+)
+
 type Permit struct {
-	ID                     int64     `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
-	DomainID               int64     `json:"domain_id" gorm:"column:domain_id;not null"`
-	DivisionID             *int64    `json:"division_id" gorm:"column:division_id"`
-	PermitTypeID           int64     `json:"permit_type_id" gorm:"column:permit_type_id;not null"`
-	Name                   string    `json:"name" gorm:"column:name;not null"`
-	ApplicationType        string    `json:"application_type" gorm:"column:application_type;not null"`
-	PermitNo               string    `json:"permit_no" gorm:"column:permit_no;not null"`
-	EffectiveDate          time.Time `json:"effective_date" gorm:"column:effective_date;not null"`
-	ExpiryDate             time.Time `json:"expiry_date" gorm:"column:expiry_date;not null"`
-	EffectiveTerm          *string   `json:"effective_term" gorm:"column:effective_term"`
-	ResponsiblePersonID    *int64    `json:"responsible_person_id" gorm:"column:responsible_person_id"`
-	ResponsibleDocPersonID *int64    `json:"responsible_doc_person_id" gorm:"column:responsible_doc_person_id"`
-	DocName                *string   `json:"doc_name" gorm:"column:doc_name"`
-	DocNumber              *string   `json:"doc_number" gorm:"column:doc_number"`
-	DocFileName            *string   `json:"doc_file_name" gorm:"column:doc_file_name"`
-	DocFilePath            *string   `json:"doc_file_path" gorm:"column:doc_file_path"`
-	DocFileSize            *int64    `json:"doc_file_size" gorm:"column:doc_file_size"`
-	DocFileType            *string   `json:"doc_file_type" gorm:"column:doc_file_type"`
-	Status                 string    `json:"status" gorm:"column:status;not null;default:'active'"`
-	CreatedAt              time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt              time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
-
-	Domain              *Domain     `json:"domain,omitempty" gorm:"foreignKey:DomainID;references:ID"`
-	Division            *Division   `json:"division,omitempty" gorm:"foreignKey:DivisionID;references:ID"`
-	PermitType          *PermitType `json:"permit_type,omitempty" gorm:"foreignKey:PermitTypeID;references:ID"`
-	ResponsiblePerson   *User       `json:"responsible_person,omitempty" gorm:"foreignKey:ResponsiblePersonID;references:ID"`
-	ResponsibleDocPerson *User      `json:"responsible_doc_person,omitempty" gorm:"foreignKey:ResponsibleDocPersonID;references:ID"`
+	ID                     int64      `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	DomainID               int64      `json:"domain_id" gorm:"column:domain_id;not null"`
+	DivisionID             *int64     `json:"division_id" gorm:"column:division_id"`
+	PermitTypeID           int64      `json:"permit_type_id" gorm:"column:permit_type_id;not null"`
+	Name                   string     `json:"name" gorm:"column:name;not null"`
+	ApplicationType        string     `json:"application_type" gorm:"column:application_type;not null"`
+	PermitNo               string     `json:"permit_no" gorm:"column:permit_no;not null"`
+	EffectiveDate          time.Time  `json:"effective_date" gorm:"column:effective_date;not null"`
+	ExpiryDate             time.Time  `json:"expiry_date" gorm:"column:expiry_date;not null"`
+	EffectiveTerm          *string    `json:"effective_term" gorm:"column:effective_term"`
+	ResponsiblePersonID    *int64     `json:"responsible_person_id" gorm:"column:responsible_person_id"`
+	ResponsibleDocPersonID *int64     `json:"responsible_doc_person_id" gorm:"column:responsible_doc_person_id"`
+	DocName                *string    `json:"doc_name" gorm:"column:doc_name"`
+	DocNumber              *string    `json:"doc_number" gorm:"column:doc_number"`
+	Status                 string     `json:"status" gorm:"column:status;not null;default:'active'"`
+	PreviousPermitID       *int64     `json:"previous_permit_id" gorm:"column:previous_permit_id"`
+	SupersededAt           *time.Time `json:"superseded_at" gorm:"column:superseded_at"`
+	RequestedBy            *int64     `json:"requested_by" gorm:"column:requested_by"`
+	ApprovalStatusID       *int64     `json:"approval_status_id" gorm:"column:approval_status_id"`
+	CreatedAt              time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt              time.Time  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+	DeletedAt              *time.Time `json:"deleted_at,omitempty" gorm:"column:deleted_at;index"`
+
+	Domain               *Domain          `json:"domain,omitempty" gorm:"foreignKey:DomainID;references:ID"`
+	Division             *Division        `json:"division,omitempty" gorm:"foreignKey:DivisionID;references:ID"`
+	PermitType           *PermitType      `json:"permit_type,omitempty" gorm:"foreignKey:PermitTypeID;references:ID"`
+	ResponsiblePerson    *User            `json:"responsible_person,omitempty" gorm:"foreignKey:ResponsiblePersonID;references:ID"`
+	ResponsibleDocPerson *User            `json:"responsible_doc_person,omitempty" gorm:"foreignKey:ResponsibleDocPersonID;references:ID"`
+	Documents            []PermitDocument `json:"documents,omitempty" gorm:"foreignKey:PermitID;references:ID"`
+	Approvals            []PermitApproval `json:"approvals,omitempty" gorm:"foreignKey:PermitID;references:ID"`
 }
 
 func (Permit) TableName() string {
 	return "permits"
 }
 
+// Permit approval steps. The division head verifies the permit, then a permit
+// manager of the domain approves it.
+const (
+	PermitApprovalSequenceVerify  = 1
+	PermitApprovalSequenceApprove = 2
+)
+
+// PermitApproval is one step of a permit's approval, modelled on ApprovalTask.
+// Each submission starts a new round; Status is false for steps of earlier rounds.
+type PermitApproval struct {
+	ID               int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	PermitID         int64      `gorm:"not null;index" json:"permit_id"`
+	Sequence         int16      `gorm:"not null" json:"sequence"`
+	ApprovedBy       *int64     `gorm:"index" json:"approved_by"`
+	ApprovalStatusID *int64     `gorm:"index" json:"approval_status_id"`
+	ApprovalDate     *time.Time `json:"approval_date"`
+	Note             *string    `gorm:"size:500" json:"note"`
+	Status           bool       `gorm:"default:true" json:"status"`
+	CreatedAt        time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Permit         *Permit    `gorm:"foreignKey:PermitID" json:"permit,omitempty"`
+	Approver       *User      `gorm:"foreignKey:ApprovedBy" json:"approved_by_user,omitempty"`
+	ApprovalStatus *Reference `gorm:"foreignKey:ApprovalStatusID" json:"approval_status,omitempty"`
+}
+
+func (PermitApproval) TableName() string {
+	return "permit_approvals"
+}
+
+// PermitDocument is a file attached to a permit. The document type is a
+// reference from the "Permit Document Type" category.
+type PermitDocument struct {
+	ID             int64     `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	PermitID       int64     `json:"permit_id" gorm:"column:permit_id;not null"`
+	DocumentTypeID int64     `json:"document_type_id" gorm:"column:document_type_id;not null"`
+	FileName       string    `json:"file_name" gorm:"column:file_name;not null"`
+	FilePath       string    `json:"-" gorm:"column:file_path;not null"`
+	FileSize       *int64    `json:"file_size" gorm:"column:file_size"`
+	FileType       *string   `json:"file_type" gorm:"column:file_type"`
+	UploadedBy     *int64    `json:"uploaded_by" gorm:"column:uploaded_by"`
+	CreatedAt      time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt      time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+
+	DocumentType *Reference `json:"document_type,omitempty" gorm:"foreignKey:DocumentTypeID;references:ID"`
+	Uploader     *User      `json:"uploader,omitempty" gorm:"foreignKey:UploadedBy;references:ID"`
+}
+
+func (PermitDocument) TableName() string {
+	return "permit_documents"
+}
+
 // PermitFormRequest is for multipart/form-data binding
 type PermitFormRequest struct {
 	DomainID               int64   `form:"domain_id" validate:"required"`
 	DivisionID             *int64  `form:"division_id"`
 	PermitTypeID           int64   `form:"permit_type_id" validate:"required"`
 	Name                   string  `form:"name" validate:"required"`
-	ApplicationType        string  `form:"application_type" validate:"required"`
+	ApplicationType        string  `form:"application_type"`
 	PermitNo               string  `form:"permit_no" validate:"required"`
 	EffectiveDate          string  `form:"effective_date" validate:"required"`
-	ExpiryDate             string  `form:"expiry_date" validate:"required"`
+	ExpiryDate             string  `form:"expiry_date"`
 	EffectiveTerm          *string `form:"effective_term"`
 	ResponsiblePersonID    *int64  `form:"responsible_person_id"`
 	ResponsibleDocPersonID *int64  `form:"responsible_doc_person_id"`
 	DocName                *string `form:"doc_name"`
 	DocNumber              *string `form:"doc_number"`
-	Status                 string  `form:"status" validate:"required"`
+	Status                 string  `form:"status" validate:"omitempty,oneof=draft pending"`
 }
 
+// PermitRequest leaves ApplicationType and ExpiryDate optional, they default from
+// the permit type's default application type and validity period
 type PermitRequest struct {
 	DomainID               int64       `json:"domain_id" form:"domain_id" validate:"required"`
 	DivisionID             *int64      `json:"division_id" form:"division_id"`
 	PermitTypeID           int64       `json:"permit_type_id" form:"permit_type_id" validate:"required"`
 	Name                   string      `json:"name" form:"name" validate:"required"`
-	ApplicationType        string      `json:"application_type" form:"application_type" validate:"required"`
+	ApplicationType        string      `json:"application_type" form:"application_type"`
 	PermitNo               string      `json:"permit_no" form:"permit_no" validate:"required"`
 	EffectiveDate          helper.Date `json:"effective_date" form:"effective_date" validate:"required"`
-	ExpiryDate             helper.Date `json:"expiry_date" form:"expiry_date" validate:"required"`
+	ExpiryDate             helper.Date `json:"expiry_date" form:"expiry_date"`
 	EffectiveTerm          *string     `json:"effective_term" form:"effective_term"`
 	ResponsiblePersonID    *int64      `json:"responsible_person_id" form:"responsible_person_id"`
 	ResponsibleDocPersonID *int64      `json:"responsible_doc_person_id" form:"responsible_doc_person_id"`
 	DocName                *string     `json:"doc_name" form:"doc_name"`
 	DocNumber              *string     `json:"doc_number" form:"doc_number"`
-	DocFileName            *string     `json:"doc_file_name" form:"doc_file_name"`
-	DocFilePath            *string     `json:"doc_file_path" form:"doc_file_path"`
-	DocFileSize            *int64      `json:"doc_file_size" form:"doc_file_size"`
-	DocFileType            *string     `json:"doc_file_type" form:"doc_file_type"`
-	Status                 string      `json:"status" form:"status" validate:"required"`
+	Status                 string      `json:"status" form:"status" validate:"omitempty,oneof=draft pending"`
 }
 
 // PermitFormUpdateRequest is for multipart/form-data binding
@@ -93,7 +162,7 @@ type PermitFormUpdateRequest struct {
 	ResponsibleDocPersonID *int64  `form:"responsible_doc_person_id"`
 	DocName                *string `form:"doc_name"`
 	DocNumber              *string `form:"doc_number"`
-	Status                 string  `form:"status" validate:"required"`
+	Status                 string  `form:"status" validate:"omitempty,oneof=draft pending active expiring expired in_renewal revoked archived superseded"`
 }
 
 type PermitUpdateRequest struct {
@@ -108,42 +177,99 @@ type PermitUpdateRequest struct {
 	EffectiveTerm          *string     `json:"effective_term" form:"effective_term"`
 	ResponsiblePersonID    *int64      `json:"responsible_person_id" form:"responsible_person_id"`
 	ResponsibleDocPersonID *int64      `json:"responsible_doc_person_id" form:"responsible_doc_person_id"`
-	DocName                *string   `json:"doc_name" form:"doc_name"`
-	DocNumber              *string   `json:"doc_number" form:"doc_number"`
-	DocFileName            *string   `json:"doc_file_name" form:"doc_file_name"`
-	DocFilePath            *string   `json:"doc_file_path" form:"doc_file_path"`
-	DocFileSize            *int64    `json:"doc_file_size" form:"doc_file_size"`
-	DocFileType            *string   `json:"doc_file_type" form:"doc_file_type"`
-	Status                 string    `json:"status" form:"status" validate:"required"`
+	DocName                *string     `json:"doc_name" form:"doc_name"`
+	DocNumber              *string     `json:"doc_number" form:"doc_number"`
+	Status                 string      `json:"status" form:"status" validate:"omitempty,oneof=draft pending active expiring expired in_renewal revoked archived superseded"`
 }
 
+// PermitResponse keeps the DocFile* fields of the single-document API; they are
+// filled from the permit's latest license document.
 type PermitResponse struct {
-	ID                     int64               `json:"id"`
-	DomainID               int64               `json:"domain_id"`
-	DivisionID             *int64              `json:"division_id"`
-	PermitTypeID           int64               `json:"permit_type_id"`
-	Name                   string              `json:"name"`
-	ApplicationType        string              `json:"application_type"`
-	PermitNo               string              `json:"permit_no"`
-	EffectiveDate          time.Time           `json:"effective_date"`
-	ExpiryDate             time.Time           `json:"expiry_date"`
-	EffectiveTerm          *string             `json:"effective_term"`
-	ResponsiblePersonID    *int64              `json:"responsible_person_id"`
-	ResponsibleDocPersonID *int64              `json:"responsible_doc_person_id"`
-	DocName                *string             `json:"doc_name"`
-	DocNumber              *string             `json:"doc_number"`
-	DocFileName            *string             `json:"doc_file_name"`
-	DocFilePath            *string             `json:"doc_file_path"`
-	DocFileSize            *int64              `json:"doc_file_size"`
-	DocFileType            *string             `json:"doc_file_type"`
-	Status                 string              `json:"status"`
-	CreatedAt              time.Time           `json:"created_at"`
-	UpdatedAt              time.Time           `json:"updated_at"`
-	Domain                 *DomainResponse     `json:"domain,omitempty"`
-	Division               *DivisionResponse   `json:"division,omitempty"`
-	PermitType             *PermitTypeResponse `json:"permit_type,omitempty"`
-	ResponsiblePerson      *UserResponse       `json:"responsible_person,omitempty"`
-	ResponsibleDocPerson   *UserResponse       `json:"responsible_doc_person,omitempty"`
+	ID                     int64                    `json:"id"`
+	DomainID               int64                    `json:"domain_id"`
+	DivisionID             *int64                   `json:"division_id"`
+	PermitTypeID           int64                    `json:"permit_type_id"`
+	Name                   string                   `json:"name"`
+	ApplicationType        string                   `json:"application_type"`
+	PermitNo               string                   `json:"permit_no"`
+	EffectiveDate          time.Time                `json:"effective_date"`
+	ExpiryDate             time.Time                `json:"expiry_date"`
+	EffectiveTerm          *string                  `json:"effective_term"`
+	ResponsiblePersonID    *int64                   `json:"responsible_person_id"`
+	ResponsibleDocPersonID *int64                   `json:"responsible_doc_person_id"`
+	DocName                *string                  `json:"doc_name"`
+	DocNumber              *string                  `json:"doc_number"`
+	DocFileName            *string                  `json:"doc_file_name"`
+	DocFilePath            *string                  `json:"-"`
+	DocFileSize            *int64                   `json:"doc_file_size"`
+	DocFileType            *string                  `json:"doc_file_type"`
+	Status                 string                   `json:"status"`
+	PreviousPermitID       *int64                   `json:"previous_permit_id"`
+	SupersededAt           *time.Time               `json:"superseded_at"`
+	RequestedBy            *int64                   `json:"requested_by"`
+	ApprovalStatusID       *int64                   `json:"approval_status_id"`
+	CreatedAt              time.Time                `json:"created_at"`
+	UpdatedAt              time.Time                `json:"updated_at"`
+	DeletedAt              *time.Time               `json:"deleted_at,omitempty"`
+	Domain                 *DomainResponse          `json:"domain,omitempty"`
+	Division               *DivisionResponse        `json:"division,omitempty"`
+	PermitType             *PermitTypeResponse      `json:"permit_type,omitempty"`
+	ResponsiblePerson      *UserResponse            `json:"responsible_person,omitempty"`
+	ResponsibleDocPerson   *UserResponse            `json:"responsible_doc_person,omitempty"`
+	Documents              []PermitDocumentResponse `json:"documents"`
+	Warnings               []string                 `json:"warnings,omitempty"`
+}
+
+// PermitDocumentUploadRequest is for multipart/form-data binding alongside the "file" field
+type PermitDocumentUploadRequest struct {
+	DocumentTypeID int64 `form:"document_type_id" validate:"required"`
+}
+
+type PermitDocumentResponse struct {
+	ID             int64              `json:"id"`
+	PermitID       int64              `json:"permit_id"`
+	DocumentTypeID int64              `json:"document_type_id"`
+	FileName       string             `json:"file_name"`
+	FilePath       string             `json:"-"`
+	FileSize       *int64             `json:"file_size"`
+	FileType       *string            `json:"file_type"`
+	UploadedBy     *int64             `json:"uploaded_by"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+	DocumentType   *ReferenceResponse `json:"document_type,omitempty"`
+}
+
+// PermitRenewRequest creates the next validity period of an existing permit.
+// Division, permit type, responsible people and the documents are carried over
+// from the previous period.
+type PermitRenewRequest struct {
+	PermitNo        string      `json:"permit_no" form:"permit_no" validate:"required"`
+	ApplicationType string      `json:"application_type" form:"application_type"`
+	EffectiveDate   helper.Date `json:"effective_date" form:"effective_date" validate:"required"`
+	ExpiryDate      helper.Date `json:"expiry_date" form:"expiry_date" validate:"required"`
+	EffectiveTerm   *string     `json:"effective_term" form:"effective_term"`
+	DocName         *string     `json:"doc_name" form:"doc_name"`
+	DocNumber       *string     `json:"doc_number" form:"doc_number"`
+}
+
+type PermitApprovalResponse struct {
+	ID               int64              `json:"id"`
+	PermitID         int64              `json:"permit_id"`
+	Sequence         int16              `json:"sequence"`
+	ApprovedBy       *int64             `json:"approved_by"`
+	ApprovalStatusID *int64             `json:"approval_status_id"`
+	ApprovalDate     *time.Time         `json:"approval_date"`
+	Note             *string            `json:"note"`
+	Status           bool               `json:"status"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+	Approver         *UserResponse      `json:"approver,omitempty"`
+	ApprovalStatus   *ReferenceResponse `json:"approval_status,omitempty"`
+}
+
+// PermitStatusRequest moves a permit to one of the statuses users may set directly
+type PermitStatusRequest struct {
+	Status string `json:"status" form:"status" validate:"required,oneof=active in_renewal revoked archived"`
 }
 
 type PermitListRequest struct {
@@ -158,3 +284,11 @@ type PermitListRequest struct {
 	Page              int    `json:"page" form:"page" validate:"omitempty,min=1"`
 	Limit             int    `json:"limit" form:"limit" validate:"omitempty,min=1,max=10000"`
 }
+
+// PermitExportRequest takes the permit list filters, plus the search term of the
+// search endpoint. Page and limit are ignored, the export covers every match.
+type PermitExportRequest struct {
+	PermitListRequest
+	Format string `json:"format" form:"format" validate:"required,oneof=xlsx csv pdf"`
+	Query  string `json:"q" form:"q"`
+}
@@ -0,0 +1,34 @@
+package model
+
+// Permit import modes
+const (
+	PermitImportModeDryRun = "dry_run"
+	PermitImportModeCommit = "commit"
+)
+
+// PermitImportRequest is for multipart/form-data binding, the CSV or XLSX sheet is
+// sent as "file". The dry run validates every row without saving anything.
+type PermitImportRequest struct {
+	DomainID int64  `form:"domain_id"`
+	Mode     string `form:"mode" validate:"omitempty,oneof=dry_run commit"`
+}
+
+// PermitImportRowResult is the outcome of one sheet row. Row is the row number as
+// shown in the spreadsheet, the header being row 1.
+type PermitImportRowResult struct {
+	Row      int      `json:"row"`
+	PermitNo string   `json:"permit_no"`
+	Name     string   `json:"name"`
+	Valid    bool     `json:"valid"`
+	Errors   []string `json:"errors"`
+	PermitID *int64   `json:"permit_id,omitempty"`
+}
+
+type PermitImportResponse struct {
+	Mode        string                  `json:"mode"`
+	TotalRows   int                     `json:"total_rows"`
+	ValidRows   int                     `json:"valid_rows"`
+	InvalidRows int                     `json:"invalid_rows"`
+	Imported    int                     `json:"imported"`
+	Rows        []PermitImportRowResult `json:"rows"`
+}
@@ -0,0 +1,131 @@
+package model
+
+import (
+	"permit-app/helper"
+	"time"
+)
+
+// Obligation recurrence rules. A once obligation is completed by its first
+// completion, the others move their next due date forward.
+const (
+	ObligationRecurrenceOnce       = "once"
+	ObligationRecurrenceMonthly    = "monthly"
+	ObligationRecurrenceQuarterly  = "quarterly"
+	ObligationRecurrenceSemiAnnual = "semi_annual"
+	ObligationRecurrenceAnnual     = "annual"
+)
+
+// Obligation status values
+const (
+	ObligationStatusOpen      = "open"
+	ObligationStatusCompleted = "completed"
+)
+
+// PermitObligation is a condition attached to a permit, such as a quarterly
+// report, an annual inspection or a fee payment
+type PermitObligation struct {
+	ID                int64     `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	PermitID          int64     `json:"permit_id" gorm:"column:permit_id;not null"`
+	Description       string    `json:"description" gorm:"column:description;not null"`
+	RecurrenceRule    string    `json:"recurrence_rule" gorm:"column:recurrence_rule;not null;default:'once'"`
+	NextDueDate       time.Time `json:"next_due_date" gorm:"column:next_due_date;type:date;not null"`
+	ResponsibleUserID *int64    `json:"responsible_user_id" gorm:"column:responsible_user_id"`
+	Status            string    `json:"status" gorm:"column:status;not null;default:'open'"`
+	CreatedBy         *int64    `json:"created_by" gorm:"column:created_by"`
+	CreatedAt         time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt         time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+
+	Permit          *Permit                      `json:"permit,omitempty" gorm:"foreignKey:PermitID;references:ID"`
+	ResponsibleUser *User                        `json:"responsible_user,omitempty" gorm:"foreignKey:ResponsibleUserID;references:ID"`
+	Completions     []PermitObligationCompletion `json:"completions,omitempty" gorm:"foreignKey:ObligationID;references:ID"`
+}
+
+func (PermitObligation) TableName() string {
+	return "permit_obligations"
+}
+
+// PermitObligationCompletion records that one due date of an obligation was fulfilled
+type PermitObligationCompletion struct {
+	ID           int64     `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	ObligationID int64     `json:"obligation_id" gorm:"column:obligation_id;not null"`
+	DueDate      time.Time `json:"due_date" gorm:"column:due_date;type:date;not null"`
+	CompletedAt  time.Time `json:"completed_at" gorm:"column:completed_at;not null"`
+	CompletedBy  *int64    `json:"completed_by" gorm:"column:completed_by"`
+	Notes        *string   `json:"notes" gorm:"column:notes"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+
+	CompletedByUser *User                      `json:"completed_by_user,omitempty" gorm:"foreignKey:CompletedBy;references:ID"`
+	Evidence        []PermitObligationEvidence `json:"evidence,omitempty" gorm:"foreignKey:CompletionID;references:ID"`
+}
+
+func (PermitObligationCompletion) TableName() string {
+	return "permit_obligation_completions"
+}
+
+// PermitObligationEvidence is a file uploaded as proof of a completion
+type PermitObligationEvidence struct {
+	ID           int64     `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	CompletionID int64     `json:"completion_id" gorm:"column:completion_id;not null"`
+	FileName     string    `json:"file_name" gorm:"column:file_name;not null"`
+	FilePath     string    `json:"-" gorm:"column:file_path;not null"`
+	FileSize     *int64    `json:"file_size" gorm:"column:file_size"`
+	FileType     *string   `json:"file_type" gorm:"column:file_type"`
+	UploadedBy   *int64    `json:"uploaded_by" gorm:"column:uploaded_by"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (PermitObligationEvidence) TableName() string {
+	return "permit_obligation_evidence"
+}
+
+type PermitObligationRequest struct {
+	Description       string      `json:"description" validate:"required"`
+	RecurrenceRule    string      `json:"recurrence_rule" validate:"required,oneof=once monthly quarterly semi_annual annual"`
+	NextDueDate       helper.Date `json:"next_due_date" validate:"required"`
+	ResponsibleUserID *int64      `json:"responsible_user_id"`
+}
+
+// PermitObligationCompleteRequest is for multipart/form-data binding alongside
+// any number of "files" evidence uploads. CompletedAt defaults to now.
+type PermitObligationCompleteRequest struct {
+	CompletedAt helper.Date `form:"completed_at"`
+	Notes       *string     `form:"notes"`
+}
+
+type PermitObligationResponse struct {
+	ID                int64                                `json:"id"`
+	PermitID          int64                                `json:"permit_id"`
+	Description       string                               `json:"description"`
+	RecurrenceRule    string                               `json:"recurrence_rule"`
+	NextDueDate       time.Time                            `json:"next_due_date"`
+	ResponsibleUserID *int64                               `json:"responsible_user_id"`
+	Status            string                               `json:"status"`
+	IsOverdue         bool                                 `json:"is_overdue"`
+	CreatedBy         *int64                               `json:"created_by"`
+	CreatedAt         time.Time                            `json:"created_at"`
+	UpdatedAt         time.Time                            `json:"updated_at"`
+	ResponsibleUser   *UserResponse                        `json:"responsible_user,omitempty"`
+	Completions       []PermitObligationCompletionResponse `json:"completions"`
+}
+
+type PermitObligationCompletionResponse struct {
+	ID              int64                              `json:"id"`
+	ObligationID    int64                              `json:"obligation_id"`
+	DueDate         time.Time                          `json:"due_date"`
+	CompletedAt     time.Time                          `json:"completed_at"`
+	CompletedBy     *int64                             `json:"completed_by"`
+	Notes           *string                            `json:"notes"`
+	CreatedAt       time.Time                          `json:"created_at"`
+	CompletedByUser *UserResponse                      `json:"completed_by_user,omitempty"`
+	Evidence        []PermitObligationEvidenceResponse `json:"evidence"`
+}
+
+type PermitObligationEvidenceResponse struct {
+	ID           int64     `json:"id"`
+	CompletionID int64     `json:"completion_id"`
+	FileName     string    `json:"file_name"`
+	FileSize     *int64    `json:"file_size"`
+	FileType     *string   `json:"file_type"`
+	UploadedBy   *int64    `json:"uploaded_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
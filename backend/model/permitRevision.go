@@ -0,0 +1,91 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Permit revision actions
+const (
+	PermitRevisionActionCreate         = "create"
+	PermitRevisionActionUpdate         = "update"
+	PermitRevisionActionUpload         = "upload"
+	PermitRevisionActionRemoveDocument = "remove_document"
+	PermitRevisionActionDelete         = "delete"
+	PermitRevisionActionRestore        = "restore"
+	PermitRevisionActionUndelete       = "undelete"
+	PermitRevisionActionPurge          = "purge"
+	PermitRevisionActionStatusChange   = "status_change"
+)
+
+// PermitRevision is an immutable record of a permit's state after a change.
+// Rows are insert-only and survive deletion of the permit itself.
+type PermitRevision struct {
+	ID        int64           `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	PermitID  int64           `json:"permit_id" gorm:"column:permit_id;not null"`
+	Revision  int             `json:"revision" gorm:"column:revision;not null"`
+	Action    string          `json:"action" gorm:"column:action;not null"`
+	Snapshot  json.RawMessage `json:"snapshot" gorm:"column:snapshot;type:jsonb;not null"`
+	Changes   json.RawMessage `json:"changes" gorm:"column:changes;type:jsonb"`
+	ChangedBy *int64          `json:"changed_by" gorm:"column:changed_by"`
+	CreatedAt time.Time       `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+
+	ChangedByUser *User `json:"changed_by_user,omitempty" gorm:"foreignKey:ChangedBy;references:ID"`
+}
+
+func (PermitRevision) TableName() string {
+	return "permit_revisions"
+}
+
+// PermitSnapshot holds the stored columns of a permit at a given revision
+type PermitSnapshot struct {
+	ID                     int64      `json:"id"`
+	DomainID               int64      `json:"domain_id"`
+	DivisionID             *int64     `json:"division_id"`
+	PermitTypeID           int64      `json:"permit_type_id"`
+	Name                   string     `json:"name"`
+	ApplicationType        string     `json:"application_type"`
+	PermitNo               string     `json:"permit_no"`
+	EffectiveDate          time.Time  `json:"effective_date"`
+	ExpiryDate             time.Time  `json:"expiry_date"`
+	EffectiveTerm          *string    `json:"effective_term"`
+	ResponsiblePersonID    *int64     `json:"responsible_person_id"`
+	ResponsibleDocPersonID *int64     `json:"responsible_doc_person_id"`
+	DocName                *string    `json:"doc_name"`
+	DocNumber              *string    `json:"doc_number"`
+	Status                 string     `json:"status"`
+	PreviousPermitID       *int64     `json:"previous_permit_id"`
+	SupersededAt           *time.Time `json:"superseded_at"`
+	CreatedAt              time.Time  `json:"created_at"`
+	UpdatedAt              time.Time  `json:"updated_at"`
+
+	Documents []PermitSnapshotDocument `json:"documents"`
+}
+
+// PermitSnapshotDocument is the document metadata kept in a snapshot
+type PermitSnapshotDocument struct {
+	ID             int64   `json:"id"`
+	DocumentTypeID int64   `json:"document_type_id"`
+	FileName       string  `json:"file_name"`
+	FileSize       *int64  `json:"file_size"`
+	FileType       *string `json:"file_type"`
+}
+
+// PermitFieldChange describes a single field that differs between two revisions
+type PermitFieldChange struct {
+	Field    string      `json:"field"`
+	OldValue interface{} `json:"old_value"`
+	NewValue interface{} `json:"new_value"`
+}
+
+type PermitRevisionResponse struct {
+	ID            int64               `json:"id"`
+	PermitID      int64               `json:"permit_id"`
+	Revision      int                 `json:"revision"`
+	Action        string              `json:"action"`
+	Snapshot      *PermitSnapshot     `json:"snapshot,omitempty"`
+	Changes       []PermitFieldChange `json:"changes"`
+	ChangedBy     *int64              `json:"changed_by"`
+	ChangedByUser *UserResponse       `json:"changed_by_user,omitempty"`
+	CreatedAt     time.Time           `json:"created_at"`
+}
@@ -1,6 +1,9 @@
 package model
 
-import "time"
+import (
+	"permit-app/helper"
+	"time"
+)
 
 type PermitType struct {
 	ID                     int64     `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
@@ -13,7 +16,8 @@ type PermitType struct {
 	CreatedAt              time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
 	UpdatedAt              time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
 
-	Division *Division `json:"division,omitempty" gorm:"foreignKey:DivisionID;references:ID"`
+	Division          *Division          `json:"division,omitempty" gorm:"foreignKey:DivisionID;references:ID"`
+	ReminderSchedules []ReminderSchedule `json:"reminder_schedules,omitempty" gorm:"foreignKey:PermitTypeID;references:ID"`
 }
 
 func (PermitType) TableName() string {
@@ -39,21 +43,24 @@ type PermitTypeUpdateRequest struct {
 }
 
 type PermitTypeResponse struct {
-	ID                     int64             `json:"id"`
-	DivisionID             *int64            `json:"division_id"`
-	Name                   string            `json:"name"`
-	RiskPoint              *string           `json:"risk_point"`
-	DefaultApplicationType *string           `json:"default_application_type"`
-	DefaultValidityPeriod  *string           `json:"default_validity_period"`
-	Notes                  *string           `json:"notes"`
-	CreatedAt              time.Time         `json:"created_at"`
-	UpdatedAt              time.Time         `json:"updated_at"`
-	Division               *DivisionResponse `json:"division,omitempty"`
+	ID                     int64                      `json:"id"`
+	DivisionID             *int64                     `json:"division_id"`
+	Name                   string                     `json:"name"`
+	RiskPoint              *string                    `json:"risk_point"`
+	DefaultApplicationType *string                    `json:"default_application_type"`
+	DefaultValidityPeriod  *string                    `json:"default_validity_period"`
+	DefaultValidity        *helper.ValidityPeriod     `json:"default_validity"` // parsed default_validity_period, null when unset
+	Notes                  *string                    `json:"notes"`
+	CreatedAt              time.Time                  `json:"created_at"`
+	UpdatedAt              time.Time                  `json:"updated_at"`
+	Division               *DivisionResponse          `json:"division,omitempty"`
+	ReminderSchedules      []ReminderScheduleResponse `json:"reminder_schedules"`
 }
 
 type PermitTypeListRequest struct {
+	DomainID   *int64 `json:"domain_id" form:"domain_id"`
 	DivisionID *int64 `json:"division_id" form:"division_id"`
 	Name       string `json:"name" form:"name"`
 	Page       int    `json:"page" form:"page" validate:"omitempty,min=1"`
 	Limit      int    `json:"limit" form:"limit" validate:"omitempty,min=1,max=10000"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// PermitVerificationToken is the unguessable token printed as a QR code on a
+// posted permit. Unlike calendar tokens it is stored as is, since the QR code
+// has to be rendered again whenever the permit is reprinted.
+type PermitVerificationToken struct {
+	ID        int64      `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	PermitID  int64      `json:"permit_id" gorm:"column:permit_id;not null"`
+	Token     string     `json:"-" gorm:"column:token;not null"`
+	CreatedBy *int64     `json:"created_by" gorm:"column:created_by"`
+	RevokedAt *time.Time `json:"revoked_at" gorm:"column:revoked_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+
+	Permit *Permit `json:"permit,omitempty" gorm:"foreignKey:PermitID;references:ID"`
+}
+
+func (PermitVerificationToken) TableName() string {
+	return "permit_verification_tokens"
+}
+
+// PermitVerificationTokenResponse describes the permit's current verification
+// token. VerifyURL is the address encoded in the QR code.
+type PermitVerificationTokenResponse struct {
+	PermitID  int64     `json:"permit_id"`
+	Token     string    `json:"token"`
+	VerifyURL string    `json:"verify_url"`
+	CreatedBy *int64    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PermitVerificationResponse is the public result of scanning a permit's QR code.
+// It only carries what an inspector needs to compare with the posted permit.
+type PermitVerificationResponse struct {
+	Name          string    `json:"name"`
+	PermitNo      string    `json:"permit_no"`
+	EffectiveDate time.Time `json:"effective_date"`
+	ExpiryDate    time.Time `json:"expiry_date"`
+	Status        string    `json:"status"`
+	Valid         bool      `json:"valid"`
+	CheckedAt     time.Time `json:"checked_at"`
+}
+
+// PermitQRCodeRequest selects the QR code image format and size in pixels
+type PermitQRCodeRequest struct {
+	Format string `form:"format" validate:"omitempty,oneof=png svg"`
+	Size   int    `form:"size" validate:"omitempty,min=64,max=1024"`
+}
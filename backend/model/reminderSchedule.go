@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// Reminder severities
+const (
+	ReminderSeverityInfo     = "info"
+	ReminderSeverityWarning  = "warning"
+	ReminderSeverityCritical = "critical"
+)
+
+// ReminderSchedule is one expiry reminder offset. It belongs either to a permit
+// type or, as a default for permit types without their own schedule, to a domain.
+type ReminderSchedule struct {
+	ID           int64     `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	PermitTypeID *int64    `json:"permit_type_id" gorm:"column:permit_type_id"`
+	DomainID     *int64    `json:"domain_id" gorm:"column:domain_id"`
+	DaysBefore   int       `json:"days_before" gorm:"column:days_before;not null"`
+	Severity     string    `json:"severity" gorm:"column:severity;not null"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (ReminderSchedule) TableName() string {
+	return "reminder_schedules"
+}
+
+// ReminderScheduleItem is a single offset in a schedule request. DaysBefore 0 is the expiry day.
+type ReminderScheduleItem struct {
+	DaysBefore int    `json:"days_before" validate:"min=0,max=3650"`
+	Severity   string `json:"severity" validate:"required,oneof=info warning critical"`
+}
+
+type ReminderScheduleRequest struct {
+	Schedules []ReminderScheduleItem `json:"schedules" validate:"dive"`
+}
+
+type ReminderScheduleResponse struct {
+	ID           int64     `json:"id"`
+	PermitTypeID *int64    `json:"permit_type_id"`
+	DomainID     *int64    `json:"domain_id"`
+	DaysBefore   int       `json:"days_before"`
+	Severity     string    `json:"severity"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
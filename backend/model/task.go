@@ -58,7 +58,7 @@ type TaskFile struct {
 	ID           int64     `gorm:"primaryKey;autoIncrement" json:"id"`
 	TaskID       int64     `gorm:"not null;index" json:"task_id"`
 	FileName     string    `gorm:"size:255;not null" json:"file_name"`
-	FilePath     string    `gorm:"size:500;not null" json:"file_path"`
+	FilePath     string    `gorm:"size:500;not null" json:"-"`
 	FileSize     *string   `gorm:"size:50" json:"file_size"`
 	FileType     *string   `gorm:"size:100" json:"file_type"`
 	TaskFileType *int      `json:"task_file_type"`
@@ -207,7 +207,7 @@ type TaskFileResponse struct {
 	ID           int64     `json:"id"`
 	TaskID       int64     `json:"task_id"`
 	FileName     string    `json:"file_name"`
-	FilePath     string    `json:"file_path"`
+	FilePath     string    `json:"-"`
 	FileSize     *string   `json:"file_size"`
 	FileType     *string   `json:"file_type"`
 	TaskFileType *int      `json:"task_file_type"`
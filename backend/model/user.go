@@ -10,6 +10,7 @@ type User struct {
 	FullName    string    `gorm:"type:varchar(255);not null" json:"full_name"`
 	PhoneNumber string    `gorm:"type:varchar(20)" json:"phone_number"`
 	Nip         string    `gorm:"type:varchar(50)" json:"nip"`
+	Locale      *string   `gorm:"type:varchar(5)" json:"locale"` // email locale, nil follows the domain's
 	IsActive    bool      `gorm:"type:boolean;not null;default:true" json:"is_active"`
 	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
@@ -38,6 +39,7 @@ type UserRequest struct {
 	FullName    string                    `json:"full_name" validate:"required,max=255"`
 	PhoneNumber string                    `json:"phone_number" validate:"omitempty,max=20"`
 	Nip         string                    `json:"nip" validate:"omitempty,max=50"`
+	Locale      *string                   `json:"locale" validate:"omitempty,oneof=id en"`
 	IsActive    *bool                     `json:"is_active"`
 	DomainRoles []UserDomainRoleRequest   `json:"domain_roles" validate:"required,min=1"`
 }
@@ -55,6 +57,7 @@ type UserUpdateRequest struct {
 	FullName    string                    `json:"full_name" validate:"omitempty,max=255"`
 	PhoneNumber string                    `json:"phone_number" validate:"omitempty,max=20"`
 	Nip         string                    `json:"nip" validate:"omitempty,max=50"`
+	Locale      *string                   `json:"locale" validate:"omitempty,oneof=id en"`
 	IsActive    *bool                     `json:"is_active"`
 	DomainRoles []UserDomainRoleRequest   `json:"domain_roles" validate:"omitempty,min=1"`
 }
@@ -66,6 +69,7 @@ type UserResponse struct {
 	FullName        string                    `json:"full_name"`
 	PhoneNumber     string                    `json:"phone_number"`
 	Nip             string                    `json:"nip"`
+	Locale          *string                   `json:"locale"`
 	IsActive        bool                      `json:"is_active"`
 	CreatedAt       time.Time                 `json:"created_at"`
 	UpdatedAt       time.Time                 `json:"updated_at"`
@@ -124,9 +128,10 @@ type ChangePasswordRequest struct {
 }
 
 type UpdateProfileRequest struct {
-	FullName    string `json:"full_name" validate:"omitempty,max=255"`
-	Email       string `json:"email" validate:"omitempty,email,max=255"`
-	PhoneNumber string `json:"phone_number" validate:"omitempty,max=20"`
-	Nip         string `json:"nip" validate:"omitempty,max=50"`
+	FullName    string  `json:"full_name" validate:"omitempty,max=255"`
+	Email       string  `json:"email" validate:"omitempty,email,max=255"`
+	PhoneNumber string  `json:"phone_number" validate:"omitempty,max=20"`
+	Nip         string  `json:"nip" validate:"omitempty,max=50"`
+	Locale      *string `json:"locale" validate:"omitempty,oneof=id en"`
 }
 
@@ -0,0 +1,239 @@
+package model
+
+import (
+	"encoding/json"
+	"permit-app/helper"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook event types subscriptions can select
+const (
+	WebhookEventPermitCreated  = "permit.created"
+	WebhookEventPermitExpiring = "permit.expiring"
+	WebhookEventPermitExpired  = "permit.expired"
+	WebhookEventTaskAssigned   = "task.assigned"
+	WebhookEventTaskApproved   = "task.approved"
+	WebhookEventTaskRejected   = "task.rejected"
+)
+
+// WebhookEventPing is sent by the test ping only, subscriptions cannot select it
+const WebhookEventPing = "ping"
+
+var WebhookEventTypes = []string{
+	WebhookEventPermitCreated,
+	WebhookEventPermitExpiring,
+	WebhookEventPermitExpired,
+	WebhookEventTaskAssigned,
+	WebhookEventTaskApproved,
+	WebhookEventTaskRejected,
+}
+
+// Webhook delivery statuses
+const (
+	WebhookStatusPending   = "pending" // waiting for its next attempt
+	WebhookStatusSending   = "sending" // claimed by the worker
+	WebhookStatusDelivered = "delivered"
+	WebhookStatusFailed    = "failed" // gave up after MaxAttempts
+)
+
+// WebhookDefaultMaxAttempts is how often an event is posted before its delivery
+// is marked failed
+const WebhookDefaultMaxAttempts = 8
+
+// WebhookSubscription is a URL of a domain's receiver and the events posted to
+// it. Every delivery is signed with the secret.
+type WebhookSubscription struct {
+	ID          int64     `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	DomainID    int64     `json:"domain_id" gorm:"column:domain_id;not null"`
+	URL         string    `json:"url" gorm:"column:url;not null"`
+	Secret      string    `json:"-" gorm:"column:secret;not null"`
+	EventTypes  []string  `json:"event_types" gorm:"column:event_types;type:jsonb;serializer:json;not null"`
+	Description *string   `json:"description" gorm:"column:description"`
+	IsActive    bool      `json:"is_active" gorm:"column:is_active;not null"`
+	CreatedBy   *int64    `json:"created_by" gorm:"column:created_by"`
+	UpdatedBy   *int64    `json:"updated_by" gorm:"column:updated_by"`
+	CreatedAt   time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// WebhookDelivery is one event queued for one subscription and delivered by the
+// webhook worker. Failed posts are retried with exponential backoff until the
+// receiver answers with a 2xx status or MaxAttempts is reached.
+type WebhookDelivery struct {
+	ID             int64           `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	SubscriptionID int64           `json:"subscription_id" gorm:"column:subscription_id;not null"`
+	EventID        string          `json:"event_id" gorm:"column:event_id;not null"` // same for every subscription the event went to, receivers dedupe on it
+	EventType      string          `json:"event_type" gorm:"column:event_type;not null"`
+	Payload        json.RawMessage `json:"payload" gorm:"column:payload;type:jsonb;not null"` // the posted body
+	Status         string          `json:"status" gorm:"column:status;not null"`
+	Attempts       int             `json:"attempts" gorm:"column:attempts;not null"`
+	MaxAttempts    int             `json:"max_attempts" gorm:"column:max_attempts;not null"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at" gorm:"column:next_attempt_at;not null"`
+	LockedUntil    *time.Time      `json:"locked_until" gorm:"column:locked_until"` // lease of the worker posting it, expired leases are claimed again
+	LastError      *string         `json:"last_error" gorm:"column:last_error"`
+	ResponseStatus *int            `json:"response_status" gorm:"column:response_status"` // HTTP status of the last attempt
+	DeliveredAt    *time.Time      `json:"delivered_at" gorm:"column:delivered_at"`
+	CreatedAt      time.Time       `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt      time.Time       `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+
+	Subscription     *WebhookSubscription     `json:"-" gorm:"foreignKey:SubscriptionID;references:ID"`
+	DeliveryAttempts []WebhookDeliveryAttempt `json:"delivery_attempts,omitempty" gorm:"foreignKey:DeliveryID;references:ID"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookDeliveryAttempt is the outcome of one post of a webhook delivery
+type WebhookDeliveryAttempt struct {
+	ID             int64     `json:"id" gorm:"primaryKey;column:id;autoIncrement"`
+	DeliveryID     int64     `json:"delivery_id" gorm:"column:delivery_id;not null"`
+	Attempt        int       `json:"attempt" gorm:"column:attempt;not null"`
+	Status         string    `json:"status" gorm:"column:status;not null"` // delivered or failed
+	ResponseStatus *int      `json:"response_status" gorm:"column:response_status"`
+	ResponseBody   *string   `json:"response_body" gorm:"column:response_body"` // start of the receiver's answer
+	Error          *string   `json:"error" gorm:"column:error"`
+	DurationMs     int64     `json:"duration_ms" gorm:"column:duration_ms;not null"`
+	CreatedAt      time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (WebhookDeliveryAttempt) TableName() string {
+	return "webhook_delivery_attempts"
+}
+
+// WebhookEvent is the JSON body posted to the receivers of an event
+type WebhookEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	DomainID  int64     `json:"domain_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+func NewWebhookEvent(eventType string, domainID int64, data any) *WebhookEvent {
+	return &WebhookEvent{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		DomainID:  domainID,
+		CreatedAt: time.Now(),
+		Data:      data,
+	}
+}
+
+// Deliveries queues the event for immediate delivery to each subscription
+func (e *WebhookEvent) Deliveries(subscriptions []WebhookSubscription) ([]*WebhookDelivery, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]*WebhookDelivery, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		deliveries = append(deliveries, &WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        e.ID,
+			EventType:      e.Type,
+			Payload:        payload,
+			Status:         WebhookStatusPending,
+			MaxAttempts:    WebhookDefaultMaxAttempts,
+			NextAttemptAt:  e.CreatedAt,
+		})
+	}
+	return deliveries, nil
+}
+
+// WebhookPermitData is the data of permit events
+type WebhookPermitData struct {
+	ID           int64     `json:"id"`
+	PermitNo     string    `json:"permit_no"`
+	Name         string    `json:"name"`
+	Status       string    `json:"status"`
+	PermitTypeID int64     `json:"permit_type_id"`
+	DivisionID   *int64    `json:"division_id"`
+	ExpiryDate   time.Time `json:"expiry_date"`
+	URL          string    `json:"url"`
+}
+
+func NewWebhookPermitData(permit *Permit) WebhookPermitData {
+	return WebhookPermitData{
+		ID:           permit.ID,
+		PermitNo:     permit.PermitNo,
+		Name:         permit.Name,
+		Status:       permit.Status,
+		PermitTypeID: permit.PermitTypeID,
+		DivisionID:   permit.DivisionID,
+		ExpiryDate:   permit.ExpiryDate,
+		URL:          webhookURL(NotificationEntityPermit, permit.ID),
+	}
+}
+
+// WebhookTaskData is the data of task events. Sequence and Note describe the
+// approval step of task.approved and task.rejected.
+type WebhookTaskData struct {
+	ID         int64   `json:"id"`
+	Code       string  `json:"code"`
+	Title      string  `json:"title"`
+	ProjectID  int64   `json:"project_id"`
+	AssignedID *int64  `json:"assigned_id"`
+	ActorID    int64   `json:"actor_id"` // user who made the change
+	Sequence   *int16  `json:"sequence,omitempty"`
+	Approved   *bool   `json:"approved,omitempty"` // true once the last step approved the task
+	Note       *string `json:"note,omitempty"`
+	URL        string  `json:"url"`
+}
+
+func NewWebhookTaskData(task *Task, actorID int64) WebhookTaskData {
+	return WebhookTaskData{
+		ID:         task.ID,
+		Code:       task.Code,
+		Title:      task.Title,
+		ProjectID:  task.ProjectID,
+		AssignedID: task.AssignedID,
+		ActorID:    actorID,
+		URL:        webhookURL(NotificationEntityTask, task.ID),
+	}
+}
+
+// WebhookPingData is the data of the test ping
+type WebhookPingData struct {
+	SubscriptionID int64    `json:"subscription_id"`
+	EventTypes     []string `json:"event_types"`
+}
+
+// webhookURL makes the client path of an entity absolute with APP_URL
+func webhookURL(entityType string, entityID int64) string {
+	return strings.TrimRight(helper.GetEnv("APP_URL"), "/") + NotificationLink(entityType, entityID)
+}
+
+type CreateWebhookSubscriptionRequest struct {
+	DomainID    int64    `json:"domain_id"`
+	URL         string   `json:"url" validate:"required,http_url,max=500"`
+	Secret      string   `json:"secret" validate:"required,min=16,max=255"`
+	EventTypes  []string `json:"event_types" validate:"required,min=1,unique,dive,oneof=permit.created permit.expiring permit.expired task.assigned task.approved task.rejected"`
+	Description *string  `json:"description" validate:"omitempty,max=255"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+// UpdateWebhookSubscriptionRequest changes the fields it carries, a new secret
+// applies to deliveries posted from then on
+type UpdateWebhookSubscriptionRequest struct {
+	URL         *string  `json:"url" validate:"omitempty,http_url,max=500"`
+	Secret      *string  `json:"secret" validate:"omitempty,min=16,max=255"`
+	EventTypes  []string `json:"event_types" validate:"omitempty,min=1,unique,dive,oneof=permit.created permit.expiring permit.expired task.assigned task.approved task.rejected"`
+	Description *string  `json:"description" validate:"omitempty,max=255"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+type WebhookDeliveryListRequest struct {
+	Status    string `form:"status" validate:"omitempty,oneof=pending sending delivered failed"`
+	EventType string `form:"event_type"`
+	Page      int    `form:"page" validate:"omitempty,min=1"`
+	Limit     int    `form:"limit" validate:"omitempty,min=1,max=10000"`
+}
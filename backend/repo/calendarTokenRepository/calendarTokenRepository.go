@@ -0,0 +1,67 @@
+package calendarTokenRepository
+
+import (
+	"errors"
+	"permit-app/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CalendarTokenRepository interface {
+	FindByUserID(userID int64) (*model.CalendarToken, error)
+	FindByTokenHash(tokenHash string) (*model.CalendarToken, error)
+	Replace(token *model.CalendarToken) error
+	DeleteByUserID(userID int64) error
+	UpdateLastUsed(id int64, usedAt time.Time) error
+}
+
+type calendarTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewCalendarTokenRepository(db *gorm.DB) CalendarTokenRepository {
+	return &calendarTokenRepository{db: db}
+}
+
+func (r *calendarTokenRepository) FindByUserID(userID int64) (*model.CalendarToken, error) {
+	var token model.CalendarToken
+	err := r.db.Where("user_id = ?", userID).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *calendarTokenRepository) FindByTokenHash(tokenHash string) (*model.CalendarToken, error) {
+	var token model.CalendarToken
+	err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Replace stores the user's new token, revoking the previous one
+func (r *calendarTokenRepository) Replace(token *model.CalendarToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", token.UserID).Delete(&model.CalendarToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+func (r *calendarTokenRepository) DeleteByUserID(userID int64) error {
+	return r.db.Where("user_id = ?", userID).Delete(&model.CalendarToken{}).Error
+}
+
+func (r *calendarTokenRepository) UpdateLastUsed(id int64, usedAt time.Time) error {
+	return r.db.Model(&model.CalendarToken{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
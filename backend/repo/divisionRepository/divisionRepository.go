@@ -5,14 +5,18 @@ import (
 	"permit-app/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type DivisionRepository interface {
 	Create(division *model.Division) error
 	FindByID(id int64) (*model.Division, error)
+	FindByIDInDomain(id int64, domainID *int64) (*model.Division, error)
 	FindByCodeAndDomainID(code string, domainID int64) (*model.Division, error)
+	FindByCodeOrNameAndDomainID(value string, domainID int64) (*model.Division, error)
 	FindAll(filter *model.DivisionListRequest) ([]model.Division, int64, error)
 	Update(id int64, division *model.Division) error
+	UpdateHead(id int64, headUserID *int64) error
 	Delete(id int64) error
 }
 
@@ -29,8 +33,18 @@ func (r *divisionRepository) Create(division *model.Division) error {
 }
 
 func (r *divisionRepository) FindByID(id int64) (*model.Division, error) {
+	return r.FindByIDInDomain(id, nil)
+}
+
+// FindByIDInDomain finds a division that belongs to the domain. A nil domainID
+// matches divisions of every domain.
+func (r *divisionRepository) FindByIDInDomain(id int64, domainID *int64) (*model.Division, error) {
 	var division model.Division
-	err := r.db.Preload("Domain").Where("id = ?", id).First(&division).Error
+	query := r.db.Preload("Domain").Where("id = ?", id)
+	if domainID != nil {
+		query = query.Where("domain_id = ?", *domainID)
+	}
+	err := query.First(&division).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("division not found")
@@ -52,6 +66,21 @@ func (r *divisionRepository) FindByCodeAndDomainID(code string, domainID int64)
 	return &division, nil
 }
 
+// FindByCodeOrNameAndDomainID matches the division code first, then the name case-insensitively
+func (r *divisionRepository) FindByCodeOrNameAndDomainID(value string, domainID int64) (*model.Division, error) {
+	var division model.Division
+	err := r.db.Where("domain_id = ? AND (code = ? OR LOWER(name) = LOWER(?))", domainID, value, value).
+		Order(clause.Expr{SQL: "code = ? DESC", Vars: []interface{}{value}}).
+		First(&division).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &division, nil
+}
+
 func (r *divisionRepository) FindAll(filter *model.DivisionListRequest) ([]model.Division, int64, error) {
 	var divisions []model.Division
 	var total int64
@@ -92,6 +121,11 @@ func (r *divisionRepository) Update(id int64, division *model.Division) error {
 	return r.db.Model(&model.Division{}).Where("id = ?", id).Updates(division).Error
 }
 
+// UpdateHead sets the division head, nil removes it
+func (r *divisionRepository) UpdateHead(id int64, headUserID *int64) error {
+	return r.db.Model(&model.Division{}).Where("id = ?", id).Update("head_user_id", headUserID).Error
+}
+
 func (r *divisionRepository) Delete(id int64) error {
 	return r.db.Delete(&model.Division{}, id).Error
 }
\ No newline at end of file
@@ -0,0 +1,146 @@
+package emailOutboxRepository
+
+import (
+	"errors"
+	"permit-app/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EmailOutboxRepository interface {
+	ClaimDue(now time.Time, limit int, lease time.Duration) ([]model.EmailOutbox, error)
+	RecordAttempt(email *model.EmailOutbox, attempt *model.EmailDeliveryAttempt) error
+	FindAll(filter *model.EmailOutboxListRequest) ([]model.EmailOutbox, int64, error)
+	FindByID(id int64) (*model.EmailOutbox, error)
+	Requeue(id int64, now time.Time) error
+	DeleteSentBefore(before time.Time) (int64, error)
+}
+
+type emailOutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailOutboxRepository(db *gorm.DB) EmailOutboxRepository {
+	return &emailOutboxRepository{db: db}
+}
+
+// ClaimDue marks up to limit emails whose next attempt is due as sending, with
+// a lease of the given length. Emails of a worker that stopped mid-send are
+// claimed again once their lease expired. Rows claimed by another worker are skipped.
+func (r *emailOutboxRepository) ClaimDue(now time.Time, limit int, lease time.Duration) ([]model.EmailOutbox, error) {
+	var emails []model.EmailOutbox
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND locked_until < ?)",
+				model.EmailStatusPending, now, model.EmailStatusSending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&emails).Error
+		if err != nil || len(emails) == 0 {
+			return err
+		}
+
+		lockedUntil := now.Add(lease)
+		ids := make([]int64, 0, len(emails))
+		for i := range emails {
+			emails[i].Status = model.EmailStatusSending
+			emails[i].LockedUntil = &lockedUntil
+			ids = append(ids, emails[i].ID)
+		}
+
+		return tx.Model(&model.EmailOutbox{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":       model.EmailStatusSending,
+				"locked_until": lockedUntil,
+			}).Error
+	})
+	return emails, err
+}
+
+// RecordAttempt logs the attempt and saves the email's resulting state together
+func (r *emailOutboxRepository) RecordAttempt(email *model.EmailOutbox, attempt *model.EmailDeliveryAttempt) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.EmailOutbox{}).
+			Where("id = ?", email.ID).
+			Updates(map[string]interface{}{
+				"status":          email.Status,
+				"attempts":        email.Attempts,
+				"next_attempt_at": email.NextAttemptAt,
+				"locked_until":    email.LockedUntil,
+				"last_error":      email.LastError,
+				"sent_at":         email.SentAt,
+			}).Error
+	})
+}
+
+func (r *emailOutboxRepository) FindAll(filter *model.EmailOutboxListRequest) ([]model.EmailOutbox, int64, error) {
+	var emails []model.EmailOutbox
+	var total int64
+
+	query := r.db.Model(&model.EmailOutbox{})
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if filter.Recipient != "" {
+		query = query.Where("recipients::text ILIKE ?", "%"+filter.Recipient+"%")
+	}
+
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Page > 0 && filter.Limit > 0 {
+		offset := (filter.Page - 1) * filter.Limit
+		query = query.Offset(offset).Limit(filter.Limit)
+	}
+
+	err = query.Order("created_at DESC").Find(&emails).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return emails, total, nil
+}
+
+// FindByID returns the email with its delivery attempts, or nil when it does not exist
+func (r *emailOutboxRepository) FindByID(id int64) (*model.EmailOutbox, error) {
+	var email model.EmailOutbox
+	err := r.db.Preload("DeliveryAttempts", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at")
+	}).First(&email, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &email, nil
+}
+
+// Requeue gives the email a fresh set of attempts, starting now
+func (r *emailOutboxRepository) Requeue(id int64, now time.Time) error {
+	return r.db.Model(&model.EmailOutbox{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          model.EmailStatusPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"locked_until":    nil,
+		}).Error
+}
+
+// DeleteSentBefore removes the emails sent before the given time together with
+// their delivery attempts and returns how many were removed
+func (r *emailOutboxRepository) DeleteSentBefore(before time.Time) (int64, error) {
+	result := r.db.Where("status = ? AND sent_at < ?", model.EmailStatusSent, before).Delete(&model.EmailOutbox{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,57 @@
+package emailTemplateRepository
+
+import (
+	"errors"
+	"permit-app/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EmailTemplateRepository interface {
+	FindByDomainID(domainID int64) ([]model.EmailTemplate, error)
+	FindOverride(domainID int64, name string, locale string) (*model.EmailTemplate, error)
+	Save(template *model.EmailTemplate) error
+	Delete(domainID int64, name string, locale string) error
+}
+
+type emailTemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailTemplateRepository(db *gorm.DB) EmailTemplateRepository {
+	return &emailTemplateRepository{db: db}
+}
+
+func (r *emailTemplateRepository) FindByDomainID(domainID int64) ([]model.EmailTemplate, error) {
+	var templates []model.EmailTemplate
+	err := r.db.Where("domain_id = ?", domainID).Order("name, locale").Find(&templates).Error
+	return templates, err
+}
+
+// FindOverride returns the domain's override of the template in the locale, or
+// nil when the built-in template applies
+func (r *emailTemplateRepository) FindOverride(domainID int64, name string, locale string) (*model.EmailTemplate, error) {
+	var template model.EmailTemplate
+	err := r.db.Where("domain_id = ? AND name = ? AND locale = ?", domainID, name, locale).First(&template).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+// Save inserts the override, replacing the domain's existing one for the
+// template and locale
+func (r *emailTemplateRepository) Save(template *model.EmailTemplate) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "domain_id"}, {Name: "name"}, {Name: "locale"}},
+		DoUpdates: clause.AssignmentColumns([]string{"subject", "html_body", "text_body", "updated_by", "updated_at"}),
+	}).Create(template).Error
+}
+
+func (r *emailTemplateRepository) Delete(domainID int64, name string, locale string) error {
+	return r.db.Where("domain_id = ? AND name = ? AND locale = ?", domainID, name, locale).Delete(&model.EmailTemplate{}).Error
+}
@@ -0,0 +1,123 @@
+package escalationRepository
+
+import (
+	"permit-app/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type EscalationRepository interface {
+	FindRulesByDomainID(domainID int64) ([]model.EscalationRule, error)
+	FindAllRules() ([]model.EscalationRule, error)
+	ReplaceRules(domainID int64, rules []model.EscalationRule) error
+	CheckExisting(permitID int64, daysBefore int) (bool, error)
+	FindFirstReminderAt(permitID int64) (*time.Time, error)
+	HasActivitySince(permitID int64, since time.Time) (bool, error)
+	FindEscalations(domainID int64, filter *model.PermitEscalationListRequest) ([]model.PermitEscalation, int64, error)
+}
+
+type escalationRepository struct {
+	db *gorm.DB
+}
+
+func NewEscalationRepository(db *gorm.DB) EscalationRepository {
+	return &escalationRepository{db: db}
+}
+
+func (r *escalationRepository) FindRulesByDomainID(domainID int64) ([]model.EscalationRule, error) {
+	var rules []model.EscalationRule
+	err := r.db.Where("domain_id = ?", domainID).Order("level").Find(&rules).Error
+	return rules, err
+}
+
+// FindAllRules returns the rules of every domain, ordered by domain and level
+func (r *escalationRepository) FindAllRules() ([]model.EscalationRule, error) {
+	var rules []model.EscalationRule
+	err := r.db.Order("domain_id, level").Find(&rules).Error
+	return rules, err
+}
+
+// ReplaceRules swaps the domain's rules for the given ones in one transaction
+func (r *escalationRepository) ReplaceRules(domainID int64, rules []model.EscalationRule) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("domain_id = ?", domainID).Delete(&model.EscalationRule{}).Error; err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		return tx.Create(&rules).Error
+	})
+}
+
+func (r *escalationRepository) CheckExisting(permitID int64, daysBefore int) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.PermitEscalation{}).
+		Where("permit_id = ? AND days_before = ?", permitID, daysBefore).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindFirstReminderAt returns when the permit's first expiry reminder was sent,
+// or nil when it has not been reminded yet
+func (r *escalationRepository) FindFirstReminderAt(permitID int64) (*time.Time, error) {
+	var sentAt []time.Time
+	err := r.db.Model(&model.ReminderDelivery{}).
+		Where("permit_id = ? AND obligation_id IS NULL", permitID).
+		Order("created_at").
+		Limit(1).
+		Pluck("created_at", &sentAt).Error
+	if err != nil || len(sentAt) == 0 {
+		return nil, err
+	}
+	return &sentAt[0], nil
+}
+
+// HasActivitySince reports whether anyone acted on the permit's expiry since
+// the given time: read one of its expiry reminders or changed the permit.
+// Changes made by the system, such as scheduled status updates, do not count.
+func (r *escalationRepository) HasActivitySince(permitID int64, since time.Time) (bool, error) {
+	var read int64
+	err := r.db.Model(&model.Notification{}).
+		Where("permit_id = ? AND obligation_id IS NULL AND reminder_days IS NOT NULL AND is_read = ?", permitID, true).
+		Count(&read).Error
+	if err != nil || read > 0 {
+		return read > 0, err
+	}
+
+	var changed int64
+	err = r.db.Model(&model.PermitRevision{}).
+		Where("permit_id = ? AND changed_by IS NOT NULL AND created_at >= ?", permitID, since).
+		Count(&changed).Error
+	return changed > 0, err
+}
+
+// FindEscalations lists the domain's recorded escalations, newest first
+func (r *escalationRepository) FindEscalations(domainID int64, filter *model.PermitEscalationListRequest) ([]model.PermitEscalation, int64, error) {
+	var escalations []model.PermitEscalation
+	var total int64
+
+	query := r.db.Model(&model.PermitEscalation{}).Where("domain_id = ?", domainID)
+
+	if filter.PermitID != nil {
+		query = query.Where("permit_id = ?", *filter.PermitID)
+	}
+
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Page > 0 && filter.Limit > 0 {
+		offset := (filter.Page - 1) * filter.Limit
+		query = query.Offset(offset).Limit(filter.Limit)
+	}
+
+	err = query.Preload("Permit").Order("created_at DESC, id DESC").Find(&escalations).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return escalations, total, nil
+}
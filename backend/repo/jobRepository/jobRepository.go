@@ -0,0 +1,195 @@
+package jobRepository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"permit-app/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobRepository interface {
+	FindStates() ([]model.JobState, error)
+	FindState(name string) (*model.JobState, error)
+	SaveState(state *model.JobState) error
+	CreateRun(run *model.JobRun) error
+	FinishRun(run *model.JobRun) error
+	FindLastRuns() ([]model.JobRun, error)
+	FindRuns(jobName string, filter *model.JobRunListRequest) ([]model.JobRun, int64, error)
+	DeleteRunsBefore(before time.Time) (int64, error)
+	FailInterruptedRuns(jobName string, message string) (int64, error)
+	TryLock(key string) (*AdvisoryLock, error)
+}
+
+type jobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+func (r *jobRepository) FindStates() ([]model.JobState, error) {
+	var states []model.JobState
+	err := r.db.Find(&states).Error
+	return states, err
+}
+
+// FindState returns the job's state, or nil when it has none
+func (r *jobRepository) FindState(name string) (*model.JobState, error) {
+	var state model.JobState
+	err := r.db.Where("name = ?", name).First(&state).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &state, nil
+}
+
+// SaveState creates the job's state or replaces it
+func (r *jobRepository) SaveState(state *model.JobState) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"is_paused", "paused_by", "paused_at", "updated_at"}),
+	}).Create(state).Error
+}
+
+func (r *jobRepository) CreateRun(run *model.JobRun) error {
+	return r.db.Create(run).Error
+}
+
+// FinishRun saves the outcome of the run
+func (r *jobRepository) FinishRun(run *model.JobRun) error {
+	return r.db.Model(&model.JobRun{}).
+		Where("id = ?", run.ID).
+		Updates(map[string]interface{}{
+			"status":          run.Status,
+			"finished_at":     run.FinishedAt,
+			"duration_ms":     run.DurationMs,
+			"items_processed": run.ItemsProcessed,
+			"error":           run.Error,
+		}).Error
+}
+
+// FindLastRuns returns the latest run of every job that has run
+func (r *jobRepository) FindLastRuns() ([]model.JobRun, error) {
+	var runs []model.JobRun
+	err := r.db.Raw("SELECT DISTINCT ON (job_name) * FROM job_runs ORDER BY job_name, started_at DESC, id DESC").
+		Scan(&runs).Error
+	return runs, err
+}
+
+func (r *jobRepository) FindRuns(jobName string, filter *model.JobRunListRequest) ([]model.JobRun, int64, error) {
+	var runs []model.JobRun
+	var total int64
+
+	query := r.db.Model(&model.JobRun{}).Where("job_name = ?", jobName)
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Page > 0 && filter.Limit > 0 {
+		offset := (filter.Page - 1) * filter.Limit
+		query = query.Offset(offset).Limit(filter.Limit)
+	}
+
+	err = query.Order("started_at DESC, id DESC").Find(&runs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return runs, total, nil
+}
+
+// DeleteRunsBefore removes the finished runs started before the given time
+func (r *jobRepository) DeleteRunsBefore(before time.Time) (int64, error) {
+	result := r.db.Where("started_at < ? AND status <> ?", before, model.JobRunStatusRunning).Delete(&model.JobRun{})
+	return result.RowsAffected, result.Error
+}
+
+// FailInterruptedRuns marks the job's runs still recorded as running as failed
+// with the given message. It is called while holding the job's lock, so such
+// runs belong to an instance that stopped before finishing them.
+func (r *jobRepository) FailInterruptedRuns(jobName string, message string) (int64, error) {
+	result := r.db.Model(&model.JobRun{}).
+		Where("job_name = ? AND status = ?", jobName, model.JobRunStatusRunning).
+		Updates(map[string]interface{}{
+			"status":      model.JobRunStatusFailed,
+			"finished_at": time.Now(),
+			"error":       message,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// AdvisoryLock is a Postgres session advisory lock held on a connection of its
+// own. Postgres releases it when that connection drops, so the lock of an
+// instance that died does not outlive it.
+type AdvisoryLock struct {
+	conn *sql.Conn
+	key  string
+}
+
+// TryLock takes the advisory lock for the key, or returns nil when another
+// session holds it
+func (r *jobRepository) TryLock(key string) (*AdvisoryLock, error) {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keepalives let Postgres notice a holder whose host went away without
+	// closing the connection, within about a minute
+	_, err = conn.ExecContext(ctx, "SELECT set_config('tcp_keepalives_idle', '30', false), "+
+		"set_config('tcp_keepalives_interval', '10', false), set_config('tcp_keepalives_count', '3', false)")
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", key).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !acquired {
+		conn.Close()
+		return nil, nil
+	}
+
+	return &AdvisoryLock{conn: conn, key: key}, nil
+}
+
+// Check makes sure the connection holding the lock is still alive, when it is
+// not the lock is gone
+func (l *AdvisoryLock) Check() error {
+	return l.conn.PingContext(context.Background())
+}
+
+// Release unlocks and returns the connection to the pool. When unlocking
+// fails the connection is closed instead, which releases the lock as well.
+func (l *AdvisoryLock) Release() error {
+	_, err := l.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", l.key)
+	if err != nil {
+		l.conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+	l.conn.Close()
+	return err
+}
@@ -0,0 +1,133 @@
+package notificationPreferenceRepository
+
+import (
+	"errors"
+	"permit-app/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationPreferenceRepository interface {
+	FindByUserID(userID int64) ([]model.NotificationPreference, error)
+	FindChannels(userIDs []int64, eventType string) (map[int64]model.NotificationChannels, error)
+	SavePreferences(preferences []model.NotificationPreference) error
+	FindRulesByDomainID(domainID int64) ([]model.NotificationRecipientRule, error)
+	FindRule(domainID int64, eventType string) (*model.NotificationRecipientRule, error)
+	SaveRules(rules []model.NotificationRecipientRule) error
+	DeleteRule(domainID int64, eventType string) error
+	FindDigestSetting(userID int64) (*model.NotificationDigestSetting, error)
+	SaveDigestFrequency(userID int64, frequency string) error
+	MarkDigestSent(userID int64, sentAt time.Time) error
+}
+
+type notificationPreferenceRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationPreferenceRepository(db *gorm.DB) NotificationPreferenceRepository {
+	return &notificationPreferenceRepository{db: db}
+}
+
+func (r *notificationPreferenceRepository) FindByUserID(userID int64) ([]model.NotificationPreference, error) {
+	var preferences []model.NotificationPreference
+	err := r.db.Where("user_id = ?", userID).Find(&preferences).Error
+	return preferences, err
+}
+
+// FindChannels returns the channels each user receives the event on. Users
+// without a preference for the event get the default channels.
+func (r *notificationPreferenceRepository) FindChannels(userIDs []int64, eventType string) (map[int64]model.NotificationChannels, error) {
+	channels := make(map[int64]model.NotificationChannels, len(userIDs))
+	for _, userID := range userIDs {
+		channels[userID] = model.DefaultNotificationChannels
+	}
+	if len(userIDs) == 0 {
+		return channels, nil
+	}
+
+	var preferences []model.NotificationPreference
+	err := r.db.Where("user_id IN ? AND event_type = ?", userIDs, eventType).Find(&preferences).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range preferences {
+		channels[preferences[i].UserID] = preferences[i].Channels()
+	}
+
+	return channels, nil
+}
+
+// SavePreferences inserts the preferences, replacing the channels of ones the
+// user already has for the event
+func (r *notificationPreferenceRepository) SavePreferences(preferences []model.NotificationPreference) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "event_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"in_app", "email", "digest", "updated_at"}),
+	}).Create(&preferences).Error
+}
+
+func (r *notificationPreferenceRepository) FindRulesByDomainID(domainID int64) ([]model.NotificationRecipientRule, error) {
+	var rules []model.NotificationRecipientRule
+	err := r.db.Where("domain_id = ?", domainID).Find(&rules).Error
+	return rules, err
+}
+
+// FindRule returns the domain's recipient rule for the event, or nil when the
+// built-in recipients apply
+func (r *notificationPreferenceRepository) FindRule(domainID int64, eventType string) (*model.NotificationRecipientRule, error) {
+	var rule model.NotificationRecipientRule
+	err := r.db.Where("domain_id = ? AND event_type = ?", domainID, eventType).First(&rule).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// SaveRules inserts the rules, replacing the domain's existing rule for an event
+func (r *notificationPreferenceRepository) SaveRules(rules []model.NotificationRecipientRule) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "domain_id"}, {Name: "event_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"notify_responsible", "role_codes", "updated_at"}),
+	}).Create(&rules).Error
+}
+
+func (r *notificationPreferenceRepository) DeleteRule(domainID int64, eventType string) error {
+	return r.db.Where("domain_id = ? AND event_type = ?", domainID, eventType).Delete(&model.NotificationRecipientRule{}).Error
+}
+
+// FindDigestSetting returns the user's digest setting, or nil when the user has
+// not chosen a frequency and no digest was sent yet
+func (r *notificationPreferenceRepository) FindDigestSetting(userID int64) (*model.NotificationDigestSetting, error) {
+	var setting model.NotificationDigestSetting
+	err := r.db.Where("user_id = ?", userID).First(&setting).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &setting, nil
+}
+
+func (r *notificationPreferenceRepository) SaveDigestFrequency(userID int64, frequency string) error {
+	setting := model.NotificationDigestSetting{UserID: userID, Frequency: frequency}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"frequency", "updated_at"}),
+	}).Create(&setting).Error
+}
+
+// MarkDigestSent records when the user's last digest was sent, keeping the
+// chosen frequency
+func (r *notificationPreferenceRepository) MarkDigestSent(userID int64, sentAt time.Time) error {
+	setting := model.NotificationDigestSetting{UserID: userID, Frequency: model.DefaultDigestFrequency, LastSentAt: &sentAt}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_sent_at", "updated_at"}),
+	}).Create(&setting).Error
+}
@@ -1,9 +1,13 @@
 package notificationRepository
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"permit-app/model"
 	"time"
 
+	"github.com/jackc/pgx/v5/stdlib"
 	"gorm.io/gorm"
 )
 
@@ -11,12 +15,20 @@ type NotificationRepository interface {
 	Create(notification *model.Notification) error
 	FindByUserID(userID int64, limit int, offset int) ([]model.Notification, error)
 	FindUnreadByUserID(userID int64) ([]model.Notification, error)
+	FindByUserIDAfter(userID int64, afterID int64, limit int) ([]model.Notification, error)
 	CountUnreadByUserID(userID int64) (int64, error)
 	MarkAsRead(notificationIDs []int64, userID int64) error
 	MarkAllAsRead(userID int64) error
 	FindByID(id int64) (*model.Notification, error)
 	Delete(id int64) error
-	CheckExistingNotification(permitID int64, notificationType string, createdAfter time.Time) (bool, error)
+	CheckExistingReminder(permitID int64, expiryDate time.Time, reminderDays int) (bool, error)
+	CheckExistingObligationReminder(obligationID int64, dueDate time.Time, reminderDays int) (bool, error)
+	SaveDispatch(dispatch *model.NotificationDispatch) error
+	FindPendingDigestUserIDs() ([]int64, error)
+	FindPendingDigestItems(userID int64) ([]model.NotificationDigestItem, error)
+	CountPendingDigestItems(userID int64) (int64, error)
+	QueueDigestEmail(email *model.EmailOutbox, itemIDs []int64, sentAt time.Time) error
+	ListenCreated(ctx context.Context, handle func(event model.NotificationCreatedEvent)) error
 }
 
 type notificationRepository struct {
@@ -51,6 +63,18 @@ func (r *notificationRepository) FindUnreadByUserID(userID int64) ([]model.Notif
 	return notifications, err
 }
 
+// FindByUserIDAfter returns the user's notifications created after the one with
+// afterID, oldest first
+func (r *notificationRepository) FindByUserIDAfter(userID int64, afterID int64, limit int) ([]model.Notification, error) {
+	var notifications []model.Notification
+	err := r.db.Where("user_id = ? AND id > ?", userID, afterID).
+		Preload("Permit").
+		Order("id ASC").
+		Limit(limit).
+		Find(&notifications).Error
+	return notifications, err
+}
+
 func (r *notificationRepository) CountUnreadByUserID(userID int64) (int64, error) {
 	var count int64
 	err := r.db.Model(&model.Notification{}).
@@ -92,10 +116,135 @@ func (r *notificationRepository) Delete(id int64) error {
 	return r.db.Delete(&model.Notification{}, id).Error
 }
 
-func (r *notificationRepository) CheckExistingNotification(permitID int64, notificationType string, createdAfter time.Time) (bool, error) {
+func (r *notificationRepository) CheckExistingReminder(permitID int64, expiryDate time.Time, reminderDays int) (bool, error) {
 	var count int64
-	err := r.db.Model(&model.Notification{}).
-		Where("permit_id = ? AND type = ? AND created_at >= ?", permitID, notificationType, createdAfter).
+	err := r.db.Model(&model.ReminderDelivery{}).
+		Where("permit_id = ? AND obligation_id IS NULL AND expiry_date = ? AND reminder_days = ?", permitID, expiryDate.Format("2006-01-02"), reminderDays).
 		Count(&count).Error
 	return count > 0, err
 }
+
+func (r *notificationRepository) CheckExistingObligationReminder(obligationID int64, dueDate time.Time, reminderDays int) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.ReminderDelivery{}).
+		Where("obligation_id = ? AND due_date = ? AND reminder_days = ?", obligationID, dueDate.Format("2006-01-02"), reminderDays).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// SaveDispatch saves the notifications, digest items, queued emails and the
+// reminder delivery or escalation of one notification event together
+func (r *notificationRepository) SaveDispatch(dispatch *model.NotificationDispatch) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(dispatch.Notifications) > 0 {
+			if err := tx.Create(dispatch.Notifications).Error; err != nil {
+				return err
+			}
+		}
+		if len(dispatch.DigestItems) > 0 {
+			if err := tx.Create(dispatch.DigestItems).Error; err != nil {
+				return err
+			}
+		}
+		if len(dispatch.Emails) > 0 {
+			if err := tx.Create(dispatch.Emails).Error; err != nil {
+				return err
+			}
+		}
+		if dispatch.Reminder != nil {
+			if err := tx.Create(dispatch.Reminder).Error; err != nil {
+				return err
+			}
+		}
+		if dispatch.Escalation != nil {
+			if err := tx.Create(dispatch.Escalation).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindPendingDigestUserIDs returns the users with items waiting for their next digest
+func (r *notificationRepository) FindPendingDigestUserIDs() ([]int64, error) {
+	var userIDs []int64
+	err := r.db.Model(&model.NotificationDigestItem{}).
+		Where("sent_at IS NULL").
+		Distinct().
+		Order("user_id").
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+func (r *notificationRepository) FindPendingDigestItems(userID int64) ([]model.NotificationDigestItem, error) {
+	var items []model.NotificationDigestItem
+	err := r.db.Where("user_id = ? AND sent_at IS NULL", userID).
+		Preload("Domain").
+		Order("domain_id, created_at").
+		Find(&items).Error
+	return items, err
+}
+
+func (r *notificationRepository) CountPendingDigestItems(userID int64) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.NotificationDigestItem{}).
+		Where("user_id = ? AND sent_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// QueueDigestEmail queues the digest email and marks its items sent together
+func (r *notificationRepository) QueueDigestEmail(email *model.EmailOutbox, itemIDs []int64, sentAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(email).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.NotificationDigestItem{}).
+			Where("id IN ?", itemIDs).
+			Update("sent_at", sentAt).Error
+	})
+}
+
+// ListenCreated calls handle for every notification inserted by any instance,
+// as announced by the notify_notification_created trigger. It holds a
+// dedicated connection until ctx is done or the connection fails.
+func (r *notificationRepository) ListenCreated(ctx context.Context, handle func(event model.NotificationCreatedEvent)) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected database driver connection %T", driverConn)
+		}
+		pgConn := stdlibConn.Conn()
+
+		if _, err := pgConn.Exec(ctx, "LISTEN "+model.NotificationCreatedChannel); err != nil {
+			return err
+		}
+		// The connection goes back to the pool, stop listening on it
+		defer pgConn.Exec(context.Background(), "UNLISTEN *")
+
+		for {
+			notification, err := pgConn.WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+
+			// Payloads come from the trigger only, skip anything else sent on the channel
+			var event model.NotificationCreatedEvent
+			if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+				continue
+			}
+			handle(event)
+		}
+	})
+}
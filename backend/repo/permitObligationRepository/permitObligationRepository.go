@@ -0,0 +1,129 @@
+package permitObligationRepository
+
+import (
+	"errors"
+	"permit-app/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PermitObligationRepository interface {
+	Create(obligation *model.PermitObligation) error
+	FindByID(permitID int64, id int64) (*model.PermitObligation, error)
+	FindByPermitID(permitID int64) ([]model.PermitObligation, error)
+	Update(obligation *model.PermitObligation) error
+	Delete(id int64) error
+	Complete(obligation *model.PermitObligation, completion *model.PermitObligationCompletion) error
+	CreateEvidence(evidence *model.PermitObligationEvidence) error
+	FindEvidenceByID(obligationID int64, evidenceID int64) (*model.PermitObligationEvidence, error)
+	FindEvidenceByObligationID(obligationID int64) ([]model.PermitObligationEvidence, error)
+	FindOpenDueBefore(until time.Time, permitStatuses []string) ([]model.PermitObligation, error)
+}
+
+type permitObligationRepository struct {
+	db *gorm.DB
+}
+
+func NewPermitObligationRepository(db *gorm.DB) PermitObligationRepository {
+	return &permitObligationRepository{db: db}
+}
+
+func completionsByDueDate(db *gorm.DB) *gorm.DB {
+	return db.Order("due_date DESC, id DESC")
+}
+
+func (r *permitObligationRepository) Create(obligation *model.PermitObligation) error {
+	return r.db.Create(obligation).Error
+}
+
+func (r *permitObligationRepository) FindByID(permitID int64, id int64) (*model.PermitObligation, error) {
+	var obligation model.PermitObligation
+	err := r.db.Preload("ResponsibleUser").
+		Preload("Completions", completionsByDueDate).
+		Preload("Completions.CompletedByUser").
+		Preload("Completions.Evidence").
+		Where("id = ? AND permit_id = ?", id, permitID).
+		First(&obligation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("obligation not found")
+		}
+		return nil, err
+	}
+	return &obligation, nil
+}
+
+func (r *permitObligationRepository) FindByPermitID(permitID int64) ([]model.PermitObligation, error) {
+	var obligations []model.PermitObligation
+	err := r.db.Preload("ResponsibleUser").
+		Preload("Completions", completionsByDueDate).
+		Preload("Completions.CompletedByUser").
+		Preload("Completions.Evidence").
+		Where("permit_id = ?", permitID).
+		Order("status ASC, next_due_date ASC, id ASC").
+		Find(&obligations).Error
+	return obligations, err
+}
+
+func (r *permitObligationRepository) Update(obligation *model.PermitObligation) error {
+	return r.db.Omit("Permit", "ResponsibleUser", "Completions").Save(obligation).Error
+}
+
+func (r *permitObligationRepository) Delete(id int64) error {
+	return r.db.Delete(&model.PermitObligation{}, id).Error
+}
+
+// Complete stores the completion and the obligation's next due date and status in one transaction
+func (r *permitObligationRepository) Complete(obligation *model.PermitObligation, completion *model.PermitObligationCompletion) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(completion).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.PermitObligation{}).
+			Where("id = ?", obligation.ID).
+			Updates(map[string]interface{}{
+				"next_due_date": obligation.NextDueDate,
+				"status":        obligation.Status,
+			}).Error
+	})
+}
+
+func (r *permitObligationRepository) CreateEvidence(evidence *model.PermitObligationEvidence) error {
+	return r.db.Create(evidence).Error
+}
+
+func (r *permitObligationRepository) FindEvidenceByID(obligationID int64, evidenceID int64) (*model.PermitObligationEvidence, error) {
+	var evidence model.PermitObligationEvidence
+	err := r.db.Joins("JOIN permit_obligation_completions c ON c.id = permit_obligation_evidence.completion_id").
+		Where("permit_obligation_evidence.id = ? AND c.obligation_id = ?", evidenceID, obligationID).
+		First(&evidence).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("evidence not found")
+		}
+		return nil, err
+	}
+	return &evidence, nil
+}
+
+func (r *permitObligationRepository) FindEvidenceByObligationID(obligationID int64) ([]model.PermitObligationEvidence, error) {
+	var evidence []model.PermitObligationEvidence
+	err := r.db.Joins("JOIN permit_obligation_completions c ON c.id = permit_obligation_evidence.completion_id").
+		Where("c.obligation_id = ?", obligationID).
+		Find(&evidence).Error
+	return evidence, err
+}
+
+// FindOpenDueBefore returns the open obligations due on or before until, for
+// permits in one of the given statuses that are not in the trash
+func (r *permitObligationRepository) FindOpenDueBefore(until time.Time, permitStatuses []string) ([]model.PermitObligation, error) {
+	var obligations []model.PermitObligation
+	err := r.db.Preload("Permit").
+		Joins("JOIN permits p ON p.id = permit_obligations.permit_id").
+		Where("permit_obligations.status = ? AND permit_obligations.next_due_date <= ?", model.ObligationStatusOpen, until).
+		Where("p.status IN ? AND p.deleted_at IS NULL", permitStatuses).
+		Order("permit_obligations.next_due_date ASC").
+		Find(&obligations).Error
+	return obligations, err
+}
@@ -2,6 +2,8 @@ package permitRepository
 
 import (
 	"errors"
+	"fmt"
+	"permit-app/helper"
 	"permit-app/model"
 	"time"
 
@@ -11,12 +13,34 @@ import (
 type PermitRepository interface {
 	Create(permit *model.Permit) error
 	FindByID(id int64) (*model.Permit, error)
+	FindByIDInDomain(id int64, domainID *int64) (*model.Permit, error)
 	FindByPermitNoAndDomainID(permitNo string, domainID int64) (*model.Permit, error)
 	FindAll(filter *model.PermitListRequest) ([]model.Permit, int64, error)
 	Update(id int64, permit *model.Permit) error
+	UpdateFields(id int64, permit *model.Permit, fields []string) error
 	Delete(id int64) error
+	FindDeleted(filter *model.PermitListRequest) ([]model.Permit, int64, error)
+	FindDeletedByIDInDomain(id int64, domainID *int64) (*model.Permit, error)
+	FindEvidenceFilePaths(permitID int64) ([]string, error)
+	Restore(id int64) error
+	Purge(id int64) error
 	FindExpiringPermits(startDate time.Time, endDate time.Time) ([]model.Permit, error)
+	FindByStatusesExpiringBefore(statuses []string, before time.Time) ([]model.Permit, error)
+	CreateBatch(permits []*model.Permit) error
+	FindForCalendar(domainID int64, userID int64, statuses []string) ([]model.Permit, error)
+	FindForDashboard(domainID *int64, divisionID *int64) ([]model.Permit, error)
 	Search(query string, filter *model.PermitListRequest) ([]model.Permit, int64, error)
+	FindByPreviousPermitID(previousPermitID int64) (*model.Permit, error)
+	Renew(previousPermitID int64, renewal *model.Permit) error
+	Supersede(id int64) error
+	CreateDocument(document *model.PermitDocument) error
+	FindDocumentsByPermitID(permitID int64) ([]model.PermitDocument, error)
+	FindDocumentByID(permitID int64, documentID int64) (*model.PermitDocument, error)
+	DeleteDocument(id int64) error
+	StartApproval(permit *model.Permit, approvals []model.PermitApproval) error
+	FindApprovals(permitID int64) ([]model.PermitApproval, error)
+	DecideApproval(permit *model.Permit, fields []string, approvals []model.PermitApproval) (bool, error)
+	FindPendingApproval(domainID *int64) ([]model.Permit, error)
 }
 
 type permitRepository struct {
@@ -27,13 +51,31 @@ func NewPermitRepository(db *gorm.DB) PermitRepository {
 	return &permitRepository{db: db}
 }
 
+// notDeleted is the condition that keeps trashed permits out of a query
+const notDeleted = "permits.deleted_at IS NULL"
+
+// documentsByCreatedAt keeps preloaded documents in upload order
+func documentsByCreatedAt(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at ASC, id ASC")
+}
+
 func (r *permitRepository) Create(permit *model.Permit) error {
 	return r.db.Create(permit).Error
 }
 
 func (r *permitRepository) FindByID(id int64) (*model.Permit, error) {
+	return r.FindByIDInDomain(id, nil)
+}
+
+// FindByIDInDomain finds a permit that belongs to the domain. A nil domainID
+// matches permits of every domain. Trashed permits are not found.
+func (r *permitRepository) FindByIDInDomain(id int64, domainID *int64) (*model.Permit, error) {
 	var permit model.Permit
-	err := r.db.Preload("Domain").Preload("Division.Domain").Preload("PermitType.Division.Domain").Preload("ResponsiblePerson").Preload("ResponsibleDocPerson").Where("id = ?", id).First(&permit).Error
+	query := r.db.Preload("Domain").Preload("Division.Domain").Preload("PermitType.Division.Domain").Preload("ResponsiblePerson").Preload("ResponsibleDocPerson").Preload("Documents", documentsByCreatedAt).Preload("Documents.DocumentType").Where("id = ?", id).Where(notDeleted)
+	if domainID != nil {
+		query = query.Where("domain_id = ?", *domainID)
+	}
+	err := query.First(&permit).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("permit not found")
@@ -43,6 +85,8 @@ func (r *permitRepository) FindByID(id int64) (*model.Permit, error) {
 	return &permit, nil
 }
 
+// FindByPermitNoAndDomainID includes trashed permits, which keep their number
+// until they are purged
 func (r *permitRepository) FindByPermitNoAndDomainID(permitNo string, domainID int64) (*model.Permit, error) {
 	var permit model.Permit
 	err := r.db.Where("permit_no = ? AND domain_id = ?", permitNo, domainID).First(&permit).Error
@@ -59,7 +103,7 @@ func (r *permitRepository) FindAll(filter *model.PermitListRequest) ([]model.Per
 	var permits []model.Permit
 	var total int64
 
-	query := r.db.Model(&model.Permit{}).Preload("Domain").Preload("Division.Domain").Preload("PermitType.Division.Domain").Preload("ResponsiblePerson").Preload("ResponsibleDocPerson")
+	query := r.db.Model(&model.Permit{}).Preload("Domain").Preload("Division.Domain").Preload("PermitType.Division.Domain").Preload("ResponsiblePerson").Preload("ResponsibleDocPerson").Preload("Documents", documentsByCreatedAt).Preload("Documents.DocumentType").Where(notDeleted)
 
 	if filter.DomainID != nil {
 		query = query.Where("domain_id = ?", *filter.DomainID)
@@ -103,7 +147,7 @@ func (r *permitRepository) FindAll(filter *model.PermitListRequest) ([]model.Per
 		query = query.Offset(offset).Limit(filter.Limit)
 	}
 
-	err = query.Order("created_at DESC").Find(&permits).Error
+	err = query.Order("created_at DESC, id DESC").Find(&permits).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -115,25 +159,186 @@ func (r *permitRepository) Update(id int64, permit *model.Permit) error {
 	return r.db.Model(&model.Permit{}).Where("id = ?", id).Updates(permit).Error
 }
 
+// UpdateFields writes only the given columns, including zero and nil values
+func (r *permitRepository) UpdateFields(id int64, permit *model.Permit, fields []string) error {
+	return r.db.Model(&model.Permit{}).Where("id = ?", id).Select(fields).Updates(permit).Error
+}
+
+// Delete moves the permit to the trash. Its documents stay in place so the
+// permit can be restored.
 func (r *permitRepository) Delete(id int64) error {
+	return r.db.Model(&model.Permit{}).
+		Where("id = ?", id).
+		Where(notDeleted).
+		Update("deleted_at", time.Now()).Error
+}
+
+// FindDeleted returns the trashed permits, most recently deleted first
+func (r *permitRepository) FindDeleted(filter *model.PermitListRequest) ([]model.Permit, int64, error) {
+	var permits []model.Permit
+	var total int64
+
+	query := r.db.Model(&model.Permit{}).Preload("Domain").Preload("Division").Preload("PermitType").Preload("ResponsiblePerson").Preload("Documents", documentsByCreatedAt).
+		Where("permits.deleted_at IS NOT NULL")
+
+	if filter.DomainID != nil {
+		query = query.Where("domain_id = ?", *filter.DomainID)
+	}
+
+	if filter.DivisionID != nil {
+		query = query.Where("division_id = ?", *filter.DivisionID)
+	}
+
+	if filter.PermitTypeID != nil {
+		query = query.Where("permit_type_id = ?", *filter.PermitTypeID)
+	}
+
+	if filter.Name != "" {
+		query = query.Where("name LIKE ?", "%"+filter.Name+"%")
+	}
+
+	if filter.PermitNo != "" {
+		query = query.Where("permit_no LIKE ?", "%"+filter.PermitNo+"%")
+	}
+
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Page > 0 && filter.Limit > 0 {
+		offset := (filter.Page - 1) * filter.Limit
+		query = query.Offset(offset).Limit(filter.Limit)
+	}
+
+	err = query.Order("deleted_at DESC, id DESC").Find(&permits).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return permits, total, nil
+}
+
+// FindDeletedByIDInDomain finds a trashed permit that belongs to the domain. A
+// nil domainID matches permits of every domain.
+func (r *permitRepository) FindDeletedByIDInDomain(id int64, domainID *int64) (*model.Permit, error) {
+	var permit model.Permit
+	query := r.db.Preload("Domain").Preload("Division.Domain").Preload("PermitType.Division.Domain").Preload("ResponsiblePerson").Preload("ResponsibleDocPerson").Preload("Documents", documentsByCreatedAt).Preload("Documents.DocumentType").
+		Where("id = ? AND permits.deleted_at IS NOT NULL", id)
+	if domainID != nil {
+		query = query.Where("domain_id = ?", *domainID)
+	}
+	err := query.First(&permit).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("permit not found")
+		}
+		return nil, err
+	}
+	return &permit, nil
+}
+
+// FindEvidenceFilePaths returns the stored files of the evidence recorded for
+// the permit's obligations
+func (r *permitRepository) FindEvidenceFilePaths(permitID int64) ([]string, error) {
+	var paths []string
+	err := r.db.Model(&model.PermitObligationEvidence{}).
+		Joins("JOIN permit_obligation_completions c ON c.id = permit_obligation_evidence.completion_id").
+		Joins("JOIN permit_obligations o ON o.id = c.obligation_id").
+		Where("o.permit_id = ?", permitID).
+		Pluck("permit_obligation_evidence.file_path", &paths).Error
+	return paths, err
+}
+
+// Restore takes the permit out of the trash
+func (r *permitRepository) Restore(id int64) error {
+	return r.db.Model(&model.Permit{}).
+		Where("id = ? AND permits.deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil).Error
+}
+
+// Purge removes the permit row for good. Documents, approvals and obligations
+// are removed by the database cascade, revisions are kept.
+func (r *permitRepository) Purge(id int64) error {
 	return r.db.Delete(&model.Permit{}, id).Error
 }
 
 func (r *permitRepository) FindExpiringPermits(startDate time.Time, endDate time.Time) ([]model.Permit, error) {
-var permits []model.Permit
-err := r.db.Where("expiry_date BETWEEN ? AND ? AND status = ?", startDate, endDate, "active").
-Preload("Domain").
-Preload("ResponsiblePerson").
-Preload("ResponsibleDocPerson").
-Find(&permits).Error
-return permits, err
+	var permits []model.Permit
+	// Permits that already have an approved renewal in place no longer need expiry
+	// reminders. A renewal still waiting for approval may be rejected.
+	statuses := []string{model.PermitStatusActive, model.PermitStatusExpiring, model.PermitStatusInRenewal, model.PermitStatusExpired}
+	unapproved := []string{model.PermitStatusDraft, model.PermitStatusPending}
+	err := r.db.Where("expiry_date BETWEEN ? AND ? AND status IN ?", startDate, endDate, statuses).
+		Where(notDeleted).
+		Where("NOT EXISTS (SELECT 1 FROM permits renewals WHERE renewals.previous_permit_id = permits.id AND renewals.deleted_at IS NULL AND renewals.status NOT IN ?)", unapproved).
+		Preload("Domain").
+		Preload("Division").
+		Preload("ResponsiblePerson").
+		Preload("ResponsibleDocPerson").
+		Find(&permits).Error
+	return permits, err
+}
+
+// CreateBatch inserts all permits in one transaction, none are kept if one fails
+func (r *permitRepository) CreateBatch(permits []*model.Permit) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, permit := range permits {
+			if err := tx.Create(permit).Error; err != nil {
+				return fmt.Errorf("permit %s: %w", permit.PermitNo, err)
+			}
+		}
+		return nil
+	})
+}
+
+// FindForCalendar returns the permits of the domain together with the permits
+// the user is responsible for in any domain
+func (r *permitRepository) FindForCalendar(domainID int64, userID int64, statuses []string) ([]model.Permit, error) {
+	var permits []model.Permit
+	err := r.db.Preload("Domain").Preload("Division").Preload("PermitType").Preload("ResponsiblePerson").
+		Where("status IN ?", statuses).
+		Where("domain_id = ? OR responsible_person_id = ? OR responsible_doc_person_id = ?", domainID, userID, userID).
+		Where(notDeleted).
+		Order("expiry_date ASC").
+		Find(&permits).Error
+	return permits, err
+}
+
+// FindForDashboard returns the permits to aggregate on the compliance dashboard,
+// with their domain, division, permit type and documents
+func (r *permitRepository) FindForDashboard(domainID *int64, divisionID *int64) ([]model.Permit, error) {
+	var permits []model.Permit
+	query := r.db.Preload("Domain").Preload("Division").Preload("PermitType").Preload("Documents", documentsByCreatedAt).Where(notDeleted)
+
+	if domainID != nil {
+		query = query.Where("domain_id = ?", *domainID)
+	}
+
+	if divisionID != nil {
+		query = query.Where("division_id = ?", *divisionID)
+	}
+
+	err := query.Order("domain_id ASC, division_id ASC, expiry_date ASC").Find(&permits).Error
+	return permits, err
+}
+
+// FindByStatusesExpiringBefore returns permits in one of the statuses whose expiry date is before the given date
+func (r *permitRepository) FindByStatusesExpiringBefore(statuses []string, before time.Time) ([]model.Permit, error) {
+	var permits []model.Permit
+	err := r.db.Preload("Documents", documentsByCreatedAt).
+		Where("status IN ? AND expiry_date < ?", statuses, before).
+		Where(notDeleted).
+		Order("expiry_date ASC").
+		Find(&permits).Error
+	return permits, err
 }
 
 func (r *permitRepository) Search(query string, filter *model.PermitListRequest) ([]model.Permit, int64, error) {
 	var permits []model.Permit
 	var total int64
 
-	db := r.db.Model(&model.Permit{}).Preload("Domain").Preload("Division.Domain").Preload("PermitType.Division.Domain").Preload("ResponsiblePerson").Preload("ResponsibleDocPerson")
+	db := r.db.Model(&model.Permit{}).Preload("Domain").Preload("Division.Domain").Preload("PermitType.Division.Domain").Preload("ResponsiblePerson").Preload("ResponsibleDocPerson").Preload("Documents", documentsByCreatedAt).Preload("Documents.DocumentType").Where(notDeleted)
 
 	// Search across multiple fields
 	searchPattern := "%" + query + "%"
@@ -166,10 +371,148 @@ func (r *permitRepository) Search(query string, filter *model.PermitListRequest)
 		db = db.Offset(offset).Limit(filter.Limit)
 	}
 
-	err = db.Order("created_at DESC").Find(&permits).Error
+	err = db.Order("created_at DESC, id DESC").Find(&permits).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
 	return permits, total, nil
 }
+
+// FindByPreviousPermitID skips a trashed renewal, the previous period can be
+// renewed again while it is in the trash
+func (r *permitRepository) FindByPreviousPermitID(previousPermitID int64) (*model.Permit, error) {
+	var permit model.Permit
+	err := r.db.Preload("Domain").Preload("Division.Domain").Preload("PermitType.Division.Domain").Preload("ResponsiblePerson").Preload("ResponsibleDocPerson").Preload("Documents", documentsByCreatedAt).Preload("Documents.DocumentType").Where("previous_permit_id = ?", previousPermitID).Where(notDeleted).First(&permit).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &permit, nil
+}
+
+// Renew inserts the renewal period and marks the previous period as in renewal in one transaction
+func (r *permitRepository) Renew(previousPermitID int64, renewal *model.Permit) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(renewal).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.Permit{}).
+			Where("id = ?", previousPermitID).
+			Update("status", model.PermitStatusInRenewal).Error
+	})
+}
+
+// Supersede marks a renewed period as superseded
+func (r *permitRepository) Supersede(id int64) error {
+	return r.db.Model(&model.Permit{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":        model.PermitStatusSuperseded,
+			"superseded_at": time.Now(),
+		}).Error
+}
+
+func (r *permitRepository) CreateDocument(document *model.PermitDocument) error {
+	return r.db.Create(document).Error
+}
+
+func (r *permitRepository) FindDocumentsByPermitID(permitID int64) ([]model.PermitDocument, error) {
+	var documents []model.PermitDocument
+	err := r.db.Preload("DocumentType").Where("permit_id = ?", permitID).Order("created_at ASC, id ASC").Find(&documents).Error
+	return documents, err
+}
+
+func (r *permitRepository) FindDocumentByID(permitID int64, documentID int64) (*model.PermitDocument, error) {
+	var document model.PermitDocument
+	err := r.db.Preload("DocumentType").Where("id = ? AND permit_id = ?", documentID, permitID).First(&document).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("permit document not found")
+		}
+		return nil, err
+	}
+	return &document, nil
+}
+
+func (r *permitRepository) DeleteDocument(id int64) error {
+	return r.db.Delete(&model.PermitDocument{}, id).Error
+}
+
+// StartApproval opens a new approval round: the steps of earlier rounds are
+// closed, the new steps created and the permit moved to pending in one transaction
+func (r *permitRepository) StartApproval(permit *model.Permit, approvals []model.PermitApproval) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.PermitApproval{}).
+			Where("permit_id = ? AND status = ?", permit.ID, true).
+			Update("status", false).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Create(&approvals).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.Permit{}).
+			Where("id = ?", permit.ID).
+			Select("status", "requested_by", "approval_status_id").
+			Updates(permit).Error
+	})
+}
+
+func (r *permitRepository) FindApprovals(permitID int64) ([]model.PermitApproval, error) {
+	var approvals []model.PermitApproval
+	err := r.db.Preload("Approver").Preload("ApprovalStatus").
+		Where("permit_id = ?", permitID).
+		Order("created_at ASC, sequence ASC, id ASC").
+		Find(&approvals).Error
+	return approvals, err
+}
+
+// errApprovalDecided rolls back a decision on a step that is no longer waiting
+var errApprovalDecided = errors.New("approval step is no longer waiting")
+
+// DecideApproval stores the decided approval steps together with the permit
+// fields that follow from the decision. It returns false and stores nothing
+// when one of the steps was decided in the meantime.
+func (r *permitRepository) DecideApproval(permit *model.Permit, fields []string, approvals []model.PermitApproval) (bool, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range approvals {
+			result := tx.Model(&model.PermitApproval{}).
+				Where("id = ? AND approval_status_id = ?", approvals[i].ID, helper.ApprovalStatusWaiting).
+				Select("approved_by", "approval_status_id", "approval_date", "note").
+				Updates(&approvals[i])
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected != 1 {
+				return errApprovalDecided
+			}
+		}
+
+		return tx.Model(&model.Permit{}).Where("id = ?", permit.ID).Select(fields).Updates(permit).Error
+	})
+	if errors.Is(err, errApprovalDecided) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// FindPendingApproval returns the permits waiting for a decision, with the steps
+// of their current round. A nil domainID matches permits of every domain.
+func (r *permitRepository) FindPendingApproval(domainID *int64) ([]model.Permit, error) {
+	var permits []model.Permit
+	query := r.db.Preload("Domain").Preload("Division").Preload("PermitType").
+		Preload("Approvals", "status = ?", true).
+		Where("status = ?", model.PermitStatusPending).
+		Where(notDeleted)
+	if domainID != nil {
+		query = query.Where("domain_id = ?", *domainID)
+	}
+	err := query.Order("updated_at ASC, id ASC").Find(&permits).Error
+	return permits, err
+}
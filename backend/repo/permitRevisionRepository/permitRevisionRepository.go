@@ -0,0 +1,71 @@
+package permitRevisionRepository
+
+import (
+	"errors"
+	"permit-app/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PermitRevisionRepository interface {
+	Create(revision *model.PermitRevision) error
+	FindByPermitID(permitID int64) ([]model.PermitRevision, error)
+	FindByPermitIDAndRevision(permitID int64, revision int) (*model.PermitRevision, error)
+	FindLatest(permitID int64) (*model.PermitRevision, error)
+}
+
+type permitRevisionRepository struct {
+	db *gorm.DB
+}
+
+func NewPermitRevisionRepository(db *gorm.DB) PermitRevisionRepository {
+	return &permitRevisionRepository{db: db}
+}
+
+// Create assigns the next revision number for the permit and inserts the revision
+func (r *permitRevisionRepository) Create(revision *model.PermitRevision) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var latest model.PermitRevision
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("permit_id = ?", revision.PermitID).
+			Order("revision DESC").
+			First(&latest).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		revision.Revision = latest.Revision + 1
+		return tx.Create(revision).Error
+	})
+}
+
+func (r *permitRevisionRepository) FindByPermitID(permitID int64) ([]model.PermitRevision, error) {
+	var revisions []model.PermitRevision
+	err := r.db.Preload("ChangedByUser").Where("permit_id = ?", permitID).Order("revision DESC").Find(&revisions).Error
+	return revisions, err
+}
+
+func (r *permitRevisionRepository) FindByPermitIDAndRevision(permitID int64, revision int) (*model.PermitRevision, error) {
+	var permitRevision model.PermitRevision
+	err := r.db.Preload("ChangedByUser").Where("permit_id = ? AND revision = ?", permitID, revision).First(&permitRevision).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("permit revision not found")
+		}
+		return nil, err
+	}
+	return &permitRevision, nil
+}
+
+func (r *permitRevisionRepository) FindLatest(permitID int64) (*model.PermitRevision, error) {
+	var permitRevision model.PermitRevision
+	err := r.db.Where("permit_id = ?", permitID).Order("revision DESC").First(&permitRevision).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &permitRevision, nil
+}
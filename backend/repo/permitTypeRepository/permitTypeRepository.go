@@ -5,11 +5,14 @@ import (
 	"permit-app/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PermitTypeRepository interface {
 	Create(permitType *model.PermitType) error
 	FindByID(id int64) (*model.PermitType, error)
+	FindByIDInDomain(id int64, domainID *int64) (*model.PermitType, error)
+	FindByCodeOrNameAndDomainID(value string, domainID int64) (*model.PermitType, error)
 	FindAll(filter *model.PermitTypeListRequest) ([]model.PermitType, int64, error)
 	Update(id int64, permitType *model.PermitType) error
 	Delete(id int64) error
@@ -23,13 +26,31 @@ func NewPermitTypeRepository(db *gorm.DB) PermitTypeRepository {
 	return &permitTypeRepository{db: db}
 }
 
+// inDomain matches permit types of the domain's divisions and shared permit types without a division
+const inDomain = "division_id IS NULL OR division_id IN (SELECT id FROM divisions WHERE domain_id = ?)"
+
+// schedulesByDaysBefore lists reminder offsets from the earliest reminder to the expiry day
+func schedulesByDaysBefore(db *gorm.DB) *gorm.DB {
+	return db.Order("days_before DESC")
+}
+
 func (r *permitTypeRepository) Create(permitType *model.PermitType) error {
 	return r.db.Create(permitType).Error
 }
 
 func (r *permitTypeRepository) FindByID(id int64) (*model.PermitType, error) {
+	return r.FindByIDInDomain(id, nil)
+}
+
+// FindByIDInDomain finds a permit type of one of the domain's divisions or a
+// shared permit type. A nil domainID matches permit types of every domain.
+func (r *permitTypeRepository) FindByIDInDomain(id int64, domainID *int64) (*model.PermitType, error) {
 	var permitType model.PermitType
-	err := r.db.Preload("Division.Domain").Where("id = ?", id).First(&permitType).Error
+	query := r.db.Preload("Division.Domain").Preload("ReminderSchedules", schedulesByDaysBefore).Where("id = ?", id)
+	if domainID != nil {
+		query = query.Where(inDomain, *domainID)
+	}
+	err := query.First(&permitType).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("permit type not found")
@@ -39,11 +60,32 @@ func (r *permitTypeRepository) FindByID(id int64) (*model.PermitType, error) {
 	return &permitType, nil
 }
 
+// FindByCodeOrNameAndDomainID matches the permit type code first, then the name
+// case-insensitively, among permit types of the domain's divisions and shared permit types
+func (r *permitTypeRepository) FindByCodeOrNameAndDomainID(value string, domainID int64) (*model.PermitType, error) {
+	var permitType model.PermitType
+	err := r.db.Where("code = ? OR LOWER(name) = LOWER(?)", value, value).
+		Where(inDomain, domainID).
+		Order(clause.Expr{SQL: "code = ? DESC", Vars: []interface{}{value}}).
+		First(&permitType).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &permitType, nil
+}
+
 func (r *permitTypeRepository) FindAll(filter *model.PermitTypeListRequest) ([]model.PermitType, int64, error) {
 	var permitTypes []model.PermitType
 	var total int64
 
-	query := r.db.Model(&model.PermitType{}).Preload("Division.Domain")
+	query := r.db.Model(&model.PermitType{}).Preload("Division.Domain").Preload("ReminderSchedules", schedulesByDaysBefore)
+
+	if filter.DomainID != nil {
+		query = query.Where(inDomain, *filter.DomainID)
+	}
 
 	if filter.DivisionID != nil && *filter.DivisionID > 0 {
 		query = query.Where("division_id = ?", *filter.DivisionID)
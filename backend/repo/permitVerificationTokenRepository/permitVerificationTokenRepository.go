@@ -0,0 +1,70 @@
+package permitVerificationTokenRepository
+
+import (
+	"errors"
+	"permit-app/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PermitVerificationTokenRepository interface {
+	FindActiveByPermitID(permitID int64) (*model.PermitVerificationToken, error)
+	FindActiveByToken(token string) (*model.PermitVerificationToken, error)
+	Replace(token *model.PermitVerificationToken) error
+	RevokeByPermitID(permitID int64, revokedAt time.Time) error
+}
+
+type permitVerificationTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPermitVerificationTokenRepository(db *gorm.DB) PermitVerificationTokenRepository {
+	return &permitVerificationTokenRepository{db: db}
+}
+
+func (r *permitVerificationTokenRepository) FindActiveByPermitID(permitID int64) (*model.PermitVerificationToken, error) {
+	var token model.PermitVerificationToken
+	err := r.db.Where("permit_id = ? AND revoked_at IS NULL", permitID).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// FindActiveByToken finds an unrevoked token together with its permit. The
+// permit is left nil when it is in the trash.
+func (r *permitVerificationTokenRepository) FindActiveByToken(token string) (*model.PermitVerificationToken, error) {
+	var verificationToken model.PermitVerificationToken
+	err := r.db.Preload("Permit", "deleted_at IS NULL").Where("token = ? AND revoked_at IS NULL", token).First(&verificationToken).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &verificationToken, nil
+}
+
+// Replace stores the permit's new token, revoking the previous one. Revoked
+// tokens are kept as a record of the QR codes that were issued.
+func (r *permitVerificationTokenRepository) Replace(token *model.PermitVerificationToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.PermitVerificationToken{}).
+			Where("permit_id = ? AND revoked_at IS NULL", token.PermitID).
+			Update("revoked_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+func (r *permitVerificationTokenRepository) RevokeByPermitID(permitID int64, revokedAt time.Time) error {
+	return r.db.Model(&model.PermitVerificationToken{}).
+		Where("permit_id = ? AND revoked_at IS NULL", permitID).
+		Update("revoked_at", revokedAt).Error
+}
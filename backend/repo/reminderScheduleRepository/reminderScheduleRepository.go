@@ -0,0 +1,67 @@
+package reminderScheduleRepository
+
+import (
+	"permit-app/model"
+
+	"gorm.io/gorm"
+)
+
+type ReminderScheduleRepository interface {
+	FindByPermitTypeID(permitTypeID int64) ([]model.ReminderSchedule, error)
+	FindByDomainID(domainID int64) ([]model.ReminderSchedule, error)
+	ReplaceForPermitType(permitTypeID int64, schedules []model.ReminderSchedule) error
+	ReplaceForDomain(domainID int64, schedules []model.ReminderSchedule) error
+	FindMaxDaysBefore() (int, error)
+}
+
+type reminderScheduleRepository struct {
+	db *gorm.DB
+}
+
+func NewReminderScheduleRepository(db *gorm.DB) ReminderScheduleRepository {
+	return &reminderScheduleRepository{db: db}
+}
+
+func (r *reminderScheduleRepository) FindByPermitTypeID(permitTypeID int64) ([]model.ReminderSchedule, error) {
+	var schedules []model.ReminderSchedule
+	err := r.db.Where("permit_type_id = ?", permitTypeID).Order("days_before DESC").Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *reminderScheduleRepository) FindByDomainID(domainID int64) ([]model.ReminderSchedule, error) {
+	var schedules []model.ReminderSchedule
+	err := r.db.Where("domain_id = ?", domainID).Order("days_before DESC").Find(&schedules).Error
+	return schedules, err
+}
+
+// ReplaceForPermitType swaps the permit type's whole schedule in one transaction
+func (r *reminderScheduleRepository) ReplaceForPermitType(permitTypeID int64, schedules []model.ReminderSchedule) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("permit_type_id = ?", permitTypeID).Delete(&model.ReminderSchedule{}).Error; err != nil {
+			return err
+		}
+		if len(schedules) == 0 {
+			return nil
+		}
+		return tx.Create(&schedules).Error
+	})
+}
+
+// ReplaceForDomain swaps the domain's default schedule in one transaction
+func (r *reminderScheduleRepository) ReplaceForDomain(domainID int64, schedules []model.ReminderSchedule) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("domain_id = ?", domainID).Delete(&model.ReminderSchedule{}).Error; err != nil {
+			return err
+		}
+		if len(schedules) == 0 {
+			return nil
+		}
+		return tx.Create(&schedules).Error
+	})
+}
+
+func (r *reminderScheduleRepository) FindMaxDaysBefore() (int, error) {
+	var maxDays int
+	err := r.db.Model(&model.ReminderSchedule{}).Select("COALESCE(MAX(days_before), 0)").Scan(&maxDays).Error
+	return maxDays, err
+}
@@ -20,6 +20,7 @@ type TaskRepository interface {
 	SetReason(id int64, domainID int64, reason string, updatedBy int64) error
 	SetRevision(id int64, domainID int64, revision *string, updatedBy int64) error
 	GenerateCode(projectID int64) (string, error)
+	FindOpenByAssignee(userID int64) ([]model.Task, error)
 
 	// Approval related
 	CreateApprovalTasks(approvalTasks []model.ApprovalTask) error
@@ -240,6 +241,19 @@ func (r *taskRepository) Delete(id int64, domainID int64) error {
 		Update("deleted_at", now).Error
 }
 
+// FindOpenByAssignee returns the user's assigned tasks that have a due date and are not done yet
+func (r *taskRepository) FindOpenByAssignee(userID int64) ([]model.Task, error) {
+	var tasks []model.Task
+	err := r.db.Where("assigned_id = ? AND deleted_at IS NULL AND due_date IS NOT NULL", userID).
+		Where("done_at IS NULL AND completed_date IS NULL").
+		Preload("Project").
+		Preload("StatusTask").
+		Preload("Priority").
+		Order("due_date ASC").
+		Find(&tasks).Error
+	return tasks, err
+}
+
 func (r *taskRepository) ChangeStatus(id int64, domainID int64, statusID int64, updatedBy int64) error {
 	updates := map[string]interface{}{
 		"status_id":  statusID,
@@ -15,6 +15,7 @@ type UserRepository interface {
 	FindByUsername(username string) (*model.User, error)
 	FindByEmail(email string) (*model.User, error)
 	FindByUsernameOrEmail(usernameOrEmail string) (*model.User, error)
+	FindByNip(nip string) (*model.User, error)
 	FindAll(filter *model.UserListRequest) ([]model.User, int64, error)
 	Update(id int64, user *model.User) error
 	Delete(id int64) error
@@ -99,6 +100,18 @@ func (r *userRepository) FindByUsernameOrEmail(usernameOrEmail string) (*model.U
 	return &user, nil
 }
 
+func (r *userRepository) FindByNip(nip string) (*model.User, error) {
+	var user model.User
+	err := r.db.Where("nip = ?", nip).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *userRepository) FindAll(filter *model.UserListRequest) ([]model.User, int64, error) {
 	var users []model.User
 	var total int64
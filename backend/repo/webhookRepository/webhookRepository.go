@@ -0,0 +1,217 @@
+package webhookRepository
+
+import (
+	"encoding/json"
+	"errors"
+	"permit-app/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository interface {
+	FindSubscriptions(domainID int64) ([]model.WebhookSubscription, error)
+	FindSubscriptionByID(id int64) (*model.WebhookSubscription, error)
+	CreateSubscription(subscription *model.WebhookSubscription) error
+	UpdateSubscription(subscription *model.WebhookSubscription) error
+	DeleteSubscription(id int64) error
+	Enqueue(event *model.WebhookEvent) error
+	CreateDelivery(delivery *model.WebhookDelivery) error
+	ClaimDue(now time.Time, limit int, lease time.Duration) ([]model.WebhookDelivery, error)
+	RecordAttempt(delivery *model.WebhookDelivery, attempt *model.WebhookDeliveryAttempt) error
+	FindDeliveries(subscriptionID int64, filter *model.WebhookDeliveryListRequest) ([]model.WebhookDelivery, int64, error)
+	FindDeliveryByID(id int64) (*model.WebhookDelivery, error)
+	Requeue(id int64, now time.Time) error
+	DeleteDeliveredBefore(before time.Time) (int64, error)
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) FindSubscriptions(domainID int64) ([]model.WebhookSubscription, error) {
+	var subscriptions []model.WebhookSubscription
+	err := r.db.Where("domain_id = ?", domainID).Order("id").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// FindSubscriptionByID returns the subscription, or nil when it does not exist
+func (r *webhookRepository) FindSubscriptionByID(id int64) (*model.WebhookSubscription, error) {
+	var subscription model.WebhookSubscription
+	err := r.db.First(&subscription, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *webhookRepository) CreateSubscription(subscription *model.WebhookSubscription) error {
+	return r.db.Create(subscription).Error
+}
+
+func (r *webhookRepository) UpdateSubscription(subscription *model.WebhookSubscription) error {
+	return r.db.Save(subscription).Error
+}
+
+// DeleteSubscription removes the subscription together with its deliveries
+func (r *webhookRepository) DeleteSubscription(id int64) error {
+	return r.db.Delete(&model.WebhookSubscription{}, id).Error
+}
+
+// Enqueue queues the event for every active subscription of its domain that
+// selected the event type
+func (r *webhookRepository) Enqueue(event *model.WebhookEvent) error {
+	eventTypes, err := json.Marshal([]string{event.Type})
+	if err != nil {
+		return err
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var subscriptions []model.WebhookSubscription
+		err := tx.Where("domain_id = ? AND is_active = ? AND event_types @> ?::jsonb", event.DomainID, true, string(eventTypes)).
+			Find(&subscriptions).Error
+		if err != nil || len(subscriptions) == 0 {
+			return err
+		}
+
+		deliveries, err := event.Deliveries(subscriptions)
+		if err != nil {
+			return err
+		}
+		return tx.Create(deliveries).Error
+	})
+}
+
+func (r *webhookRepository) CreateDelivery(delivery *model.WebhookDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+// ClaimDue marks up to limit deliveries whose next attempt is due as sending,
+// with a lease of the given length, and returns them with their subscription.
+// Deliveries of a worker that stopped mid-post are claimed again once their
+// lease expired. Rows claimed by another worker and deliveries of inactive
+// subscriptions are skipped.
+func (r *webhookRepository) ClaimDue(now time.Time, limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []int64
+		err := tx.Model(&model.WebhookDelivery{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND locked_until < ?)",
+				model.WebhookStatusPending, now, model.WebhookStatusSending, now).
+			Where("subscription_id IN (?)", tx.Model(&model.WebhookSubscription{}).Select("id").Where("is_active = ?", true)).
+			Order("next_attempt_at").
+			Limit(limit).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		err = tx.Model(&model.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":       model.WebhookStatusSending,
+				"locked_until": now.Add(lease),
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Preload("Subscription").Where("id IN ?", ids).Order("next_attempt_at").Find(&deliveries).Error
+	})
+	return deliveries, err
+}
+
+// RecordAttempt logs the attempt and saves the delivery's resulting state together
+func (r *webhookRepository) RecordAttempt(delivery *model.WebhookDelivery, attempt *model.WebhookDeliveryAttempt) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.WebhookDelivery{}).
+			Where("id = ?", delivery.ID).
+			Updates(map[string]interface{}{
+				"status":          delivery.Status,
+				"attempts":        delivery.Attempts,
+				"next_attempt_at": delivery.NextAttemptAt,
+				"locked_until":    delivery.LockedUntil,
+				"last_error":      delivery.LastError,
+				"response_status": delivery.ResponseStatus,
+				"delivered_at":    delivery.DeliveredAt,
+			}).Error
+	})
+}
+
+func (r *webhookRepository) FindDeliveries(subscriptionID int64, filter *model.WebhookDeliveryListRequest) ([]model.WebhookDelivery, int64, error) {
+	var deliveries []model.WebhookDelivery
+	var total int64
+
+	query := r.db.Model(&model.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if filter.EventType != "" {
+		query = query.Where("event_type = ?", filter.EventType)
+	}
+
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Page > 0 && filter.Limit > 0 {
+		offset := (filter.Page - 1) * filter.Limit
+		query = query.Offset(offset).Limit(filter.Limit)
+	}
+
+	err = query.Order("created_at DESC, id DESC").Find(&deliveries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+// FindDeliveryByID returns the delivery with its attempts, or nil when it does not exist
+func (r *webhookRepository) FindDeliveryByID(id int64) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := r.db.Preload("DeliveryAttempts", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at")
+	}).First(&delivery, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// Requeue gives the delivery a fresh set of attempts, starting now
+func (r *webhookRepository) Requeue(id int64, now time.Time) error {
+	return r.db.Model(&model.WebhookDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          model.WebhookStatusPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"locked_until":    nil,
+		}).Error
+}
+
+// DeleteDeliveredBefore removes the deliveries delivered before the given time
+// together with their attempts and returns how many were removed
+func (r *webhookRepository) DeleteDeliveredBefore(before time.Time) (int64, error) {
+	result := r.db.Where("status = ? AND delivered_at < ?", model.WebhookStatusDelivered, before).Delete(&model.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
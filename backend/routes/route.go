@@ -2,47 +2,80 @@ package routes
 
 import (
 	"os"
+	"permit-app/controller/calendarController"
+	"permit-app/controller/dashboardController"
 	"permit-app/controller/divisionController"
 	"permit-app/controller/domainController"
 	"permit-app/controller/menuController"
+	"permit-app/controller/emailOutboxController"
+	"permit-app/controller/emailTemplateController"
+	"permit-app/controller/jobController"
 	"permit-app/controller/moduleController"
 	"permit-app/controller/notificationController"
+	"permit-app/controller/permitApprovalController"
 	"permit-app/controller/permitController"
+	"permit-app/controller/permitExportController"
+	"permit-app/controller/permitObligationController"
 	"permit-app/controller/permitTypeController"
+	"permit-app/controller/permitVerificationController"
 	"permit-app/controller/projectController"
 	"permit-app/controller/referenceCategoryController"
 	"permit-app/controller/referenceController"
+	"permit-app/controller/reminderScheduleController"
 	"permit-app/controller/roleController"
 	"permit-app/controller/taskController"
 	"permit-app/controller/taskRequestController"
 	"permit-app/controller/userController"
+	"permit-app/controller/webhookController"
+	"permit-app/helper"
 	"permit-app/middleware"
+	"permit-app/repo/calendarTokenRepository"
 	"permit-app/repo/divisionRepository"
 	"permit-app/repo/domainRepository"
+	"permit-app/repo/emailOutboxRepository"
+	"permit-app/repo/emailTemplateRepository"
+	"permit-app/repo/escalationRepository"
 	"permit-app/repo/menuRepository"
 	"permit-app/repo/moduleRepository"
+	"permit-app/repo/notificationPreferenceRepository"
 	"permit-app/repo/notificationRepository"
+	"permit-app/repo/permitObligationRepository"
 	"permit-app/repo/permitRepository"
+	"permit-app/repo/permitRevisionRepository"
+	"permit-app/repo/reminderScheduleRepository"
 	"permit-app/repo/permitTypeRepository"
+	"permit-app/repo/permitVerificationTokenRepository"
 	"permit-app/repo/projectRepository"
 	"permit-app/repo/referenceCategoryRepository"
 	"permit-app/repo/referenceRepository"
 	"permit-app/repo/roleRepository"
 	"permit-app/repo/taskRepository"
 	"permit-app/repo/userRepository"
+	"permit-app/repo/webhookRepository"
+	"permit-app/service/calendarService"
+	"permit-app/service/dashboardService"
 	"permit-app/service/divisionService"
 	"permit-app/service/domainService"
+	"permit-app/service/emailOutboxService"
+	"permit-app/service/emailTemplateService"
+	"permit-app/service/jobService"
 	"permit-app/service/menuService"
 	"permit-app/service/moduleService"
 	"permit-app/service/notificationService"
+	"permit-app/service/notificationStreamService"
+	"permit-app/service/permitExportService"
+	"permit-app/service/permitObligationService"
 	"permit-app/service/permitService"
 	"permit-app/service/permitTypeService"
+	"permit-app/service/permitVerificationService"
 	"permit-app/service/projectService"
 	"permit-app/service/referenceCategoryService"
 	"permit-app/service/referenceService"
+	"permit-app/service/reminderScheduleService"
 	"permit-app/service/roleService"
 	"permit-app/service/taskService"
 	"permit-app/service/userService"
+	"permit-app/service/webhookService"
 	"strings"
 	"time"
 
@@ -77,7 +110,7 @@ func corsConfig() cors.Config {
 	}
 }
 
-func NewRoute(db *gorm.DB) *gin.Engine {
+func NewRoute(db *gorm.DB, notificationStreamSvc notificationStreamService.NotificationStreamService, jobSvc jobService.JobService) *gin.Engine {
 	validate := validator.New()
 
 	// Repositories
@@ -85,46 +118,76 @@ func NewRoute(db *gorm.DB) *gin.Engine {
 	divisionRepo := divisionRepository.NewDivisionRepository(db)
 	permitTypeRepo := permitTypeRepository.NewPermitTypeRepository(db)
 	permitRepo := permitRepository.NewPermitRepository(db)
+	permitRevisionRepo := permitRevisionRepository.NewPermitRevisionRepository(db)
+	permitObligationRepo := permitObligationRepository.NewPermitObligationRepository(db)
+	reminderScheduleRepo := reminderScheduleRepository.NewReminderScheduleRepository(db)
 	roleRepo := roleRepository.NewRoleRepository(db)
 	userRepo := userRepository.NewUserRepository(db)
 	menuRepo := menuRepository.NewMenuRepository(db)
 	notificationRepo := notificationRepository.NewNotificationRepository(db)
+	notificationPreferenceRepo := notificationPreferenceRepository.NewNotificationPreferenceRepository(db)
 	moduleRepo := moduleRepository.NewModuleRepository(db)
 	referenceCategoryRepo := referenceCategoryRepository.NewReferenceCategoryRepository(db)
 	referenceRepo := referenceRepository.NewReferenceRepository(db)
 	projectRepo := projectRepository.NewProjectRepository(db)
 	taskRepo := taskRepository.NewTaskRepository(db)
+	calendarTokenRepo := calendarTokenRepository.NewCalendarTokenRepository(db)
+	permitVerificationTokenRepo := permitVerificationTokenRepository.NewPermitVerificationTokenRepository(db)
+	emailOutboxRepo := emailOutboxRepository.NewEmailOutboxRepository(db)
+	emailTemplateRepo := emailTemplateRepository.NewEmailTemplateRepository(db)
+	webhookRepo := webhookRepository.NewWebhookRepository(db)
+	escalationRepo := escalationRepository.NewEscalationRepository(db)
 
 	// Services
 	domainSvc := domainService.NewDomainService(domainRepo)
 	divisionSvc := divisionService.NewDivisionService(divisionRepo)
-	permitTypeSvc := permitTypeService.NewPermitTypeService(permitTypeRepo)
-	permitSvc := permitService.NewPermitService(permitRepo)
+	permitTypeSvc := permitTypeService.NewPermitTypeService(permitTypeRepo, divisionRepo)
+	reminderScheduleSvc := reminderScheduleService.NewReminderScheduleService(reminderScheduleRepo, permitTypeRepo, domainRepo)
+	permitExportSvc := permitExportService.NewPermitExportService(permitRepo)
+	permitSvc := permitService.NewPermitService(permitRepo, permitRevisionRepo, referenceRepo, divisionRepo, permitTypeRepo, userRepo, notificationRepo, notificationPreferenceRepo, webhookRepo)
+	permitObligationSvc := permitObligationService.NewPermitObligationService(permitObligationRepo, permitRepo, userRepo)
 	roleSvc := roleService.NewRoleService(roleRepo)
 	userSvc := userService.NewUserService(userRepo)
 	menuSvc := menuService.NewMenuService(menuRepo)
-	notificationSvc := notificationService.NewNotificationService(notificationRepo, permitRepo, userRepo)
+	notificationSvc := notificationService.NewNotificationService(notificationRepo, permitRepo, userRepo, reminderScheduleRepo, permitObligationRepo, notificationPreferenceRepo, roleRepo, domainRepo, emailTemplateRepo, escalationRepo)
 	moduleSvc := moduleService.NewModuleService(moduleRepo)
 	referenceCategorySvc := referenceCategoryService.NewReferenceCategoryService(referenceCategoryRepo, moduleRepo)
 	referenceSvc := referenceService.NewReferenceService(referenceRepo, referenceCategoryRepo)
-	taskSvc := taskService.NewTaskService(taskRepo)
+	taskSvc := taskService.NewTaskService(taskRepo, userRepo, notificationRepo, notificationPreferenceRepo, webhookRepo)
 	projectSvc := projectService.NewProjectService(projectRepo, referenceRepo)
+	dashboardSvc := dashboardService.NewDashboardService(permitRepo)
+	calendarSvc := calendarService.NewCalendarService(calendarTokenRepo, permitRepo, taskRepo, userRepo)
+	permitVerificationSvc := permitVerificationService.NewPermitVerificationService(permitVerificationTokenRepo, permitRepo)
+	emailOutboxSvc := emailOutboxService.NewEmailOutboxService(emailOutboxRepo)
+	emailTemplateSvc := emailTemplateService.NewEmailTemplateService(emailTemplateRepo)
+	webhookSvc := webhookService.NewWebhookService(webhookRepo)
 
 	// Controllers
 	domainCtrl := domainController.NewDomainController(domainSvc)
 	divisionCtrl := divisionController.NewDivisionController(divisionSvc)
 	permitTypeCtrl := permitTypeController.NewPermitTypeController(permitTypeSvc)
+	reminderScheduleCtrl := reminderScheduleController.NewReminderScheduleController(reminderScheduleSvc)
 	permitCtrl := permitController.NewPermitController(permitSvc)
+	permitApprovalCtrl := permitApprovalController.NewPermitApprovalController(permitSvc)
+	permitExportCtrl := permitExportController.NewPermitExportController(permitExportSvc)
+	permitObligationCtrl := permitObligationController.NewPermitObligationController(permitObligationSvc)
 	roleCtrl := roleController.NewRoleController(roleSvc)
 	userCtrl := userController.NewUserController(userSvc)
 	menuCtrl := menuController.NewMenuController(menuSvc)
-	notificationCtrl := notificationController.NewNotificationController(notificationSvc, validate)
+	notificationCtrl := notificationController.NewNotificationController(notificationSvc, notificationStreamSvc, validate)
 	moduleCtrl := moduleController.NewModuleController(moduleSvc)
 	referenceCategoryCtrl := referenceCategoryController.NewReferenceCategoryController(referenceCategorySvc)
 	referenceCtrl := referenceController.NewReferenceController(referenceSvc)
 	taskCtrl := taskController.NewTaskController(taskSvc)
 	taskRequestCtrl := taskRequestController.NewTaskRequestController(taskSvc)
 	projectCtrl := projectController.NewProjectController(projectSvc)
+	dashboardCtrl := dashboardController.NewDashboardController(dashboardSvc)
+	calendarCtrl := calendarController.NewCalendarController(calendarSvc)
+	permitVerificationCtrl := permitVerificationController.NewPermitVerificationController(permitVerificationSvc)
+	emailOutboxCtrl := emailOutboxController.NewEmailOutboxController(emailOutboxSvc)
+	emailTemplateCtrl := emailTemplateController.NewEmailTemplateController(emailTemplateSvc)
+	webhookCtrl := webhookController.NewWebhookController(webhookSvc)
+	jobCtrl := jobController.NewJobController(jobSvc)
 
 	app := gin.Default()
 
@@ -132,9 +195,6 @@ func NewRoute(db *gorm.DB) *gin.Engine {
 	app.Use(cors.New(corsConfig()))
 	app.OPTIONS("/*any", func(c *gin.Context) { c.Status(204) })
 
-	// Serve static files
-	app.Static("/file", "./file")
-
 	/* API Routes */
 
 	// Public routes (no authentication required)
@@ -143,6 +203,15 @@ func NewRoute(db *gorm.DB) *gin.Engine {
 		auth.POST("/login", userCtrl.Login)
 	}
 
+	// Calendar feed, authenticated by the token in the URL so calendar clients can subscribe
+	app.GET("/calendar/feed/:token", calendarCtrl.Feed)
+
+	// Permit verification, opened by scanning the QR code on a posted permit
+	app.GET("/verify/:token", permitVerificationCtrl.Verify)
+
+	// Notification stream, also accepting a stream token in the URL for EventSource clients
+	app.GET("/notifications/stream", middleware.StreamTokenAuth(), notificationCtrl.Stream)
+
 	// Protected routes (authentication required)
 	protected := app.Group("")
 	protected.Use(middleware.AuthMiddleware())
@@ -175,6 +244,8 @@ func NewRoute(db *gorm.DB) *gin.Engine {
 			domain.GET("/:id", domainCtrl.GetByID)
 			domain.PUT("/:id", domainCtrl.Update)
 			domain.DELETE("/:id", domainCtrl.Delete)
+			domain.GET("/:id/reminder-schedules", reminderScheduleCtrl.GetByDomain)
+			domain.PUT("/:id/reminder-schedules", reminderScheduleCtrl.UpdateByDomain)
 		}
 
 		// Division endpoints
@@ -195,6 +266,8 @@ func NewRoute(db *gorm.DB) *gin.Engine {
 			permitType.GET("/:id", permitTypeCtrl.GetByID)
 			permitType.PUT("/:id", permitTypeCtrl.Update)
 			permitType.DELETE("/:id", permitTypeCtrl.Delete)
+			permitType.GET("/:id/reminder-schedules", reminderScheduleCtrl.GetByPermitType)
+			permitType.PUT("/:id/reminder-schedules", reminderScheduleCtrl.UpdateByPermitType)
 		}
 
 		// Permit endpoints
@@ -203,12 +276,44 @@ func NewRoute(db *gorm.DB) *gin.Engine {
 			permit.POST("", permitCtrl.Create)
 			permit.GET("", permitCtrl.GetAll)
 			permit.GET("/search", permitCtrl.Search)
+			permit.POST("/import", permitCtrl.Import)
+			permit.GET("/export", permitExportCtrl.Export)
+			permit.GET("/approvals", permitApprovalCtrl.GetPending)
+			permit.GET("/trash", permitCtrl.GetTrash)
 			permit.GET("/:id", permitCtrl.GetByID)
 			permit.PUT("/:id", permitCtrl.Update)
 			permit.DELETE("/:id", permitCtrl.Delete)
+			permit.POST("/:id/restore", permitCtrl.Restore)
+			permit.DELETE("/:id/purge", middleware.RequireRoleCode(helper.RoleCodeAdmin, helper.RoleCodeSuperAdmin), permitCtrl.Purge)
 			permit.POST("/:id/upload", permitCtrl.UploadDocument)
 			permit.GET("/:id/download", permitCtrl.DownloadDocument)
 			permit.GET("/:id/preview", permitCtrl.PreviewDocument)
+			permit.POST("/:id/renew", permitCtrl.Renew)
+			permit.POST("/:id/status", permitCtrl.ChangeStatus)
+			permit.POST("/:id/submit", permitApprovalCtrl.Submit)
+			permit.GET("/:id/approvals", permitApprovalCtrl.GetApprovals)
+			permit.POST("/:id/approvals/:approval_id/approve", permitApprovalCtrl.Approve)
+			permit.POST("/:id/approvals/:approval_id/reject", permitApprovalCtrl.Reject)
+			permit.GET("/:id/renewals", permitCtrl.GetRenewals)
+			permit.GET("/:id/history", permitCtrl.GetHistory)
+			permit.GET("/:id/history/:rev", permitCtrl.GetRevision)
+			permit.POST("/:id/history/:rev/restore", permitCtrl.RestoreRevision)
+			permit.GET("/:id/documents", permitCtrl.GetDocuments)
+			permit.POST("/:id/documents", permitCtrl.UploadPermitDocument)
+			permit.DELETE("/:id/documents/:document_id", permitCtrl.DeletePermitDocument)
+			permit.GET("/:id/documents/:document_id/download", permitCtrl.DownloadPermitDocument)
+			permit.GET("/:id/documents/:document_id/preview", permitCtrl.PreviewPermitDocument)
+			permit.GET("/:id/obligations", permitObligationCtrl.GetAll)
+			permit.POST("/:id/obligations", permitObligationCtrl.Create)
+			permit.GET("/:id/obligations/:obligation_id", permitObligationCtrl.GetByID)
+			permit.PUT("/:id/obligations/:obligation_id", permitObligationCtrl.Update)
+			permit.DELETE("/:id/obligations/:obligation_id", permitObligationCtrl.Delete)
+			permit.POST("/:id/obligations/:obligation_id/complete", permitObligationCtrl.Complete)
+			permit.GET("/:id/obligations/:obligation_id/evidence/:evidence_id/download", permitObligationCtrl.DownloadEvidence)
+			permit.GET("/:id/verification", permitVerificationCtrl.GetToken)
+			permit.POST("/:id/verification", permitVerificationCtrl.RotateToken)
+			permit.DELETE("/:id/verification", permitVerificationCtrl.RevokeToken)
+			permit.GET("/:id/verification/qr", permitVerificationCtrl.QRCode)
 		}
 
 		// Role endpoints
@@ -294,19 +399,87 @@ func NewRoute(db *gorm.DB) *gin.Engine {
 			notification.GET("", notificationCtrl.GetNotifications)
 			notification.GET("/unread", notificationCtrl.GetUnreadNotifications)
 			notification.GET("/unread/count", notificationCtrl.GetUnreadCount)
+			notification.POST("/stream-token", notificationCtrl.StreamToken)
 			notification.POST("/read", notificationCtrl.MarkAsRead)
 			notification.POST("/read/all", notificationCtrl.MarkAllAsRead)
+			notification.GET("/preferences", notificationCtrl.GetPreferences)
+			notification.PUT("/preferences", notificationCtrl.UpdatePreferences)
+			notification.GET("/digest", notificationCtrl.GetDigestSetting)
+			notification.PUT("/digest", notificationCtrl.UpdateDigestSetting)
+			notification.GET("/recipient-rules", notificationCtrl.GetRecipientRules)
+			notification.PUT("/recipient-rules", middleware.RequireRoleCode(helper.RoleCodeAdmin, helper.RoleCodeSuperAdmin), notificationCtrl.UpdateRecipientRules)
+			notification.DELETE("/recipient-rules/:event_type", middleware.RequireRoleCode(helper.RoleCodeAdmin, helper.RoleCodeSuperAdmin), notificationCtrl.ResetRecipientRule)
+			notification.GET("/escalation-rules", notificationCtrl.GetEscalationRules)
+			notification.PUT("/escalation-rules", middleware.RequireRoleCode(helper.RoleCodeAdmin, helper.RoleCodeSuperAdmin), notificationCtrl.UpdateEscalationRules)
+			notification.GET("/escalations", middleware.RequireRoleCode(helper.RoleCodeAdmin, helper.RoleCodeSuperAdmin), notificationCtrl.GetEscalations)
 
 			// Task endpoints
 			notification.DELETE("/:id", notificationCtrl.DeleteNotification)
 		}
 
+		// Dashboard endpoints
+		dashboard := protected.Group("/dashboard")
+		{
+			dashboard.GET("/permits", dashboardCtrl.GetPermitDashboard)
+		}
+
+		// Calendar feed token endpoints
+		calendar := protected.Group("/calendar")
+		{
+			calendar.GET("/token", calendarCtrl.GetToken)
+			calendar.POST("/token", calendarCtrl.CreateToken)
+			calendar.DELETE("/token", calendarCtrl.RevokeToken)
+		}
+
+		// Email outbox endpoints (admin only)
+		emailOutbox := protected.Group("/email-outbox", middleware.RequireRoleCode(helper.RoleCodeAdmin, helper.RoleCodeSuperAdmin))
+		{
+			emailOutbox.GET("", emailOutboxCtrl.GetAll)
+			emailOutbox.GET("/:id", emailOutboxCtrl.GetByID)
+			emailOutbox.POST("/:id/resend", emailOutboxCtrl.Resend)
+		}
+
+		// Email template overrides of the domain (admin only)
+		emailTemplates := protected.Group("/email-templates", middleware.RequireRoleCode(helper.RoleCodeAdmin, helper.RoleCodeSuperAdmin))
+		{
+			emailTemplates.GET("", emailTemplateCtrl.GetAll)
+			emailTemplates.PUT("/:name/:locale", emailTemplateCtrl.Update)
+			emailTemplates.DELETE("/:name/:locale", emailTemplateCtrl.Reset)
+			emailTemplates.POST("/:name/:locale/preview", emailTemplateCtrl.Preview)
+		}
+
+		// Outbound webhooks of the domain (admin only)
+		webhooks := protected.Group("/webhooks", middleware.RequireRoleCode(helper.RoleCodeAdmin, helper.RoleCodeSuperAdmin))
+		{
+			webhooks.GET("", webhookCtrl.GetAll)
+			webhooks.POST("", webhookCtrl.Create)
+			webhooks.GET("/:id", webhookCtrl.GetByID)
+			webhooks.PUT("/:id", webhookCtrl.Update)
+			webhooks.DELETE("/:id", webhookCtrl.Delete)
+			webhooks.POST("/:id/ping", webhookCtrl.Ping)
+			webhooks.GET("/:id/deliveries", webhookCtrl.GetDeliveries)
+			webhooks.GET("/:id/deliveries/:deliveryId", webhookCtrl.GetDelivery)
+			webhooks.POST("/:id/deliveries/:deliveryId/redeliver", webhookCtrl.Redeliver)
+		}
+
+		// Scheduled jobs and their run history (admin only)
+		jobs := protected.Group("/jobs", middleware.RequireRoleCode(helper.RoleCodeAdmin, helper.RoleCodeSuperAdmin))
+		{
+			jobs.GET("", jobCtrl.GetAll)
+			jobs.GET("/:name", jobCtrl.GetByName)
+			jobs.GET("/:name/runs", jobCtrl.GetRuns)
+			jobs.POST("/:name/run", jobCtrl.Run)
+			jobs.POST("/:name/pause", jobCtrl.Pause)
+			jobs.POST("/:name/resume", jobCtrl.Resume)
+		}
+
 		tasks := protected.Group("/tasks")
 		{
 			tasks.POST("", taskCtrl.Create)
 			tasks.GET("", taskCtrl.GetAll)
 			tasks.GET("/:id", taskCtrl.GetByID)
 			tasks.GET("/code/:code", taskCtrl.GetByCode)
+			tasks.GET("/:id/files/:file_id/download", taskCtrl.DownloadFile)
 			tasks.PUT("/:id", taskCtrl.Update)
 			tasks.DELETE("/:id", taskCtrl.Delete)
 			tasks.POST("/:id/change-status", taskCtrl.ChangeStatus)
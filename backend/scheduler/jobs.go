@@ -0,0 +1,145 @@
+package scheduler
+
+import (
+	"fmt"
+	"permit-app/service/emailOutboxService"
+	"permit-app/service/jobService"
+	"permit-app/service/notificationService"
+	"permit-app/service/permitService"
+	"permit-app/service/webhookService"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Job names
+const (
+	JobPermitExpiryStatus  = "permit_expiry_status"
+	JobExpiryReminders     = "expiry_reminders"
+	JobObligationReminders = "obligation_reminders"
+	JobNotificationDigests = "notification_digests"
+	JobEmailOutbox         = "email_outbox"
+	JobWebhookDeliveries   = "webhook_deliveries"
+	JobCleanup             = "cleanup"
+)
+
+// RegisterJobs registers the application's jobs. Jobs due at the same time run
+// in this order, so expiry statuses are updated before reminders are sent and
+// reminders are queued before digests and the outbox go out.
+func RegisterJobs(
+	jobSvc jobService.JobService,
+	notificationSvc notificationService.NotificationService,
+	permitSvc permitService.PermitService,
+	emailOutboxSvc emailOutboxService.EmailOutboxService,
+	webhookSvc webhookService.WebhookService,
+) error {
+	daily := dailyCron()
+	jobs := []struct {
+		name        string
+		description string
+		cron        string
+		run         jobService.JobFunc
+	}{
+		{
+			name:        JobPermitExpiryStatus,
+			description: "Moves permits into expiring and expired as their expiry dates come closer",
+			cron:        daily,
+			run:         permitSvc.UpdateExpiryStatuses,
+		},
+		{
+			name:        JobExpiryReminders,
+			description: "Sends permit expiry reminders and escalates the ignored ones",
+			cron:        daily,
+			run:         notificationSvc.CheckAndSendExpiryNotifications,
+		},
+		{
+			name:        JobObligationReminders,
+			description: "Sends reminders for upcoming and overdue permit obligations",
+			cron:        daily,
+			run:         notificationSvc.CheckAndSendObligationNotifications,
+		},
+		{
+			name:        JobNotificationDigests,
+			description: "Emails the users whose daily or weekly digest is due",
+			cron:        daily,
+			run:         notificationSvc.SendDigests,
+		},
+		{
+			name:        JobEmailOutbox,
+			description: "Delivers the emails queued in the outbox",
+			cron:        everySeconds("EMAIL_WORKER_INTERVAL_SECONDS", 30),
+			run:         emailOutboxSvc.DeliverDue,
+		},
+		{
+			name:        JobWebhookDeliveries,
+			description: "Posts queued webhook events to the domains' receivers",
+			cron:        everySeconds("WEBHOOK_WORKER_INTERVAL_SECONDS", 10),
+			run:         webhookSvc.DeliverDue,
+		},
+		{
+			name:        JobCleanup,
+			description: "Removes old job runs, sent emails and delivered webhooks",
+			cron:        "0 3 * * *",
+			run: func() (int, error) {
+				return cleanup(jobSvc, emailOutboxSvc, webhookSvc)
+			},
+		},
+	}
+
+	for _, job := range jobs {
+		cron := getEnv("JOB_"+strings.ToUpper(job.name)+"_CRON", job.cron)
+		if err := jobSvc.Register(job.name, job.description, cron, job.run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanup removes the records older than the retention period. Failed emails
+// and webhook deliveries are kept, they wait to be resent.
+func cleanup(jobSvc jobService.JobService, emailOutboxSvc emailOutboxService.EmailOutboxService, webhookSvc webhookService.WebhookService) (int, error) {
+	before := time.Now().AddDate(0, 0, -positiveEnv("CLEANUP_RETENTION_DAYS", 90))
+
+	runs, err := jobSvc.PurgeRuns(before)
+	if err != nil {
+		return 0, err
+	}
+	emails, err := emailOutboxSvc.PurgeSent(before)
+	if err != nil {
+		return runs, err
+	}
+	deliveries, err := webhookSvc.PurgeDelivered(before)
+	return runs + emails + deliveries, err
+}
+
+// dailyCron is the schedule of the daily jobs: every day at SCHEDULER_HOUR and
+// SCHEDULER_MINUTE (default 08:00), or every SCHEDULER_INTERVAL_MINUTES
+// (default 5) when SCHEDULER_MODE is testing
+func dailyCron() string {
+	if getEnv("SCHEDULER_MODE", "") == "testing" {
+		return fmt.Sprintf("@every %dm", positiveEnv("SCHEDULER_INTERVAL_MINUTES", 5))
+	}
+
+	hour := 8 // default
+	if h, err := strconv.Atoi(getEnv("SCHEDULER_HOUR", "8")); err == nil && h >= 0 && h <= 23 {
+		hour = h
+	}
+	minute := 0 // default
+	if m, err := strconv.Atoi(getEnv("SCHEDULER_MINUTE", "0")); err == nil && m >= 0 && m <= 59 {
+		minute = m
+	}
+	return fmt.Sprintf("%d %d * * *", minute, hour)
+}
+
+func everySeconds(key string, defaultValue int) string {
+	return fmt.Sprintf("@every %ds", positiveEnv(key, defaultValue))
+}
+
+// positiveEnv reads a positive number from the environment, falling back to
+// the default when it is missing or invalid
+func positiveEnv(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(getEnv(key, "")); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"permit-app/service/notificationStreamService"
+	"time"
+)
+
+// notificationListenerRetryDelay is the wait before listening again after the
+// database connection was lost
+const notificationListenerRetryDelay = 5 * time.Second
+
+// NotificationListener relays the notifications created by any instance to the
+// notification streams open on this one
+type NotificationListener struct {
+	streamService notificationStreamService.NotificationStreamService
+	cancel        context.CancelFunc
+	done          chan struct{}
+}
+
+func NewNotificationListener(streamService notificationStreamService.NotificationStreamService) *NotificationListener {
+	return &NotificationListener{
+		streamService: streamService,
+		done:          make(chan struct{}),
+	}
+}
+
+// Start listens in the background, reconnecting whenever the connection drops.
+// Open streams are dropped with the connection and resume through Last-Event-ID.
+func (l *NotificationListener) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+
+	go func() {
+		defer close(l.done)
+		for {
+			err := l.streamService.Listen(ctx)
+			if ctx.Err() != nil {
+				log.Println("Notification Listener: Stopped")
+				return
+			}
+			log.Printf("Notification Listener: %v, listening again in %v", err, notificationListenerRetryDelay)
+
+			select {
+			case <-time.After(notificationListenerRetryDelay):
+			case <-ctx.Done():
+				log.Println("Notification Listener: Stopped")
+				return
+			}
+		}
+	}()
+
+	log.Println("Notification Listener: Started")
+}
+
+func (l *NotificationListener) Stop() {
+	l.cancel()
+	<-l.done
+}
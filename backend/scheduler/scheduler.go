@@ -1,125 +1,173 @@
 package scheduler
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"os"
-	"permit-app/service/notificationService"
-	"strconv"
+	"permit-app/service/jobService"
+	"sync"
 	"time"
 )
 
+// schedulerLeaderCheckInterval is how often a follower tries to take the lead
+// and the leader checks it still holds it. An instance that dies loses the lead
+// with its database connection, and another one takes over at its next check.
+const schedulerLeaderCheckInterval = 15 * time.Second
+
+// Scheduler runs the registered jobs on their cron schedules. With several
+// instances running, only the one holding the leader lock schedules jobs.
 type Scheduler struct {
-	notificationService notificationService.NotificationService
-	ticker              *time.Ticker
-	done                chan bool
+	jobService jobService.JobService
+	cancel     context.CancelFunc
+	done       chan struct{}
 }
 
-func NewScheduler(notificationService notificationService.NotificationService) *Scheduler {
+func NewScheduler(jobService jobService.JobService) *Scheduler {
 	return &Scheduler{
-		notificationService: notificationService,
-		done:                make(chan bool),
+		jobService: jobService,
+		done:       make(chan struct{}),
 	}
 }
 
-// Start memulai scheduler untuk check permit expiry setiap hari pada jam 8 pagi
+// Start competes for the lead in the background. Once this instance leads it
+// runs the jobs on their schedules until it loses the lead or is stopped.
 func (s *Scheduler) Start() {
-	// Jalankan pertama kali saat start
-	go func() {
-		log.Println("Notification Scheduler: Initial check for expiring permits")
-		if err := s.notificationService.CheckAndSendExpiryNotifications(); err != nil {
-			log.Printf("Error in initial notification check: %v", err)
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	names := s.jobService.Names()
+	go s.lead(ctx, names)
+
+	log.Printf("Job Scheduler: Started with %d job(s)", len(names))
+}
+
+func (s *Scheduler) lead(ctx context.Context, names []string) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(schedulerLeaderCheckInterval)
+	defer ticker.Stop()
+
+	var stopLoop context.CancelFunc
+	var loopDone chan struct{}
+	for {
+		leading, err := s.jobService.Lead()
+		if err != nil {
+			log.Printf("Error checking scheduler leadership: %v", err)
+		}
+
+		if leading && stopLoop == nil {
+			log.Println("Job Scheduler: Leading, jobs run on this instance")
+			var loopCtx context.Context
+			loopCtx, stopLoop = context.WithCancel(ctx)
+			loopDone = make(chan struct{})
+			go s.loop(loopCtx, names, loopDone)
+		} else if !leading && stopLoop != nil {
+			log.Println("Job Scheduler: Lost the lead, another instance takes over")
+			stopLoop()
+			<-loopDone
+			stopLoop = nil
 		}
-	}()
-
-	// Schedule untuk check setiap hari jam 8 pagi
-	go func() {
-		for {
-			now := time.Now()
-			// Hitung waktu ke jam berikutnya berdasarkan konfigurasi
-			next := time.Date(now.Year(), now.Month(), now.Day(), s.GetScheduledHour(), s.GetScheduledMinute(), 0, 0, now.Location())
-			if now.After(next) {
-				next = next.Add(24 * time.Hour)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if stopLoop != nil {
+				stopLoop()
+				<-loopDone
 			}
-			
-			duration := time.Until(next)
-			log.Printf("Notification Scheduler: Next run scheduled at %v (in %v)", next.Format("2006-01-02 15:04:05"), duration)
-			
-			timer := time.NewTimer(duration)
-			
-			select {
-			case <-timer.C:
-				log.Println("Notification Scheduler: Running scheduled check for expiring permits")
-				if err := s.notificationService.CheckAndSendExpiryNotifications(); err != nil {
-					log.Printf("Error in scheduled notification check: %v", err)
-				}
-			case <-s.done:
-				timer.Stop()
-				log.Println("Notification Scheduler: Stopped")
-				return
+			if err := s.jobService.StepDown(); err != nil {
+				log.Printf("Error stepping down as scheduler leader: %v", err)
 			}
+			log.Println("Job Scheduler: Stopped")
+			return
 		}
-	}()
-
-	log.Println("Notification Scheduler: Started successfully")
+	}
 }
 
-// StartWithInterval untuk testing - check setiap interval tertentu
-func (s *Scheduler) StartWithInterval(interval time.Duration) {
-	s.ticker = time.NewTicker(interval)
-	
-	// Jalankan pertama kali
-	go func() {
-		log.Println("Notification Scheduler: Initial check for expiring permits")
-		if err := s.notificationService.CheckAndSendExpiryNotifications(); err != nil {
-			log.Printf("Error in initial notification check: %v", err)
-		}
-	}()
-	
-	go func() {
-		for {
-			select {
-			case <-s.ticker.C:
-				log.Println("Notification Scheduler: Running periodic check for expiring permits")
-				if err := s.notificationService.CheckAndSendExpiryNotifications(); err != nil {
-					log.Printf("Error in notification check: %v", err)
-				}
-			case <-s.done:
-				s.ticker.Stop()
-				log.Println("Notification Scheduler: Stopped")
-				return
+// loop runs the jobs on their schedules until the context is cancelled, then
+// waits for the runs in progress. With SCHEDULER_EXPIRY_STATUS_ON_START set,
+// permit expiry statuses are brought up to date right away instead of waiting
+// for the job's next run.
+func (s *Scheduler) loop(ctx context.Context, names []string, done chan struct{}) {
+	var runs sync.WaitGroup
+	defer close(done)
+	defer runs.Wait()
+
+	now := time.Now()
+	next := make(map[string]time.Time, len(names))
+	for _, name := range names {
+		next[name] = s.jobService.ScheduleNext(name, now)
+	}
+	if getEnv("SCHEDULER_EXPIRY_STATUS_ON_START", "false") == "true" {
+		runs.Go(func() { s.run(ctx, []string{JobPermitExpiryStatus}) })
+	}
+
+	for {
+		var wake time.Time
+		for _, name := range names {
+			if at := next[name]; !at.IsZero() && (wake.IsZero() || at.Before(wake)) {
+				wake = at
 			}
 		}
-	}()
-	
-	fmt.Printf("Notification Scheduler: Started with %v interval\n", interval)
-}
+		if wake.IsZero() {
+			<-ctx.Done()
+			return
+		}
 
-// Stop menghentikan scheduler
-func (s *Scheduler) Stop() {
-	s.done <- true
-}
+		timer := time.NewTimer(time.Until(wake))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
 
-// GetScheduledHour returns the hour when scheduler should run (default: 8)
-func (s *Scheduler) GetScheduledHour() int {
-	hour := 8 // default
-	if hourStr := getEnv("SCHEDULER_HOUR", "8"); hourStr != "" {
-		if h, err := strconv.Atoi(hourStr); err == nil && h >= 0 && h <= 23 {
-			hour = h
+		now := time.Now()
+		var due []string
+		for _, name := range names {
+			if at := next[name]; !at.IsZero() && !at.After(now) {
+				due = append(due, name)
+				next[name] = s.jobService.ScheduleNext(name, now)
+			}
 		}
+		runs.Go(func() { s.run(ctx, due) })
 	}
-	return hour
 }
 
-// GetScheduledMinute returns the minute when scheduler should run (default: 0)
-func (s *Scheduler) GetScheduledMinute() int {
-	minute := 0 // default
-	if minuteStr := getEnv("SCHEDULER_MINUTE", "0"); minuteStr != "" {
-		if m, err := strconv.Atoi(minuteStr); err == nil && m >= 0 && m <= 59 {
-			minute = m
+// run runs the due jobs one after the other in registration order, so the
+// day's reminders are queued before the digests and the outbox go out. Once the
+// context is cancelled, because the lead was lost or the scheduler stopped, the
+// jobs not started yet are left to the next leader.
+func (s *Scheduler) run(ctx context.Context, names []string) {
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return
+		}
+		run, err := s.jobService.RunScheduled(name)
+		if err != nil {
+			log.Printf("Error running job %s: %v", name, err)
+			continue
 		}
+		if run == nil {
+			continue
+		}
+		if run.Error != nil {
+			log.Printf("Job Scheduler: %s failed: %s", name, *run.Error)
+		}
+		if run.ItemsProcessed > 0 {
+			log.Printf("Job Scheduler: %s processed %d item(s)", name, run.ItemsProcessed)
+		}
+	}
+}
+
+// Stop stops scheduling new runs, waits for the runs in progress and gives up
+// the lead
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
 	}
-	return minute
+	s.cancel()
+	<-s.done
 }
 
 // getEnv gets environment variable with default value
@@ -0,0 +1,224 @@
+package calendarService
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"permit-app/helper"
+	"permit-app/model"
+	"permit-app/repo/calendarTokenRepository"
+	"permit-app/repo/permitRepository"
+	"permit-app/repo/taskRepository"
+	"permit-app/repo/userRepository"
+	"strings"
+	"time"
+)
+
+// ErrCalendarFeedNotFound is returned for unknown or revoked feed tokens
+var ErrCalendarFeedNotFound = errors.New("calendar feed not found")
+
+// calendarPermitStatuses are the permit statuses whose expiry still matters
+var calendarPermitStatuses = []string{
+	model.PermitStatusActive,
+	model.PermitStatusExpiring,
+	model.PermitStatusExpired,
+	model.PermitStatusInRenewal,
+}
+
+var permitExpiryAlarms = []helper.CalendarAlarm{
+	{Before: 30 * 24 * time.Hour, Description: "Permit expires in 30 days"},
+	{Before: 7 * 24 * time.Hour, Description: "Permit expires in 7 days"},
+	{Before: 24 * time.Hour, Description: "Permit expires tomorrow"},
+}
+
+var taskDueAlarms = []helper.CalendarAlarm{
+	{Before: 24 * time.Hour, Description: "Task is due tomorrow"},
+}
+
+type CalendarService interface {
+	GetToken(userID int64) (*model.CalendarTokenResponse, error)
+	CreateToken(userID int64, domainID int64) (*model.CalendarTokenResponse, error)
+	RevokeToken(userID int64) error
+	WriteFeed(token string, w io.Writer) error
+}
+
+type calendarService struct {
+	tokenRepo  calendarTokenRepository.CalendarTokenRepository
+	permitRepo permitRepository.PermitRepository
+	taskRepo   taskRepository.TaskRepository
+	userRepo   userRepository.UserRepository
+}
+
+func NewCalendarService(
+	tokenRepo calendarTokenRepository.CalendarTokenRepository,
+	permitRepo permitRepository.PermitRepository,
+	taskRepo taskRepository.TaskRepository,
+	userRepo userRepository.UserRepository,
+) CalendarService {
+	return &calendarService{
+		tokenRepo:  tokenRepo,
+		permitRepo: permitRepo,
+		taskRepo:   taskRepo,
+		userRepo:   userRepo,
+	}
+}
+
+func (s *calendarService) GetToken(userID int64) (*model.CalendarTokenResponse, error) {
+	token, err := s.tokenRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return &model.CalendarTokenResponse{Active: false}, nil
+	}
+
+	return &model.CalendarTokenResponse{
+		Active:     true,
+		DomainID:   &token.DomainID,
+		LastUsedAt: token.LastUsedAt,
+		CreatedAt:  &token.CreatedAt,
+	}, nil
+}
+
+// CreateToken issues a new feed token for the user and domain, replacing any
+// previous token. The plain token is only returned here.
+func (s *calendarService) CreateToken(userID int64, domainID int64) (*model.CalendarTokenResponse, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate calendar token: %w", err)
+	}
+	plain := hex.EncodeToString(raw)
+
+	token := &model.CalendarToken{
+		UserID:    userID,
+		DomainID:  domainID,
+		TokenHash: hashCalendarToken(plain),
+	}
+	if err := s.tokenRepo.Replace(token); err != nil {
+		return nil, err
+	}
+
+	return &model.CalendarTokenResponse{
+		Active:    true,
+		DomainID:  &token.DomainID,
+		Token:     plain,
+		CreatedAt: &token.CreatedAt,
+	}, nil
+}
+
+func (s *calendarService) RevokeToken(userID int64) error {
+	return s.tokenRepo.DeleteByUserID(userID)
+}
+
+// WriteFeed writes the iCalendar feed of the token's owner: expiry dates of the
+// permits in the token's domain or under the user's responsibility, and due
+// dates of the user's open tasks
+func (s *calendarService) WriteFeed(plain string, w io.Writer) error {
+	token, err := s.tokenRepo.FindByTokenHash(hashCalendarToken(plain))
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return ErrCalendarFeedNotFound
+	}
+
+	user, err := s.userRepo.FindByID(token.UserID)
+	if err != nil || user == nil || !user.IsActive {
+		return ErrCalendarFeedNotFound
+	}
+
+	// Domain permits are only included while the user still belongs to the domain
+	domainID := int64(0)
+	domainRoles, err := s.userRepo.GetUserDomainRoles(user.ID)
+	if err != nil {
+		return err
+	}
+	for _, domainRole := range domainRoles {
+		if domainRole.DomainID == token.DomainID {
+			domainID = token.DomainID
+			break
+		}
+	}
+
+	permits, err := s.permitRepo.FindForCalendar(domainID, user.ID, calendarPermitStatuses)
+	if err != nil {
+		return err
+	}
+
+	tasks, err := s.taskRepo.FindOpenByAssignee(user.ID)
+	if err != nil {
+		return err
+	}
+
+	events := make([]helper.CalendarEvent, 0, len(permits)+len(tasks))
+	for i := range permits {
+		events = append(events, permitEvent(&permits[i]))
+	}
+	for i := range tasks {
+		events = append(events, taskEvent(&tasks[i]))
+	}
+
+	if err := helper.WriteCalendar(w, "Permits and Tasks - "+user.FullName, events); err != nil {
+		return err
+	}
+
+	return s.tokenRepo.UpdateLastUsed(token.ID, time.Now())
+}
+
+func hashCalendarToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+func permitEvent(permit *model.Permit) helper.CalendarEvent {
+	details := []string{"Permit No: " + permit.PermitNo}
+	if permit.PermitType != nil {
+		details = append(details, "Permit Type: "+permit.PermitType.Name)
+	}
+	if permit.Division != nil {
+		details = append(details, "Division: "+permit.Division.Name)
+	}
+	if permit.Domain != nil {
+		details = append(details, "Domain: "+permit.Domain.Name)
+	}
+	if permit.ResponsiblePerson != nil {
+		details = append(details, "Responsible Person: "+permit.ResponsiblePerson.FullName)
+	}
+	details = append(details, "Status: "+permit.Status)
+
+	return helper.CalendarEvent{
+		UID:          fmt.Sprintf("permit-%d@permit-app", permit.ID),
+		Date:         permit.ExpiryDate,
+		Summary:      fmt.Sprintf("Permit expires: %s (%s)", permit.Name, permit.PermitNo),
+		Description:  strings.Join(details, "\n"),
+		Categories:   []string{"Permit Expiry"},
+		LastModified: permit.UpdatedAt,
+		Alarms:       permitExpiryAlarms,
+	}
+}
+
+func taskEvent(task *model.Task) helper.CalendarEvent {
+	details := []string{"Task Code: " + task.Code}
+	if task.Project != nil {
+		details = append(details, "Project: "+task.Project.Name)
+	}
+	if task.Priority != nil {
+		details = append(details, "Priority: "+task.Priority.Name)
+	}
+	if task.StatusTask != nil {
+		details = append(details, "Status: "+task.StatusTask.Name)
+	}
+
+	return helper.CalendarEvent{
+		UID:          fmt.Sprintf("task-%d@permit-app", task.ID),
+		Date:         *task.DueDate,
+		Summary:      fmt.Sprintf("Task due: %s %s", task.Code, task.Title),
+		Description:  strings.Join(details, "\n"),
+		Categories:   []string{"Task Due"},
+		LastModified: task.UpdatedAt,
+		Alarms:       taskDueAlarms,
+	}
+}
@@ -0,0 +1,263 @@
+package dashboardService
+
+import (
+	"math"
+	"permit-app/helper"
+	"permit-app/model"
+	"permit-app/repo/permitRepository"
+	"sort"
+	"strings"
+	"time"
+)
+
+// riskHorizonDays is how far ahead of expiry a permit starts adding to the risk score
+const riskHorizonDays = 90
+
+// topRiskLimit is how many permits are listed in TopRisks
+const topRiskLimit = 10
+
+// riskWeights maps PermitType.RiskPoint to its weight in the risk score.
+// Permit types without a known risk point count as low risk.
+var riskWeights = map[string]float64{
+	"high":   3,
+	"medium": 2,
+	"low":    1,
+}
+
+// Permits in these statuses are in force or being renewed, so their expiry
+// still matters for compliance
+var trackedPermitStatuses = map[string]bool{
+	model.PermitStatusActive:    true,
+	model.PermitStatusExpiring:  true,
+	model.PermitStatusExpired:   true,
+	model.PermitStatusInRenewal: true,
+}
+
+var allPermitStatuses = []string{
+	model.PermitStatusDraft,
+	model.PermitStatusPending,
+	model.PermitStatusActive,
+	model.PermitStatusExpiring,
+	model.PermitStatusExpired,
+	model.PermitStatusInRenewal,
+	model.PermitStatusRevoked,
+	model.PermitStatusArchived,
+	model.PermitStatusSuperseded,
+}
+
+type DashboardService interface {
+	GetPermitDashboard(req *model.PermitDashboardRequest) (*model.PermitDashboardResponse, error)
+}
+
+type dashboardService struct {
+	permitRepo permitRepository.PermitRepository
+}
+
+func NewDashboardService(permitRepo permitRepository.PermitRepository) DashboardService {
+	return &dashboardService{permitRepo: permitRepo}
+}
+
+// GetPermitDashboard aggregates the permits per domain and division
+func (s *dashboardService) GetPermitDashboard(req *model.PermitDashboardRequest) (*model.PermitDashboardResponse, error) {
+	permits, err := s.permitRepo.FindForDashboard(req.DomainID, req.DivisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	response := &model.PermitDashboardResponse{
+		GeneratedAt: now,
+		Summary:     newStats(),
+		Domains:     []model.PermitDashboardDomain{},
+		TopRisks:    []model.PermitDashboardRiskItem{},
+	}
+
+	domainIndex := map[int64]int{}
+	divisionIndex := map[int64]map[int64]int{}
+
+	for i := range permits {
+		permit := &permits[i]
+		days := daysToExpiry(permit.ExpiryDate, today)
+		score := riskScore(permit, days)
+
+		di, ok := domainIndex[permit.DomainID]
+		if !ok {
+			domain := model.PermitDashboardDomain{
+				DomainID:             permit.DomainID,
+				PermitDashboardStats: newStats(),
+				Divisions:            []model.PermitDashboardDivision{},
+			}
+			if permit.Domain != nil {
+				domain.DomainName = permit.Domain.Name
+			}
+			response.Domains = append(response.Domains, domain)
+			di = len(response.Domains) - 1
+			domainIndex[permit.DomainID] = di
+			divisionIndex[permit.DomainID] = map[int64]int{}
+		}
+		domain := &response.Domains[di]
+
+		// Permits without a division are grouped under division 0
+		divisionKey := int64(0)
+		if permit.DivisionID != nil {
+			divisionKey = *permit.DivisionID
+		}
+		vi, ok := divisionIndex[permit.DomainID][divisionKey]
+		if !ok {
+			division := model.PermitDashboardDivision{
+				DivisionID:           permit.DivisionID,
+				DivisionName:         "No Division",
+				PermitDashboardStats: newStats(),
+			}
+			if permit.Division != nil {
+				division.DivisionName = permit.Division.Name
+			}
+			domain.Divisions = append(domain.Divisions, division)
+			vi = len(domain.Divisions) - 1
+			divisionIndex[permit.DomainID][divisionKey] = vi
+		}
+
+		for _, stats := range []*model.PermitDashboardStats{
+			&response.Summary,
+			&domain.PermitDashboardStats,
+			&domain.Divisions[vi].PermitDashboardStats,
+		} {
+			addPermit(stats, permit, days, score)
+		}
+
+		if score > 0 {
+			response.TopRisks = append(response.TopRisks, riskItem(permit, days, score))
+		}
+	}
+
+	roundRiskScores(response)
+
+	sort.SliceStable(response.TopRisks, func(i, j int) bool {
+		if response.TopRisks[i].RiskScore != response.TopRisks[j].RiskScore {
+			return response.TopRisks[i].RiskScore > response.TopRisks[j].RiskScore
+		}
+		return response.TopRisks[i].DaysToExpiry < response.TopRisks[j].DaysToExpiry
+	})
+	if len(response.TopRisks) > topRiskLimit {
+		response.TopRisks = response.TopRisks[:topRiskLimit]
+	}
+
+	return response, nil
+}
+
+func newStats() model.PermitDashboardStats {
+	byStatus := make(map[string]int, len(allPermitStatuses))
+	for _, status := range allPermitStatuses {
+		byStatus[status] = 0
+	}
+	return model.PermitDashboardStats{ByStatus: byStatus}
+}
+
+func addPermit(stats *model.PermitDashboardStats, permit *model.Permit, days int, score float64) {
+	stats.Total++
+	stats.ByStatus[permit.Status]++
+
+	if !trackedPermitStatuses[permit.Status] {
+		return
+	}
+
+	if days >= 0 {
+		if days <= 30 {
+			stats.ExpiringWithin30Days++
+		}
+		if days <= 60 {
+			stats.ExpiringWithin60Days++
+		}
+		if days <= 90 {
+			stats.ExpiringWithin90Days++
+		}
+	} else if permit.Status == model.PermitStatusActive || permit.Status == model.PermitStatusExpiring {
+		stats.ExpiredButActive++
+	}
+
+	if !hasLicenseDocument(permit) {
+		stats.MissingDocuments++
+	}
+
+	stats.RiskScore += score
+}
+
+// riskScore weighs the permit type's risk point by how close the permit is to
+// expiry: nothing beyond riskHorizonDays, rising linearly to the full weight at
+// the expiry date and staying there once expired
+func riskScore(permit *model.Permit, days int) float64 {
+	if !trackedPermitStatuses[permit.Status] {
+		return 0
+	}
+
+	urgency := 1.0
+	if days > 0 {
+		urgency = math.Max(0, 1-float64(days)/riskHorizonDays)
+	}
+	return riskWeight(permit.PermitType) * urgency
+}
+
+func riskWeight(permitType *model.PermitType) float64 {
+	if permitType != nil && permitType.RiskPoint != nil {
+		if weight, ok := riskWeights[strings.ToLower(strings.TrimSpace(*permitType.RiskPoint))]; ok {
+			return weight
+		}
+	}
+	return riskWeights["low"]
+}
+
+func hasLicenseDocument(permit *model.Permit) bool {
+	for _, document := range permit.Documents {
+		if document.DocumentTypeID == helper.PermitDocumentTypeLicense {
+			return true
+		}
+	}
+	return false
+}
+
+func riskItem(permit *model.Permit, days int, score float64) model.PermitDashboardRiskItem {
+	item := model.PermitDashboardRiskItem{
+		PermitID:     permit.ID,
+		PermitNo:     permit.PermitNo,
+		Name:         permit.Name,
+		Status:       permit.Status,
+		ExpiryDate:   permit.ExpiryDate,
+		DaysToExpiry: days,
+		RiskScore:    roundScore(score),
+	}
+	if permit.Domain != nil {
+		item.DomainName = permit.Domain.Name
+	}
+	if permit.Division != nil {
+		item.DivisionName = permit.Division.Name
+	}
+	if permit.PermitType != nil {
+		item.PermitTypeName = permit.PermitType.Name
+		item.RiskPoint = permit.PermitType.RiskPoint
+	}
+	return item
+}
+
+func roundRiskScores(response *model.PermitDashboardResponse) {
+	response.Summary.RiskScore = roundScore(response.Summary.RiskScore)
+	for i := range response.Domains {
+		domain := &response.Domains[i]
+		domain.RiskScore = roundScore(domain.RiskScore)
+		for j := range domain.Divisions {
+			domain.Divisions[j].RiskScore = roundScore(domain.Divisions[j].RiskScore)
+		}
+	}
+}
+
+func roundScore(score float64) float64 {
+	return math.Round(score*100) / 100
+}
+
+// daysToExpiry counts calendar days from today to the expiry date, negative once expired
+func daysToExpiry(expiryDate time.Time, today time.Time) int {
+	year, month, day := expiryDate.Date()
+	expiryDay := time.Date(year, month, day, 0, 0, 0, 0, today.Location())
+	return int(math.Round(expiryDay.Sub(today).Hours() / 24))
+}
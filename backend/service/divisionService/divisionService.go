@@ -8,7 +8,7 @@ import (
 
 type DivisionService interface {
 	CreateDivision(req *model.DivisionRequest) (*model.DivisionResponse, error)
-	GetDivisionByID(id int64) (*model.DivisionResponse, error)
+	GetDivisionByID(id int64, domainID *int64) (*model.DivisionResponse, error)
 	GetAllDivisions(filter *model.DivisionListRequest) ([]model.DivisionResponse, int64, error)
 	UpdateDivision(id int64, req *model.DivisionUpdateRequest) (*model.DivisionResponse, error)
 	DeleteDivision(id int64) error
@@ -32,9 +32,10 @@ func (s *divisionService) CreateDivision(req *model.DivisionRequest) (*model.Div
 	}
 
 	division := &model.Division{
-		DomainID: req.DomainID,
-		Code:     req.Code,
-		Name:     req.Name,
+		DomainID:   req.DomainID,
+		Code:       req.Code,
+		Name:       req.Name,
+		HeadUserID: req.HeadUserID,
 	}
 
 	err = s.repo.Create(division)
@@ -51,8 +52,10 @@ func (s *divisionService) CreateDivision(req *model.DivisionRequest) (*model.Div
 	return s.toResponse(division), nil
 }
 
-func (s *divisionService) GetDivisionByID(id int64) (*model.DivisionResponse, error) {
-	division, err := s.repo.FindByID(id)
+// GetDivisionByID returns the division if it belongs to the domain. A nil
+// domainID gives access to divisions of every domain.
+func (s *divisionService) GetDivisionByID(id int64, domainID *int64) (*model.DivisionResponse, error) {
+	division, err := s.repo.FindByIDInDomain(id, domainID)
 	if err != nil {
 		return nil, err
 	}
@@ -108,6 +111,16 @@ func (s *divisionService) UpdateDivision(id int64, req *model.DivisionUpdateRequ
 		return nil, err
 	}
 
+	if req.HeadUserID != nil {
+		var headUserID *int64
+		if *req.HeadUserID > 0 {
+			headUserID = req.HeadUserID
+		}
+		if err := s.repo.UpdateHead(id, headUserID); err != nil {
+			return nil, err
+		}
+	}
+
 	updatedDivision, err := s.repo.FindByID(id)
 	if err != nil {
 		return nil, err
@@ -127,12 +140,13 @@ func (s *divisionService) DeleteDivision(id int64) error {
 
 func (s *divisionService) toResponse(division *model.Division) *model.DivisionResponse {
 	resp := &model.DivisionResponse{
-		ID:        division.ID,
-		DomainID:  division.DomainID,
-		Code:      division.Code,
-		Name:      division.Name,
-		CreatedAt: division.CreatedAt,
-		UpdatedAt: division.UpdatedAt,
+		ID:         division.ID,
+		DomainID:   division.DomainID,
+		Code:       division.Code,
+		Name:       division.Name,
+		HeadUserID: division.HeadUserID,
+		CreatedAt:  division.CreatedAt,
+		UpdatedAt:  division.UpdatedAt,
 	}
 
 	if division.Domain != nil {
@@ -2,6 +2,7 @@ package domainService
 
 import (
 	"errors"
+	"permit-app/helper/emailTemplate"
 	"permit-app/model"
 	"permit-app/repo/domainRepository"
 )
@@ -35,9 +36,14 @@ func (s *domainService) CreateDomain(req *model.DomainRequest) (*model.DomainRes
 		Code:        req.Code,
 		Name:        req.Name,
 		Description: req.Description,
+		Locale:      req.Locale,
 		IsActive:    true,
 	}
 
+	if domain.Locale == "" {
+		domain.Locale = emailTemplate.DefaultLocale
+	}
+
 	if req.IsActive != nil {
 		domain.IsActive = *req.IsActive
 	}
@@ -98,6 +104,10 @@ func (s *domainService) UpdateDomain(id int64, req *model.DomainUpdateRequest) (
 		domain.Description = req.Description
 	}
 
+	if req.Locale != "" {
+		domain.Locale = req.Locale
+	}
+
 	if req.IsActive != nil {
 		domain.IsActive = *req.IsActive
 	}
@@ -130,6 +140,7 @@ func (s *domainService) toResponse(domain *model.Domain) *model.DomainResponse {
 		Code:        domain.Code,
 		Name:        domain.Name,
 		Description: domain.Description,
+		Locale:      domain.Locale,
 		IsActive:    domain.IsActive,
 		CreatedAt:   domain.CreatedAt,
 		UpdatedAt:   domain.UpdatedAt,
@@ -0,0 +1,145 @@
+package emailOutboxService
+
+import (
+	"errors"
+	"permit-app/helper"
+	"permit-app/model"
+	"permit-app/repo/emailOutboxRepository"
+	"time"
+)
+
+var (
+	// ErrEmailNotFound is returned when the outbox email does not exist
+	ErrEmailNotFound = errors.New("email not found")
+	// ErrEmailNotFailed is returned when resending an email that has not failed
+	ErrEmailNotFailed = errors.New("only failed emails can be resent")
+)
+
+const (
+	// emailBatchSize is how many due emails one delivery run claims
+	emailBatchSize = 20
+	// emailSendLease is how long a claimed email is left to its worker before
+	// another run may claim it again
+	emailSendLease = 5 * time.Minute
+	// emailRetryBaseDelay is the wait after the first failed attempt, doubled
+	// after every further failure up to emailRetryMaxDelay
+	emailRetryBaseDelay = time.Minute
+	emailRetryMaxDelay  = 6 * time.Hour
+)
+
+type EmailOutboxService interface {
+	GetAll(filter *model.EmailOutboxListRequest) ([]model.EmailOutbox, int64, error)
+	GetByID(id int64) (*model.EmailOutbox, error)
+	Resend(id int64) (*model.EmailOutbox, error)
+	DeliverDue() (int, error)
+	PurgeSent(before time.Time) (int, error)
+}
+
+type emailOutboxService struct {
+	repo emailOutboxRepository.EmailOutboxRepository
+}
+
+func NewEmailOutboxService(repo emailOutboxRepository.EmailOutboxRepository) EmailOutboxService {
+	return &emailOutboxService{repo: repo}
+}
+
+func (s *emailOutboxService) GetAll(filter *model.EmailOutboxListRequest) ([]model.EmailOutbox, int64, error) {
+	return s.repo.FindAll(filter)
+}
+
+func (s *emailOutboxService) GetByID(id int64) (*model.EmailOutbox, error) {
+	email, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if email == nil {
+		return nil, ErrEmailNotFound
+	}
+	return email, nil
+}
+
+// Resend queues a failed email again with a fresh set of attempts. Its earlier
+// attempts stay in the delivery log.
+func (s *emailOutboxService) Resend(id int64) (*model.EmailOutbox, error) {
+	email, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if email.Status != model.EmailStatusFailed {
+		return nil, ErrEmailNotFailed
+	}
+
+	if err := s.repo.Requeue(id, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(id)
+}
+
+// DeliverDue sends the emails whose next attempt is due and returns how many
+// were sent. Failed emails are retried with exponential backoff until they
+// reach their maximum attempts.
+func (s *emailOutboxService) DeliverDue() (int, error) {
+	emails, err := s.repo.ClaimDue(time.Now(), emailBatchSize, emailSendLease)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range emails {
+		email := &emails[i]
+		sendErr := helper.SendEmail(email.Recipients, email.Subject, email.Body, email.TextBody)
+
+		now := time.Now()
+		email.Attempts++
+		email.LockedUntil = nil
+		attempt := &model.EmailDeliveryAttempt{
+			OutboxID: email.ID,
+			Attempt:  email.Attempts,
+			Status:   model.EmailStatusSent,
+		}
+
+		if sendErr == nil {
+			email.Status = model.EmailStatusSent
+			email.SentAt = &now
+			email.LastError = nil
+			sent++
+		} else {
+			message := sendErr.Error()
+			attempt.Status = model.EmailStatusFailed
+			attempt.Error = &message
+			email.LastError = &message
+			if email.Attempts >= email.MaxAttempts {
+				email.Status = model.EmailStatusFailed
+			} else {
+				email.Status = model.EmailStatusPending
+				email.NextAttemptAt = now.Add(retryDelay(email.Attempts))
+			}
+		}
+
+		if err := s.repo.RecordAttempt(email, attempt); err != nil {
+			return sent, err
+		}
+	}
+
+	return sent, nil
+}
+
+// PurgeSent removes the emails sent before the given time from the outbox and
+// returns how many were removed. Failed emails stay until they are resent.
+func (s *emailOutboxService) PurgeSent(before time.Time) (int, error) {
+	removed, err := s.repo.DeleteSentBefore(before)
+	return int(removed), err
+}
+
+// retryDelay is the wait before the next try after the given number of failed attempts
+func retryDelay(attempts int) time.Duration {
+	delay := emailRetryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= emailRetryMaxDelay {
+			return emailRetryMaxDelay
+		}
+	}
+	return delay
+}
@@ -0,0 +1,163 @@
+package emailTemplateService
+
+import (
+	"errors"
+	"fmt"
+	"permit-app/helper/emailTemplate"
+	"permit-app/model"
+	"permit-app/repo/emailTemplateRepository"
+	"slices"
+)
+
+var (
+	// ErrUnknownTemplate is returned for a template name domains cannot
+	// override or an unsupported locale
+	ErrUnknownTemplate = errors.New("unknown email template or locale")
+	// ErrInvalidTemplate is returned when a template does not parse or fails to
+	// render with sample data
+	ErrInvalidTemplate = errors.New("invalid email template")
+)
+
+type EmailTemplateService interface {
+	GetTemplates(domainID int64) ([]model.EmailTemplateResponse, error)
+	UpdateTemplate(domainID int64, name string, locale string, req *model.UpdateEmailTemplateRequest, userID int64) (*model.EmailTemplateResponse, error)
+	ResetTemplate(domainID int64, name string, locale string) error
+	Preview(domainID int64, name string, locale string, req *model.PreviewEmailTemplateRequest) (*model.EmailTemplatePreviewResponse, error)
+}
+
+type emailTemplateService struct {
+	repo emailTemplateRepository.EmailTemplateRepository
+}
+
+func NewEmailTemplateService(repo emailTemplateRepository.EmailTemplateRepository) EmailTemplateService {
+	return &emailTemplateService{repo: repo}
+}
+
+// GetTemplates returns every overridable template in every locale, the
+// domain's override where it has one and the built-in template otherwise
+func (s *emailTemplateService) GetTemplates(domainID int64) ([]model.EmailTemplateResponse, error) {
+	overrides, err := s.repo.FindByDomainID(domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]model.EmailTemplateResponse, 0, len(emailTemplate.DomainNames)*len(emailTemplate.Locales))
+	for _, name := range emailTemplate.DomainNames {
+		for _, locale := range emailTemplate.Locales {
+			index := slices.IndexFunc(overrides, func(t model.EmailTemplate) bool {
+				return t.Name == name && t.Locale == locale
+			})
+			if index >= 0 {
+				responses = append(responses, s.toResponse(&overrides[index]))
+				continue
+			}
+
+			source, err := emailTemplate.DefaultSource(name, locale)
+			if err != nil {
+				return nil, err
+			}
+			responses = append(responses, model.EmailTemplateResponse{
+				DomainID:  domainID,
+				Name:      name,
+				Locale:    locale,
+				Subject:   source.Subject,
+				HTMLBody:  source.HTML,
+				TextBody:  source.Text,
+				IsDefault: true,
+			})
+		}
+	}
+
+	return responses, nil
+}
+
+// UpdateTemplate saves the domain's override of a template after checking
+// that it renders
+func (s *emailTemplateService) UpdateTemplate(domainID int64, name string, locale string, req *model.UpdateEmailTemplateRequest, userID int64) (*model.EmailTemplateResponse, error) {
+	if !isOverridable(name, locale) {
+		return nil, ErrUnknownTemplate
+	}
+
+	source := emailTemplate.Source{Subject: req.Subject, HTML: req.HTMLBody, Text: req.TextBody}
+	if err := emailTemplate.Validate(name, locale, source); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+
+	template := &model.EmailTemplate{
+		DomainID:  domainID,
+		Name:      name,
+		Locale:    locale,
+		Subject:   req.Subject,
+		HTMLBody:  req.HTMLBody,
+		TextBody:  req.TextBody,
+		UpdatedBy: &userID,
+	}
+	if err := s.repo.Save(template); err != nil {
+		return nil, err
+	}
+
+	saved, err := s.repo.FindOverride(domainID, name, locale)
+	if err != nil {
+		return nil, err
+	}
+	response := s.toResponse(saved)
+	return &response, nil
+}
+
+// ResetTemplate removes the domain's override so the built-in template applies
+// again
+func (s *emailTemplateService) ResetTemplate(domainID int64, name string, locale string) error {
+	if !isOverridable(name, locale) {
+		return ErrUnknownTemplate
+	}
+	return s.repo.Delete(domainID, name, locale)
+}
+
+// Preview renders the posted source with sample data, or the template the
+// domain currently uses when nothing is posted
+func (s *emailTemplateService) Preview(domainID int64, name string, locale string, req *model.PreviewEmailTemplateRequest) (*model.EmailTemplatePreviewResponse, error) {
+	if !isOverridable(name, locale) {
+		return nil, ErrUnknownTemplate
+	}
+
+	var source *emailTemplate.Source
+	if req.Subject != "" || req.HTMLBody != "" || req.TextBody != "" {
+		source = &emailTemplate.Source{Subject: req.Subject, HTML: req.HTMLBody, Text: req.TextBody}
+	} else {
+		override, err := s.repo.FindOverride(domainID, name, locale)
+		if err != nil {
+			return nil, err
+		}
+		if override != nil {
+			source = &emailTemplate.Source{Subject: override.Subject, HTML: override.HTMLBody, Text: override.TextBody}
+		}
+	}
+
+	email, err := emailTemplate.Render(name, locale, emailTemplate.SampleData(name), source)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+
+	return &model.EmailTemplatePreviewResponse{
+		Subject:  email.Subject,
+		HTMLBody: email.HTML,
+		TextBody: email.Text,
+	}, nil
+}
+
+func isOverridable(name string, locale string) bool {
+	return slices.Contains(emailTemplate.DomainNames, name) && emailTemplate.IsLocale(locale)
+}
+
+func (s *emailTemplateService) toResponse(template *model.EmailTemplate) model.EmailTemplateResponse {
+	return model.EmailTemplateResponse{
+		DomainID:  template.DomainID,
+		Name:      template.Name,
+		Locale:    template.Locale,
+		Subject:   template.Subject,
+		HTMLBody:  template.HTMLBody,
+		TextBody:  template.TextBody,
+		IsDefault: false,
+		UpdatedAt: &template.UpdatedAt,
+	}
+}
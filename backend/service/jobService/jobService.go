@@ -0,0 +1,423 @@
+package jobService
+
+import (
+	"errors"
+	"fmt"
+	"permit-app/helper"
+	"permit-app/model"
+	"permit-app/repo/jobRepository"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrJobNotFound is returned for a name no job was registered under
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobRunning is returned when triggering a job that is still running,
+	// on this instance or another one
+	ErrJobRunning = errors.New("job is already running")
+)
+
+const (
+	// leaderLockKey is the advisory lock held by the instance whose scheduler
+	// runs the jobs
+	leaderLockKey = "permit-app:scheduler"
+	// jobLockPrefix starts the advisory lock key held while a job runs, on
+	// whichever instance it runs
+	jobLockPrefix = "permit-app:job:"
+	// interruptedRunError is recorded on runs whose instance stopped before
+	// finishing them
+	interruptedRunError = "interrupted: the instance running the job stopped"
+)
+
+// JobFunc does one run of a job and returns how many items it processed
+type JobFunc func() (int, error)
+
+type job struct {
+	name        string
+	description string
+	schedule    *helper.CronSchedule
+	run         JobFunc
+}
+
+type JobService interface {
+	Register(name string, description string, cron string, run JobFunc) error
+	Names() []string
+	ScheduleNext(name string, after time.Time) time.Time
+	RunScheduled(name string) (*model.JobRun, error)
+	GetJobs() ([]model.JobResponse, error)
+	GetJob(name string) (*model.JobResponse, error)
+	GetRuns(name string, filter *model.JobRunListRequest) ([]model.JobRun, int64, error)
+	Trigger(name string, userID int64) (*model.JobRun, error)
+	Pause(name string, userID int64) (*model.JobResponse, error)
+	Resume(name string) (*model.JobResponse, error)
+	PurgeRuns(before time.Time) (int, error)
+	Lead() (bool, error)
+	StepDown() error
+}
+
+// jobService is the job registry of one instance. Jobs are registered at
+// startup, before the scheduler starts; when they run next is kept in memory,
+// their pause state and runs in the database. Advisory locks keep a job from
+// running on two instances at once, and only the leading instance schedules.
+type jobService struct {
+	repo jobRepository.JobRepository
+
+	jobs  []*job
+	names map[string]*job
+
+	mu         sync.Mutex
+	running    map[string]*jobRepository.AdvisoryLock
+	next       map[string]time.Time
+	leaderLock *jobRepository.AdvisoryLock
+}
+
+func NewJobService(repo jobRepository.JobRepository) JobService {
+	return &jobService{
+		repo:    repo,
+		names:   make(map[string]*job),
+		running: make(map[string]*jobRepository.AdvisoryLock),
+		next:    make(map[string]time.Time),
+	}
+}
+
+// Register adds a job running on the given cron expression
+func (s *jobService) Register(name string, description string, cron string, run JobFunc) error {
+	if _, ok := s.names[name]; ok {
+		return fmt.Errorf("job %s is already registered", name)
+	}
+	schedule, err := helper.ParseCron(cron)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+
+	j := &job{name: name, description: description, schedule: schedule, run: run}
+	s.jobs = append(s.jobs, j)
+	s.names[name] = j
+	return nil
+}
+
+// Names returns the registered jobs in registration order
+func (s *jobService) Names() []string {
+	names := make([]string, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		names = append(names, j.name)
+	}
+	return names
+}
+
+// ScheduleNext works out and remembers the job's first run after the given
+// time, the zero time when its schedule never matches
+func (s *jobService) ScheduleNext(name string, after time.Time) time.Time {
+	j, ok := s.names[name]
+	if !ok {
+		return time.Time{}
+	}
+	next := j.schedule.Next(after)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next[name] = next
+	return next
+}
+
+// RunScheduled runs the job and returns its run. A paused job, or one that is
+// still running on any instance, is skipped and nil is returned. The returned
+// error is about recording the run, a failed job only shows in the run's status.
+func (s *jobService) RunScheduled(name string) (*model.JobRun, error) {
+	j, ok := s.names[name]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	state, err := s.repo.FindState(name)
+	if err != nil {
+		return nil, err
+	}
+	if state != nil && state.IsPaused {
+		return nil, nil
+	}
+
+	claimed, err := s.claim(name)
+	if err != nil || !claimed {
+		return nil, err
+	}
+	run, err := s.start(j, model.JobTriggerSchedule, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.execute(j, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func (s *jobService) GetJobs() ([]model.JobResponse, error) {
+	states, err := s.repo.FindStates()
+	if err != nil {
+		return nil, err
+	}
+	lastRuns, err := s.repo.FindLastRuns()
+	if err != nil {
+		return nil, err
+	}
+
+	stateByName := make(map[string]*model.JobState, len(states))
+	for i := range states {
+		stateByName[states[i].Name] = &states[i]
+	}
+	lastRunByName := make(map[string]*model.JobRun, len(lastRuns))
+	for i := range lastRuns {
+		lastRunByName[lastRuns[i].JobName] = &lastRuns[i]
+	}
+
+	jobs := make([]model.JobResponse, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, s.toResponse(j, stateByName[j.name], lastRunByName[j.name]))
+	}
+	return jobs, nil
+}
+
+func (s *jobService) GetJob(name string) (*model.JobResponse, error) {
+	j, ok := s.names[name]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	state, err := s.repo.FindState(name)
+	if err != nil {
+		return nil, err
+	}
+	runs, _, err := s.repo.FindRuns(name, &model.JobRunListRequest{Page: 1, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+
+	var lastRun *model.JobRun
+	if len(runs) > 0 {
+		lastRun = &runs[0]
+	}
+	response := s.toResponse(j, state, lastRun)
+	return &response, nil
+}
+
+// GetRuns lists the job's runs, newest first
+func (s *jobService) GetRuns(name string, filter *model.JobRunListRequest) ([]model.JobRun, int64, error) {
+	if _, ok := s.names[name]; !ok {
+		return nil, 0, ErrJobNotFound
+	}
+	return s.repo.FindRuns(name, filter)
+}
+
+// Trigger starts a run of the job right away, paused or not, and returns it
+// while it is still running. Its outcome is recorded when it finishes.
+func (s *jobService) Trigger(name string, userID int64) (*model.JobRun, error) {
+	j, ok := s.names[name]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	claimed, err := s.claim(name)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrJobRunning
+	}
+	run, err := s.start(j, model.JobTriggerManual, &userID)
+	if err != nil {
+		return nil, err
+	}
+
+	started := *run
+	go s.execute(j, run)
+	return &started, nil
+}
+
+// Pause stops the scheduler from running the job until it is resumed, a run
+// in progress is left to finish
+func (s *jobService) Pause(name string, userID int64) (*model.JobResponse, error) {
+	if _, ok := s.names[name]; !ok {
+		return nil, ErrJobNotFound
+	}
+
+	now := time.Now()
+	state := &model.JobState{Name: name, IsPaused: true, PausedBy: &userID, PausedAt: &now}
+	if err := s.repo.SaveState(state); err != nil {
+		return nil, err
+	}
+	return s.GetJob(name)
+}
+
+func (s *jobService) Resume(name string) (*model.JobResponse, error) {
+	if _, ok := s.names[name]; !ok {
+		return nil, ErrJobNotFound
+	}
+
+	if err := s.repo.SaveState(&model.JobState{Name: name}); err != nil {
+		return nil, err
+	}
+	return s.GetJob(name)
+}
+
+// PurgeRuns removes the finished runs started before the given time and
+// returns how many were removed
+func (s *jobService) PurgeRuns(before time.Time) (int, error) {
+	removed, err := s.repo.DeleteRunsBefore(before)
+	return int(removed), err
+}
+
+// claim takes the job's lock, false when the job is already running here or
+// on another instance. Runs still recorded as running belong to an instance
+// that stopped, they are marked as failed.
+func (s *jobService) claim(name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.running[name]; ok {
+		return false, nil
+	}
+
+	lock, err := s.repo.TryLock(jobLockPrefix + name)
+	if err != nil || lock == nil {
+		return false, err
+	}
+	if _, err := s.repo.FailInterruptedRuns(name, interruptedRunError); err != nil {
+		lock.Release()
+		return false, err
+	}
+
+	s.running[name] = lock
+	return true, nil
+}
+
+// release gives up the job's lock. A lock that cannot be released cleanly is
+// dropped with its connection, so the error is of no further use.
+func (s *jobService) release(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if lock, ok := s.running[name]; ok {
+		lock.Release()
+		delete(s.running, name)
+	}
+}
+
+// start records the beginning of a run of the claimed job, releasing the
+// claim when that fails
+func (s *jobService) start(j *job, trigger string, triggeredBy *int64) (*model.JobRun, error) {
+	run := &model.JobRun{
+		JobName:     j.name,
+		Trigger:     trigger,
+		TriggeredBy: triggeredBy,
+		Status:      model.JobRunStatusRunning,
+		StartedAt:   time.Now(),
+	}
+	if err := s.repo.CreateRun(run); err != nil {
+		s.release(j.name)
+		return nil, err
+	}
+	return run, nil
+}
+
+// execute runs the job, records the outcome on the run and releases the claim
+func (s *jobService) execute(j *job, run *model.JobRun) error {
+	defer s.release(j.name)
+
+	items, err := call(j.run)
+
+	finishedAt := time.Now()
+	durationMs := finishedAt.Sub(run.StartedAt).Milliseconds()
+	run.FinishedAt = &finishedAt
+	run.DurationMs = &durationMs
+	run.ItemsProcessed = items
+	run.Status = model.JobRunStatusSucceeded
+	if err != nil {
+		message := err.Error()
+		run.Status = model.JobRunStatusFailed
+		run.Error = &message
+	}
+
+	return s.repo.FinishRun(run)
+}
+
+// Lead reports whether this instance leads the scheduling, taking the leader
+// lock when no instance holds it. The leader keeps the lock until it steps
+// down or its connection drops, then another instance takes over.
+func (s *jobService) Lead() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.leaderLock != nil {
+		if err := s.leaderLock.Check(); err != nil {
+			s.leaderLock.Release()
+			s.leaderLock = nil
+			return false, err
+		}
+		return true, nil
+	}
+
+	lock, err := s.repo.TryLock(leaderLockKey)
+	if err != nil || lock == nil {
+		return false, err
+	}
+	s.leaderLock = lock
+	return true, nil
+}
+
+// StepDown gives up the leader lock so another instance can take over
+func (s *jobService) StepDown() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.leaderLock == nil {
+		return nil
+	}
+	err := s.leaderLock.Release()
+	s.leaderLock = nil
+	return err
+}
+
+// call runs the job function, turning a panic into an error so that one
+// broken job does not take the scheduler down
+func call(run JobFunc) (items int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return run()
+}
+
+func (s *jobService) toResponse(j *job, state *model.JobState, lastRun *model.JobRun) model.JobResponse {
+	s.mu.Lock()
+	_, isRunning := s.running[j.name]
+	next, scheduled := s.next[j.name]
+	s.mu.Unlock()
+
+	// Running on another instance
+	if lastRun != nil && lastRun.Status == model.JobRunStatusRunning {
+		isRunning = true
+	}
+
+	response := model.JobResponse{
+		Name:        j.name,
+		Description: j.description,
+		Cron:        j.schedule.String(),
+		IsRunning:   isRunning,
+		LastRun:     lastRun,
+	}
+	if state != nil {
+		response.IsPaused = state.IsPaused
+		response.PausedBy = state.PausedBy
+		response.PausedAt = state.PausedAt
+	}
+	if !response.IsPaused {
+		// Not scheduled on this instance, or no longer since it lost the lead
+		if now := time.Now(); !scheduled || next.Before(now) {
+			next = j.schedule.Next(now)
+		}
+		if !next.IsZero() {
+			response.NextRunAt = &next
+		}
+	}
+	return response
+}
@@ -3,11 +3,21 @@ package notificationService
 import (
 	"errors"
 	"fmt"
+	"math"
 	"permit-app/helper"
+	"permit-app/helper/emailTemplate"
 	"permit-app/model"
+	"permit-app/repo/domainRepository"
+	"permit-app/repo/emailTemplateRepository"
+	"permit-app/repo/escalationRepository"
+	"permit-app/repo/notificationPreferenceRepository"
 	"permit-app/repo/notificationRepository"
+	"permit-app/repo/permitObligationRepository"
 	"permit-app/repo/permitRepository"
+	"permit-app/repo/reminderScheduleRepository"
+	"permit-app/repo/roleRepository"
 	"permit-app/repo/userRepository"
+	"slices"
 	"time"
 )
 
@@ -18,24 +28,72 @@ type NotificationService interface {
 	MarkAsRead(notificationIDs []int64, userID int64) error
 	MarkAllAsRead(userID int64) error
 	DeleteNotification(id int64, userID int64) error
-	CheckAndSendExpiryNotifications() error
+	CheckAndSendExpiryNotifications() (int, error)
+	CheckAndSendObligationNotifications() (int, error)
+	GetPreferences(userID int64) ([]model.NotificationPreferenceResponse, error)
+	UpdatePreferences(userID int64, req *model.UpdateNotificationPreferencesRequest) ([]model.NotificationPreferenceResponse, error)
+	GetRecipientRules(domainID int64) ([]model.NotificationRecipientRuleResponse, error)
+	UpdateRecipientRules(domainID int64, req *model.UpdateNotificationRecipientRulesRequest) ([]model.NotificationRecipientRuleResponse, error)
+	ResetRecipientRule(domainID int64, eventType string) error
+	GetEscalationRules(domainID int64) ([]model.EscalationRule, error)
+	UpdateEscalationRules(domainID int64, req *model.UpdateEscalationRulesRequest) ([]model.EscalationRule, error)
+	GetEscalations(domainID int64, filter *model.PermitEscalationListRequest) ([]model.PermitEscalation, int64, error)
+	GetDigestSetting(userID int64) (*model.NotificationDigestSettingResponse, error)
+	UpdateDigestSetting(userID int64, req *model.UpdateNotificationDigestSettingRequest) (*model.NotificationDigestSettingResponse, error)
+	SendDigests() (int, error)
+}
+
+var (
+	// ErrInvalidRecipientRule is returned for recipient rules of unknown events or roles
+	ErrInvalidRecipientRule = errors.New("invalid recipient rule")
+	// ErrInvalidEscalationRule is returned for escalation rules without recipients or with unknown roles
+	ErrInvalidEscalationRule = errors.New("invalid escalation rule")
+)
+
+// builtInRecipientRules describe who is notified of the events that have
+// configurable recipients when the domain has no rule. Expiry notifications go
+// to admins of every domain, overdue obligations to the domain's Permit Managers.
+var builtInRecipientRules = map[string]model.NotificationRecipientRule{
+	model.NotificationEventPermitExpiry:     {NotifyResponsible: true, RoleCodes: []string{helper.RoleCodeAdmin, helper.RoleCodePermitManager}},
+	model.NotificationEventPermitObligation: {NotifyResponsible: true, RoleCodes: []string{helper.RoleCodePermitManager}},
 }
 
 type notificationService struct {
-	notificationRepo notificationRepository.NotificationRepository
-	permitRepo       permitRepository.PermitRepository
-	userRepo         userRepository.UserRepository
+	notificationRepo     notificationRepository.NotificationRepository
+	permitRepo           permitRepository.PermitRepository
+	userRepo             userRepository.UserRepository
+	reminderScheduleRepo reminderScheduleRepository.ReminderScheduleRepository
+	obligationRepo       permitObligationRepository.PermitObligationRepository
+	preferenceRepo       notificationPreferenceRepository.NotificationPreferenceRepository
+	roleRepo             roleRepository.RoleRepository
+	domainRepo           domainRepository.DomainRepository
+	templateRepo         emailTemplateRepository.EmailTemplateRepository
+	escalationRepo       escalationRepository.EscalationRepository
 }
 
 func NewNotificationService(
 	notificationRepo notificationRepository.NotificationRepository,
 	permitRepo permitRepository.PermitRepository,
 	userRepo userRepository.UserRepository,
+	reminderScheduleRepo reminderScheduleRepository.ReminderScheduleRepository,
+	obligationRepo permitObligationRepository.PermitObligationRepository,
+	preferenceRepo notificationPreferenceRepository.NotificationPreferenceRepository,
+	roleRepo roleRepository.RoleRepository,
+	domainRepo domainRepository.DomainRepository,
+	templateRepo emailTemplateRepository.EmailTemplateRepository,
+	escalationRepo escalationRepository.EscalationRepository,
 ) NotificationService {
 	return &notificationService{
-		notificationRepo: notificationRepo,
-		permitRepo:       permitRepo,
-		userRepo:         userRepo,
+		notificationRepo:     notificationRepo,
+		permitRepo:           permitRepo,
+		userRepo:             userRepo,
+		reminderScheduleRepo: reminderScheduleRepo,
+		obligationRepo:       obligationRepo,
+		preferenceRepo:       preferenceRepo,
+		roleRepo:             roleRepo,
+		domainRepo:           domainRepo,
+		templateRepo:         templateRepo,
+		escalationRepo:       escalationRepo,
 	}
 }
 
@@ -82,146 +140,1085 @@ func (s *notificationService) DeleteNotification(id int64, userID int64) error {
 	return s.notificationRepo.Delete(id)
 }
 
-func (s *notificationService) CheckAndSendExpiryNotifications() error {
+// GetPreferences returns the user's channels for every event, with the default
+// channels for events the user has not set
+func (s *notificationService) GetPreferences(userID int64) ([]model.NotificationPreferenceResponse, error) {
+	preferences, err := s.preferenceRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	saved := make(map[string]model.NotificationChannels, len(preferences))
+	for i := range preferences {
+		saved[preferences[i].EventType] = preferences[i].Channels()
+	}
+
+	responses := make([]model.NotificationPreferenceResponse, 0, len(model.NotificationEvents))
+	for _, event := range model.NotificationEvents {
+		channels, ok := saved[event]
+		if !ok {
+			channels = model.DefaultNotificationChannels
+		}
+		responses = append(responses, model.NotificationPreferenceResponse{
+			EventType:            event,
+			NotificationChannels: channels,
+			IsDefault:            !ok,
+		})
+	}
+
+	return responses, nil
+}
+
+func (s *notificationService) UpdatePreferences(userID int64, req *model.UpdateNotificationPreferencesRequest) ([]model.NotificationPreferenceResponse, error) {
+	preferences := make([]model.NotificationPreference, 0, len(req.Preferences))
+	for _, item := range req.Preferences {
+		preferences = append(preferences, model.NotificationPreference{
+			UserID:    userID,
+			EventType: item.EventType,
+			InApp:     item.InApp,
+			Email:     item.Email,
+			Digest:    item.Digest,
+		})
+	}
+
+	if err := s.preferenceRepo.SavePreferences(preferences); err != nil {
+		return nil, err
+	}
+
+	return s.GetPreferences(userID)
+}
+
+// GetRecipientRules returns the domain's recipient rule for every event with
+// configurable recipients, or the built-in recipients where none is set
+func (s *notificationService) GetRecipientRules(domainID int64) ([]model.NotificationRecipientRuleResponse, error) {
+	rules, err := s.preferenceRepo.FindRulesByDomainID(domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	saved := make(map[string]model.NotificationRecipientRule, len(rules))
+	for _, rule := range rules {
+		saved[rule.EventType] = rule
+	}
+
+	responses := make([]model.NotificationRecipientRuleResponse, 0, len(builtInRecipientRules))
+	for _, event := range model.NotificationEvents {
+		builtIn, configurable := builtInRecipientRules[event]
+		if !configurable {
+			continue
+		}
+
+		rule, ok := saved[event]
+		if !ok {
+			rule = builtIn
+		}
+		responses = append(responses, model.NotificationRecipientRuleResponse{
+			DomainID:          domainID,
+			EventType:         event,
+			NotifyResponsible: rule.NotifyResponsible,
+			RoleCodes:         rule.RoleCodes,
+			IsDefault:         !ok,
+		})
+	}
+
+	return responses, nil
+}
+
+func (s *notificationService) UpdateRecipientRules(domainID int64, req *model.UpdateNotificationRecipientRulesRequest) ([]model.NotificationRecipientRuleResponse, error) {
+	rules := make([]model.NotificationRecipientRule, 0, len(req.Rules))
+	for _, item := range req.Rules {
+		if _, configurable := builtInRecipientRules[item.EventType]; !configurable {
+			return nil, fmt.Errorf("%w: recipients of %s cannot be configured", ErrInvalidRecipientRule, item.EventType)
+		}
+
+		roleCodes := make([]string, 0, len(item.RoleCodes))
+		for _, code := range item.RoleCodes {
+			if slices.Contains(roleCodes, code) {
+				continue
+			}
+			if _, err := s.roleRepo.FindByCode(code); err != nil {
+				return nil, fmt.Errorf("%w: unknown role %s", ErrInvalidRecipientRule, code)
+			}
+			roleCodes = append(roleCodes, code)
+		}
+
+		rules = append(rules, model.NotificationRecipientRule{
+			DomainID:          domainID,
+			EventType:         item.EventType,
+			NotifyResponsible: item.NotifyResponsible,
+			RoleCodes:         roleCodes,
+		})
+	}
+
+	if err := s.preferenceRepo.SaveRules(rules); err != nil {
+		return nil, err
+	}
+
+	return s.GetRecipientRules(domainID)
+}
+
+func (s *notificationService) GetDigestSetting(userID int64) (*model.NotificationDigestSettingResponse, error) {
+	setting, err := s.preferenceRepo.FindDigestSetting(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := s.notificationRepo.CountPendingDigestItems(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.NotificationDigestSettingResponse{
+		Frequency:    model.DefaultDigestFrequency,
+		PendingCount: pending,
+		IsDefault:    setting == nil,
+	}
+	if setting != nil {
+		response.Frequency = setting.Frequency
+		response.LastSentAt = setting.LastSentAt
+	}
+
+	return response, nil
+}
+
+func (s *notificationService) UpdateDigestSetting(userID int64, req *model.UpdateNotificationDigestSettingRequest) (*model.NotificationDigestSettingResponse, error) {
+	if err := s.preferenceRepo.SaveDigestFrequency(userID, req.Frequency); err != nil {
+		return nil, err
+	}
+
+	return s.GetDigestSetting(userID)
+}
+
+// SendDigests emails every user with pending digest items one summary of them,
+// daily or weekly as the user chose, and returns how many digests were queued.
+// Items stay pending when the email fails and go out with the next run.
+func (s *notificationService) SendDigests() (int, error) {
+	userIDs, err := s.notificationRepo.FindPendingDigestUserIDs()
+	if err != nil {
+		return 0, err
+	}
+
 	now := time.Now()
-	
-	// Check untuk 30 hari sebelum expiry
-	thirtyDaysLater := now.AddDate(0, 0, 30)
-	// Check untuk 7 hari sebelum expiry
-	sevenDaysLater := now.AddDate(0, 0, 7)
-	// Check untuk hari ini (expired)
-	today := now
-
-	// Get permits yang akan expired dalam 30 hari
-	permits30Days, err := s.permitRepo.FindExpiringPermits(now, thirtyDaysLater)
+	today := startOfDay(now)
+
+	sent := 0
+	var errs []error
+	for _, userID := range userIDs {
+		setting, err := s.preferenceRepo.FindDigestSetting(userID)
+		if err != nil {
+			return sent, err
+		}
+
+		frequency := model.DefaultDigestFrequency
+		if setting != nil {
+			frequency = setting.Frequency
+			if !digestDue(frequency, setting.LastSentAt, today) {
+				continue
+			}
+		}
+
+		queued, err := s.sendDigest(userID, frequency, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("digest for user %d: %w", userID, err))
+		}
+		if queued {
+			sent++
+		}
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+// digestDue reports whether the user's digest goes out today: daily digests
+// once a day, weekly ones when the last was sent seven or more days ago
+func digestDue(frequency string, lastSentAt *time.Time, today time.Time) bool {
+	if lastSentAt == nil {
+		return true
+	}
+
+	days := 1
+	if frequency == model.DigestFrequencyWeekly {
+		days = 7
+	}
+	return lastSentAt.Before(today.AddDate(0, 0, 1-days))
+}
+
+func (s *notificationService) sendDigest(userID int64, frequency string, now time.Time) (bool, error) {
+	user, err := s.userRepo.FindById(userID)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	// Get permits yang akan expired dalam 7 hari
-	permits7Days, err := s.permitRepo.FindExpiringPermits(now, sevenDaysLater)
+	items, err := s.notificationRepo.FindPendingDigestItems(userID)
 	if err != nil {
-		return err
+		return false, err
+	}
+	if len(items) == 0 {
+		return false, nil
 	}
 
-	// Get permits yang expired hari ini
-	permitsExpired, err := s.permitRepo.FindExpiringPermits(today.AddDate(0, 0, -1), today)
+	itemIDs := make([]int64, 0, len(items))
+	for _, item := range items {
+		itemIDs = append(itemIDs, item.ID)
+	}
+
+	// Digests span domains, so only the user's own locale applies
+	data := emailTemplate.NewDigestData(frequency, digestDomains(items))
+	email, err := emailTemplate.Render(emailTemplate.NotificationDigest, emailLocale(user, ""), data, nil)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	// Process 30 days notification
-	for _, permit := range permits30Days {
-		daysLeft := int(time.Until(permit.ExpiryDate).Hours() / 24)
-		if daysLeft <= 30 && daysLeft > 7 {
-			s.processPermitNotification(permit, "expiry_reminder", daysLeft)
+	outbox := model.NewEmailOutbox([]string{user.Email}, email.Subject, email.HTML, email.Text)
+	if err := s.notificationRepo.QueueDigestEmail(outbox, itemIDs, now); err != nil {
+		return false, err
+	}
+
+	if err := s.preferenceRepo.MarkDigestSent(userID, now); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// digestSeverities is the order severities are listed in within a domain
+var digestSeverities = []string{
+	model.ReminderSeverityCritical,
+	model.ReminderSeverityWarning,
+	model.ReminderSeverityInfo,
+}
+
+// digestDomains groups the items, ordered by domain, into domain sections
+// with the most severe items first
+func digestDomains(items []model.NotificationDigestItem) []emailTemplate.DigestDomain {
+	var domains []emailTemplate.DigestDomain
+	for start := 0; start < len(items); {
+		end := start
+		for end < len(items) && items[end].DomainID == items[start].DomainID {
+			end++
+		}
+
+		name := fmt.Sprintf("Domain %d", items[start].DomainID)
+		if items[start].Domain != nil {
+			name = items[start].Domain.Name
 		}
+
+		domain := emailTemplate.DigestDomain{Name: name}
+		for _, severity := range digestSeverities {
+			group := emailTemplate.DigestGroup{Severity: severity}
+			for _, item := range items[start:end] {
+				if item.Severity == severity {
+					group.Entries = append(group.Entries, emailTemplate.DigestEntry{
+						Title:   item.Title,
+						Message: item.Message,
+						Link:    item.Link,
+					})
+				}
+			}
+			if len(group.Entries) > 0 {
+				domain.Groups = append(domain.Groups, group)
+			}
+		}
+		domains = append(domains, domain)
+
+		start = end
+	}
+	return domains
+}
+
+// ResetRecipientRule removes the domain's rule so the built-in recipients apply again
+func (s *notificationService) ResetRecipientRule(domainID int64, eventType string) error {
+	if _, configurable := builtInRecipientRules[eventType]; !configurable {
+		return fmt.Errorf("%w: recipients of %s cannot be configured", ErrInvalidRecipientRule, eventType)
 	}
 
-	// Process 7 days notification (warning)
-	for _, permit := range permits7Days {
-		daysLeft := int(time.Until(permit.ExpiryDate).Hours() / 24)
-		if daysLeft <= 7 && daysLeft > 0 {
-			s.processPermitNotification(permit, "expiry_warning", daysLeft)
+	return s.preferenceRepo.DeleteRule(domainID, eventType)
+}
+
+func (s *notificationService) GetEscalationRules(domainID int64) ([]model.EscalationRule, error) {
+	return s.escalationRepo.FindRulesByDomainID(domainID)
+}
+
+// UpdateEscalationRules replaces the domain's escalation rules. Levels are
+// numbered from the earliest offset on.
+func (s *notificationService) UpdateEscalationRules(domainID int64, req *model.UpdateEscalationRulesRequest) ([]model.EscalationRule, error) {
+	rules := make([]model.EscalationRule, 0, len(req.Rules))
+	for _, item := range req.Rules {
+		roleCodes := make([]string, 0, len(item.RoleCodes))
+		for _, code := range item.RoleCodes {
+			if slices.Contains(roleCodes, code) {
+				continue
+			}
+			if _, err := s.roleRepo.FindByCode(code); err != nil {
+				return nil, fmt.Errorf("%w: unknown role %s", ErrInvalidEscalationRule, code)
+			}
+			roleCodes = append(roleCodes, code)
+		}
+
+		if !item.NotifyResponsible && !item.NotifyDivisionHead && len(roleCodes) == 0 {
+			return nil, fmt.Errorf("%w: the rule at %d days before expiry notifies nobody", ErrInvalidEscalationRule, item.DaysBefore)
 		}
+
+		rules = append(rules, model.EscalationRule{
+			DomainID:           domainID,
+			DaysBefore:         item.DaysBefore,
+			NotifyResponsible:  item.NotifyResponsible,
+			NotifyDivisionHead: item.NotifyDivisionHead,
+			RoleCodes:          roleCodes,
+		})
+	}
+
+	slices.SortFunc(rules, func(a, b model.EscalationRule) int {
+		return b.DaysBefore - a.DaysBefore
+	})
+	for i := range rules {
+		rules[i].Level = i + 1
 	}
 
-	// Process expired notification
-	for _, permit := range permitsExpired {
-		s.processPermitNotification(permit, "expired", 0)
+	if err := s.escalationRepo.ReplaceRules(domainID, rules); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return s.GetEscalationRules(domainID)
+}
+
+func (s *notificationService) GetEscalations(domainID int64, filter *model.PermitEscalationListRequest) ([]model.PermitEscalation, int64, error) {
+	return s.escalationRepo.FindEscalations(domainID, filter)
 }
 
-func (s *notificationService) processPermitNotification(permit model.Permit, notificationType string, daysLeft int) error {
-	// Check apakah notifikasi sudah pernah dikirim dalam 24 jam terakhir
-	yesterday := time.Now().AddDate(0, 0, -1)
-	exists, err := s.notificationRepo.CheckExistingNotification(permit.ID, notificationType, yesterday)
+// defaultReminderSchedule applies when neither the permit type nor its domain has a schedule
+var defaultReminderSchedule = []model.ReminderSchedule{
+	{DaysBefore: 30, Severity: model.ReminderSeverityInfo},
+	{DaysBefore: 7, Severity: model.ReminderSeverityWarning},
+	{DaysBefore: 0, Severity: model.ReminderSeverityCritical},
+}
+
+// obligationPermitStatuses are the permit statuses whose obligations still have to be met
+var obligationPermitStatuses = []string{
+	model.PermitStatusActive,
+	model.PermitStatusExpiring,
+	model.PermitStatusInRenewal,
+}
+
+// obligationOverdueDays is the reminder offset of the overdue notification,
+// sent once per due date after the date has passed
+const obligationOverdueDays = -1
+
+// CheckAndSendExpiryNotifications sends the expiry reminders due today, then
+// escalates the permits whose reminders were ignored, and returns how many
+// permits were reminded or escalated
+func (s *notificationService) CheckAndSendExpiryNotifications() (int, error) {
+	now := time.Now()
+
+	maxDaysBefore, err := s.maxReminderDays()
 	if err != nil {
-		return err
+		return 0, err
 	}
-	if exists {
-		return nil // Skip jika sudah ada notifikasi
+
+	// Get permits yang akan expired, termasuk yang expired sejak kemarin
+	permits, err := s.permitRepo.FindExpiringPermits(now.AddDate(0, 0, -1), now.AddDate(0, 0, maxDaysBefore))
+	if err != nil {
+		return 0, err
 	}
 
-	// Collect recipients
-	recipients := make(map[int64]*model.User)
-	var emailRecipients []string
+	// Schedules are cached per run since many permits share a type or domain
+	permitTypeSchedules := make(map[int64][]model.ReminderSchedule)
+	domainSchedules := make(map[int64][]model.ReminderSchedule)
 
-	// Add Responsible Person
-	if permit.ResponsiblePersonID != nil && *permit.ResponsiblePersonID > 0 {
-		user, err := s.userRepo.FindById(*permit.ResponsiblePersonID)
-		if err == nil && user != nil {
-			recipients[user.ID] = user
-			emailRecipients = append(emailRecipients, user.Email)
+	today := startOfDay(now)
+	sent := 0
+	var errs []error
+	for _, permit := range permits {
+		schedules, err := s.resolveReminderSchedule(permit, permitTypeSchedules, domainSchedules)
+		if err != nil {
+			return sent, err
+		}
+
+		daysLeft := daysUntil(permit.ExpiryDate, today)
+		if daysLeft < 0 {
+			daysLeft = 0
+		}
+
+		due := dueReminder(schedules, daysLeft)
+		if due == nil {
+			continue
+		}
+
+		reminded, err := s.processPermitNotification(permit, notificationTypeFor(due), daysLeft, due.DaysBefore)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reminder for permit %d: %w", permit.ID, err))
+		}
+		if reminded {
+			sent++
 		}
 	}
 
-	// Add Document Responsible Person
-	if permit.ResponsibleDocPersonID != nil && *permit.ResponsibleDocPersonID > 0 {
-		user, err := s.userRepo.FindById(*permit.ResponsibleDocPersonID)
-		if err == nil && user != nil {
-			if _, exists := recipients[user.ID]; !exists {
-				recipients[user.ID] = user
-				emailRecipients = append(emailRecipients, user.Email)
-			}
+	escalated, err := s.escalateIgnoredExpiries(today)
+	errs = append(errs, err)
+	return sent + escalated, errors.Join(errs...)
+}
+
+// escalateIgnoredExpiries escalates permits whose expiry reminders nobody acted
+// on to the recipients of the tightest escalation rule of their domain reached
+// today. Permits in renewal or already renewed are being taken care of.
+func (s *notificationService) escalateIgnoredExpiries(today time.Time) (int, error) {
+	rules, err := s.escalationRepo.FindAllRules()
+	if err != nil || len(rules) == 0 {
+		return 0, err
+	}
+
+	domainRules := make(map[int64][]model.EscalationRule)
+	maxDaysBefore, minDaysBefore := 0, 0
+	for _, rule := range rules {
+		domainRules[rule.DomainID] = append(domainRules[rule.DomainID], rule)
+		maxDaysBefore = max(maxDaysBefore, rule.DaysBefore)
+		minDaysBefore = min(minDaysBefore, rule.DaysBefore)
+	}
+
+	permits, err := s.permitRepo.FindExpiringPermits(today.AddDate(0, 0, minDaysBefore), today.AddDate(0, 0, maxDaysBefore))
+	if err != nil {
+		return 0, err
+	}
+
+	escalated := 0
+	for _, permit := range permits {
+		permitRules, ok := domainRules[permit.DomainID]
+		if !ok || permit.Status == model.PermitStatusInRenewal {
+			continue
+		}
+
+		daysLeft := daysUntil(permit.ExpiryDate, today)
+
+		rule := dueEscalation(permitRules, daysLeft)
+		if rule == nil {
+			continue
+		}
+
+		sent, err := s.processPermitEscalation(permit, rule, daysLeft, today)
+		if err != nil {
+			return escalated, err
 		}
+		if sent {
+			escalated++
+		}
+	}
+
+	return escalated, nil
+}
+
+// CheckAndSendObligationNotifications reminds people of upcoming obligation due
+// dates using the same reminder offsets as the permit's expiry, and once more
+// when a due date has passed without a completion. It returns how many
+// obligations were reminded.
+func (s *notificationService) CheckAndSendObligationNotifications() (int, error) {
+	today := startOfDay(time.Now())
+
+	maxDaysBefore, err := s.maxReminderDays()
+	if err != nil {
+		return 0, err
+	}
+
+	obligations, err := s.obligationRepo.FindOpenDueBefore(today.AddDate(0, 0, maxDaysBefore), obligationPermitStatuses)
+	if err != nil {
+		return 0, err
 	}
 
-	// Add Admin users (using role code)
-	admins, err := s.userRepo.FindByRoleCode("ADMIN")
-	if err == nil {
-		for _, admin := range admins {
-			if _, exists := recipients[admin.ID]; !exists {
-				recipients[admin.ID] = &admin
-				emailRecipients = append(emailRecipients, admin.Email)
+	permitTypeSchedules := make(map[int64][]model.ReminderSchedule)
+	domainSchedules := make(map[int64][]model.ReminderSchedule)
+
+	sent := 0
+	for _, obligation := range obligations {
+		if obligation.Permit == nil {
+			continue
+		}
+
+		daysLeft := daysUntil(obligation.NextDueDate, today)
+
+		if daysLeft < 0 {
+			reminded, err := s.processObligationNotification(obligation, "obligation_overdue", daysLeft, obligationOverdueDays)
+			if err != nil {
+				return sent, err
+			}
+			if reminded {
+				sent++
 			}
+			continue
+		}
+
+		schedules, err := s.resolveReminderSchedule(*obligation.Permit, permitTypeSchedules, domainSchedules)
+		if err != nil {
+			return sent, err
+		}
+
+		due := dueReminder(schedules, daysLeft)
+		if due == nil {
+			continue
+		}
+
+		reminded, err := s.processObligationNotification(obligation, obligationNotificationTypeFor(due), daysLeft, due.DaysBefore)
+		if err != nil {
+			return sent, err
+		}
+		if reminded {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// maxReminderDays is how far ahead reminders look, the longest configured or built-in offset
+func (s *notificationService) maxReminderDays() (int, error) {
+	maxDaysBefore, err := s.reminderScheduleRepo.FindMaxDaysBefore()
+	if err != nil {
+		return 0, err
+	}
+	for _, schedule := range defaultReminderSchedule {
+		if schedule.DaysBefore > maxDaysBefore {
+			maxDaysBefore = schedule.DaysBefore
 		}
 	}
+	return maxDaysBefore, nil
+}
+
+// dueReminder returns the tightest offset already reached, the one that applies today
+func dueReminder(schedules []model.ReminderSchedule, daysLeft int) *model.ReminderSchedule {
+	var due *model.ReminderSchedule
+	for i := range schedules {
+		if schedules[i].DaysBefore >= daysLeft && (due == nil || schedules[i].DaysBefore < due.DaysBefore) {
+			due = &schedules[i]
+		}
+	}
+	return due
+}
+
+// dueEscalation returns the tightest escalation rule already reached
+func dueEscalation(rules []model.EscalationRule, daysLeft int) *model.EscalationRule {
+	var due *model.EscalationRule
+	for i := range rules {
+		if rules[i].DaysBefore >= daysLeft && (due == nil || rules[i].DaysBefore < due.DaysBefore) {
+			due = &rules[i]
+		}
+	}
+	return due
+}
+
+// resolveReminderSchedule picks the permit type's schedule, then the domain default, then the built-in one
+func (s *notificationService) resolveReminderSchedule(
+	permit model.Permit,
+	permitTypeSchedules map[int64][]model.ReminderSchedule,
+	domainSchedules map[int64][]model.ReminderSchedule,
+) ([]model.ReminderSchedule, error) {
+	schedules, cached := permitTypeSchedules[permit.PermitTypeID]
+	if !cached {
+		var err error
+		schedules, err = s.reminderScheduleRepo.FindByPermitTypeID(permit.PermitTypeID)
+		if err != nil {
+			return nil, err
+		}
+		permitTypeSchedules[permit.PermitTypeID] = schedules
+	}
+	if len(schedules) > 0 {
+		return schedules, nil
+	}
+
+	schedules, cached = domainSchedules[permit.DomainID]
+	if !cached {
+		var err error
+		schedules, err = s.reminderScheduleRepo.FindByDomainID(permit.DomainID)
+		if err != nil {
+			return nil, err
+		}
+		domainSchedules[permit.DomainID] = schedules
+	}
+	if len(schedules) > 0 {
+		return schedules, nil
+	}
+
+	return defaultReminderSchedule, nil
+}
+
+// notificationTypeFor maps a reminder offset to its notification type. The
+// expiry day itself is always reported as expired.
+func notificationTypeFor(schedule *model.ReminderSchedule) string {
+	if schedule.DaysBefore == 0 {
+		return "expired"
+	}
+
+	switch schedule.Severity {
+	case model.ReminderSeverityCritical:
+		return "expiry_critical"
+	case model.ReminderSeverityWarning:
+		return "expiry_warning"
+	default:
+		return "expiry_reminder"
+	}
+}
+
+// obligationNotificationTypeFor maps a reminder offset to its obligation notification type
+func obligationNotificationTypeFor(schedule *model.ReminderSchedule) string {
+	if schedule.DaysBefore == 0 {
+		return "obligation_due"
+	}
+
+	switch schedule.Severity {
+	case model.ReminderSeverityCritical:
+		return "obligation_critical"
+	case model.ReminderSeverityWarning:
+		return "obligation_warning"
+	default:
+		return "obligation_reminder"
+	}
+}
+
+func (s *notificationService) processPermitNotification(permit model.Permit, notificationType string, daysLeft int, reminderDays int) (bool, error) {
+	// Each reminder offset is sent once per expiry date, a changed date is reminded again
+	exists, err := s.notificationRepo.CheckExistingReminder(permit.ID, permit.ExpiryDate, reminderDays)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil // Skip jika sudah ada notifikasi
+	}
+
+	// Collect recipients, from the domain's rule when one is set
+	responsibleIDs := []*int64{permit.ResponsiblePersonID, permit.ResponsibleDocPersonID}
+	rule, err := s.preferenceRepo.FindRule(permit.DomainID, model.NotificationEventPermitExpiry)
+	if err != nil {
+		return false, err
+	}
+
+	var recipients map[int64]*model.User
+	if rule != nil {
+		recipients = s.ruleRecipients(rule, permit.DomainID, responsibleIDs)
+	} else {
+		recipients = s.usersByID(responsibleIDs)
 
-	// Add Permit Manager users from the same domain
-	if permit.DomainID > 0 {
-		managers, err := s.userRepo.FindByDomainAndRoleCode(permit.DomainID, "PERMIT_MANAGER")
+		// Add Admin users (using role code)
+		admins, err := s.userRepo.FindByRoleCode(helper.RoleCodeAdmin)
 		if err == nil {
-			for _, manager := range managers {
-				if _, exists := recipients[manager.ID]; !exists {
-					recipients[manager.ID] = &manager
-					emailRecipients = append(emailRecipients, manager.Email)
-				}
+			addRecipients(recipients, admins)
+		}
+
+		// Add Permit Manager users from the same domain
+		if permit.DomainID > 0 {
+			managers, err := s.userRepo.FindByDomainAndRoleCode(permit.DomainID, helper.RoleCodePermitManager)
+			if err == nil {
+				addRecipients(recipients, managers)
 			}
 		}
 	}
 
+	channels, err := s.preferenceRepo.FindChannels(recipientIDs(recipients), model.NotificationEventPermitExpiry)
+	if err != nil {
+		return false, err
+	}
+
 	// Create notification title and message
 	title, message := s.getNotificationContent(permit, notificationType, daysLeft)
 
-	// Create in-app notifications for each recipient
+	emailData := emailTemplate.PermitExpiryData{
+		PermitName: permit.Name,
+		PermitNo:   permit.PermitNo,
+		ExpiryDate: permit.ExpiryDate,
+		DaysLeft:   daysLeft,
+		Link:       model.NotificationLink(model.NotificationEntityPermit, permit.ID),
+	}
+	domainLocale := s.domainLocale(permit.DomainID)
+	emails := make(map[string]*emailTemplate.Email)
+
+	dispatch := &model.NotificationDispatch{
+		Reminder: &model.ReminderDelivery{
+			PermitID:     permit.ID,
+			ExpiryDate:   &permit.ExpiryDate,
+			ReminderDays: reminderDays,
+		},
+	}
+	for _, user := range recipients {
+		// Users who receive the event in their digest get no individual email
+		if channels[user.ID].Email && !channels[user.ID].Digest {
+			email, err := s.renderEmail(emails, emailTemplate.PermitExpiry, permit.DomainID, emailLocale(user, domainLocale), emailData)
+			if err != nil {
+				return false, err
+			}
+			dispatch.Emails = append(dispatch.Emails, model.NewEmailOutbox([]string{user.Email}, email.Subject, email.HTML, email.Text))
+		}
+
+		notification := &model.Notification{
+			UserID:       user.ID,
+			EntityType:   model.NotificationEntityPermit,
+			EntityID:     permit.ID,
+			Link:         model.NotificationLink(model.NotificationEntityPermit, permit.ID),
+			PermitID:     &permit.ID,
+			Type:         notificationType,
+			Title:        title,
+			Message:      message,
+			IsRead:       false,
+			ReminderDays: &reminderDays,
+		}
+		dispatch.Add(notification, permit.DomainID, channels[user.ID])
+	}
+
+	if err := s.notificationRepo.SaveDispatch(dispatch); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// processPermitEscalation notifies the rule's recipients when the permit's
+// reminders were ignored, and records the escalation level it reached. A
+// reminder only counts as ignored after a day without anyone reading a
+// reminder or changing the permit.
+func (s *notificationService) processPermitEscalation(permit model.Permit, rule *model.EscalationRule, daysLeft int, today time.Time) (bool, error) {
+	// Each rule escalates once per permit
+	exists, err := s.escalationRepo.CheckExisting(permit.ID, rule.DaysBefore)
+	if err != nil || exists {
+		return false, err
+	}
+
+	remindedSince, err := s.escalationRepo.FindFirstReminderAt(permit.ID)
+	if err != nil || remindedSince == nil || !remindedSince.Before(today) {
+		return false, err
+	}
+
+	active, err := s.escalationRepo.HasActivitySince(permit.ID, *remindedSince)
+	if err != nil || active {
+		return false, err
+	}
+
+	recipients := s.escalationRecipients(rule, permit)
+
+	channels, err := s.preferenceRepo.FindChannels(recipientIDs(recipients), model.NotificationEventPermitExpiry)
+	if err != nil {
+		return false, err
+	}
+
+	notificationType := "expiry_escalation"
+	title, message := s.getNotificationContent(permit, notificationType, daysLeft)
+
+	emailData := emailTemplate.PermitEscalationData{
+		PermitName:    permit.Name,
+		PermitNo:      permit.PermitNo,
+		ExpiryDate:    permit.ExpiryDate,
+		DaysLeft:      daysLeft,
+		Level:         rule.Level,
+		RemindedSince: *remindedSince,
+		Link:          model.NotificationLink(model.NotificationEntityPermit, permit.ID),
+	}
+	domainLocale := s.domainLocale(permit.DomainID)
+	emails := make(map[string]*emailTemplate.Email)
+
+	// Recorded even when the rule names nobody, so the level is not evaluated again
+	dispatch := &model.NotificationDispatch{
+		Escalation: &model.PermitEscalation{
+			PermitID:   permit.ID,
+			DomainID:   permit.DomainID,
+			Level:      rule.Level,
+			DaysBefore: rule.DaysBefore,
+			ExpiryDate: permit.ExpiryDate,
+			Recipients: len(recipients),
+		},
+	}
+	for _, user := range recipients {
+		if channels[user.ID].Email && !channels[user.ID].Digest {
+			email, err := s.renderEmail(emails, emailTemplate.PermitEscalation, permit.DomainID, emailLocale(user, domainLocale), emailData)
+			if err != nil {
+				return false, err
+			}
+			dispatch.Emails = append(dispatch.Emails, model.NewEmailOutbox([]string{user.Email}, email.Subject, email.HTML, email.Text))
+		}
+
+		notification := &model.Notification{
+			UserID:     user.ID,
+			EntityType: model.NotificationEntityPermit,
+			EntityID:   permit.ID,
+			Link:       model.NotificationLink(model.NotificationEntityPermit, permit.ID),
+			PermitID:   &permit.ID,
+			Type:       notificationType,
+			Title:      title,
+			Message:    message,
+			IsRead:     false,
+		}
+		dispatch.Add(notification, permit.DomainID, channels[user.ID])
+	}
+
+	if err := s.notificationRepo.SaveDispatch(dispatch); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *notificationService) processObligationNotification(obligation model.PermitObligation, notificationType string, daysLeft int, reminderDays int) (bool, error) {
+	// Each reminder offset is sent once per due date
+	exists, err := s.notificationRepo.CheckExistingObligationReminder(obligation.ID, obligation.NextDueDate, reminderDays)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	permit := obligation.Permit
+
+	// The responsible user, or the permit's responsible person when none is set
+	responsibleID := obligation.ResponsibleUserID
+	if responsibleID == nil {
+		responsibleID = permit.ResponsiblePersonID
+	}
+
+	rule, err := s.preferenceRepo.FindRule(permit.DomainID, model.NotificationEventPermitObligation)
+	if err != nil {
+		return false, err
+	}
+
+	var recipients map[int64]*model.User
+	if rule != nil {
+		recipients = s.ruleRecipients(rule, permit.DomainID, []*int64{responsibleID})
+	} else {
+		recipients = s.usersByID([]*int64{responsibleID})
+
+		// Overdue obligations are also reported to the Permit Managers of the domain
+		if reminderDays == obligationOverdueDays && permit.DomainID > 0 {
+			managers, err := s.userRepo.FindByDomainAndRoleCode(permit.DomainID, helper.RoleCodePermitManager)
+			if err == nil {
+				addRecipients(recipients, managers)
+			}
+		}
+	}
+
+	channels, err := s.preferenceRepo.FindChannels(recipientIDs(recipients), model.NotificationEventPermitObligation)
+	if err != nil {
+		return false, err
+	}
+
+	title, message := s.getObligationNotificationContent(obligation, notificationType, daysLeft)
+
+	emailData := emailTemplate.ObligationReminderData{
+		Description: obligation.Description,
+		PermitName:  permit.Name,
+		PermitNo:    permit.PermitNo,
+		DueDate:     obligation.NextDueDate,
+		DaysLeft:    daysLeft,
+		Link:        model.NotificationLink(model.NotificationEntityPermit, permit.ID),
+	}
+	domainLocale := s.domainLocale(permit.DomainID)
+	emails := make(map[string]*emailTemplate.Email)
+
+	dueDate := obligation.NextDueDate
+	dispatch := &model.NotificationDispatch{
+		Reminder: &model.ReminderDelivery{
+			PermitID:     permit.ID,
+			ObligationID: &obligation.ID,
+			DueDate:      &dueDate,
+			ReminderDays: reminderDays,
+		},
+	}
 	for _, user := range recipients {
+		if channels[user.ID].Email && !channels[user.ID].Digest {
+			email, err := s.renderEmail(emails, emailTemplate.ObligationReminder, permit.DomainID, emailLocale(user, domainLocale), emailData)
+			if err != nil {
+				return false, err
+			}
+			dispatch.Emails = append(dispatch.Emails, model.NewEmailOutbox([]string{user.Email}, email.Subject, email.HTML, email.Text))
+		}
+
 		notification := &model.Notification{
-			UserID:   user.ID,
-			PermitID: permit.ID,
-			Type:     notificationType,
-			Title:    title,
-			Message:  message,
-			IsRead:   false,
+			UserID:       user.ID,
+			EntityType:   model.NotificationEntityPermit,
+			EntityID:     permit.ID,
+			Link:         model.NotificationLink(model.NotificationEntityPermit, permit.ID),
+			PermitID:     &permit.ID,
+			ObligationID: &obligation.ID,
+			DueDate:      &dueDate,
+			Type:         notificationType,
+			Title:        title,
+			Message:      message,
+			IsRead:       false,
+			ReminderDays: &reminderDays,
+		}
+		dispatch.Add(notification, permit.DomainID, channels[user.ID])
+	}
+
+	if err := s.notificationRepo.SaveDispatch(dispatch); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// emailLocale returns the locale of a user's emails: the user's own, else the
+// domain's, else the default locale
+func emailLocale(user *model.User, domainLocale string) string {
+	if user.Locale != nil && emailTemplate.IsLocale(*user.Locale) {
+		return *user.Locale
+	}
+	if emailTemplate.IsLocale(domainLocale) {
+		return domainLocale
+	}
+	return emailTemplate.DefaultLocale
+}
+
+// domainLocale returns the domain's email locale, empty when the domain cannot
+// be loaded so the default applies
+func (s *notificationService) domainLocale(domainID int64) string {
+	domain, err := s.domainRepo.FindByID(domainID)
+	if err != nil || domain == nil {
+		return ""
+	}
+	return domain.Locale
+}
+
+// renderEmail renders the template in the locale with the domain's override
+// when it has one, caching the result per locale for the recipients of one
+// notification. An override that fails on real data falls back to the
+// built-in template so the reminder still goes out.
+func (s *notificationService) renderEmail(cache map[string]*emailTemplate.Email, name string, domainID int64, locale string, data any) (*emailTemplate.Email, error) {
+	if email, ok := cache[locale]; ok {
+		return email, nil
+	}
+
+	override, err := s.templateRepo.FindOverride(domainID, name, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	var email *emailTemplate.Email
+	if override != nil {
+		source := &emailTemplate.Source{Subject: override.Subject, HTML: override.HTMLBody, Text: override.TextBody}
+		email, err = emailTemplate.Render(name, locale, data, source)
+	}
+	if email == nil {
+		email, err = emailTemplate.Render(name, locale, data, nil)
+		if err != nil {
+			return nil, err
 		}
-		s.notificationRepo.Create(notification)
 	}
 
-	// Send email notification
-	if len(emailRecipients) > 0 {
-		expiryDateStr := permit.ExpiryDate.Format("02 January 2006")
-		go helper.SendPermitExpiryNotification(
-			emailRecipients,
-			permit.Name,
-			permit.PermitNo,
-			expiryDateStr,
-			daysLeft,
-		)
+	cache[locale] = email
+	return email, nil
+}
+
+// ruleRecipients returns the users a domain's recipient rule names: the given
+// responsible people when the rule includes them, and the domain's users with
+// one of the rule's roles
+func (s *notificationService) ruleRecipients(rule *model.NotificationRecipientRule, domainID int64, responsibleIDs []*int64) map[int64]*model.User {
+	recipients := make(map[int64]*model.User)
+	if rule.NotifyResponsible {
+		recipients = s.usersByID(responsibleIDs)
+	}
+
+	for _, roleCode := range rule.RoleCodes {
+		users, err := s.userRepo.FindByDomainAndRoleCode(domainID, roleCode)
+		if err == nil {
+			addRecipients(recipients, users)
+		}
+	}
+
+	return recipients
+}
+
+// escalationRecipients returns the users an escalation rule names: the
+// permit's responsible people, the head of its division and the users with one
+// of the rule's roles in the permit's domain. Super admins are not tied to a
+// domain and are notified wherever their role was granted.
+func (s *notificationService) escalationRecipients(rule *model.EscalationRule, permit model.Permit) map[int64]*model.User {
+	var userIDs []*int64
+	if rule.NotifyResponsible {
+		userIDs = append(userIDs, permit.ResponsiblePersonID, permit.ResponsibleDocPersonID)
+	}
+	if rule.NotifyDivisionHead && permit.Division != nil {
+		userIDs = append(userIDs, permit.Division.HeadUserID)
+	}
+	recipients := s.usersByID(userIDs)
+
+	for _, roleCode := range rule.RoleCodes {
+		var users []model.User
+		var err error
+		if roleCode == helper.RoleCodeSuperAdmin {
+			users, err = s.userRepo.FindByRoleCode(roleCode)
+		} else {
+			users, err = s.userRepo.FindByDomainAndRoleCode(permit.DomainID, roleCode)
+		}
+		if err == nil {
+			addRecipients(recipients, users)
+		}
+	}
+
+	return recipients
+}
+
+// usersByID looks up the set IDs, users that cannot be found are left out
+func (s *notificationService) usersByID(userIDs []*int64) map[int64]*model.User {
+	recipients := make(map[int64]*model.User)
+	for _, userID := range userIDs {
+		if userID == nil || *userID <= 0 {
+			continue
+		}
+		if _, exists := recipients[*userID]; exists {
+			continue
+		}
+		user, err := s.userRepo.FindById(*userID)
+		if err == nil && user != nil {
+			recipients[user.ID] = user
+		}
+	}
+	return recipients
+}
+
+func addRecipients(recipients map[int64]*model.User, users []model.User) {
+	for i := range users {
+		if _, exists := recipients[users[i].ID]; !exists {
+			recipients[users[i].ID] = &users[i]
+		}
 	}
+}
 
-	return nil
+func recipientIDs(recipients map[int64]*model.User) []int64 {
+	userIDs := make([]int64, 0, len(recipients))
+	for userID := range recipients {
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs
+}
+
+func (s *notificationService) getObligationNotificationContent(obligation model.PermitObligation, notificationType string, daysLeft int) (string, string) {
+	permit := obligation.Permit
+	dueDate := obligation.NextDueDate.Format("02 Jan 2006")
+
+	switch notificationType {
+	case "obligation_reminder":
+		return fmt.Sprintf("Kewajiban permit %s jatuh tempo dalam %d hari", permit.Name, daysLeft),
+			fmt.Sprintf("Kewajiban \"%s\" untuk permit %s (No: %s) jatuh tempo pada %s.",
+				obligation.Description, permit.Name, permit.PermitNo, dueDate)
+	case "obligation_warning":
+		return fmt.Sprintf("PERINGATAN: Kewajiban permit %s jatuh tempo dalam %d hari", permit.Name, daysLeft),
+			fmt.Sprintf("Kewajiban \"%s\" untuk permit %s (No: %s) jatuh tempo pada %s. Harap segera ditindaklanjuti!",
+				obligation.Description, permit.Name, permit.PermitNo, dueDate)
+	case "obligation_critical":
+		return fmt.Sprintf("KRITIS: Kewajiban permit %s jatuh tempo dalam %d hari", permit.Name, daysLeft),
+			fmt.Sprintf("Kewajiban \"%s\" untuk permit %s (No: %s) jatuh tempo pada %s. Harus segera diselesaikan!",
+				obligation.Description, permit.Name, permit.PermitNo, dueDate)
+	case "obligation_due":
+		return fmt.Sprintf("Kewajiban permit %s jatuh tempo hari ini", permit.Name),
+			fmt.Sprintf("Kewajiban \"%s\" untuk permit %s (No: %s) jatuh tempo hari ini (%s).",
+				obligation.Description, permit.Name, permit.PermitNo, dueDate)
+	case "obligation_overdue":
+		return fmt.Sprintf("TERLAMBAT: Kewajiban permit %s melewati jatuh tempo", permit.Name),
+			fmt.Sprintf("Kewajiban \"%s\" untuk permit %s (No: %s) telah jatuh tempo pada %s dan belum diselesaikan!",
+				obligation.Description, permit.Name, permit.PermitNo, dueDate)
+	default:
+		return "Notifikasi Kewajiban Permit", "Ada update terkait kewajiban permit Anda"
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// daysUntil counts the calendar days from today to the date. Expiry and due
+// dates are calendar dates, so whole days are counted in local time whatever
+// the time of the run, and rounding absorbs daylight saving shifts.
+func daysUntil(date time.Time, today time.Time) int {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, today.Location())
+	return int(math.Round(day.Sub(today).Hours() / 24))
 }
 
 func (s *notificationService) getNotificationContent(permit model.Permit, notificationType string, daysLeft int) (string, string) {
@@ -234,10 +1231,23 @@ func (s *notificationService) getNotificationContent(permit model.Permit, notifi
 		return fmt.Sprintf("PERINGATAN: Permit %s akan expired dalam %d hari", permit.Name, daysLeft),
 			fmt.Sprintf("Permit %s (No: %s) akan expired pada %s. Harap segera ditindaklanjuti!",
 				permit.Name, permit.PermitNo, permit.ExpiryDate.Format("02 Jan 2006"))
+	case "expiry_critical":
+		return fmt.Sprintf("KRITIS: Permit %s akan expired dalam %d hari", permit.Name, daysLeft),
+			fmt.Sprintf("Permit %s (No: %s) akan expired pada %s. Perpanjangan harus segera diproses!",
+				permit.Name, permit.PermitNo, permit.ExpiryDate.Format("02 Jan 2006"))
 	case "expired":
 		return fmt.Sprintf("EXPIRED: Permit %s telah expired", permit.Name),
 			fmt.Sprintf("Permit %s (No: %s) telah expired pada %s. Segera lakukan perpanjangan!",
 				permit.Name, permit.PermitNo, permit.ExpiryDate.Format("02 Jan 2006"))
+	case "expiry_escalation":
+		if daysLeft <= 0 {
+			return fmt.Sprintf("ESKALASI: Permit %s telah expired tanpa tindak lanjut", permit.Name),
+				fmt.Sprintf("Pengingat untuk permit %s (No: %s) tidak ditindaklanjuti dan permit telah expired pada %s. Pastikan perpanjangan segera diproses!",
+					permit.Name, permit.PermitNo, permit.ExpiryDate.Format("02 Jan 2006"))
+		}
+		return fmt.Sprintf("ESKALASI: Permit %s akan expired dalam %d hari tanpa tindak lanjut", permit.Name, daysLeft),
+			fmt.Sprintf("Pengingat untuk permit %s (No: %s) belum ditindaklanjuti, sedangkan permit akan expired pada %s. Pastikan perpanjangan segera diproses!",
+				permit.Name, permit.PermitNo, permit.ExpiryDate.Format("02 Jan 2006"))
 	default:
 		return "Notifikasi Permit", "Ada update terkait permit Anda"
 	}
@@ -247,15 +1257,19 @@ func (s *notificationService) convertToResponse(notifications []model.Notificati
 	responses := make([]model.NotificationResponse, len(notifications))
 	for i, notif := range notifications {
 		responses[i] = model.NotificationResponse{
-			ID:        notif.ID,
-			UserID:    notif.UserID,
-			PermitID:  notif.PermitID,
-			Type:      notif.Type,
-			Title:     notif.Title,
-			Message:   notif.Message,
-			IsRead:    notif.IsRead,
-			ReadAt:    notif.ReadAt,
-			CreatedAt: notif.CreatedAt,
+			ID:           notif.ID,
+			UserID:       notif.UserID,
+			EntityType:   notif.EntityType,
+			EntityID:     notif.EntityID,
+			Link:         notif.Link,
+			PermitID:     notif.PermitID,
+			ObligationID: notif.ObligationID,
+			Type:         notif.Type,
+			Title:        notif.Title,
+			Message:      notif.Message,
+			IsRead:       notif.IsRead,
+			ReadAt:       notif.ReadAt,
+			CreatedAt:    notif.CreatedAt,
 		}
 
 		if notif.Permit != nil {
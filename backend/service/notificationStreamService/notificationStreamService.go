@@ -0,0 +1,197 @@
+package notificationStreamService
+
+import (
+	"context"
+	"permit-app/model"
+	"permit-app/repo/notificationRepository"
+	"sync"
+	"time"
+)
+
+const (
+	// streamBufferSize is how many events a subscription holds for a slow
+	// client before it is dropped, the client then resumes with Last-Event-ID
+	streamBufferSize = 32
+	// streamResumePageSize is how many missed notifications are read at a time
+	// for a resuming client
+	streamResumePageSize = 100
+)
+
+// Subscription is one open notification stream of a user. Events is closed
+// when the subscription is dropped, for falling behind or because listening
+// stopped.
+type Subscription struct {
+	UserID int64
+	Events <-chan model.NotificationStreamEvent
+
+	events chan model.NotificationStreamEvent
+}
+
+type NotificationStreamService interface {
+	Subscribe(userID int64) *Subscription
+	Unsubscribe(subscription *Subscription)
+	Missed(userID int64, lastEventID int64) ([]model.NotificationResponse, bool, error)
+	UnreadCount(userID int64) (int64, error)
+	Listen(ctx context.Context) error
+}
+
+// notificationStreamService is the in-process broker of one instance. Every
+// instance listens for the notifications created by all of them, so a user is
+// reached whichever instance their stream is connected to.
+type notificationStreamService struct {
+	notificationRepo notificationRepository.NotificationRepository
+
+	mu            sync.Mutex
+	subscriptions map[int64]map[*Subscription]struct{}
+}
+
+func NewNotificationStreamService(notificationRepo notificationRepository.NotificationRepository) NotificationStreamService {
+	return &notificationStreamService{
+		notificationRepo: notificationRepo,
+		subscriptions:    make(map[int64]map[*Subscription]struct{}),
+	}
+}
+
+func (s *notificationStreamService) Subscribe(userID int64) *Subscription {
+	events := make(chan model.NotificationStreamEvent, streamBufferSize)
+	subscription := &Subscription{UserID: userID, Events: events, events: events}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subscriptions[userID] == nil {
+		s.subscriptions[userID] = make(map[*Subscription]struct{})
+	}
+	s.subscriptions[userID][subscription] = struct{}{}
+
+	return subscription
+}
+
+func (s *notificationStreamService) Unsubscribe(subscription *Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(subscription)
+}
+
+// remove drops the subscription and closes its events, the caller holds s.mu
+func (s *notificationStreamService) remove(subscription *Subscription) {
+	subscriptions := s.subscriptions[subscription.UserID]
+	if _, ok := subscriptions[subscription]; !ok {
+		return
+	}
+
+	delete(subscriptions, subscription)
+	if len(subscriptions) == 0 {
+		delete(s.subscriptions, subscription.UserID)
+	}
+	close(subscription.events)
+}
+
+// Missed returns a page of the user's notifications created after lastEventID,
+// oldest first, for a client resuming its stream. It reports whether more
+// follow, the next page starts after the last one returned.
+func (s *notificationStreamService) Missed(userID int64, lastEventID int64) ([]model.NotificationResponse, bool, error) {
+	notifications, err := s.notificationRepo.FindByUserIDAfter(userID, lastEventID, streamResumePageSize+1)
+	if err != nil {
+		return nil, false, err
+	}
+
+	more := len(notifications) > streamResumePageSize
+	if more {
+		notifications = notifications[:streamResumePageSize]
+	}
+
+	responses := make([]model.NotificationResponse, len(notifications))
+	for i := range notifications {
+		responses[i] = s.toResponse(&notifications[i])
+	}
+	return responses, more, nil
+}
+
+func (s *notificationStreamService) UnreadCount(userID int64) (int64, error) {
+	return s.notificationRepo.CountUnreadByUserID(userID)
+}
+
+// Listen pushes every notification created by any instance to its recipient's
+// open streams until ctx is done or the database connection fails. Streams are
+// then dropped, so their clients reconnect and resume what they would miss
+// while nothing listens.
+func (s *notificationStreamService) Listen(ctx context.Context) error {
+	err := s.notificationRepo.ListenCreated(ctx, s.publish)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, subscriptions := range s.subscriptions {
+		for subscription := range subscriptions {
+			s.remove(subscription)
+		}
+	}
+
+	return err
+}
+
+func (s *notificationStreamService) publish(created model.NotificationCreatedEvent) {
+	s.mu.Lock()
+	connected := len(s.subscriptions[created.UserID]) > 0
+	s.mu.Unlock()
+	if !connected {
+		return
+	}
+
+	// The notification may already be deleted, its recipient then has nothing to see
+	notification, err := s.notificationRepo.FindByID(created.ID)
+	if err != nil {
+		return
+	}
+	unreadCount, err := s.notificationRepo.CountUnreadByUserID(created.UserID)
+	if err != nil {
+		return
+	}
+	event := model.NotificationStreamEvent{
+		Notification: s.toResponse(notification),
+		UnreadCount:  unreadCount,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for subscription := range s.subscriptions[created.UserID] {
+		select {
+		case subscription.events <- event:
+		default:
+			// Never block the broker on one slow client
+			s.remove(subscription)
+		}
+	}
+}
+
+func (s *notificationStreamService) toResponse(notification *model.Notification) model.NotificationResponse {
+	response := model.NotificationResponse{
+		ID:           notification.ID,
+		UserID:       notification.UserID,
+		EntityType:   notification.EntityType,
+		EntityID:     notification.EntityID,
+		Link:         notification.Link,
+		PermitID:     notification.PermitID,
+		ObligationID: notification.ObligationID,
+		Type:         notification.Type,
+		Title:        notification.Title,
+		Message:      notification.Message,
+		IsRead:       notification.IsRead,
+		ReadAt:       notification.ReadAt,
+		CreatedAt:    notification.CreatedAt,
+	}
+
+	if notification.Permit != nil {
+		response.Permit = &struct {
+			ID         int64     `json:"id"`
+			Name       string    `json:"name"`
+			PermitNo   string    `json:"permit_no"`
+			ExpiryDate time.Time `json:"expiry_date"`
+		}{
+			ID:         notification.Permit.ID,
+			Name:       notification.Permit.Name,
+			PermitNo:   notification.Permit.PermitNo,
+			ExpiryDate: notification.Permit.ExpiryDate,
+		}
+	}
+	return response
+}
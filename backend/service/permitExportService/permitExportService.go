@@ -0,0 +1,164 @@
+package permitExportService
+
+import (
+	"io"
+	"math"
+	"permit-app/helper"
+	"permit-app/model"
+	"permit-app/repo/permitRepository"
+	"time"
+)
+
+// exportBatchSize is how many permits are loaded at a time while writing the export
+const exportBatchSize = 500
+
+type PermitExportService interface {
+	ExportPermits(req *model.PermitExportRequest, w io.Writer) error
+}
+
+type permitExportService struct {
+	repo permitRepository.PermitRepository
+}
+
+func NewPermitExportService(repo permitRepository.PermitRepository) PermitExportService {
+	return &permitExportService{repo: repo}
+}
+
+// exportColumn is one column of the permit register. Columns without a PDF width
+// are left out of the PDF, which has to fit a landscape page.
+type exportColumn struct {
+	title     string
+	width     float64
+	pdfWidth  float64
+	valueFunc func(permit *model.Permit, today time.Time) interface{}
+}
+
+var permitExportColumns = []exportColumn{
+	{"Permit No", 20, 30, func(p *model.Permit, _ time.Time) interface{} { return p.PermitNo }},
+	{"Name", 30, 50, func(p *model.Permit, _ time.Time) interface{} { return p.Name }},
+	{"Domain", 20, 0, func(p *model.Permit, _ time.Time) interface{} {
+		if p.Domain == nil {
+			return ""
+		}
+		return p.Domain.Name
+	}},
+	{"Division", 20, 30, func(p *model.Permit, _ time.Time) interface{} {
+		if p.Division == nil {
+			return ""
+		}
+		return p.Division.Name
+	}},
+	{"Permit Type", 20, 30, func(p *model.Permit, _ time.Time) interface{} {
+		if p.PermitType == nil {
+			return ""
+		}
+		return p.PermitType.Name
+	}},
+	{"Risk Point", 10, 0, func(p *model.Permit, _ time.Time) interface{} {
+		if p.PermitType == nil {
+			return ""
+		}
+		return p.PermitType.RiskPoint
+	}},
+	{"Application Type", 18, 0, func(p *model.Permit, _ time.Time) interface{} { return p.ApplicationType }},
+	{"Effective Date", 14, 22, func(p *model.Permit, _ time.Time) interface{} { return p.EffectiveDate }},
+	{"Expiry Date", 14, 22, func(p *model.Permit, _ time.Time) interface{} { return p.ExpiryDate }},
+	{"Days to Expiry", 14, 18, func(p *model.Permit, today time.Time) interface{} { return daysToExpiry(p.ExpiryDate, today) }},
+	{"Effective Term", 14, 0, func(p *model.Permit, _ time.Time) interface{} { return p.EffectiveTerm }},
+	{"Status", 12, 20, func(p *model.Permit, _ time.Time) interface{} { return p.Status }},
+	{"Responsible Person", 24, 35, func(p *model.Permit, _ time.Time) interface{} { return userName(p.ResponsiblePerson) }},
+	{"Responsible Person NIP", 18, 0, func(p *model.Permit, _ time.Time) interface{} {
+		if p.ResponsiblePerson == nil {
+			return ""
+		}
+		return p.ResponsiblePerson.Nip
+	}},
+	{"Document Person", 24, 0, func(p *model.Permit, _ time.Time) interface{} { return userName(p.ResponsibleDocPerson) }},
+	{"Doc Name", 24, 0, func(p *model.Permit, _ time.Time) interface{} { return p.DocName }},
+	{"Doc Number", 16, 0, func(p *model.Permit, _ time.Time) interface{} { return p.DocNumber }},
+}
+
+// ExportPermits writes every permit matching the list filters, or the search term
+// when one is given, to w. Permits are loaded in batches so the CSV output is
+// streamed as it is produced.
+func (s *permitExportService) ExportPermits(req *model.PermitExportRequest, w io.Writer) error {
+	filter := req.PermitListRequest
+	filter.Page = 1
+	filter.Limit = exportBatchSize
+
+	// Load the first batch before anything is written so errors can still be reported
+	permits, total, err := s.findPermits(req.Query, &filter)
+	if err != nil {
+		return err
+	}
+
+	columns := permitExportColumns
+	if req.Format == helper.ExportFormatPDF {
+		columns = nil
+		for _, column := range permitExportColumns {
+			if column.pdfWidth > 0 {
+				columns = append(columns, exportColumn{column.title, column.pdfWidth, column.pdfWidth, column.valueFunc})
+			}
+		}
+	}
+
+	tableColumns := make([]helper.TableColumn, len(columns))
+	for i, column := range columns {
+		tableColumns[i] = helper.TableColumn{Title: column.title, Width: column.width}
+	}
+
+	writer, err := helper.NewTableWriter(req.Format, w, "Permit Register", tableColumns)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	written := int64(0)
+	for {
+		for i := range permits {
+			values := make([]interface{}, len(columns))
+			for j, column := range columns {
+				values[j] = column.valueFunc(&permits[i], today)
+			}
+			if err := writer.WriteRow(values); err != nil {
+				return err
+			}
+		}
+		written += int64(len(permits))
+
+		if len(permits) < exportBatchSize || written >= total {
+			break
+		}
+
+		filter.Page++
+		permits, _, err = s.findPermits(req.Query, &filter)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+func (s *permitExportService) findPermits(query string, filter *model.PermitListRequest) ([]model.Permit, int64, error) {
+	if query != "" {
+		return s.repo.Search(query, filter)
+	}
+	return s.repo.FindAll(filter)
+}
+
+// daysToExpiry counts calendar days from today to the expiry date, negative once expired
+func daysToExpiry(expiryDate time.Time, today time.Time) int {
+	year, month, day := expiryDate.Date()
+	expiryDay := time.Date(year, month, day, 0, 0, 0, 0, today.Location())
+	return int(math.Round(expiryDay.Sub(today).Hours() / 24))
+}
+
+func userName(user *model.User) string {
+	if user == nil {
+		return ""
+	}
+	return user.FullName
+}
@@ -0,0 +1,358 @@
+package permitObligationService
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"permit-app/helper"
+	"permit-app/model"
+	"permit-app/repo/permitObligationRepository"
+	"permit-app/repo/permitRepository"
+	"permit-app/repo/userRepository"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrPermitNotFound is returned when the permit does not exist or is outside the caller's domain
+	ErrPermitNotFound = errors.New("permit not found")
+	// ErrObligationNotFound is returned when the obligation does not belong to the permit
+	ErrObligationNotFound = errors.New("obligation not found")
+	// ErrEvidenceNotFound is returned when the evidence file does not belong to the obligation
+	ErrEvidenceNotFound = errors.New("evidence not found")
+	// ErrInvalidObligation wraps request problems that are reported as bad requests
+	ErrInvalidObligation = errors.New("invalid obligation")
+)
+
+// recurrenceMonths is how far each recurrence rule moves the due date
+var recurrenceMonths = map[string]int{
+	model.ObligationRecurrenceMonthly:    1,
+	model.ObligationRecurrenceQuarterly:  3,
+	model.ObligationRecurrenceSemiAnnual: 6,
+	model.ObligationRecurrenceAnnual:     12,
+}
+
+type PermitObligationService interface {
+	GetObligations(permitID int64, domainID *int64) ([]model.PermitObligationResponse, error)
+	GetObligation(permitID int64, id int64, domainID *int64) (*model.PermitObligationResponse, error)
+	CreateObligation(permitID int64, req *model.PermitObligationRequest, userID int64, domainID *int64) (*model.PermitObligationResponse, error)
+	UpdateObligation(permitID int64, id int64, req *model.PermitObligationRequest, domainID *int64) (*model.PermitObligationResponse, error)
+	DeleteObligation(permitID int64, id int64, domainID *int64) error
+	CompleteObligation(permitID int64, id int64, req *model.PermitObligationCompleteRequest, files []*multipart.FileHeader, userID int64, domainID *int64) (*model.PermitObligationResponse, error)
+	GetEvidence(permitID int64, id int64, evidenceID int64, domainID *int64) (*model.PermitObligationEvidence, error)
+}
+
+type permitObligationService struct {
+	repo       permitObligationRepository.PermitObligationRepository
+	permitRepo permitRepository.PermitRepository
+	userRepo   userRepository.UserRepository
+}
+
+func NewPermitObligationService(
+	repo permitObligationRepository.PermitObligationRepository,
+	permitRepo permitRepository.PermitRepository,
+	userRepo userRepository.UserRepository,
+) PermitObligationService {
+	return &permitObligationService{
+		repo:       repo,
+		permitRepo: permitRepo,
+		userRepo:   userRepo,
+	}
+}
+
+func (s *permitObligationService) GetObligations(permitID int64, domainID *int64) ([]model.PermitObligationResponse, error) {
+	if err := s.checkPermit(permitID, domainID); err != nil {
+		return nil, err
+	}
+
+	obligations, err := s.repo.FindByPermitID(permitID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]model.PermitObligationResponse, 0, len(obligations))
+	for i := range obligations {
+		responses = append(responses, *toResponse(&obligations[i]))
+	}
+
+	return responses, nil
+}
+
+func (s *permitObligationService) GetObligation(permitID int64, id int64, domainID *int64) (*model.PermitObligationResponse, error) {
+	obligation, err := s.findObligation(permitID, id, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toResponse(obligation), nil
+}
+
+func (s *permitObligationService) CreateObligation(permitID int64, req *model.PermitObligationRequest, userID int64, domainID *int64) (*model.PermitObligationResponse, error) {
+	if err := s.checkPermit(permitID, domainID); err != nil {
+		return nil, err
+	}
+	if err := s.checkResponsibleUser(req.ResponsibleUserID); err != nil {
+		return nil, err
+	}
+
+	obligation := &model.PermitObligation{
+		PermitID:          permitID,
+		Description:       strings.TrimSpace(req.Description),
+		RecurrenceRule:    req.RecurrenceRule,
+		NextDueDate:       startOfDay(req.NextDueDate.Time),
+		ResponsibleUserID: req.ResponsibleUserID,
+		Status:            model.ObligationStatusOpen,
+	}
+	if userID > 0 {
+		obligation.CreatedBy = &userID
+	}
+
+	if err := s.repo.Create(obligation); err != nil {
+		return nil, err
+	}
+
+	return s.GetObligation(permitID, obligation.ID, nil)
+}
+
+// UpdateObligation edits the obligation. Setting a due date reopens a completed one-off obligation.
+func (s *permitObligationService) UpdateObligation(permitID int64, id int64, req *model.PermitObligationRequest, domainID *int64) (*model.PermitObligationResponse, error) {
+	obligation, err := s.findObligation(permitID, id, domainID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkResponsibleUser(req.ResponsibleUserID); err != nil {
+		return nil, err
+	}
+
+	nextDueDate := startOfDay(req.NextDueDate.Time)
+	if obligation.Status == model.ObligationStatusCompleted && !nextDueDate.Equal(obligation.NextDueDate) {
+		obligation.Status = model.ObligationStatusOpen
+	}
+
+	obligation.Description = strings.TrimSpace(req.Description)
+	obligation.RecurrenceRule = req.RecurrenceRule
+	obligation.NextDueDate = nextDueDate
+	obligation.ResponsibleUserID = req.ResponsibleUserID
+
+	if err := s.repo.Update(obligation); err != nil {
+		return nil, err
+	}
+
+	return s.GetObligation(permitID, id, nil)
+}
+
+func (s *permitObligationService) DeleteObligation(permitID int64, id int64, domainID *int64) error {
+	obligation, err := s.findObligation(permitID, id, domainID)
+	if err != nil {
+		return err
+	}
+
+	evidence, err := s.repo.FindEvidenceByObligationID(obligation.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(obligation.ID); err != nil {
+		return err
+	}
+
+	// Completion and evidence rows are removed by the database cascade, only the files remain
+	for _, file := range evidence {
+		helper.DeleteFile(file.FilePath)
+	}
+
+	return nil
+}
+
+// CompleteObligation records the completion of the current due date with its
+// evidence files and moves a recurring obligation to its next due date
+func (s *permitObligationService) CompleteObligation(permitID int64, id int64, req *model.PermitObligationCompleteRequest, files []*multipart.FileHeader, userID int64, domainID *int64) (*model.PermitObligationResponse, error) {
+	obligation, err := s.findObligation(permitID, id, domainID)
+	if err != nil {
+		return nil, err
+	}
+	if obligation.Status == model.ObligationStatusCompleted {
+		return nil, fmt.Errorf("%w: obligation is already completed", ErrInvalidObligation)
+	}
+
+	completedAt := time.Now()
+	if !req.CompletedAt.IsZero() {
+		completedAt = req.CompletedAt.Time
+	}
+
+	completion := &model.PermitObligationCompletion{
+		ObligationID: obligation.ID,
+		DueDate:      obligation.NextDueDate,
+		CompletedAt:  completedAt,
+		Notes:        req.Notes,
+	}
+	if userID > 0 {
+		completion.CompletedBy = &userID
+	}
+
+	// Files are stored first so the evidence rows are created together with the completion
+	for _, file := range files {
+		filePath, err := helper.SaveFile(file, "obligations")
+		if err != nil {
+			deleteEvidenceFiles(completion.Evidence)
+			return nil, fmt.Errorf("%w: %v", ErrInvalidObligation, err)
+		}
+
+		fileSize := file.Size
+		fileType := helper.GetMimeType(file.Filename)
+		evidence := model.PermitObligationEvidence{
+			FileName: file.Filename,
+			FilePath: filePath,
+			FileSize: &fileSize,
+			FileType: &fileType,
+		}
+		if userID > 0 {
+			evidence.UploadedBy = &userID
+		}
+		completion.Evidence = append(completion.Evidence, evidence)
+	}
+
+	months, recurring := recurrenceMonths[obligation.RecurrenceRule]
+	if recurring {
+		obligation.NextDueDate = addMonths(obligation.NextDueDate, months)
+	} else {
+		obligation.Status = model.ObligationStatusCompleted
+	}
+
+	if err := s.repo.Complete(obligation, completion); err != nil {
+		deleteEvidenceFiles(completion.Evidence)
+		return nil, err
+	}
+
+	return s.GetObligation(permitID, id, nil)
+}
+
+func (s *permitObligationService) GetEvidence(permitID int64, id int64, evidenceID int64, domainID *int64) (*model.PermitObligationEvidence, error) {
+	obligation, err := s.findObligation(permitID, id, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	evidence, err := s.repo.FindEvidenceByID(obligation.ID, evidenceID)
+	if err != nil {
+		return nil, ErrEvidenceNotFound
+	}
+
+	return evidence, nil
+}
+
+// checkPermit makes sure the permit exists within the domain scope, a nil scope matches every domain
+func (s *permitObligationService) checkPermit(permitID int64, domainID *int64) error {
+	if _, err := s.permitRepo.FindByIDInDomain(permitID, domainID); err != nil {
+		return ErrPermitNotFound
+	}
+	return nil
+}
+
+func (s *permitObligationService) findObligation(permitID int64, id int64, domainID *int64) (*model.PermitObligation, error) {
+	if err := s.checkPermit(permitID, domainID); err != nil {
+		return nil, err
+	}
+
+	obligation, err := s.repo.FindByID(permitID, id)
+	if err != nil {
+		return nil, ErrObligationNotFound
+	}
+
+	return obligation, nil
+}
+
+func (s *permitObligationService) checkResponsibleUser(userID *int64) error {
+	if userID == nil {
+		return nil
+	}
+	if _, err := s.userRepo.FindById(*userID); err != nil {
+		return fmt.Errorf("%w: responsible user %d not found", ErrInvalidObligation, *userID)
+	}
+	return nil
+}
+
+// addMonths moves the date by whole months, keeping the day of month where the
+// target month allows it and using its last day otherwise (31 Jan -> 28 Feb)
+func addMonths(date time.Time, months int) time.Time {
+	firstOfTarget := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, date.Location())
+	lastDay := firstOfTarget.AddDate(0, 1, -1).Day()
+
+	day := date.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return time.Date(firstOfTarget.Year(), firstOfTarget.Month(), day, 0, 0, 0, 0, date.Location())
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func deleteEvidenceFiles(evidence []model.PermitObligationEvidence) {
+	for _, file := range evidence {
+		helper.DeleteFile(file.FilePath)
+	}
+}
+
+func toResponse(obligation *model.PermitObligation) *model.PermitObligationResponse {
+	resp := &model.PermitObligationResponse{
+		ID:                obligation.ID,
+		PermitID:          obligation.PermitID,
+		Description:       obligation.Description,
+		RecurrenceRule:    obligation.RecurrenceRule,
+		NextDueDate:       obligation.NextDueDate,
+		ResponsibleUserID: obligation.ResponsibleUserID,
+		Status:            obligation.Status,
+		IsOverdue:         obligation.Status == model.ObligationStatusOpen && obligation.NextDueDate.Before(startOfDay(time.Now())),
+		CreatedBy:         obligation.CreatedBy,
+		CreatedAt:         obligation.CreatedAt,
+		UpdatedAt:         obligation.UpdatedAt,
+		Completions:       make([]model.PermitObligationCompletionResponse, 0, len(obligation.Completions)),
+	}
+
+	if obligation.ResponsibleUser != nil {
+		resp.ResponsibleUser = toUserResponse(obligation.ResponsibleUser)
+	}
+
+	for _, completion := range obligation.Completions {
+		completionResp := model.PermitObligationCompletionResponse{
+			ID:           completion.ID,
+			ObligationID: completion.ObligationID,
+			DueDate:      completion.DueDate,
+			CompletedAt:  completion.CompletedAt,
+			CompletedBy:  completion.CompletedBy,
+			Notes:        completion.Notes,
+			CreatedAt:    completion.CreatedAt,
+			Evidence:     make([]model.PermitObligationEvidenceResponse, 0, len(completion.Evidence)),
+		}
+		if completion.CompletedByUser != nil {
+			completionResp.CompletedByUser = toUserResponse(completion.CompletedByUser)
+		}
+		for _, evidence := range completion.Evidence {
+			completionResp.Evidence = append(completionResp.Evidence, model.PermitObligationEvidenceResponse{
+				ID:           evidence.ID,
+				CompletionID: evidence.CompletionID,
+				FileName:     evidence.FileName,
+				FileSize:     evidence.FileSize,
+				FileType:     evidence.FileType,
+				UploadedBy:   evidence.UploadedBy,
+				CreatedAt:    evidence.CreatedAt,
+			})
+		}
+		resp.Completions = append(resp.Completions, completionResp)
+	}
+
+	return resp
+}
+
+func toUserResponse(user *model.User) *model.UserResponse {
+	return &model.UserResponse{
+		ID:       user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		FullName: user.FullName,
+		IsActive: user.IsActive,
+	}
+}
@@ -1,32 +1,151 @@
 package permitService
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"mime/multipart"
 	"permit-app/helper"
 	"permit-app/model"
+	"permit-app/repo/divisionRepository"
+	"permit-app/repo/notificationPreferenceRepository"
+	"permit-app/repo/notificationRepository"
 	"permit-app/repo/permitRepository"
+	"permit-app/repo/permitRevisionRepository"
+	"permit-app/repo/permitTypeRepository"
+	"permit-app/repo/referenceRepository"
+	"permit-app/repo/userRepository"
+	"permit-app/repo/webhookRepository"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
 )
 
 type PermitService interface {
-	CreatePermit(req *model.PermitRequest) (*model.PermitResponse, error)
-	GetPermitByID(id int64) (*model.PermitResponse, error)
+	CreatePermit(req *model.PermitRequest, userID int64) (*model.PermitResponse, error)
+	GetPermitByID(id int64, domainID *int64) (*model.PermitResponse, error)
 	GetAllPermits(filter *model.PermitListRequest) ([]model.PermitResponse, int64, error)
-	UpdatePermit(id int64, req *model.PermitUpdateRequest) (*model.PermitResponse, error)
-	DeletePermit(id int64) error
-	HandleFileUpload(id int64, file *multipart.FileHeader) (*model.PermitResponse, error)
+	UpdatePermit(id int64, req *model.PermitUpdateRequest, userID int64) (*model.PermitResponse, error)
+	DeletePermit(id int64, userID int64) error
+	GetDeletedPermits(filter *model.PermitListRequest) ([]model.PermitResponse, int64, error)
+	RestorePermit(id int64, domainID *int64, userID int64) (*model.PermitResponse, error)
+	PurgePermit(id int64, domainID *int64, userID int64) error
+	HandleFileUpload(id int64, file *multipart.FileHeader, userID int64) (*model.PermitResponse, error)
 	SearchPermits(query string, filter *model.PermitListRequest) ([]model.PermitResponse, int64, error)
+	RenewPermit(id int64, req *model.PermitRenewRequest, userID int64) (*model.PermitResponse, error)
+	GetRenewalChain(id int64) ([]model.PermitResponse, error)
+	GetPermitHistory(id int64) ([]model.PermitRevisionResponse, error)
+	GetPermitRevision(id int64, revision int) (*model.PermitRevisionResponse, error)
+	RestorePermitRevision(id int64, revision int, userID int64) (*model.PermitResponse, error)
+	GetPermitDocuments(id int64) ([]model.PermitDocumentResponse, error)
+	GetPermitDocument(id int64, documentID int64) (*model.PermitDocumentResponse, error)
+	UploadPermitDocument(id int64, documentTypeID int64, file *multipart.FileHeader, userID int64) (*model.PermitDocumentResponse, error)
+	DeletePermitDocument(id int64, documentID int64, userID int64) error
+	ChangePermitStatus(id int64, status string, userID int64) (*model.PermitResponse, error)
+	UpdateExpiryStatuses() (int, error)
+	ImportPermits(domainID int64, file *multipart.FileHeader, mode string, userID int64) (*model.PermitImportResponse, error)
+	SubmitPermit(id int64, userID int64) (*model.PermitResponse, error)
+	GetPermitApprovals(id int64) ([]model.PermitApprovalResponse, error)
+	ApprovePermit(id int64, approvalID int64, req *model.ApprovalRequest, userID int64, domainID *int64) (*model.PermitResponse, error)
+	RejectPermit(id int64, approvalID int64, req *model.ApprovalRequest, userID int64, domainID *int64) (*model.PermitResponse, error)
+	GetPendingApprovals(userID int64, domainID *int64) ([]model.PermitResponse, error)
+}
+
+var (
+	// ErrApprovalNotFound is returned when the approval step is not part of the permit's current submission
+	ErrApprovalNotFound = errors.New("permit approval not found")
+	// ErrNotApprover is returned when the user may not decide the approval step
+	ErrNotApprover = errors.New("user is not an approver of this step")
+	// ErrInvalidApproval wraps submissions and decisions that do not fit the permit's state
+	ErrInvalidApproval = errors.New("invalid approval")
+	// ErrApprovalAlreadyDecided is returned when another decision on the approval step was stored first
+	ErrApprovalAlreadyDecided = errors.New("approval step has already been decided")
+	// ErrInvalidPermit wraps permit requests and import files that do not give a valid permit
+	ErrInvalidPermit = errors.New("invalid permit")
+	// ErrPermitNotInTrash is returned when restoring or purging a permit that was not deleted
+	ErrPermitNotInTrash = errors.New("permit not found in trash")
+	// ErrInvalidStatusTransition wraps status changes the permit lifecycle does not allow
+	ErrInvalidStatusTransition = errors.New("invalid permit status transition")
+	// ErrPermitAlreadyRenewed is returned when renewing a permit that already has a renewal
+	ErrPermitAlreadyRenewed = errors.New("permit has already been renewed")
+	// ErrPermitNotRenewable wraps renewals of permits whose status does not allow it
+	ErrPermitNotRenewable = errors.New("permit cannot be renewed")
+	// ErrDuplicatePermitNo is returned when the permit number is taken in the domain
+	ErrDuplicatePermitNo = errors.New("permit number already exists in this domain")
+	// ErrPermitPendingApproval is returned when editing a permit while its approvers decide on it
+	ErrPermitPendingApproval = errors.New("permit is pending approval and cannot be edited")
+)
+
+// expiringWindowDays is how many days before its expiry date an active permit becomes expiring
+const expiringWindowDays = 30
+
+// validityToleranceDays is how far a manually entered expiry date may be from the
+// permit type's standard validity before it is reported
+const validityToleranceDays = 1
+
+// permitStatusTransitions lists the statuses each status may move to. Moves into
+// expiring and expired follow from the expiry date, superseded from a renewal,
+// and moves into and out of pending from the approval flow.
+var permitStatusTransitions = map[string][]string{
+	model.PermitStatusDraft:      {model.PermitStatusPending, model.PermitStatusArchived},
+	model.PermitStatusPending:    {model.PermitStatusDraft, model.PermitStatusActive, model.PermitStatusExpiring, model.PermitStatusExpired, model.PermitStatusArchived},
+	model.PermitStatusActive:     {model.PermitStatusExpiring, model.PermitStatusExpired, model.PermitStatusInRenewal, model.PermitStatusRevoked, model.PermitStatusArchived, model.PermitStatusSuperseded},
+	model.PermitStatusExpiring:   {model.PermitStatusActive, model.PermitStatusExpired, model.PermitStatusInRenewal, model.PermitStatusRevoked, model.PermitStatusArchived, model.PermitStatusSuperseded},
+	model.PermitStatusExpired:    {model.PermitStatusInRenewal, model.PermitStatusArchived, model.PermitStatusSuperseded},
+	model.PermitStatusInRenewal:  {model.PermitStatusActive, model.PermitStatusExpiring, model.PermitStatusExpired, model.PermitStatusRevoked, model.PermitStatusArchived, model.PermitStatusSuperseded},
+	model.PermitStatusRevoked:    {model.PermitStatusArchived},
+	model.PermitStatusArchived:   {},
+	model.PermitStatusSuperseded: {model.PermitStatusArchived},
+}
+
+// manualPermitStatuses are the statuses users may pick themselves
+var manualPermitStatuses = []string{
+	model.PermitStatusActive,
+	model.PermitStatusInRenewal,
+	model.PermitStatusRevoked,
+	model.PermitStatusArchived,
 }
 
 type permitService struct {
-	repo permitRepository.PermitRepository
+	repo             permitRepository.PermitRepository
+	revisionRepo     permitRevisionRepository.PermitRevisionRepository
+	referenceRepo    referenceRepository.ReferenceRepository
+	divisionRepo     divisionRepository.DivisionRepository
+	permitTypeRepo   permitTypeRepository.PermitTypeRepository
+	userRepo         userRepository.UserRepository
+	notificationRepo notificationRepository.NotificationRepository
+	preferenceRepo   notificationPreferenceRepository.NotificationPreferenceRepository
+	webhookRepo      webhookRepository.WebhookRepository
 }
 
-func NewPermitService(repo permitRepository.PermitRepository) PermitService {
-	return &permitService{repo: repo}
+func NewPermitService(
+	repo permitRepository.PermitRepository,
+	revisionRepo permitRevisionRepository.PermitRevisionRepository,
+	referenceRepo referenceRepository.ReferenceRepository,
+	divisionRepo divisionRepository.DivisionRepository,
+	permitTypeRepo permitTypeRepository.PermitTypeRepository,
+	userRepo userRepository.UserRepository,
+	notificationRepo notificationRepository.NotificationRepository,
+	preferenceRepo notificationPreferenceRepository.NotificationPreferenceRepository,
+	webhookRepo webhookRepository.WebhookRepository,
+) PermitService {
+	return &permitService{
+		repo:             repo,
+		revisionRepo:     revisionRepo,
+		referenceRepo:    referenceRepo,
+		divisionRepo:     divisionRepo,
+		permitTypeRepo:   permitTypeRepo,
+		userRepo:         userRepo,
+		notificationRepo: notificationRepo,
+		preferenceRepo:   preferenceRepo,
+		webhookRepo:      webhookRepo,
+	}
 }
 
-func (s *permitService) CreatePermit(req *model.PermitRequest) (*model.PermitResponse, error) {
+func (s *permitService) CreatePermit(req *model.PermitRequest, userID int64) (*model.PermitResponse, error) {
 	existing, err := s.repo.FindByPermitNoAndDomainID(req.PermitNo, req.DomainID)
 	if err != nil {
 		return nil, err
@@ -35,6 +154,19 @@ func (s *permitService) CreatePermit(req *model.PermitRequest) (*model.PermitRes
 		return nil, errors.New("permit number already exists in this domain")
 	}
 
+	if err := s.checkDomainReferences(req.DomainID, req.DivisionID, req.PermitTypeID); err != nil {
+		return nil, err
+	}
+
+	permitType, err := s.permitTypeRepo.FindByID(req.PermitTypeID)
+	if err != nil {
+		return nil, err
+	}
+	warnings, err := applyPermitTypeDefaults(req, permitType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPermit, err)
+	}
+
 	permit := &model.Permit{
 		DomainID:               req.DomainID,
 		DivisionID:             req.DivisionID,
@@ -49,7 +181,13 @@ func (s *permitService) CreatePermit(req *model.PermitRequest) (*model.PermitRes
 		ResponsibleDocPersonID: req.ResponsibleDocPersonID,
 		DocName:                req.DocName,
 		DocNumber:              req.DocNumber,
-		Status:                 req.Status,
+		Status:                 model.PermitStatusPending,
+	}
+	// New permits are submitted for approval right away unless kept as a draft
+	if req.Status == model.PermitStatusDraft {
+		permit.Status = model.PermitStatusDraft
+	} else {
+		newApprovalRound(permit, userID)
 	}
 
 	err = s.repo.Create(permit)
@@ -57,16 +195,73 @@ func (s *permitService) CreatePermit(req *model.PermitRequest) (*model.PermitRes
 		return nil, err
 	}
 
+	if permit.Status == model.PermitStatusPending {
+		s.notifyVerifiers(permit)
+	}
+
 	created, err := s.repo.FindByID(permit.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.toResponse(created), nil
+	err = s.recordRevision(created, model.PermitRevisionActionCreate, nil, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := s.toResponse(created)
+	resp.Warnings = warnings
+	return resp, nil
 }
 
-func (s *permitService) GetPermitByID(id int64) (*model.PermitResponse, error) {
-	permit, err := s.repo.FindByID(id)
+// applyPermitTypeDefaults fills the application type, expiry date and effective
+// term the request leaves out from the permit type. A manually entered expiry
+// date that deviates from the type's standard validity is returned as a warning.
+func applyPermitTypeDefaults(req *model.PermitRequest, permitType *model.PermitType) ([]string, error) {
+	if req.ApplicationType == "" && permitType.DefaultApplicationType != nil {
+		req.ApplicationType = *permitType.DefaultApplicationType
+	}
+	if req.ApplicationType == "" {
+		return nil, fmt.Errorf("application type is required, permit type %s has no default application type", permitType.Name)
+	}
+
+	// Periods stored before they were validated may not parse, they count as no default
+	var period *helper.ValidityPeriod
+	if permitType.DefaultValidityPeriod != nil {
+		if parsed, err := helper.ParseValidityPeriod(*permitType.DefaultValidityPeriod); err == nil {
+			period = &parsed
+		}
+	}
+
+	if req.ExpiryDate.IsZero() {
+		if period == nil {
+			return nil, fmt.Errorf("expiry date is required, permit type %s has no default validity period", permitType.Name)
+		}
+		req.ExpiryDate = helper.Date{Time: period.AddTo(req.EffectiveDate.Time)}
+		if req.EffectiveTerm == nil {
+			req.EffectiveTerm = permitType.DefaultValidityPeriod
+		}
+		return nil, nil
+	}
+
+	if period == nil {
+		return nil, nil
+	}
+	expected := period.AddTo(req.EffectiveDate.Time)
+	if deviation := req.ExpiryDate.Sub(expected).Hours() / 24; deviation > validityToleranceDays || deviation < -validityToleranceDays {
+		return []string{fmt.Sprintf(
+			"expiry date %s deviates from the standard validity of %s for permit type %s, which ends on %s",
+			req.ExpiryDate.Format("2006-01-02"), period, permitType.Name, expected.Format("2006-01-02"),
+		)}, nil
+	}
+
+	return nil, nil
+}
+
+// GetPermitByID returns the permit if it belongs to the domain. A nil domainID
+// gives access to permits of every domain.
+func (s *permitService) GetPermitByID(id int64, domainID *int64) (*model.PermitResponse, error) {
+	permit, err := s.repo.FindByIDInDomain(id, domainID)
 	if err != nil {
 		return nil, err
 	}
@@ -88,11 +283,16 @@ func (s *permitService) GetAllPermits(filter *model.PermitListRequest) ([]model.
 	return responses, total, nil
 }
 
-func (s *permitService) UpdatePermit(id int64, req *model.PermitUpdateRequest) (*model.PermitResponse, error) {
+func (s *permitService) UpdatePermit(id int64, req *model.PermitUpdateRequest, userID int64) (*model.PermitResponse, error) {
 	permit, err := s.repo.FindByID(id)
 	if err != nil {
 		return nil, err
 	}
+	// The approvers decide on the permit as it was submitted
+	if permit.Status == model.PermitStatusPending {
+		return nil, ErrPermitPendingApproval
+	}
+	before := toSnapshot(permit)
 
 	if req.DomainID > 0 {
 		permit.DomainID = req.DomainID
@@ -147,9 +347,24 @@ func (s *permitService) UpdatePermit(id int64, req *model.PermitUpdateRequest) (
 	if req.DocNumber != nil {
 		permit.DocNumber = req.DocNumber
 	}
-	if req.Status != "" {
-		permit.Status = req.Status
+
+	divisionChanged := (permit.DivisionID == nil) != (before.DivisionID == nil) ||
+		(permit.DivisionID != nil && *permit.DivisionID != *before.DivisionID)
+	if permit.DomainID != before.DomainID || divisionChanged || permit.PermitTypeID != before.PermitTypeID {
+		if err := s.checkDomainReferences(permit.DomainID, permit.DivisionID, permit.PermitTypeID); err != nil {
+			return nil, err
+		}
+	}
+
+	status := permit.Status
+	if req.Status != "" && req.Status != permit.Status {
+		status, err = nextPermitStatus(permit.Status, req.Status, permit.ExpiryDate, time.Now())
+		if err != nil {
+			return nil, err
+		}
 	}
+	// A changed expiry date can move the permit between active, expiring and expired
+	permit.Status = statusForExpiry(status, permit.ExpiryDate, time.Now())
 
 	err = s.repo.Update(id, permit)
 	if err != nil {
@@ -161,67 +376,316 @@ func (s *permitService) UpdatePermit(id int64, req *model.PermitUpdateRequest) (
 		return nil, err
 	}
 
+	err = s.recordRevision(updated, model.PermitRevisionActionUpdate, &before, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	return s.toResponse(updated), nil
 }
 
-func (s *permitService) DeletePermit(id int64) error {
+// checkDomainReferences makes sure the division and permit type of a permit
+// belong to the permit's domain
+func (s *permitService) checkDomainReferences(domainID int64, divisionID *int64, permitTypeID int64) error {
+	if divisionID != nil {
+		if _, err := s.divisionRepo.FindByIDInDomain(*divisionID, &domainID); err != nil {
+			return fmt.Errorf("%w: division %d is not part of domain %d", helper.ErrOutsideDomain, *divisionID, domainID)
+		}
+	}
+	if _, err := s.permitTypeRepo.FindByIDInDomain(permitTypeID, &domainID); err != nil {
+		return fmt.Errorf("%w: permit type %d is not available in domain %d", helper.ErrOutsideDomain, permitTypeID, domainID)
+	}
+	return nil
+}
+
+// DeletePermit moves the permit to the trash. Its documents are kept until the
+// permit is purged.
+func (s *permitService) DeletePermit(id int64, userID int64) error {
 	permit, err := s.repo.FindByID(id)
 	if err != nil {
 		return err
 	}
 
-	// Delete associated file if exists
-	if permit.DocFilePath != nil && *permit.DocFilePath != "" {
-		helper.DeleteFile(*permit.DocFilePath)
+	err = s.repo.Delete(id)
+	if err != nil {
+		return err
 	}
 
-	return s.repo.Delete(id)
+	return s.recordRevision(permit, model.PermitRevisionActionDelete, nil, userID)
 }
 
-func (s *permitService) HandleFileUpload(id int64, file *multipart.FileHeader) (*model.PermitResponse, error) {
-	// Get existing permit
-	permit, err := s.repo.FindByID(id)
+func (s *permitService) GetDeletedPermits(filter *model.PermitListRequest) ([]model.PermitResponse, int64, error) {
+	permits, total, err := s.repo.FindDeleted(filter)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	// Delete old file if exists
-	if permit.DocFilePath != nil && *permit.DocFilePath != "" {
-		helper.DeleteFile(*permit.DocFilePath)
+	responses := make([]model.PermitResponse, 0, len(permits))
+	for i := range permits {
+		responses = append(responses, *s.toResponse(&permits[i]))
 	}
 
-	// Save new file
-	filePath, err := helper.SaveFile(file, "permits")
+	return responses, total, nil
+}
+
+// RestorePermit takes a permit out of the trash. A nil domainID gives access to
+// permits of every domain. A trashed renewal cannot come back once its previous
+// period has been renewed again.
+func (s *permitService) RestorePermit(id int64, domainID *int64, userID int64) (*model.PermitResponse, error) {
+	deleted, err := s.repo.FindDeletedByIDInDomain(id, domainID)
 	if err != nil {
+		return nil, ErrPermitNotInTrash
+	}
+
+	if deleted.PreviousPermitID != nil {
+		renewed, err := s.repo.FindByPreviousPermitID(*deleted.PreviousPermitID)
+		if err != nil {
+			return nil, err
+		}
+		if renewed != nil {
+			return nil, fmt.Errorf("%w (permit %s)", ErrPermitAlreadyRenewed, renewed.PermitNo)
+		}
+	}
+
+	if err := s.repo.Restore(id); err != nil {
 		return nil, err
 	}
 
-	// Update permit with file information
-	fileName := file.Filename
-	fileSize := file.Size
-	fileType := helper.GetMimeType(file.Filename)
+	restored, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
 
-	permit.DocFileName = &fileName
-	permit.DocFilePath = &filePath
-	permit.DocFileSize = &fileSize
-	permit.DocFileType = &fileType
+	if err := s.recordRevision(restored, model.PermitRevisionActionUndelete, nil, userID); err != nil {
+		return nil, err
+	}
 
-	err = s.repo.Update(id, permit)
+	return s.toResponse(restored), nil
+}
+
+// PurgePermit permanently removes a trashed permit together with its stored
+// documents and obligation evidence. Only permits in the trash can be purged.
+func (s *permitService) PurgePermit(id int64, domainID *int64, userID int64) error {
+	permit, err := s.repo.FindDeletedByIDInDomain(id, domainID)
+	if err != nil {
+		return ErrPermitNotInTrash
+	}
+
+	evidencePaths, err := s.repo.FindEvidenceFilePaths(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Purge(id); err != nil {
+		return err
+	}
+
+	// Rows are removed by the database cascade, only the files remain
+	deleteDocumentFiles(permit.Documents)
+	for _, path := range evidencePaths {
+		helper.DeleteFile(path)
+	}
+
+	// The last revision keeps the permit's final state after the row is gone
+	return s.recordRevision(permit, model.PermitRevisionActionPurge, nil, userID)
+}
+
+// HandleFileUpload replaces the permit's license document. It backs the
+// single-document endpoints, other document types go through UploadPermitDocument.
+func (s *permitService) HandleFileUpload(id int64, file *multipart.FileHeader, userID int64) (*model.PermitResponse, error) {
+	// Get existing permit
+	permit, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	before := toSnapshot(permit)
+
+	_, err = s.saveDocument(id, helper.PermitDocumentTypeLicense, file, userID)
 	if err != nil {
-		// If database update fails, delete the uploaded file
-		helper.DeleteFile(filePath)
 		return nil, err
 	}
 
+	// Remove the license documents that were replaced
+	for _, document := range permit.Documents {
+		if document.DocumentTypeID != helper.PermitDocumentTypeLicense {
+			continue
+		}
+		if err := s.repo.DeleteDocument(document.ID); err != nil {
+			return nil, err
+		}
+		helper.DeleteFile(document.FilePath)
+	}
+
 	// Fetch updated permit with all relations
 	updated, err := s.repo.FindByID(id)
 	if err != nil {
 		return nil, err
 	}
 
+	err = s.recordRevision(updated, model.PermitRevisionActionUpload, &before, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	return s.toResponse(updated), nil
 }
 
+// RenewPermit starts the permit's next period. The renewal goes through approval
+// like a new permit, meanwhile the previous period is in renewal. It is superseded
+// once the renewal is approved.
+func (s *permitService) RenewPermit(id int64, req *model.PermitRenewRequest, userID int64) (*model.PermitResponse, error) {
+	previous, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	before := toSnapshot(previous)
+
+	if previous.Status == model.PermitStatusSuperseded {
+		return nil, ErrPermitAlreadyRenewed
+	}
+	if !canTransitionPermitStatus(previous.Status, model.PermitStatusSuperseded) {
+		return nil, fmt.Errorf("%w: a %s permit cannot be renewed", ErrPermitNotRenewable, previous.Status)
+	}
+
+	renewed, err := s.repo.FindByPreviousPermitID(id)
+	if err != nil {
+		return nil, err
+	}
+	if renewed != nil {
+		return nil, fmt.Errorf("%w (permit %s)", ErrPermitAlreadyRenewed, renewed.PermitNo)
+	}
+
+	if !req.ExpiryDate.After(req.EffectiveDate.Time) {
+		return nil, fmt.Errorf("%w: expiry date must be after effective date", ErrInvalidPermit)
+	}
+
+	existing, err := s.repo.FindByPermitNoAndDomainID(req.PermitNo, previous.DomainID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrDuplicatePermitNo
+	}
+
+	applicationType := req.ApplicationType
+	if applicationType == "" {
+		applicationType = "Renewal"
+	}
+
+	renewal := &model.Permit{
+		DomainID:               previous.DomainID,
+		DivisionID:             previous.DivisionID,
+		PermitTypeID:           previous.PermitTypeID,
+		Name:                   previous.Name,
+		ApplicationType:        applicationType,
+		PermitNo:               req.PermitNo,
+		EffectiveDate:          req.EffectiveDate.Time,
+		ExpiryDate:             req.ExpiryDate.Time,
+		EffectiveTerm:          previous.EffectiveTerm,
+		ResponsiblePersonID:    previous.ResponsiblePersonID,
+		ResponsibleDocPersonID: previous.ResponsibleDocPersonID,
+		DocName:                previous.DocName,
+		DocNumber:              previous.DocNumber,
+		Status:                 model.PermitStatusPending,
+		PreviousPermitID:       &previous.ID,
+	}
+	if req.EffectiveTerm != nil {
+		renewal.EffectiveTerm = req.EffectiveTerm
+	}
+	if req.DocName != nil {
+		renewal.DocName = req.DocName
+	}
+	if req.DocNumber != nil {
+		renewal.DocNumber = req.DocNumber
+	}
+
+	// Carry over the documents as their own copies so each period keeps its files
+	// even if the other period is deleted later
+	for _, document := range previous.Documents {
+		filePath, err := helper.CopyFile(document.FilePath, "permits")
+		if err != nil {
+			deleteDocumentFiles(renewal.Documents)
+			return nil, err
+		}
+		renewal.Documents = append(renewal.Documents, model.PermitDocument{
+			DocumentTypeID: document.DocumentTypeID,
+			FileName:       document.FileName,
+			FilePath:       filePath,
+			FileSize:       document.FileSize,
+			FileType:       document.FileType,
+			UploadedBy:     document.UploadedBy,
+		})
+	}
+
+	newApprovalRound(renewal, userID)
+
+	err = s.repo.Renew(previous.ID, renewal)
+	if err != nil {
+		deleteDocumentFiles(renewal.Documents)
+		return nil, err
+	}
+	s.notifyVerifiers(renewal)
+
+	created, err := s.repo.FindByID(renewal.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.recordRevision(created, model.PermitRevisionActionCreate, nil, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	inRenewal, err := s.repo.FindByID(previous.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.recordRevision(inRenewal, model.PermitRevisionActionStatusChange, &before, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toResponse(created), nil
+}
+
+// GetRenewalChain returns every period of the permit's renewal chain, oldest first
+func (s *permitService) GetRenewalChain(id int64) ([]model.PermitResponse, error) {
+	permit, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk back to the first period
+	chain := []model.Permit{*permit}
+	for chain[0].PreviousPermitID != nil {
+		previous, err := s.repo.FindByID(*chain[0].PreviousPermitID)
+		if err != nil {
+			return nil, err
+		}
+		chain = append([]model.Permit{*previous}, chain...)
+	}
+
+	// Walk forward to the latest period
+	for {
+		next, err := s.repo.FindByPreviousPermitID(chain[len(chain)-1].ID)
+		if err != nil {
+			return nil, err
+		}
+		if next == nil {
+			break
+		}
+		chain = append(chain, *next)
+	}
+
+	responses := make([]model.PermitResponse, len(chain))
+	for i := range chain {
+		responses[i] = *s.toResponse(&chain[i])
+	}
+
+	return responses, nil
+}
+
 func (s *permitService) toResponse(permit *model.Permit) *model.PermitResponse {
 	resp := &model.PermitResponse{
 		ID:                     permit.ID,
@@ -238,13 +702,28 @@ func (s *permitService) toResponse(permit *model.Permit) *model.PermitResponse {
 		ResponsibleDocPersonID: permit.ResponsibleDocPersonID,
 		DocName:                permit.DocName,
 		DocNumber:              permit.DocNumber,
-		DocFileName:            permit.DocFileName,
-		DocFilePath:            permit.DocFilePath,
-		DocFileSize:            permit.DocFileSize,
-		DocFileType:            permit.DocFileType,
 		Status:                 permit.Status,
+		PreviousPermitID:       permit.PreviousPermitID,
+		SupersededAt:           permit.SupersededAt,
+		RequestedBy:            permit.RequestedBy,
+		ApprovalStatusID:       permit.ApprovalStatusID,
 		CreatedAt:              permit.CreatedAt,
 		UpdatedAt:              permit.UpdatedAt,
+		DeletedAt:              permit.DeletedAt,
+		Documents:              make([]model.PermitDocumentResponse, 0, len(permit.Documents)),
+	}
+
+	for i := range permit.Documents {
+		document := &permit.Documents[i]
+		resp.Documents = append(resp.Documents, *toDocumentResponse(document))
+
+		// Documents are ordered by upload time, so the last license wins
+		if document.DocumentTypeID == helper.PermitDocumentTypeLicense {
+			resp.DocFileName = &document.FileName
+			resp.DocFilePath = &document.FilePath
+			resp.DocFileSize = document.FileSize
+			resp.DocFileType = document.FileType
+		}
 	}
 
 	if permit.Domain != nil {
@@ -261,12 +740,13 @@ func (s *permitService) toResponse(permit *model.Permit) *model.PermitResponse {
 
 	if permit.Division != nil {
 		resp.Division = &model.DivisionResponse{
-			ID:        permit.Division.ID,
-			DomainID:  permit.Division.DomainID,
-			Code:      permit.Division.Code,
-			Name:      permit.Division.Name,
-			CreatedAt: permit.Division.CreatedAt,
-			UpdatedAt: permit.Division.UpdatedAt,
+			ID:         permit.Division.ID,
+			DomainID:   permit.Division.DomainID,
+			Code:       permit.Division.Code,
+			Name:       permit.Division.Name,
+			HeadUserID: permit.Division.HeadUserID,
+			CreatedAt:  permit.Division.CreatedAt,
+			UpdatedAt:  permit.Division.UpdatedAt,
 		}
 
 		if permit.Division.Domain != nil {
@@ -343,3 +823,1450 @@ func (s *permitService) SearchPermits(query string, filter *model.PermitListRequ
 
 	return responses, total, nil
 }
+
+func (s *permitService) GetPermitHistory(id int64) ([]model.PermitRevisionResponse, error) {
+	revisions, err := s.revisionRepo.FindByPermitID(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		// Permits created before history tracking have no revisions yet
+		if _, err := s.repo.FindByID(id); err != nil {
+			return nil, err
+		}
+	}
+
+	responses := make([]model.PermitRevisionResponse, 0, len(revisions))
+	for i := range revisions {
+		resp, err := s.toRevisionResponse(&revisions[i])
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, *resp)
+	}
+
+	return responses, nil
+}
+
+func (s *permitService) GetPermitRevision(id int64, revision int) (*model.PermitRevisionResponse, error) {
+	permitRevision, err := s.revisionRepo.FindByPermitIDAndRevision(id, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toRevisionResponse(permitRevision)
+}
+
+// RestorePermitRevision writes the permit fields stored in an earlier revision back
+// to the permit and records the result as a new revision. Documents and the
+// renewal chain are not part of the restore since they are managed separately.
+func (s *permitService) RestorePermitRevision(id int64, revision int, userID int64) (*model.PermitResponse, error) {
+	permit, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if permit.Status == model.PermitStatusPending {
+		return nil, ErrPermitPendingApproval
+	}
+	before := toSnapshot(permit)
+
+	permitRevision, err := s.revisionRepo.FindByPermitIDAndRevision(id, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot model.PermitSnapshot
+	if err := json.Unmarshal(permitRevision.Snapshot, &snapshot); err != nil {
+		return nil, err
+	}
+
+	if snapshot.PermitNo != permit.PermitNo {
+		existing, err := s.repo.FindByPermitNoAndDomainID(snapshot.PermitNo, permit.DomainID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil && existing.ID != id {
+			return nil, errors.New("permit number already exists in this domain")
+		}
+	}
+
+	permit.DivisionID = snapshot.DivisionID
+	permit.PermitTypeID = snapshot.PermitTypeID
+	permit.Name = snapshot.Name
+	permit.ApplicationType = snapshot.ApplicationType
+	permit.PermitNo = snapshot.PermitNo
+	permit.EffectiveDate = snapshot.EffectiveDate
+	permit.ExpiryDate = snapshot.ExpiryDate
+	permit.EffectiveTerm = snapshot.EffectiveTerm
+	permit.ResponsiblePersonID = snapshot.ResponsiblePersonID
+	permit.ResponsibleDocPersonID = snapshot.ResponsibleDocPersonID
+	permit.DocName = snapshot.DocName
+	permit.DocNumber = snapshot.DocNumber
+	// The status is not restored since moving back to an earlier status could skip
+	// the allowed transitions, only the restored expiry date is taken into account
+	permit.Status = statusForExpiry(permit.Status, permit.ExpiryDate, time.Now())
+
+	err = s.repo.UpdateFields(id, permit, []string{
+		"division_id", "permit_type_id", "name", "application_type", "permit_no",
+		"effective_date", "expiry_date", "effective_term", "responsible_person_id",
+		"responsible_doc_person_id", "doc_name", "doc_number", "status",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	restored, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.recordRevision(restored, model.PermitRevisionActionRestore, &before, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toResponse(restored), nil
+}
+
+// ChangePermitStatus moves a permit to a status chosen by the user
+func (s *permitService) ChangePermitStatus(id int64, status string, userID int64) (*model.PermitResponse, error) {
+	permit, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	before := toSnapshot(permit)
+
+	next, err := nextPermitStatus(permit.Status, status, permit.ExpiryDate, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if next == permit.Status {
+		if next != status {
+			return nil, fmt.Errorf("%w: permit stays %s because of its expiry date", ErrInvalidStatusTransition, next)
+		}
+		return nil, fmt.Errorf("%w: permit is already %s", ErrInvalidStatusTransition, next)
+	}
+
+	permit.Status = next
+	err = s.repo.UpdateFields(id, permit, []string{"status"})
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.recordRevision(updated, model.PermitRevisionActionStatusChange, &before, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toResponse(updated), nil
+}
+
+// SubmitPermit sends a draft permit, a new one or one returned by a rejection,
+// to the division head for verification
+func (s *permitService) SubmitPermit(id int64, userID int64) (*model.PermitResponse, error) {
+	permit, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	before := toSnapshot(permit)
+
+	if permit.Status != model.PermitStatusDraft {
+		return nil, fmt.Errorf("%w: only draft permits can be submitted, permit is %s", ErrInvalidApproval, permit.Status)
+	}
+
+	if err := s.startApproval(permit, userID); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.recordRevision(updated, model.PermitRevisionActionStatusChange, &before, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toResponse(updated), nil
+}
+
+// GetPermitApprovals returns the approval steps of every submission of the permit, oldest first
+func (s *permitService) GetPermitApprovals(id int64) ([]model.PermitApprovalResponse, error) {
+	if _, err := s.repo.FindByID(id); err != nil {
+		return nil, err
+	}
+
+	approvals, err := s.repo.FindApprovals(id)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]model.PermitApprovalResponse, 0, len(approvals))
+	for i := range approvals {
+		responses = append(responses, *toApprovalResponse(&approvals[i]))
+	}
+
+	return responses, nil
+}
+
+// ApprovePermit decides the waiting approval step. The division head's
+// verification hands the permit to the permit managers, their approval makes it
+// active and supersedes the period it renews. A nil domainID is a super admin, who may decide any step.
+func (s *permitService) ApprovePermit(id int64, approvalID int64, req *model.ApprovalRequest, userID int64, domainID *int64) (*model.PermitResponse, error) {
+	permit, _, approval, err := s.findDecidableApproval(id, approvalID, userID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	before := toSnapshot(permit)
+
+	now := time.Now()
+	approveStatusID := int64(helper.ApprovalStatusApprove)
+	approval.ApprovedBy = &userID
+	approval.ApprovalStatusID = &approveStatusID
+	approval.ApprovalDate = &now
+	approval.Note = req.Note
+
+	fields := []string{"approval_status_id"}
+	if approval.Sequence == model.PermitApprovalSequenceVerify {
+		pendingManagerStatusID := int64(helper.ApprovalStatusPendingManager)
+		permit.ApprovalStatusID = &pendingManagerStatusID
+	} else {
+		// Fully approved, the permit starts counting toward its expiry from here on
+		permit.ApprovalStatusID = &approveStatusID
+		permit.Status = statusForExpiry(model.PermitStatusActive, permit.ExpiryDate, now)
+		fields = append(fields, "status")
+	}
+
+	decided, err := s.repo.DecideApproval(permit, fields, []model.PermitApproval{*approval})
+	if err != nil {
+		return nil, err
+	}
+	if !decided {
+		return nil, ErrApprovalAlreadyDecided
+	}
+
+	updated, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if approval.Sequence == model.PermitApprovalSequenceVerify {
+		s.notifyApprovers(updated, model.PermitApprovalSequenceApprove)
+		return s.toResponse(updated), nil
+	}
+
+	err = s.recordRevision(updated, model.PermitRevisionActionStatusChange, &before, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if updated.PreviousPermitID != nil {
+		if err := s.supersedePrevious(*updated.PreviousPermitID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Receivers only learn about a permit once it is in force, drafts and
+	// rejected submissions never reach them
+	s.publishWebhook(model.WebhookEventPermitCreated, updated)
+
+	s.notifyRequester(updated, "approval_approved", "Permit Approved",
+		fmt.Sprintf("Permit %s (%s) has been approved and is now %s.", updated.PermitNo, updated.Name, updated.Status))
+
+	return s.toResponse(updated), nil
+}
+
+// supersedePrevious ends the previous period of an approved renewal. A period
+// that was archived or revoked in the meantime is left as it is.
+func (s *permitService) supersedePrevious(id int64, userID int64) error {
+	previous, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if !canTransitionPermitStatus(previous.Status, model.PermitStatusSuperseded) {
+		return nil
+	}
+	before := toSnapshot(previous)
+
+	if err := s.repo.Supersede(previous.ID); err != nil {
+		return err
+	}
+
+	superseded, err := s.repo.FindByID(previous.ID)
+	if err != nil {
+		return err
+	}
+	return s.recordRevision(superseded, model.PermitRevisionActionStatusChange, &before, userID)
+}
+
+// RejectPermit rejects the waiting approval step and returns the permit to its
+// requester as a draft. A rejected verification also closes the manager step,
+// like a rejected first sequence does for tasks.
+func (s *permitService) RejectPermit(id int64, approvalID int64, req *model.ApprovalRequest, userID int64, domainID *int64) (*model.PermitResponse, error) {
+	permit, round, approval, err := s.findDecidableApproval(id, approvalID, userID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	before := toSnapshot(permit)
+
+	now := time.Now()
+	rejectStatusID := int64(helper.ApprovalStatusReject)
+	rejected := []model.PermitApproval{*approval}
+	if approval.Sequence == model.PermitApprovalSequenceVerify {
+		rejected = round
+	}
+	for i := range rejected {
+		rejected[i].ApprovedBy = &userID
+		rejected[i].ApprovalStatusID = &rejectStatusID
+		rejected[i].ApprovalDate = &now
+		rejected[i].Note = req.Note
+	}
+
+	permit.Status = model.PermitStatusDraft
+	permit.ApprovalStatusID = &rejectStatusID
+	decided, err := s.repo.DecideApproval(permit, []string{"status", "approval_status_id"}, rejected)
+	if err != nil {
+		return nil, err
+	}
+	if !decided {
+		return nil, ErrApprovalAlreadyDecided
+	}
+
+	updated, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.recordRevision(updated, model.PermitRevisionActionStatusChange, &before, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("Permit %s (%s) has been rejected and returned to draft.", updated.PermitNo, updated.Name)
+	if req.Note != nil && *req.Note != "" {
+		message += " Note: " + *req.Note
+	}
+	s.notifyRequester(updated, "approval_rejected", "Permit Rejected", message)
+
+	return s.toResponse(updated), nil
+}
+
+// GetPendingApprovals returns the pending permits whose waiting step the user
+// may decide. A nil domainID is a super admin, who sees every pending permit.
+func (s *permitService) GetPendingApprovals(userID int64, domainID *int64) ([]model.PermitResponse, error) {
+	permits, err := s.repo.FindPendingApproval(domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := []model.PermitResponse{}
+	for i := range permits {
+		step := waitingApproval(permits[i].Approvals)
+		if step == nil {
+			continue
+		}
+		if domainID != nil {
+			allowed, err := s.canDecide(&permits[i], step.Sequence, userID)
+			if err != nil {
+				return nil, err
+			}
+			if !allowed {
+				continue
+			}
+		}
+		responses = append(responses, *s.toResponse(&permits[i]))
+	}
+
+	return responses, nil
+}
+
+// startApproval opens a new approval round for a stored permit and lets the
+// verifiers know
+func (s *permitService) startApproval(permit *model.Permit, userID int64) error {
+	approvals := newApprovalRound(permit, userID)
+	if err := s.repo.StartApproval(permit, approvals); err != nil {
+		return fmt.Errorf("failed to create permit approvals: %v", err)
+	}
+
+	s.notifyVerifiers(permit)
+	return nil
+}
+
+// newApprovalRound moves the permit to pending and returns the steps of its new
+// approval round. A permit that is not stored yet gets the steps as its approvals,
+// so they are created in the same transaction as the permit.
+func newApprovalRound(permit *model.Permit, userID int64) []model.PermitApproval {
+	waitingStatusID := int64(helper.ApprovalStatusWaiting)
+	permit.Status = model.PermitStatusPending
+	permit.RequestedBy = &userID
+	permit.ApprovalStatusID = &waitingStatusID
+
+	approvals := []model.PermitApproval{
+		{
+			PermitID:         permit.ID,
+			Sequence:         model.PermitApprovalSequenceVerify,
+			ApprovalStatusID: &waitingStatusID,
+			Status:           true,
+		},
+		{
+			PermitID:         permit.ID,
+			Sequence:         model.PermitApprovalSequenceApprove,
+			ApprovalStatusID: &waitingStatusID,
+			Status:           true,
+		},
+	}
+	if permit.ID == 0 {
+		permit.Approvals = approvals
+	}
+
+	return approvals
+}
+
+// notifyVerifiers lets the verifiers know a permit is waiting for them
+func (s *permitService) notifyVerifiers(permit *model.Permit) {
+	// The division is only preloaded on permits read back from the repository
+	if permit.Division == nil && permit.DivisionID != nil {
+		if division, err := s.divisionRepo.FindByID(*permit.DivisionID); err == nil {
+			permit.Division = division
+		}
+	}
+	s.notifyApprovers(permit, model.PermitApprovalSequenceVerify)
+}
+
+// findDecidableApproval loads the permit with the steps of its current submission
+// and checks that the requested step is the one waiting for a decision and that
+// the user may decide it
+func (s *permitService) findDecidableApproval(id int64, approvalID int64, userID int64, domainID *int64) (*model.Permit, []model.PermitApproval, *model.PermitApproval, error) {
+	permit, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	approvals, err := s.repo.FindApprovals(id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var round []model.PermitApproval
+	var approval *model.PermitApproval
+	for _, step := range approvals {
+		if !step.Status {
+			continue
+		}
+		// Clear relations to avoid GORM confusion with preloaded data
+		step.Approver = nil
+		step.ApprovalStatus = nil
+		round = append(round, step)
+	}
+	for i := range round {
+		if round[i].ID == approvalID {
+			approval = &round[i]
+		}
+	}
+	if approval == nil {
+		return nil, nil, nil, ErrApprovalNotFound
+	}
+
+	if permit.Status != model.PermitStatusPending {
+		return nil, nil, nil, fmt.Errorf("%w: permit is %s, not waiting for approval", ErrInvalidApproval, permit.Status)
+	}
+	waiting := waitingApproval(round)
+	if waiting == nil || waiting.ID != approval.ID {
+		if approval.Sequence == model.PermitApprovalSequenceApprove {
+			return nil, nil, nil, fmt.Errorf("%w: the permit has to be verified by the division head first", ErrInvalidApproval)
+		}
+		return nil, nil, nil, fmt.Errorf("%w: approval step has already been decided", ErrInvalidApproval)
+	}
+
+	if domainID != nil {
+		allowed, err := s.canDecide(permit, approval.Sequence, userID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if !allowed {
+			return nil, nil, nil, ErrNotApprover
+		}
+	}
+
+	return permit, round, approval, nil
+}
+
+// waitingApproval returns the first step of the round that still waits for a decision
+func waitingApproval(round []model.PermitApproval) *model.PermitApproval {
+	var waiting *model.PermitApproval
+	for i := range round {
+		if round[i].ApprovalStatusID == nil || *round[i].ApprovalStatusID != helper.ApprovalStatusWaiting {
+			continue
+		}
+		if waiting == nil || round[i].Sequence < waiting.Sequence {
+			waiting = &round[i]
+		}
+	}
+	return waiting
+}
+
+// approvers returns the users who decide a step. The division head verifies,
+// permit managers of the domain approve and also verify for divisions without a head.
+func (s *permitService) approvers(permit *model.Permit, sequence int16) ([]model.User, error) {
+	if sequence == model.PermitApprovalSequenceVerify && permit.Division != nil && permit.Division.HeadUserID != nil {
+		head, err := s.userRepo.FindByID(*permit.Division.HeadUserID)
+		if err != nil {
+			return nil, err
+		}
+		return []model.User{*head}, nil
+	}
+
+	return s.userRepo.FindByDomainAndRoleCode(permit.DomainID, helper.RoleCodePermitManager)
+}
+
+func (s *permitService) canDecide(permit *model.Permit, sequence int16, userID int64) (bool, error) {
+	approvers, err := s.approvers(permit, sequence)
+	if err != nil {
+		return false, err
+	}
+
+	return slices.ContainsFunc(approvers, func(user model.User) bool {
+		return user.ID == userID
+	}), nil
+}
+
+// notifyApprovers notifies the users who decide the step on the channels they chose
+func (s *permitService) notifyApprovers(permit *model.Permit, sequence int16) {
+	approvers, err := s.approvers(permit, sequence)
+	if err != nil {
+		return
+	}
+
+	userIDs := make([]int64, 0, len(approvers))
+	for _, user := range approvers {
+		userIDs = append(userIDs, user.ID)
+	}
+	channels, err := s.preferenceRepo.FindChannels(userIDs, model.NotificationEventPermitApproval)
+	if err != nil {
+		return
+	}
+
+	action, title := "verification", "Permit Awaiting Verification"
+	if sequence == model.PermitApprovalSequenceApprove {
+		action, title = "approval", "Permit Awaiting Approval"
+	}
+
+	dispatch := &model.NotificationDispatch{}
+	for _, user := range approvers {
+		notification := &model.Notification{
+			UserID:     user.ID,
+			EntityType: model.NotificationEntityPermit,
+			EntityID:   permit.ID,
+			Link:       model.NotificationLink(model.NotificationEntityPermit, permit.ID),
+			PermitID:   &permit.ID,
+			Type:       "approval_requested",
+			Title:      title,
+			Message:    fmt.Sprintf("Permit %s (%s) is waiting for your %s.", permit.PermitNo, permit.Name, action),
+			IsRead:     false,
+		}
+		dispatch.Add(notification, permit.DomainID, channels[user.ID])
+	}
+	s.notificationRepo.SaveDispatch(dispatch)
+}
+
+// publishWebhook queues the permit event for the domain's webhook receivers.
+// Like notifications, failing to queue it does not undo the change.
+func (s *permitService) publishWebhook(eventType string, permit *model.Permit) {
+	s.webhookRepo.Enqueue(model.NewWebhookEvent(eventType, permit.DomainID, model.NewWebhookPermitData(permit)))
+}
+
+// notifyRequester notifies the user who submitted the permit on the channels they chose
+func (s *permitService) notifyRequester(permit *model.Permit, notificationType string, title string, message string) {
+	if permit.RequestedBy == nil {
+		return
+	}
+
+	channels, err := s.preferenceRepo.FindChannels([]int64{*permit.RequestedBy}, model.NotificationEventPermitApproval)
+	if err != nil {
+		return
+	}
+
+	notification := &model.Notification{
+		UserID:     *permit.RequestedBy,
+		EntityType: model.NotificationEntityPermit,
+		EntityID:   permit.ID,
+		Link:       model.NotificationLink(model.NotificationEntityPermit, permit.ID),
+		PermitID:   &permit.ID,
+		Type:       notificationType,
+		Title:      title,
+		Message:    message,
+		IsRead:     false,
+	}
+	dispatch := &model.NotificationDispatch{}
+	dispatch.Add(notification, permit.DomainID, channels[*permit.RequestedBy])
+	s.notificationRepo.SaveDispatch(dispatch)
+}
+
+func toApprovalResponse(approval *model.PermitApproval) *model.PermitApprovalResponse {
+	resp := &model.PermitApprovalResponse{
+		ID:               approval.ID,
+		PermitID:         approval.PermitID,
+		Sequence:         approval.Sequence,
+		ApprovedBy:       approval.ApprovedBy,
+		ApprovalStatusID: approval.ApprovalStatusID,
+		ApprovalDate:     approval.ApprovalDate,
+		Note:             approval.Note,
+		Status:           approval.Status,
+		CreatedAt:        approval.CreatedAt,
+		UpdatedAt:        approval.UpdatedAt,
+	}
+
+	if approval.Approver != nil {
+		resp.Approver = &model.UserResponse{
+			ID:       approval.Approver.ID,
+			Username: approval.Approver.Username,
+			Email:    approval.Approver.Email,
+			FullName: approval.Approver.FullName,
+			IsActive: approval.Approver.IsActive,
+		}
+	}
+
+	if approval.ApprovalStatus != nil {
+		resp.ApprovalStatus = &model.ReferenceResponse{
+			ID:   approval.ApprovalStatus.ID,
+			Name: approval.ApprovalStatus.Name,
+		}
+	}
+
+	return resp
+}
+
+// UpdateExpiryStatuses moves active permits into expiring and expiring permits into
+// expired as their expiry dates come closer. It is run by the scheduler and
+// returns the number of permits that changed status.
+func (s *permitService) UpdateExpiryStatuses() (int, error) {
+	now := time.Now()
+	permits, err := s.repo.FindByStatusesExpiringBefore(
+		[]string{model.PermitStatusActive, model.PermitStatusExpiring},
+		startOfDay(now).AddDate(0, 0, expiringWindowDays+1),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for i := range permits {
+		permit := &permits[i]
+		status := statusForExpiry(permit.Status, permit.ExpiryDate, now)
+		if status == permit.Status {
+			continue
+		}
+
+		before := toSnapshot(permit)
+		permit.Status = status
+		if err := s.repo.UpdateFields(permit.ID, permit, []string{"status"}); err != nil {
+			return changed, err
+		}
+		if err := s.recordRevision(permit, model.PermitRevisionActionStatusChange, &before, 0); err != nil {
+			return changed, err
+		}
+		if status == model.PermitStatusExpired {
+			s.publishWebhook(model.WebhookEventPermitExpired, permit)
+		} else if status == model.PermitStatusExpiring {
+			s.publishWebhook(model.WebhookEventPermitExpiring, permit)
+		}
+		changed++
+	}
+
+	return changed, nil
+}
+
+// nextPermitStatus resolves the status a permit moves to when a user asks for the
+// requested status. Activating a permit lands on expiring or expired when the
+// expiry date says so.
+func nextPermitStatus(current string, requested string, expiryDate time.Time, now time.Time) (string, error) {
+	if !slices.Contains(manualPermitStatuses, requested) {
+		return "", fmt.Errorf("%w: status %s is set by the system", ErrInvalidStatusTransition, requested)
+	}
+
+	next := statusForExpiry(requested, expiryDate, now)
+	if next == current {
+		return next, nil
+	}
+	if (current == model.PermitStatusDraft || current == model.PermitStatusPending) && next != model.PermitStatusArchived {
+		return "", fmt.Errorf("%w: a %s permit becomes %s once it is approved", ErrInvalidStatusTransition, current, next)
+	}
+	if !canTransitionPermitStatus(current, next) {
+		return "", fmt.Errorf("%w: cannot change permit status from %s to %s", ErrInvalidStatusTransition, current, next)
+	}
+
+	return next, nil
+}
+
+func canTransitionPermitStatus(from string, to string) bool {
+	return slices.Contains(permitStatusTransitions[from], to)
+}
+
+// statusForExpiry derives active, expiring or expired from the expiry date.
+// Other statuses are not driven by the date and are returned unchanged.
+func statusForExpiry(status string, expiryDate time.Time, now time.Time) string {
+	if status != model.PermitStatusActive && status != model.PermitStatusExpiring && status != model.PermitStatusExpired {
+		return status
+	}
+
+	// Compare calendar days, the permit is valid through its expiry date
+	year, month, day := expiryDate.Date()
+	expiryDay := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	today := startOfDay(now)
+
+	switch {
+	case expiryDay.Before(today):
+		return model.PermitStatusExpired
+	case expiryDay.Before(today.AddDate(0, 0, expiringWindowDays+1)):
+		return model.PermitStatusExpiring
+	default:
+		return model.PermitStatusActive
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// recordRevision stores the permit's current state together with the fields that
+// changed since before. before is nil for actions without a previous state to compare.
+func (s *permitService) recordRevision(permit *model.Permit, action string, before *model.PermitSnapshot, userID int64) error {
+	after := toSnapshot(permit)
+
+	snapshot, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+
+	changes := []model.PermitFieldChange{}
+	if before != nil {
+		changes = diffSnapshots(before, &after)
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	revision := &model.PermitRevision{
+		PermitID: permit.ID,
+		Action:   action,
+		Snapshot: snapshot,
+		Changes:  changesJSON,
+	}
+	if userID > 0 {
+		revision.ChangedBy = &userID
+	}
+
+	if err := s.revisionRepo.Create(revision); err != nil {
+		return fmt.Errorf("failed to record permit revision: %w", err)
+	}
+
+	return nil
+}
+
+func toSnapshot(permit *model.Permit) model.PermitSnapshot {
+	snapshot := model.PermitSnapshot{
+		ID:                     permit.ID,
+		DomainID:               permit.DomainID,
+		DivisionID:             permit.DivisionID,
+		PermitTypeID:           permit.PermitTypeID,
+		Name:                   permit.Name,
+		ApplicationType:        permit.ApplicationType,
+		PermitNo:               permit.PermitNo,
+		EffectiveDate:          permit.EffectiveDate,
+		ExpiryDate:             permit.ExpiryDate,
+		EffectiveTerm:          permit.EffectiveTerm,
+		ResponsiblePersonID:    permit.ResponsiblePersonID,
+		ResponsibleDocPersonID: permit.ResponsibleDocPersonID,
+		DocName:                permit.DocName,
+		DocNumber:              permit.DocNumber,
+		Status:                 permit.Status,
+		PreviousPermitID:       permit.PreviousPermitID,
+		SupersededAt:           permit.SupersededAt,
+		CreatedAt:              permit.CreatedAt,
+		UpdatedAt:              permit.UpdatedAt,
+		Documents:              make([]model.PermitSnapshotDocument, 0, len(permit.Documents)),
+	}
+
+	for _, document := range permit.Documents {
+		snapshot.Documents = append(snapshot.Documents, model.PermitSnapshotDocument{
+			ID:             document.ID,
+			DocumentTypeID: document.DocumentTypeID,
+			FileName:       document.FileName,
+			FileSize:       document.FileSize,
+			FileType:       document.FileType,
+		})
+	}
+
+	return snapshot
+}
+
+// diffSnapshots compares two snapshots field by field using their JSON values
+func diffSnapshots(before, after *model.PermitSnapshot) []model.PermitFieldChange {
+	changes := []model.PermitFieldChange{}
+
+	beforeValue := reflect.ValueOf(*before)
+	afterValue := reflect.ValueOf(*after)
+	snapshotType := beforeValue.Type()
+
+	for i := 0; i < snapshotType.NumField(); i++ {
+		field := strings.Split(snapshotType.Field(i).Tag.Get("json"), ",")[0]
+		if field == "updated_at" {
+			continue
+		}
+
+		oldJSON, _ := json.Marshal(beforeValue.Field(i).Interface())
+		newJSON, _ := json.Marshal(afterValue.Field(i).Interface())
+		if string(oldJSON) == string(newJSON) {
+			continue
+		}
+
+		changes = append(changes, model.PermitFieldChange{
+			Field:    field,
+			OldValue: json.RawMessage(oldJSON),
+			NewValue: json.RawMessage(newJSON),
+		})
+	}
+
+	return changes
+}
+
+func (s *permitService) toRevisionResponse(revision *model.PermitRevision) (*model.PermitRevisionResponse, error) {
+	resp := &model.PermitRevisionResponse{
+		ID:        revision.ID,
+		PermitID:  revision.PermitID,
+		Revision:  revision.Revision,
+		Action:    revision.Action,
+		Changes:   []model.PermitFieldChange{},
+		ChangedBy: revision.ChangedBy,
+		CreatedAt: revision.CreatedAt,
+	}
+
+	var snapshot model.PermitSnapshot
+	if err := json.Unmarshal(revision.Snapshot, &snapshot); err != nil {
+		return nil, err
+	}
+	resp.Snapshot = &snapshot
+
+	if len(revision.Changes) > 0 {
+		if err := json.Unmarshal(revision.Changes, &resp.Changes); err != nil {
+			return nil, err
+		}
+	}
+
+	if revision.ChangedByUser != nil {
+		resp.ChangedByUser = &model.UserResponse{
+			ID:       revision.ChangedByUser.ID,
+			Username: revision.ChangedByUser.Username,
+			Email:    revision.ChangedByUser.Email,
+			FullName: revision.ChangedByUser.FullName,
+			IsActive: revision.ChangedByUser.IsActive,
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *permitService) GetPermitDocuments(id int64) ([]model.PermitDocumentResponse, error) {
+	if _, err := s.repo.FindByID(id); err != nil {
+		return nil, err
+	}
+
+	documents, err := s.repo.FindDocumentsByPermitID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]model.PermitDocumentResponse, 0, len(documents))
+	for i := range documents {
+		responses = append(responses, *toDocumentResponse(&documents[i]))
+	}
+
+	return responses, nil
+}
+
+func (s *permitService) GetPermitDocument(id int64, documentID int64) (*model.PermitDocumentResponse, error) {
+	document, err := s.repo.FindDocumentByID(id, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toDocumentResponse(document), nil
+}
+
+func (s *permitService) UploadPermitDocument(id int64, documentTypeID int64, file *multipart.FileHeader, userID int64) (*model.PermitDocumentResponse, error) {
+	permit, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	before := toSnapshot(permit)
+
+	documentType, err := s.referenceRepo.FindByID(documentTypeID)
+	if err != nil {
+		return nil, errors.New("document type not found")
+	}
+	if documentType.ReferenceCategoryID != helper.ReferenceCategoryPermitDocumentType || !documentType.IsActive {
+		return nil, errors.New("invalid document type")
+	}
+
+	document, err := s.saveDocument(id, documentTypeID, file, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.recordRevision(updated, model.PermitRevisionActionUpload, &before, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.repo.FindDocumentByID(id, document.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toDocumentResponse(created), nil
+}
+
+func (s *permitService) DeletePermitDocument(id int64, documentID int64, userID int64) error {
+	permit, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	before := toSnapshot(permit)
+
+	document, err := s.repo.FindDocumentByID(id, documentID)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.DeleteDocument(document.ID)
+	if err != nil {
+		return err
+	}
+	helper.DeleteFile(document.FilePath)
+
+	updated, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	return s.recordRevision(updated, model.PermitRevisionActionRemoveDocument, &before, userID)
+}
+
+// saveDocument stores the uploaded file and its permit_documents row
+func (s *permitService) saveDocument(permitID int64, documentTypeID int64, file *multipart.FileHeader, userID int64) (*model.PermitDocument, error) {
+	filePath, err := helper.SaveFile(file, "permits")
+	if err != nil {
+		return nil, err
+	}
+
+	fileSize := file.Size
+	fileType := helper.GetMimeType(file.Filename)
+
+	document := &model.PermitDocument{
+		PermitID:       permitID,
+		DocumentTypeID: documentTypeID,
+		FileName:       file.Filename,
+		FilePath:       filePath,
+		FileSize:       &fileSize,
+		FileType:       &fileType,
+	}
+	if userID > 0 {
+		document.UploadedBy = &userID
+	}
+
+	err = s.repo.CreateDocument(document)
+	if err != nil {
+		// If database insert fails, delete the uploaded file
+		helper.DeleteFile(filePath)
+		return nil, err
+	}
+
+	return document, nil
+}
+
+func deleteDocumentFiles(documents []model.PermitDocument) {
+	for _, document := range documents {
+		helper.DeleteFile(document.FilePath)
+	}
+}
+
+func toDocumentResponse(document *model.PermitDocument) *model.PermitDocumentResponse {
+	resp := &model.PermitDocumentResponse{
+		ID:             document.ID,
+		PermitID:       document.PermitID,
+		DocumentTypeID: document.DocumentTypeID,
+		FileName:       document.FileName,
+		FilePath:       document.FilePath,
+		FileSize:       document.FileSize,
+		FileType:       document.FileType,
+		UploadedBy:     document.UploadedBy,
+		CreatedAt:      document.CreatedAt,
+		UpdatedAt:      document.UpdatedAt,
+	}
+
+	if document.DocumentType != nil {
+		resp.DocumentType = &model.ReferenceResponse{
+			ID:                  document.DocumentType.ID,
+			ReferenceCategoryID: document.DocumentType.ReferenceCategoryID,
+			Name:                document.DocumentType.Name,
+			IsActive:            document.DocumentType.IsActive,
+			CreatedAt:           document.DocumentType.CreatedAt,
+			UpdatedAt:           document.DocumentType.UpdatedAt,
+		}
+	}
+
+	return resp
+}
+
+// maxImportRows caps the number of permits accepted in one import
+const maxImportRows = 5000
+
+// permitImportColumns maps the accepted sheet headers to import fields. Headers are
+// matched case-insensitively with spaces and dashes read as underscores.
+var permitImportColumns = map[string]string{
+	"permit_no":                  "permit_no",
+	"permit_number":              "permit_no",
+	"name":                       "name",
+	"permit_name":                "name",
+	"division":                   "division",
+	"division_code":              "division",
+	"division_name":              "division",
+	"permit_type":                "permit_type",
+	"permit_type_code":           "permit_type",
+	"permit_type_name":           "permit_type",
+	"application_type":           "application_type",
+	"effective_date":             "effective_date",
+	"expiry_date":                "expiry_date",
+	"effective_term":             "effective_term",
+	"responsible_person":         "responsible_person",
+	"responsible_person_nip":     "responsible_person",
+	"responsible_doc_person":     "responsible_doc_person",
+	"responsible_doc_person_nip": "responsible_doc_person",
+	"doc_name":                   "doc_name",
+	"doc_number":                 "doc_number",
+	"status":                     "status",
+}
+
+// requiredImportColumns leaves out expiry_date, which can follow from the permit type's validity period
+var requiredImportColumns = []string{"permit_no", "name", "permit_type", "effective_date"}
+
+// permitImportLookups caches the lookups of one import since rows tend to repeat
+// the same divisions, permit types and people
+type permitImportLookups struct {
+	divisions   map[string]*model.Division
+	permitTypes map[string]*model.PermitType
+	users       map[string]*model.User
+}
+
+// ImportPermits validates every row of a CSV or XLSX sheet and, in commit mode,
+// creates the permits of the valid rows in one transaction and submits them for
+// approval. Invalid rows are reported and skipped.
+func (s *permitService) ImportPermits(domainID int64, file *multipart.FileHeader, mode string, userID int64) (*model.PermitImportResponse, error) {
+	if mode == "" {
+		mode = model.PermitImportModeDryRun
+	}
+
+	rows, err := helper.ReadSpreadsheet(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPermit, err)
+	}
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("%w: file has no permit rows", ErrInvalidPermit)
+	}
+	if len(rows)-1 > maxImportRows {
+		return nil, fmt.Errorf("%w: file has %d rows, at most %d permits can be imported at once", ErrInvalidPermit, len(rows)-1, maxImportRows)
+	}
+
+	columns, err := mapImportColumns(rows[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPermit, err)
+	}
+
+	response := &model.PermitImportResponse{
+		Mode: mode,
+		Rows: []model.PermitImportRowResult{},
+	}
+	lookups := &permitImportLookups{
+		divisions:   make(map[string]*model.Division),
+		permitTypes: make(map[string]*model.PermitType),
+		users:       make(map[string]*model.User),
+	}
+	seenPermitNos := make(map[string]int)
+
+	var permits []*model.Permit
+	var resultIndexes []int
+	for i, row := range rows[1:] {
+		if helper.IsEmptyRow(row) {
+			continue
+		}
+
+		values := make(map[string]string)
+		for index, field := range columns {
+			if index < len(row) && row[index] != "" {
+				values[field] = row[index]
+			}
+		}
+
+		result := model.PermitImportRowResult{
+			Row:      i + 2,
+			PermitNo: values["permit_no"],
+			Name:     values["name"],
+			Errors:   []string{},
+		}
+
+		permit, rowErrors, err := s.buildImportPermit(domainID, values, lookups)
+		if err != nil {
+			return nil, err
+		}
+		result.Errors = append(result.Errors, rowErrors...)
+
+		if result.PermitNo != "" {
+			if firstRow, seen := seenPermitNos[result.PermitNo]; seen {
+				result.Errors = append(result.Errors, fmt.Sprintf("permit_no is repeated, first used on row %d", firstRow))
+			} else {
+				seenPermitNos[result.PermitNo] = result.Row
+
+				existing, err := s.repo.FindByPermitNoAndDomainID(result.PermitNo, domainID)
+				if err != nil {
+					return nil, err
+				}
+				if existing != nil {
+					result.Errors = append(result.Errors, "permit number already exists in this domain")
+				}
+			}
+		}
+
+		result.Valid = len(result.Errors) == 0
+		response.TotalRows++
+		if result.Valid {
+			response.ValidRows++
+			permits = append(permits, permit)
+			resultIndexes = append(resultIndexes, len(response.Rows))
+		} else {
+			response.InvalidRows++
+		}
+		response.Rows = append(response.Rows, result)
+	}
+
+	if mode != model.PermitImportModeCommit || len(permits) == 0 {
+		return response, nil
+	}
+
+	for _, permit := range permits {
+		if permit.Status == model.PermitStatusPending {
+			newApprovalRound(permit, userID)
+		}
+	}
+
+	err = s.repo.CreateBatch(permits)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, permit := range permits {
+		permitID := permit.ID
+		response.Rows[resultIndexes[i]].PermitID = &permitID
+		response.Imported++
+
+		if permit.Status == model.PermitStatusPending {
+			s.notifyVerifiers(permit)
+		}
+
+		err = s.recordRevision(permit, model.PermitRevisionActionCreate, nil, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return response, nil
+}
+
+// mapImportColumns returns the import field of each header cell, keyed by column index
+func mapImportColumns(header []string) (map[int]string, error) {
+	columns := make(map[int]string)
+	found := make(map[string]bool)
+
+	for index, cell := range header {
+		name := strings.ToLower(strings.TrimSpace(cell))
+		name = strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+
+		field, ok := permitImportColumns[name]
+		if !ok {
+			continue
+		}
+		if found[field] {
+			return nil, fmt.Errorf("column %q maps to %s, which is already given by another column", cell, field)
+		}
+		columns[index] = field
+		found[field] = true
+	}
+
+	var missing []string
+	for _, field := range requiredImportColumns {
+		if !found[field] {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required columns: %s", strings.Join(missing, ", "))
+	}
+
+	return columns, nil
+}
+
+// buildImportPermit turns one sheet row into a permit. Problems with the row are
+// returned as row errors, the error result is reserved for lookup failures.
+func (s *permitService) buildImportPermit(domainID int64, values map[string]string, lookups *permitImportLookups) (*model.Permit, []string, error) {
+	rowErrors := []string{}
+
+	req := model.PermitRequest{
+		DomainID:        domainID,
+		Name:            values["name"],
+		ApplicationType: values["application_type"],
+		PermitNo:        values["permit_no"],
+		EffectiveTerm:   optionalString(values["effective_term"]),
+		DocName:         optionalString(values["doc_name"]),
+		DocNumber:       optionalString(values["doc_number"]),
+	}
+
+	// Like created permits, imported permits go through approval unless marked as draft
+	status := strings.ToLower(values["status"])
+	if status != "" && status != model.PermitStatusPending && status != model.PermitStatusDraft {
+		rowErrors = append(rowErrors, "status must be one of: draft, pending")
+	}
+
+	var permitType *model.PermitType
+	if value := values["permit_type"]; value != "" {
+		found, err := s.lookupImportPermitType(value, domainID, lookups)
+		if err != nil {
+			return nil, nil, err
+		}
+		if found == nil {
+			rowErrors = append(rowErrors, fmt.Sprintf("permit type %q not found", value))
+		} else {
+			permitType = found
+			req.PermitTypeID = permitType.ID
+			req.DivisionID = permitType.DivisionID
+		}
+	}
+
+	if value := values["division"]; value != "" {
+		division, err := s.lookupImportDivision(value, domainID, lookups)
+		if err != nil {
+			return nil, nil, err
+		}
+		if division == nil {
+			rowErrors = append(rowErrors, fmt.Sprintf("division %q not found in this domain", value))
+		} else {
+			req.DivisionID = &division.ID
+		}
+	}
+
+	for _, field := range []string{"responsible_person", "responsible_doc_person"} {
+		value := values[field]
+		if value == "" {
+			continue
+		}
+		user, err := s.lookupImportUser(domainID, value, lookups)
+		if err != nil {
+			return nil, nil, err
+		}
+		if user == nil {
+			rowErrors = append(rowErrors, fmt.Sprintf("%s %q not found in this domain", strings.ReplaceAll(field, "_", " "), value))
+			continue
+		}
+		if field == "responsible_person" {
+			req.ResponsiblePersonID = &user.ID
+		} else {
+			req.ResponsibleDocPersonID = &user.ID
+		}
+	}
+
+	// Dates are checked here, the validator does not treat an empty date as missing
+	for _, field := range []string{"effective_date", "expiry_date"} {
+		value := values[field]
+		if value == "" {
+			// A missing expiry date may follow from the permit type below
+			if field != "expiry_date" {
+				rowErrors = append(rowErrors, fmt.Sprintf("%s is required", field))
+			}
+			continue
+		}
+		date, err := helper.ParseSpreadsheetDate(value)
+		if err != nil {
+			rowErrors = append(rowErrors, fmt.Sprintf("%s: %v", field, err))
+			continue
+		}
+		if field == "effective_date" {
+			req.EffectiveDate = helper.Date{Time: date}
+		} else {
+			req.ExpiryDate = helper.Date{Time: date}
+		}
+	}
+
+	// Like CreatePermit, the application type and expiry date default from the permit type
+	if permitType != nil && !req.EffectiveDate.IsZero() {
+		if _, err := applyPermitTypeDefaults(&req, permitType); err != nil {
+			rowErrors = append(rowErrors, err.Error())
+		}
+	} else if values["expiry_date"] == "" {
+		rowErrors = append(rowErrors, "expiry_date is required")
+	}
+
+	rowErrors = append(rowErrors, validateImportRequest(&req, values)...)
+
+	if !req.EffectiveDate.IsZero() && !req.ExpiryDate.IsZero() && !req.ExpiryDate.After(req.EffectiveDate.Time) {
+		rowErrors = append(rowErrors, "expiry date must be after effective date")
+	}
+
+	if len(rowErrors) > 0 {
+		return nil, rowErrors, nil
+	}
+
+	if status != model.PermitStatusDraft {
+		status = model.PermitStatusPending
+	}
+
+	permit := &model.Permit{
+		DomainID:               req.DomainID,
+		DivisionID:             req.DivisionID,
+		PermitTypeID:           req.PermitTypeID,
+		Name:                   req.Name,
+		ApplicationType:        req.ApplicationType,
+		PermitNo:               req.PermitNo,
+		EffectiveDate:          req.EffectiveDate.Time,
+		ExpiryDate:             req.ExpiryDate.Time,
+		EffectiveTerm:          req.EffectiveTerm,
+		ResponsiblePersonID:    req.ResponsiblePersonID,
+		ResponsibleDocPersonID: req.ResponsibleDocPersonID,
+		DocName:                req.DocName,
+		DocNumber:              req.DocNumber,
+		Status:                 status,
+	}
+
+	return permit, nil, nil
+}
+
+// validateImportRequest runs the PermitRequest validation rules and reports them
+// by column name. Cells that failed to resolve are already reported.
+func validateImportRequest(req *model.PermitRequest, values map[string]string) []string {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return strings.Split(field.Tag.Get("json"), ",")[0]
+	})
+
+	err := validate.Struct(req)
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil
+	}
+
+	// Columns that map to a resolved ID
+	cellOf := map[string]string{"permit_type_id": "permit_type"}
+
+	rowErrors := []string{}
+	for _, fieldError := range validationErrors {
+		field := fieldError.Field()
+		if cell, ok := cellOf[field]; ok {
+			if values[cell] != "" {
+				continue
+			}
+			field = cell
+		}
+
+		switch fieldError.Tag() {
+		case "required":
+			rowErrors = append(rowErrors, fmt.Sprintf("%s is required", field))
+		case "oneof":
+			rowErrors = append(rowErrors, fmt.Sprintf("%s must be one of: %s", field, strings.ReplaceAll(fieldError.Param(), " ", ", ")))
+		default:
+			rowErrors = append(rowErrors, fmt.Sprintf("%s is invalid", field))
+		}
+	}
+
+	return rowErrors
+}
+
+func (s *permitService) lookupImportDivision(value string, domainID int64, lookups *permitImportLookups) (*model.Division, error) {
+	key := strings.ToLower(value)
+	if division, cached := lookups.divisions[key]; cached {
+		return division, nil
+	}
+
+	division, err := s.divisionRepo.FindByCodeOrNameAndDomainID(value, domainID)
+	if err != nil {
+		return nil, err
+	}
+	lookups.divisions[key] = division
+	return division, nil
+}
+
+func (s *permitService) lookupImportPermitType(value string, domainID int64, lookups *permitImportLookups) (*model.PermitType, error) {
+	key := strings.ToLower(value)
+	if permitType, cached := lookups.permitTypes[key]; cached {
+		return permitType, nil
+	}
+
+	permitType, err := s.permitTypeRepo.FindByCodeOrNameAndDomainID(value, domainID)
+	if err != nil {
+		return nil, err
+	}
+	lookups.permitTypes[key] = permitType
+	return permitType, nil
+}
+
+// lookupImportUser matches the NIP first, then the username or email. Users of
+// other domains are not matched, permits are only assigned within the domain.
+func (s *permitService) lookupImportUser(domainID int64, value string, lookups *permitImportLookups) (*model.User, error) {
+	if user, cached := lookups.users[value]; cached {
+		return user, nil
+	}
+
+	user, err := s.userRepo.FindByNip(value)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		user, err = s.userRepo.FindByUsernameOrEmail(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if user != nil {
+		domainRoles, err := s.userRepo.GetUserDomainRoles(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		member := false
+		for _, domainRole := range domainRoles {
+			if domainRole.DomainID == domainID {
+				member = true
+				break
+			}
+		}
+		if !member {
+			user = nil
+		}
+	}
+	lookups.users[value] = user
+	return user, nil
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -1,27 +1,42 @@
 package permitTypeService
 
 import (
+	"fmt"
+	"permit-app/helper"
 	"permit-app/model"
+	"permit-app/repo/divisionRepository"
 	"permit-app/repo/permitTypeRepository"
+	"strings"
 )
 
+// Permit types belong to a domain through their division. Permit types without a
+// division are shared by every domain, so only users that are not limited to a
+// domain (a nil domainID) may change them.
 type PermitTypeService interface {
-	CreatePermitType(req *model.PermitTypeRequest) (*model.PermitTypeResponse, error)
-	GetPermitTypeByID(id int64) (*model.PermitTypeResponse, error)
+	CreatePermitType(req *model.PermitTypeRequest, domainID *int64) (*model.PermitTypeResponse, error)
+	GetPermitTypeByID(id int64, domainID *int64) (*model.PermitTypeResponse, error)
 	GetAllPermitTypes(filter *model.PermitTypeListRequest) ([]model.PermitTypeResponse, int64, error)
-	UpdatePermitType(id int64, req *model.PermitTypeUpdateRequest) (*model.PermitTypeResponse, error)
-	DeletePermitType(id int64) error
+	UpdatePermitType(id int64, req *model.PermitTypeUpdateRequest, domainID *int64) (*model.PermitTypeResponse, error)
+	DeletePermitType(id int64, domainID *int64) error
 }
 
 type permitTypeService struct {
-	repo permitTypeRepository.PermitTypeRepository
+	repo         permitTypeRepository.PermitTypeRepository
+	divisionRepo divisionRepository.DivisionRepository
 }
 
-func NewPermitTypeService(repo permitTypeRepository.PermitTypeRepository) PermitTypeService {
-	return &permitTypeService{repo: repo}
+func NewPermitTypeService(repo permitTypeRepository.PermitTypeRepository, divisionRepo divisionRepository.DivisionRepository) PermitTypeService {
+	return &permitTypeService{repo: repo, divisionRepo: divisionRepo}
 }
 
-func (s *permitTypeService) CreatePermitType(req *model.PermitTypeRequest) (*model.PermitTypeResponse, error) {
+func (s *permitTypeService) CreatePermitType(req *model.PermitTypeRequest, domainID *int64) (*model.PermitTypeResponse, error) {
+	if err := s.checkDivision(req.DivisionID, domainID); err != nil {
+		return nil, err
+	}
+	if err := checkValidityPeriod(req.DefaultValidityPeriod); err != nil {
+		return nil, err
+	}
+
 	permitType := &model.PermitType{
 		DivisionID:             req.DivisionID,
 		Name:                   req.Name,
@@ -44,8 +59,8 @@ func (s *permitTypeService) CreatePermitType(req *model.PermitTypeRequest) (*mod
 	return s.toResponse(created), nil
 }
 
-func (s *permitTypeService) GetPermitTypeByID(id int64) (*model.PermitTypeResponse, error) {
-	permitType, err := s.repo.FindByID(id)
+func (s *permitTypeService) GetPermitTypeByID(id int64, domainID *int64) (*model.PermitTypeResponse, error) {
+	permitType, err := s.repo.FindByIDInDomain(id, domainID)
 	if err != nil {
 		return nil, err
 	}
@@ -67,13 +82,19 @@ func (s *permitTypeService) GetAllPermitTypes(filter *model.PermitTypeListReques
 	return responses, total, nil
 }
 
-func (s *permitTypeService) UpdatePermitType(id int64, req *model.PermitTypeUpdateRequest) (*model.PermitTypeResponse, error) {
-	permitType, err := s.repo.FindByID(id)
+func (s *permitTypeService) UpdatePermitType(id int64, req *model.PermitTypeUpdateRequest, domainID *int64) (*model.PermitTypeResponse, error) {
+	permitType, err := s.repo.FindByIDInDomain(id, domainID)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkDivision(permitType.DivisionID, domainID); err != nil {
+		return nil, err
+	}
 
 	if req.DivisionID != nil {
+		if err := s.checkDivision(req.DivisionID, domainID); err != nil {
+			return nil, err
+		}
 		permitType.DivisionID = req.DivisionID
 	}
 	if req.Name != "" {
@@ -86,6 +107,9 @@ func (s *permitTypeService) UpdatePermitType(id int64, req *model.PermitTypeUpda
 		permitType.DefaultApplicationType = req.DefaultApplicationType
 	}
 	if req.DefaultValidityPeriod != nil {
+		if err := checkValidityPeriod(req.DefaultValidityPeriod); err != nil {
+			return nil, err
+		}
 		permitType.DefaultValidityPeriod = req.DefaultValidityPeriod
 	}
 	if req.Notes != nil {
@@ -105,15 +129,43 @@ func (s *permitTypeService) UpdatePermitType(id int64, req *model.PermitTypeUpda
 	return s.toResponse(updated), nil
 }
 
-func (s *permitTypeService) DeletePermitType(id int64) error {
-	_, err := s.repo.FindByID(id)
+func (s *permitTypeService) DeletePermitType(id int64, domainID *int64) error {
+	permitType, err := s.repo.FindByIDInDomain(id, domainID)
 	if err != nil {
 		return err
 	}
+	if err := s.checkDivision(permitType.DivisionID, domainID); err != nil {
+		return err
+	}
 
 	return s.repo.Delete(id)
 }
 
+// checkDivision makes sure a permit type's division belongs to the domain. Shared
+// permit types without a division can only be managed without a domain limit.
+func (s *permitTypeService) checkDivision(divisionID *int64, domainID *int64) error {
+	if domainID == nil {
+		return nil
+	}
+	if divisionID == nil {
+		return fmt.Errorf("%w: shared permit types can only be changed by a super admin", helper.ErrOutsideDomain)
+	}
+	if _, err := s.divisionRepo.FindByIDInDomain(*divisionID, domainID); err != nil {
+		return fmt.Errorf("%w: division %d is not part of domain %d", helper.ErrOutsideDomain, *divisionID, *domainID)
+	}
+	return nil
+}
+
+// checkValidityPeriod makes sure a default validity period can be used to derive
+// expiry dates. An empty value clears the default.
+func checkValidityPeriod(value *string) error {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return nil
+	}
+	_, err := helper.ParseValidityPeriod(*value)
+	return err
+}
+
 func (s *permitTypeService) toResponse(permitType *model.PermitType) *model.PermitTypeResponse {
 	response := &model.PermitTypeResponse{
 		ID:                     permitType.ID,
@@ -125,6 +177,25 @@ func (s *permitTypeService) toResponse(permitType *model.PermitType) *model.Perm
 		Notes:                  permitType.Notes,
 		CreatedAt:              permitType.CreatedAt,
 		UpdatedAt:              permitType.UpdatedAt,
+		ReminderSchedules:      make([]model.ReminderScheduleResponse, len(permitType.ReminderSchedules)),
+	}
+
+	if permitType.DefaultValidityPeriod != nil {
+		if period, err := helper.ParseValidityPeriod(*permitType.DefaultValidityPeriod); err == nil {
+			response.DefaultValidity = &period
+		}
+	}
+
+	for i, schedule := range permitType.ReminderSchedules {
+		response.ReminderSchedules[i] = model.ReminderScheduleResponse{
+			ID:           schedule.ID,
+			PermitTypeID: schedule.PermitTypeID,
+			DomainID:     schedule.DomainID,
+			DaysBefore:   schedule.DaysBefore,
+			Severity:     schedule.Severity,
+			CreatedAt:    schedule.CreatedAt,
+			UpdatedAt:    schedule.UpdatedAt,
+		}
 	}
 
 	if permitType.Division != nil && permitType.Division.ID > 0 {
@@ -0,0 +1,142 @@
+package permitVerificationService
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"permit-app/model"
+	"permit-app/repo/permitRepository"
+	"permit-app/repo/permitVerificationTokenRepository"
+	"slices"
+	"time"
+)
+
+var (
+	// ErrPermitNotFound is returned when the permit does not exist or is outside the caller's domain
+	ErrPermitNotFound = errors.New("permit not found")
+	// ErrVerificationNotFound is returned for unknown or revoked verification tokens
+	ErrVerificationNotFound = errors.New("permit verification not found")
+)
+
+// inForcePermitStatuses are the statuses in which a posted permit is genuine and
+// current, as long as its expiry date has not passed
+var inForcePermitStatuses = []string{
+	model.PermitStatusActive,
+	model.PermitStatusExpiring,
+	model.PermitStatusInRenewal,
+}
+
+type PermitVerificationService interface {
+	GetToken(permitID int64, domainID *int64, userID int64) (*model.PermitVerificationTokenResponse, error)
+	RotateToken(permitID int64, domainID *int64, userID int64) (*model.PermitVerificationTokenResponse, error)
+	RevokeToken(permitID int64, domainID *int64) error
+	Verify(token string) (*model.PermitVerificationResponse, error)
+}
+
+type permitVerificationService struct {
+	tokenRepo  permitVerificationTokenRepository.PermitVerificationTokenRepository
+	permitRepo permitRepository.PermitRepository
+}
+
+func NewPermitVerificationService(
+	tokenRepo permitVerificationTokenRepository.PermitVerificationTokenRepository,
+	permitRepo permitRepository.PermitRepository,
+) PermitVerificationService {
+	return &permitVerificationService{
+		tokenRepo:  tokenRepo,
+		permitRepo: permitRepo,
+	}
+}
+
+// GetToken returns the permit's verification token, issuing one the first time
+// the permit is asked for it. A nil domainID gives access to permits of every domain.
+func (s *permitVerificationService) GetToken(permitID int64, domainID *int64, userID int64) (*model.PermitVerificationTokenResponse, error) {
+	if _, err := s.permitRepo.FindByIDInDomain(permitID, domainID); err != nil {
+		return nil, ErrPermitNotFound
+	}
+
+	token, err := s.tokenRepo.FindActiveByPermitID(permitID)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return s.issueToken(permitID, userID)
+	}
+
+	return toTokenResponse(token), nil
+}
+
+// RotateToken revokes the permit's token and issues a new one, for example after
+// a printed QR code was lost
+func (s *permitVerificationService) RotateToken(permitID int64, domainID *int64, userID int64) (*model.PermitVerificationTokenResponse, error) {
+	if _, err := s.permitRepo.FindByIDInDomain(permitID, domainID); err != nil {
+		return nil, ErrPermitNotFound
+	}
+
+	return s.issueToken(permitID, userID)
+}
+
+// RevokeToken makes the permit's posted QR codes fail verification. A new token
+// is only issued when the permit is asked for one again.
+func (s *permitVerificationService) RevokeToken(permitID int64, domainID *int64) error {
+	if _, err := s.permitRepo.FindByIDInDomain(permitID, domainID); err != nil {
+		return ErrPermitNotFound
+	}
+
+	return s.tokenRepo.RevokeByPermitID(permitID, time.Now())
+}
+
+// Verify reports the permit behind a scanned token
+func (s *permitVerificationService) Verify(token string) (*model.PermitVerificationResponse, error) {
+	verificationToken, err := s.tokenRepo.FindActiveByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if verificationToken == nil || verificationToken.Permit == nil {
+		return nil, ErrVerificationNotFound
+	}
+
+	permit := verificationToken.Permit
+	now := time.Now()
+	year, month, day := permit.ExpiryDate.Date()
+	expiryDay := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	return &model.PermitVerificationResponse{
+		Name:          permit.Name,
+		PermitNo:      permit.PermitNo,
+		EffectiveDate: permit.EffectiveDate,
+		ExpiryDate:    permit.ExpiryDate,
+		Status:        permit.Status,
+		Valid:         slices.Contains(inForcePermitStatuses, permit.Status) && !expiryDay.Before(today),
+		CheckedAt:     now,
+	}, nil
+}
+
+func (s *permitVerificationService) issueToken(permitID int64, userID int64) (*model.PermitVerificationTokenResponse, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	token := &model.PermitVerificationToken{
+		PermitID:  permitID,
+		Token:     hex.EncodeToString(raw),
+		CreatedBy: &userID,
+	}
+	if err := s.tokenRepo.Replace(token); err != nil {
+		return nil, err
+	}
+
+	return toTokenResponse(token), nil
+}
+
+func toTokenResponse(token *model.PermitVerificationToken) *model.PermitVerificationTokenResponse {
+	return &model.PermitVerificationTokenResponse{
+		PermitID:  token.PermitID,
+		Token:     token.Token,
+		CreatedBy: token.CreatedBy,
+		CreatedAt: token.CreatedAt,
+	}
+}
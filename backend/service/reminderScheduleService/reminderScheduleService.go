@@ -0,0 +1,143 @@
+package reminderScheduleService
+
+import (
+	"fmt"
+	"permit-app/helper"
+	"permit-app/model"
+	"permit-app/repo/domainRepository"
+	"permit-app/repo/permitTypeRepository"
+	"permit-app/repo/reminderScheduleRepository"
+)
+
+type ReminderScheduleService interface {
+	GetPermitTypeSchedules(permitTypeID int64, domainID *int64) ([]model.ReminderScheduleResponse, error)
+	UpdatePermitTypeSchedules(permitTypeID int64, req *model.ReminderScheduleRequest, domainID *int64) ([]model.ReminderScheduleResponse, error)
+	GetDomainSchedules(domainID int64) ([]model.ReminderScheduleResponse, error)
+	UpdateDomainSchedules(domainID int64, req *model.ReminderScheduleRequest) ([]model.ReminderScheduleResponse, error)
+}
+
+type reminderScheduleService struct {
+	repo           reminderScheduleRepository.ReminderScheduleRepository
+	permitTypeRepo permitTypeRepository.PermitTypeRepository
+	domainRepo     domainRepository.DomainRepository
+}
+
+func NewReminderScheduleService(
+	repo reminderScheduleRepository.ReminderScheduleRepository,
+	permitTypeRepo permitTypeRepository.PermitTypeRepository,
+	domainRepo domainRepository.DomainRepository,
+) ReminderScheduleService {
+	return &reminderScheduleService{
+		repo:           repo,
+		permitTypeRepo: permitTypeRepo,
+		domainRepo:     domainRepo,
+	}
+}
+
+func (s *reminderScheduleService) GetPermitTypeSchedules(permitTypeID int64, domainID *int64) ([]model.ReminderScheduleResponse, error) {
+	if _, err := s.permitTypeRepo.FindByIDInDomain(permitTypeID, domainID); err != nil {
+		return nil, err
+	}
+
+	schedules, err := s.repo.FindByPermitTypeID(permitTypeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toResponses(schedules), nil
+}
+
+// UpdatePermitTypeSchedules replaces the permit type's schedule. An empty list
+// removes it, so the permit type falls back to the domain default.
+func (s *reminderScheduleService) UpdatePermitTypeSchedules(permitTypeID int64, req *model.ReminderScheduleRequest, domainID *int64) ([]model.ReminderScheduleResponse, error) {
+	permitType, err := s.permitTypeRepo.FindByIDInDomain(permitTypeID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	// The schedule of a shared permit type applies to every domain
+	if domainID != nil && permitType.DivisionID == nil {
+		return nil, fmt.Errorf("%w: shared permit types can only be changed by a super admin", helper.ErrOutsideDomain)
+	}
+
+	schedules, err := toSchedules(req.Schedules)
+	if err != nil {
+		return nil, err
+	}
+	for i := range schedules {
+		schedules[i].PermitTypeID = &permitTypeID
+	}
+
+	if err := s.repo.ReplaceForPermitType(permitTypeID, schedules); err != nil {
+		return nil, err
+	}
+
+	return s.GetPermitTypeSchedules(permitTypeID, domainID)
+}
+
+func (s *reminderScheduleService) GetDomainSchedules(domainID int64) ([]model.ReminderScheduleResponse, error) {
+	if _, err := s.domainRepo.FindByID(domainID); err != nil {
+		return nil, err
+	}
+
+	schedules, err := s.repo.FindByDomainID(domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toResponses(schedules), nil
+}
+
+// UpdateDomainSchedules replaces the domain default schedule. An empty list
+// removes it, so permit types without a schedule use the built-in 30/7/0 days.
+func (s *reminderScheduleService) UpdateDomainSchedules(domainID int64, req *model.ReminderScheduleRequest) ([]model.ReminderScheduleResponse, error) {
+	if _, err := s.domainRepo.FindByID(domainID); err != nil {
+		return nil, err
+	}
+
+	schedules, err := toSchedules(req.Schedules)
+	if err != nil {
+		return nil, err
+	}
+	for i := range schedules {
+		schedules[i].DomainID = &domainID
+	}
+
+	if err := s.repo.ReplaceForDomain(domainID, schedules); err != nil {
+		return nil, err
+	}
+
+	return s.GetDomainSchedules(domainID)
+}
+
+func toSchedules(items []model.ReminderScheduleItem) ([]model.ReminderSchedule, error) {
+	seen := make(map[int]bool)
+	schedules := make([]model.ReminderSchedule, 0, len(items))
+	for _, item := range items {
+		if seen[item.DaysBefore] {
+			return nil, fmt.Errorf("duplicate reminder offset: %d days", item.DaysBefore)
+		}
+		seen[item.DaysBefore] = true
+
+		schedules = append(schedules, model.ReminderSchedule{
+			DaysBefore: item.DaysBefore,
+			Severity:   item.Severity,
+		})
+	}
+	return schedules, nil
+}
+
+func toResponses(schedules []model.ReminderSchedule) []model.ReminderScheduleResponse {
+	responses := make([]model.ReminderScheduleResponse, len(schedules))
+	for i, schedule := range schedules {
+		responses[i] = model.ReminderScheduleResponse{
+			ID:           schedule.ID,
+			PermitTypeID: schedule.PermitTypeID,
+			DomainID:     schedule.DomainID,
+			DaysBefore:   schedule.DaysBefore,
+			Severity:     schedule.Severity,
+			CreatedAt:    schedule.CreatedAt,
+			UpdatedAt:    schedule.UpdatedAt,
+		}
+	}
+	return responses
+}
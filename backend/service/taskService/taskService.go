@@ -6,7 +6,11 @@ import (
 	"mime/multipart"
 	"permit-app/helper"
 	"permit-app/model"
+	"permit-app/repo/notificationPreferenceRepository"
+	"permit-app/repo/notificationRepository"
 	"permit-app/repo/taskRepository"
+	"permit-app/repo/userRepository"
+	"permit-app/repo/webhookRepository"
 	"time"
 )
 
@@ -14,6 +18,7 @@ type TaskService interface {
 	Create(req *model.TaskRequest, files []*multipart.FileHeader, domainID, userID int64) (*model.Task, error)
 	GetByID(id int64, domainID int64) (*model.TaskResponse, error)
 	GetByCode(code string, domainID int64) (*model.TaskResponse, error)
+	GetFile(taskID, fileID int64, domainID int64) (*model.TaskFile, error)
 	GetAll(domainID int64, filters *model.TaskListRequest) ([]model.TaskResponse, int64, error)
 	GetAllRequests(domainID int64, filters *model.TaskListRequest) ([]model.TaskResponse, int64, error)
 	Update(id int64, req *model.TaskUpdateRequest, files []*multipart.FileHeader, deletedFileIds []int64, domainID, userID int64) (*model.Task, error)
@@ -30,11 +35,27 @@ type TaskService interface {
 }
 
 type taskService struct {
-	taskRepo taskRepository.TaskRepository
+	taskRepo         taskRepository.TaskRepository
+	userRepo         userRepository.UserRepository
+	notificationRepo notificationRepository.NotificationRepository
+	preferenceRepo   notificationPreferenceRepository.NotificationPreferenceRepository
+	webhookRepo      webhookRepository.WebhookRepository
 }
 
-func NewTaskService(taskRepo taskRepository.TaskRepository) TaskService {
-	return &taskService{taskRepo: taskRepo}
+func NewTaskService(
+	taskRepo taskRepository.TaskRepository,
+	userRepo userRepository.UserRepository,
+	notificationRepo notificationRepository.NotificationRepository,
+	preferenceRepo notificationPreferenceRepository.NotificationPreferenceRepository,
+	webhookRepo webhookRepository.WebhookRepository,
+) TaskService {
+	return &taskService{
+		taskRepo:         taskRepo,
+		userRepo:         userRepo,
+		notificationRepo: notificationRepo,
+		preferenceRepo:   preferenceRepo,
+		webhookRepo:      webhookRepo,
+	}
 }
 
 func (s *taskService) Create(req *model.TaskRequest, files []*multipart.FileHeader, domainID, userID int64) (*model.Task, error) {
@@ -105,6 +126,12 @@ func (s *taskService) Create(req *model.TaskRequest, files []*multipart.FileHead
 		}
 	}
 
+	if task.AssignedID != nil {
+		s.notifyTask(task, []int64{*task.AssignedID}, userID, "task_assigned", "Task Assigned",
+			fmt.Sprintf("Task %s (%s) has been assigned to you.", task.Code, task.Title))
+		s.publishWebhook(model.WebhookEventTaskAssigned, task, model.NewWebhookTaskData(task, userID))
+	}
+
 	return task, nil
 }
 
@@ -126,6 +153,24 @@ func (s *taskService) GetByCode(code string, domainID int64) (*model.TaskRespons
 	return s.toTaskResponse(task), nil
 }
 
+// GetFile returns a file attached to the task, the task must belong to the domain
+func (s *taskService) GetFile(taskID, fileID int64, domainID int64) (*model.TaskFile, error) {
+	task, err := s.taskRepo.GetByID(taskID, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	taskFile, err := s.taskRepo.GetTaskFileByID(fileID)
+	if err != nil {
+		return nil, err
+	}
+	if taskFile.TaskID != task.ID {
+		return nil, errors.New("task file not found")
+	}
+
+	return taskFile, nil
+}
+
 func (s *taskService) GetAll(domainID int64, filters *model.TaskListRequest) ([]model.TaskResponse, int64, error) {
 	filterMap := make(map[string]interface{})
 
@@ -219,6 +264,8 @@ func (s *taskService) Update(id int64, req *model.TaskUpdateRequest, files []*mu
 		}
 	}
 
+	previousAssignedID := task.AssignedID
+
 	// Update fields
 	task.Title = req.Title
 	task.Description = req.Description
@@ -235,6 +282,10 @@ func (s *taskService) Update(id int64, req *model.TaskUpdateRequest, files []*mu
 		return nil, err
 	}
 
+	if task.AssignedID != nil && (previousAssignedID == nil || *previousAssignedID != *task.AssignedID) {
+		s.publishWebhook(model.WebhookEventTaskAssigned, task, model.NewWebhookTaskData(task, userID))
+	}
+
 	// Delete specified files
 	if len(deletedFileIds) > 0 {
 		if err := s.deleteTaskFiles(deletedFileIds); err != nil {
@@ -257,7 +308,20 @@ func (s *taskService) Delete(id int64, domainID int64) error {
 }
 
 func (s *taskService) ChangeStatus(id int64, req *model.TaskChangeStatusRequest, domainID, userID int64) error {
-	return s.taskRepo.ChangeStatus(id, domainID, req.StatusID, userID)
+	if err := s.taskRepo.ChangeStatus(id, domainID, req.StatusID, userID); err != nil {
+		return err
+	}
+
+	if task, err := s.taskRepo.GetByID(id, domainID); err == nil {
+		status := "a new status"
+		if task.StatusTask != nil {
+			status = task.StatusTask.Name
+		}
+		s.notifyTask(task, taskParticipants(task), userID, "task_status_changed", "Task Status Changed",
+			fmt.Sprintf("Task %s (%s) moved to %s.", task.Code, task.Title, status))
+	}
+
+	return nil
 }
 
 func (s *taskService) ChangeType(id int64, req *model.TaskChangeTypeRequest, domainID, userID int64) error {
@@ -277,7 +341,18 @@ func (s *taskService) InReview(id int64, req *model.TaskInReviewRequest, files [
 	}
 
 	// Change status to In Review
-	return s.taskRepo.ChangeStatus(id, domainID, helper.TaskStatusInReview, userID)
+	if err := s.taskRepo.ChangeStatus(id, domainID, helper.TaskStatusInReview, userID); err != nil {
+		return err
+	}
+
+	if task, err := s.taskRepo.GetByID(id, domainID); err == nil {
+		s.notifyTask(task, []int64{task.CreatedBy}, userID, "task_in_review", "Task In Review",
+			fmt.Sprintf("Task %s (%s) is ready for review.", task.Code, task.Title))
+		s.notifyTask(task, s.approverIDs(task, 1), userID, "task_approval_requested", "Task Awaiting Verification",
+			fmt.Sprintf("Task %s (%s) is waiting for your verification.", task.Code, task.Title))
+	}
+
+	return nil
 }
 
 func (s *taskService) SetReason(id int64, req *model.TaskReasonRequest, domainID, userID int64) error {
@@ -297,12 +372,24 @@ func (s *taskService) SetRevision(id int64, req *model.TaskRevisionRequest, file
 	}
 
 	// Change status to Revision
-	return s.taskRepo.ChangeStatus(id, domainID, helper.TaskStatusRevision, userID)
+	if err := s.taskRepo.ChangeStatus(id, domainID, helper.TaskStatusRevision, userID); err != nil {
+		return err
+	}
+
+	if task, err := s.taskRepo.GetByID(id, domainID); err == nil && task.AssignedID != nil {
+		message := fmt.Sprintf("Task %s (%s) was sent back for revision.", task.Code, task.Title)
+		if req.Revision != nil && *req.Revision != "" {
+			message += " " + *req.Revision
+		}
+		s.notifyTask(task, []int64{*task.AssignedID}, userID, "task_revision", "Task Needs Revision", message)
+	}
+
+	return nil
 }
 
 func (s *taskService) ApproveTask(taskID, approvalTaskID int64, req *model.ApprovalRequest, domainID, userID int64) error {
 	// Verify task exists
-	_, err := s.taskRepo.GetByID(taskID, domainID)
+	task, err := s.taskRepo.GetByID(taskID, domainID)
 	if err != nil {
 		return err
 	}
@@ -355,12 +442,22 @@ func (s *taskService) ApproveTask(taskID, approvalTaskID int64, req *model.Appro
 		if err := s.taskRepo.UpdateTaskApprovalStatus(taskID, approvalStatusID, nil, nil, userID); err != nil {
 			return err
 		}
+
+		s.notifyTask(task, taskParticipants(task), userID, "task_approved", "Task Verified",
+			fmt.Sprintf("Task %s (%s) has been verified and is waiting for the manager's approval.", task.Code, task.Title))
+		s.notifyTask(task, s.approverIDs(task, 2), userID, "task_approval_requested", "Task Awaiting Approval",
+			fmt.Sprintf("Task %s (%s) is waiting for your approval.", task.Code, task.Title))
+		s.publishApproval(model.WebhookEventTaskApproved, task, currentApproval, userID)
 	} else if currentApproval.Sequence == 2 {
 		// Sequence 2 approved - task fully approved
 		approvalStatusID := int64(helper.ApprovalStatusApprove)
 		if err := s.taskRepo.UpdateTaskApprovalStatus(taskID, approvalStatusID, &userID, &now, userID); err != nil {
 			return err
 		}
+
+		s.notifyTask(task, taskParticipants(task), userID, "task_approved", "Task Approved",
+			fmt.Sprintf("Task %s (%s) has been approved.", task.Code, task.Title))
+		s.publishApproval(model.WebhookEventTaskApproved, task, currentApproval, userID)
 	}
 
 	return nil
@@ -368,7 +465,7 @@ func (s *taskService) ApproveTask(taskID, approvalTaskID int64, req *model.Appro
 
 func (s *taskService) RejectTask(taskID, approvalTaskID int64, req *model.ApprovalRequest, domainID, userID int64) error {
 	// Verify task exists
-	_, err := s.taskRepo.GetByID(taskID, domainID)
+	task, err := s.taskRepo.GetByID(taskID, domainID)
 	if err != nil {
 		return err
 	}
@@ -437,7 +534,18 @@ func (s *taskService) RejectTask(taskID, approvalTaskID int64, req *model.Approv
 
 	// Update task approval status to Reject
 	approvalStatusID := int64(helper.ApprovalStatusReject)
-	return s.taskRepo.UpdateTaskApprovalStatus(taskID, approvalStatusID, nil, nil, userID)
+	if err := s.taskRepo.UpdateTaskApprovalStatus(taskID, approvalStatusID, nil, nil, userID); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Task %s (%s) has been rejected.", task.Code, task.Title)
+	if req.Note != nil && *req.Note != "" {
+		message += " Note: " + *req.Note
+	}
+	s.notifyTask(task, taskParticipants(task), userID, "task_rejected", "Task Rejected", message)
+	s.publishApproval(model.WebhookEventTaskRejected, task, currentApproval, userID)
+
+	return nil
 }
 
 // Helper functions
@@ -649,6 +757,83 @@ func (s *taskService) toTaskResponse(task *model.Task) *model.TaskResponse {
 	return resp
 }
 
+// taskParticipants are the task's creator and assignee
+func taskParticipants(task *model.Task) []int64 {
+	userIDs := []int64{task.CreatedBy}
+	if task.AssignedID != nil {
+		userIDs = append(userIDs, *task.AssignedID)
+	}
+	return userIDs
+}
+
+// approverIDs returns the users of the task's domain who decide the approval
+// step: the ticketing PIC verifies, the ticketing manager approves
+func (s *taskService) approverIDs(task *model.Task, sequence int16) []int64 {
+	roleCode := helper.RoleCodeTicketingPIC
+	if sequence == 2 {
+		roleCode = helper.RoleCodeTicketingManager
+	}
+
+	users, err := s.userRepo.FindByDomainAndRoleCode(task.DomainID, roleCode)
+	if err != nil {
+		return nil
+	}
+
+	userIDs := make([]int64, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+	return userIDs
+}
+
+// notifyTask notifies each user once about the task on the channels they chose,
+// leaving out the user who made the change
+func (s *taskService) notifyTask(task *model.Task, userIDs []int64, actorID int64, notificationType, title, message string) {
+	channels, err := s.preferenceRepo.FindChannels(userIDs, model.NotificationEventFor(notificationType))
+	if err != nil {
+		return
+	}
+
+	dispatch := &model.NotificationDispatch{}
+	notified := map[int64]bool{actorID: true}
+	for _, userID := range userIDs {
+		if notified[userID] {
+			continue
+		}
+		notified[userID] = true
+
+		notification := &model.Notification{
+			UserID:     userID,
+			EntityType: model.NotificationEntityTask,
+			EntityID:   task.ID,
+			Link:       model.NotificationLink(model.NotificationEntityTask, task.ID),
+			Type:       notificationType,
+			Title:      title,
+			Message:    message,
+			IsRead:     false,
+		}
+		dispatch.Add(notification, task.DomainID, channels[userID])
+	}
+	s.notificationRepo.SaveDispatch(dispatch)
+}
+
+// publishWebhook queues the task event for the domain's webhook receivers.
+// Like notifications, failing to queue it does not undo the change.
+func (s *taskService) publishWebhook(eventType string, task *model.Task, data model.WebhookTaskData) {
+	s.webhookRepo.Enqueue(model.NewWebhookEvent(eventType, task.DomainID, data))
+}
+
+// publishApproval queues the decision on an approval step. The task counts as
+// approved once its last step is.
+func (s *taskService) publishApproval(eventType string, task *model.Task, approval *model.ApprovalTask, userID int64) {
+	data := model.NewWebhookTaskData(task, userID)
+	approved := eventType == model.WebhookEventTaskApproved && approval.Sequence == 2
+	data.Sequence = &approval.Sequence
+	data.Approved = &approved
+	data.Note = approval.Note
+	s.publishWebhook(eventType, task, data)
+}
+
 func ptrInt64(v int64) *int64 {
 	return &v
 }
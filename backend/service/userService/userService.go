@@ -70,6 +70,7 @@ func (s *userService) Register(req *model.UserRequest) (*model.UserResponse, err
 		FullName:    req.FullName,
 		PhoneNumber: req.PhoneNumber,
 		Nip:         req.Nip,
+		Locale:      req.Locale,
 		IsActive:    isActive,
 	}
 
@@ -147,7 +148,11 @@ func (s *userService) Login(req *model.LoginRequest) (*model.LoginResponse, erro
 	}
 
 	// Generate JWT token with domain and role context
-	token, err := helper.GenerateTokenWithDomain(user.ID, user.Username, user.Email, selectedDomainRole.DomainID, selectedDomainRole.RoleID)
+	roleCode := ""
+	if selectedDomainRole.Role != nil {
+		roleCode = selectedDomainRole.Role.Code
+	}
+	token, err := helper.GenerateTokenWithDomain(user.ID, user.Username, user.Email, selectedDomainRole.DomainID, selectedDomainRole.RoleID, roleCode)
 	if err != nil {
 		return nil, err
 	}
@@ -172,6 +177,7 @@ func (s *userService) Login(req *model.LoginRequest) (*model.LoginResponse, erro
 			Code:        selectedDomainRole.Domain.Code,
 			Name:        selectedDomainRole.Domain.Name,
 			Description: selectedDomainRole.Domain.Description,
+			Locale:      selectedDomainRole.Domain.Locale,
 			IsActive:    selectedDomainRole.Domain.IsActive,
 			CreatedAt:   selectedDomainRole.Domain.CreatedAt,
 			UpdatedAt:   selectedDomainRole.Domain.UpdatedAt,
@@ -225,7 +231,11 @@ func (s *userService) SwitchDomain(userID int64, req *model.SwitchDomainRequest)
 	}
 
 	// Generate new JWT token with new domain context
-	token, err := helper.GenerateTokenWithDomain(user.ID, user.Username, user.Email, selectedDomainRole.DomainID, selectedDomainRole.RoleID)
+	roleCode := ""
+	if selectedDomainRole.Role != nil {
+		roleCode = selectedDomainRole.Role.Code
+	}
+	token, err := helper.GenerateTokenWithDomain(user.ID, user.Username, user.Email, selectedDomainRole.DomainID, selectedDomainRole.RoleID, roleCode)
 	if err != nil {
 		return nil, err
 	}
@@ -239,6 +249,7 @@ func (s *userService) SwitchDomain(userID int64, req *model.SwitchDomainRequest)
 			Code:        selectedDomainRole.Domain.Code,
 			Name:        selectedDomainRole.Domain.Name,
 			Description: selectedDomainRole.Domain.Description,
+			Locale:      selectedDomainRole.Domain.Locale,
 			IsActive:    selectedDomainRole.Domain.IsActive,
 			CreatedAt:   selectedDomainRole.Domain.CreatedAt,
 			UpdatedAt:   selectedDomainRole.Domain.UpdatedAt,
@@ -336,6 +347,10 @@ func (s *userService) UpdateUser(id int64, req *model.UserUpdateRequest) (*model
 		user.Nip = req.Nip
 	}
 
+	if req.Locale != nil {
+		user.Locale = req.Locale
+	}
+
 	if req.IsActive != nil {
 		user.IsActive = *req.IsActive
 	}
@@ -406,6 +421,10 @@ func (s *userService) UpdateProfile(id int64, req *model.UpdateProfileRequest) (
 		user.Nip = req.Nip
 	}
 
+	if req.Locale != nil {
+		user.Locale = req.Locale
+	}
+
 	err = s.repo.Update(id, user)
 	if err != nil {
 		return nil, err
@@ -505,6 +524,7 @@ func (s *userService) toResponse(user *model.User) *model.UserResponse {
 		FullName:    user.FullName,
 		PhoneNumber: user.PhoneNumber,
 		Nip:         user.Nip,
+		Locale:      user.Locale,
 		IsActive:    user.IsActive,
 		CreatedAt:   user.CreatedAt,
 		UpdatedAt:   user.UpdatedAt,
@@ -539,6 +559,7 @@ func (s *userService) toUserDomainRoleResponse(udr *model.UserDomainRole) *model
 			Code:        udr.Domain.Code,
 			Name:        udr.Domain.Name,
 			Description: udr.Domain.Description,
+			Locale:      udr.Domain.Locale,
 			IsActive:    udr.Domain.IsActive,
 			CreatedAt:   udr.Domain.CreatedAt,
 			UpdatedAt:   udr.Domain.UpdatedAt,
@@ -0,0 +1,286 @@
+package webhookService
+
+import (
+	"errors"
+	"permit-app/helper"
+	"permit-app/model"
+	"permit-app/repo/webhookRepository"
+	"time"
+)
+
+var (
+	// ErrWebhookNotFound is returned when the subscription does not exist or
+	// belongs to another domain
+	ErrWebhookNotFound = errors.New("webhook subscription not found")
+	// ErrDeliveryNotFound is returned when the delivery is not one of the subscription's
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	// ErrDeliveryNotFailed is returned when redelivering a delivery that has not failed
+	ErrDeliveryNotFailed = errors.New("only failed deliveries can be redelivered")
+)
+
+const (
+	// webhookBatchSize is how many due deliveries one delivery run claims
+	webhookBatchSize = 20
+	// webhookSendLease is how long a claimed delivery is left to its worker
+	// before another run may claim it again
+	webhookSendLease = 5 * time.Minute
+	// webhookRetryBaseDelay is the wait after the first failed attempt, doubled
+	// after every further failure up to webhookRetryMaxDelay
+	webhookRetryBaseDelay = time.Minute
+	webhookRetryMaxDelay  = 6 * time.Hour
+)
+
+type WebhookService interface {
+	GetSubscriptions(domainID int64) ([]model.WebhookSubscription, error)
+	GetSubscription(id int64, domainID *int64) (*model.WebhookSubscription, error)
+	CreateSubscription(domainID int64, req *model.CreateWebhookSubscriptionRequest, userID int64) (*model.WebhookSubscription, error)
+	UpdateSubscription(id int64, domainID *int64, req *model.UpdateWebhookSubscriptionRequest, userID int64) (*model.WebhookSubscription, error)
+	DeleteSubscription(id int64, domainID *int64) error
+	Ping(id int64, domainID *int64) (*model.WebhookDelivery, error)
+	GetDeliveries(id int64, domainID *int64, filter *model.WebhookDeliveryListRequest) ([]model.WebhookDelivery, int64, error)
+	GetDelivery(id int64, deliveryID int64, domainID *int64) (*model.WebhookDelivery, error)
+	Redeliver(id int64, deliveryID int64, domainID *int64) (*model.WebhookDelivery, error)
+	DeliverDue() (int, error)
+	PurgeDelivered(before time.Time) (int, error)
+}
+
+type webhookService struct {
+	repo webhookRepository.WebhookRepository
+}
+
+func NewWebhookService(repo webhookRepository.WebhookRepository) WebhookService {
+	return &webhookService{repo: repo}
+}
+
+func (s *webhookService) GetSubscriptions(domainID int64) ([]model.WebhookSubscription, error) {
+	return s.repo.FindSubscriptions(domainID)
+}
+
+// GetSubscription returns the subscription if it belongs to the domain. A nil
+// domainID gives access to subscriptions of every domain.
+func (s *webhookService) GetSubscription(id int64, domainID *int64) (*model.WebhookSubscription, error) {
+	subscription, err := s.repo.FindSubscriptionByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if subscription == nil || (domainID != nil && subscription.DomainID != *domainID) {
+		return nil, ErrWebhookNotFound
+	}
+	return subscription, nil
+}
+
+func (s *webhookService) CreateSubscription(domainID int64, req *model.CreateWebhookSubscriptionRequest, userID int64) (*model.WebhookSubscription, error) {
+	subscription := &model.WebhookSubscription{
+		DomainID:    domainID,
+		URL:         req.URL,
+		Secret:      req.Secret,
+		EventTypes:  req.EventTypes,
+		Description: req.Description,
+		IsActive:    true,
+		CreatedBy:   &userID,
+	}
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.CreateSubscription(subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *webhookService) UpdateSubscription(id int64, domainID *int64, req *model.UpdateWebhookSubscriptionRequest, userID int64) (*model.WebhookSubscription, error) {
+	subscription, err := s.GetSubscription(id, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		subscription.URL = *req.URL
+	}
+	if req.Secret != nil {
+		subscription.Secret = *req.Secret
+	}
+	if len(req.EventTypes) > 0 {
+		subscription.EventTypes = req.EventTypes
+	}
+	if req.Description != nil {
+		subscription.Description = req.Description
+	}
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+	subscription.UpdatedBy = &userID
+
+	if err := s.repo.UpdateSubscription(subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *webhookService) DeleteSubscription(id int64, domainID *int64) error {
+	if _, err := s.GetSubscription(id, domainID); err != nil {
+		return err
+	}
+	return s.repo.DeleteSubscription(id)
+}
+
+// Ping posts a ping event to the subscription right away, whether it is
+// active or not, and returns the delivery with the attempt's outcome. Pings
+// are not retried.
+func (s *webhookService) Ping(id int64, domainID *int64) (*model.WebhookDelivery, error) {
+	subscription, err := s.GetSubscription(id, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	event := model.NewWebhookEvent(model.WebhookEventPing, subscription.DomainID, model.WebhookPingData{
+		SubscriptionID: subscription.ID,
+		EventTypes:     subscription.EventTypes,
+	})
+	deliveries, err := event.Deliveries([]model.WebhookSubscription{*subscription})
+	if err != nil {
+		return nil, err
+	}
+
+	// Leased from the start, so the worker leaves the ping to this request
+	delivery := deliveries[0]
+	lockedUntil := time.Now().Add(webhookSendLease)
+	delivery.Status = model.WebhookStatusSending
+	delivery.LockedUntil = &lockedUntil
+	delivery.MaxAttempts = 1
+	if err := s.repo.CreateDelivery(delivery); err != nil {
+		return nil, err
+	}
+
+	if err := s.post(delivery, subscription); err != nil {
+		return nil, err
+	}
+	return s.repo.FindDeliveryByID(delivery.ID)
+}
+
+func (s *webhookService) GetDeliveries(id int64, domainID *int64, filter *model.WebhookDeliveryListRequest) ([]model.WebhookDelivery, int64, error) {
+	if _, err := s.GetSubscription(id, domainID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.FindDeliveries(id, filter)
+}
+
+// GetDelivery returns the subscription's delivery with the outcome of each attempt
+func (s *webhookService) GetDelivery(id int64, deliveryID int64, domainID *int64) (*model.WebhookDelivery, error) {
+	if _, err := s.GetSubscription(id, domainID); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.repo.FindDeliveryByID(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil || delivery.SubscriptionID != id {
+		return nil, ErrDeliveryNotFound
+	}
+	return delivery, nil
+}
+
+// Redeliver queues a failed delivery again with a fresh set of attempts. Its
+// earlier attempts stay in the delivery log.
+func (s *webhookService) Redeliver(id int64, deliveryID int64, domainID *int64) (*model.WebhookDelivery, error) {
+	delivery, err := s.GetDelivery(id, deliveryID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status != model.WebhookStatusFailed {
+		return nil, ErrDeliveryNotFailed
+	}
+
+	if err := s.repo.Requeue(deliveryID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindDeliveryByID(deliveryID)
+}
+
+// DeliverDue posts the deliveries whose next attempt is due and returns how
+// many were delivered. Failed deliveries are retried with exponential backoff
+// until they reach their maximum attempts.
+func (s *webhookService) DeliverDue() (int, error) {
+	deliveries, err := s.repo.ClaimDue(time.Now(), webhookBatchSize, webhookSendLease)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		if err := s.post(delivery, delivery.Subscription); err != nil {
+			return delivered, err
+		}
+		if delivery.Status == model.WebhookStatusDelivered {
+			delivered++
+		}
+	}
+
+	return delivered, nil
+}
+
+// PurgeDelivered removes the deliveries delivered before the given time and
+// returns how many were removed. Failed deliveries stay until they are redelivered.
+func (s *webhookService) PurgeDelivered(before time.Time) (int, error) {
+	removed, err := s.repo.DeleteDeliveredBefore(before)
+	return int(removed), err
+}
+
+// post makes one attempt to deliver to the subscription and records its
+// outcome. The returned error is about recording, a failed post only shows
+// in the delivery's state.
+func (s *webhookService) post(delivery *model.WebhookDelivery, subscription *model.WebhookSubscription) error {
+	started := time.Now()
+	response, postErr := helper.PostWebhook(subscription.URL, subscription.Secret, delivery.EventType, delivery.EventID, delivery.Payload)
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LockedUntil = nil
+	delivery.ResponseStatus = nil
+	attempt := &model.WebhookDeliveryAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts,
+		Status:     model.WebhookStatusDelivered,
+		DurationMs: now.Sub(started).Milliseconds(),
+	}
+	if response != nil {
+		attempt.ResponseStatus = &response.StatusCode
+		attempt.ResponseBody = &response.Body
+		delivery.ResponseStatus = &response.StatusCode
+	}
+
+	if postErr == nil {
+		delivery.Status = model.WebhookStatusDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = nil
+	} else {
+		message := postErr.Error()
+		attempt.Status = model.WebhookStatusFailed
+		attempt.Error = &message
+		delivery.LastError = &message
+		if delivery.Attempts >= delivery.MaxAttempts {
+			delivery.Status = model.WebhookStatusFailed
+		} else {
+			delivery.Status = model.WebhookStatusPending
+			delivery.NextAttemptAt = now.Add(retryDelay(delivery.Attempts))
+		}
+	}
+
+	return s.repo.RecordAttempt(delivery, attempt)
+}
+
+// retryDelay is the wait before the next try after the given number of failed attempts
+func retryDelay(attempts int) time.Duration {
+	delay := webhookRetryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= webhookRetryMaxDelay {
+			return webhookRetryMaxDelay
+		}
+	}
+	return delay
+}
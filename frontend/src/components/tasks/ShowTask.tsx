@@ -25,11 +25,40 @@ export default function ShowTask({ code }: ShowTaskProps) {
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState("");
   const [task, setTask] = useState<Task | null>(null);
+  const [imageUrls, setImageUrls] = useState<Record<number, string>>({});
 
   useEffect(() => {
     loadTaskData();
   }, [code]);
 
+  // Attachments are served with authorization, images are previewed from blobs
+  useEffect(() => {
+    const images = (task?.task_files || []).filter((file) =>
+      isImageFile(file.file_type)
+    );
+    if (images.length === 0) return;
+
+    let cancelled = false;
+    const urls: Record<number, string> = {};
+    Promise.all(
+      images.map(async (file) => {
+        try {
+          const blob = await taskService.getFileBlob(file.task_id, file.id);
+          urls[file.id] = URL.createObjectURL(blob);
+        } catch {
+          // The preview falls back to the file icon
+        }
+      })
+    ).then(() => {
+      if (!cancelled) setImageUrls(urls);
+    });
+
+    return () => {
+      cancelled = true;
+      Object.values(urls).forEach((url) => URL.revokeObjectURL(url));
+    };
+  }, [task]);
+
   const loadTaskData = async () => {
     setLoading(true);
     try {
@@ -272,8 +301,7 @@ export default function ShowTask({ code }: ShowTaskProps) {
               </h2>
               <div className="grid grid-cols-1 md:grid-cols-2 gap-4">
                 {task.task_files.map((file) => {
-                  const fileUrl = `${process.env.NEXT_PUBLIC_STORAGE_URL}/${file.file_path}`;
-                  const isImage = isImageFile(file.file_type);
+                  const imageUrl = imageUrls[file.id];
 
                   return (
                     <div
@@ -281,10 +309,10 @@ export default function ShowTask({ code }: ShowTaskProps) {
                       className="border border-gray-200 rounded-lg overflow-hidden hover:shadow-lg transition-shadow dark:border-gray-700"
                     >
                       {/* Preview */}
-                      {isImage ? (
+                      {imageUrl ? (
                         <div className="relative h-48 bg-gray-100 dark:bg-gray-800">
                           <Image
-                            src={fileUrl}
+                            src={imageUrl}
                             alt={file.file_name}
                             fill
                             className="object-contain"
@@ -308,15 +336,14 @@ export default function ShowTask({ code }: ShowTaskProps) {
                               ? formatFileSize(file.file_size)
                               : "-"}
                           </span>
-                          <a
-                            href={fileUrl}
-                            target="_blank"
-                            rel="noopener noreferrer"
+                          <button
+                            type="button"
+                            onClick={() => taskService.openFile(file.task_id, file.id)}
                             className="flex items-center gap-1 text-sm text-blue-600 hover:text-blue-800 dark:text-blue-400"
                           >
                             <FiDownload className="w-4 h-4" />
                             Download
-                          </a>
+                          </button>
                         </div>
                       </div>
                     </div>
@@ -375,14 +375,13 @@ export default function TaskForm({ code, isEdit = false }: TaskFormProps) {
 
                         {/* Action Buttons */}
                         <div className="flex gap-2">
-                          <a
-                            href={`${process.env.NEXT_PUBLIC_API_URL || "http://localhost:8080"}/${file.file_path}`}
-                            target="_blank"
-                            rel="noopener noreferrer"
+                          <button
+                            type="button"
+                            onClick={() => taskService.openFile(file.task_id, file.id)}
                             className="flex-shrink-0 px-3 py-1.5 text-xs font-medium text-blue-600 hover:text-blue-700 dark:text-blue-400 dark:hover:text-blue-300 bg-blue-100 hover:bg-blue-200 dark:bg-blue-900/30 dark:hover:bg-blue-900/50 rounded-lg transition-colors"
                           >
                             View
-                          </a>
+                          </button>
                           <button
                             type="button"
                             onClick={() => handleDeleteExistingFile(file.id)}
@@ -206,4 +206,19 @@ export const taskService = {
       data
     );
   },
+
+  async getFileBlob(taskId: number, fileId: number): Promise<Blob> {
+    const blob = await apiClient.get<Blob>(
+      `/tasks/${taskId}/files/${fileId}/download`,
+      { responseType: "blob" }
+    );
+    return blob;
+  },
+
+  async openFile(taskId: number, fileId: number): Promise<void> {
+    const blob = await taskService.getFileBlob(taskId, fileId);
+    const url = window.URL.createObjectURL(blob);
+    window.open(url, "_blank", "noopener,noreferrer");
+    setTimeout(() => window.URL.revokeObjectURL(url), 60000);
+  },
 };
@@ -14,7 +14,6 @@ export interface Permit {
   doc_name?: string | null;
   doc_number?: string | null;
   doc_file_name?: string | null;
-  doc_file_path?: string | null;
   doc_file_size?: number | null;
   doc_file_type?: string | null;
   status: string;
@@ -48,7 +48,6 @@ export interface TaskFile {
   id: number;
   task_id: number;
   file_name: string;
-  file_path: string;
   file_size?: string;
   file_type?: string;
   task_file_type?: number;